@@ -0,0 +1,179 @@
+// log_viewer/alert.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AlertRule posts to -notify-url while following, once the entries it counts
+// within a window reach a number, or a share of the window's requests:
+//
+//	{"alerts": [
+//	  {"name": "5xx rate", "filter": "status=5xx", "rate": 0.05, "window": "1m"},
+//	  {"name": "upstream resets", "filter": "UF", "count": 10, "window": "5m"}
+//	]}
+//
+// A rule fires at most once per window. Its post carries what it counted,
+// its filter to reproduce the entries in the TUI, and the window's top
+// findings as summarize would report them.
+type AlertRule struct {
+	Name   string  `json:"name"`
+	Filter string  `json:"filter,omitempty"` // Entries counted, as the TUI filter keeps them; empty for all
+	Count  int     `json:"count,omitempty"`  // Fires at this many entries within the window
+	Rate   float64 `json:"rate,omitempty"`   // Fires at this share of the window's requests, e.g. 0.05
+	Window string  `json:"window"`           // How far back entries count, e.g. 1m
+
+	window time.Duration // Parsed from Window by compile
+}
+
+// compile checks the rule and parses its window.
+func (r *AlertRule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("an alert needs a name")
+	}
+	window, err := time.ParseDuration(r.Window)
+	if err != nil || window <= 0 {
+		return fmt.Errorf("alert %s has invalid window %q, expected a duration such as 1m", r.Name, r.Window)
+	}
+	if r.Count < 0 || r.Rate < 0 || r.Rate > 1 || (r.Count == 0 && r.Rate == 0) {
+		return fmt.Errorf("alert %s needs a count above 0 or a rate between 0 and 1", r.Name)
+	}
+	r.window = window
+	return nil
+}
+
+// alertCount is what a rule counted within its window.
+type alertCount struct {
+	matched  int // Entries the filter keeps
+	requests int // Requests in the window, for the rate
+}
+
+// count counts the entries of window the rule applies to. Health checks only
+// count with healthChecks, as in summarize.
+func (r AlertRule) count(window []ParsedLog, healthChecks bool) alertCount {
+	var c alertCount
+	for _, entry := range window {
+		if !healthChecks && trafficClass(entry) == trafficHealthCheck {
+			continue
+		}
+		if _, isRequest := entry.ResponseCode(); isRequest {
+			c.requests++
+		}
+	}
+	for _, entry := range filterLogs(window, r.Filter) {
+		if healthChecks || trafficClass(entry) != trafficHealthCheck {
+			c.matched++
+		}
+	}
+	return c
+}
+
+// fires reports whether the count reaches the rule's number or rate. Too few
+// requests make any rate noise, as in summarize.
+func (r AlertRule) fires(c alertCount) bool {
+	if r.Count > 0 && c.matched >= r.Count {
+		return true
+	}
+	return r.Rate > 0 && c.requests >= minRequestsForRate && float64(c.matched)/float64(c.requests) >= r.Rate
+}
+
+// describe says what the rule counted, against its limit.
+func (r AlertRule) describe(c alertCount) string {
+	if r.Count > 0 && c.matched >= r.Count {
+		return fmt.Sprintf("%s: %d entries in the last %s, the limit is %d.", r.Name, c.matched, r.Window, r.Count)
+	}
+	return fmt.Sprintf("%s: %d of %d requests (%s) in the last %s, the limit is %g%%.",
+		r.Name, c.matched, c.requests, percent(c.matched, c.requests), r.Window, r.Rate*100)
+}
+
+// notification returns the alert's post: the rule's finding with its filter
+// first, then the top findings of the entries in its window.
+func (r AlertRule) notification(window []ParsedLog, c alertCount, source string, healthChecks bool) notification {
+	n := summarizeWith(window, source, healthChecks).notification()
+	n.Title = fmt.Sprintf("Alert %s: %s", r.Name, source)
+	n.Findings = append([]notifiedFinding{{Message: r.describe(c), Filter: r.Filter}}, n.Findings...)
+	if len(n.Findings) > maxNotifyFindings {
+		n.Findings = n.Findings[:maxNotifyFindings]
+	}
+	return n
+}
+
+// alerter evaluates the alert rules as entries stream in, and posts those
+// that fire to the webhook.
+type alerter struct {
+	rules   []AlertRule
+	webhook string
+	format  string
+	fired   []time.Time // By rule, the time of the newest entry when it last fired
+}
+
+// newAlerter returns the alerter for rules, or nil without rules or a
+// webhook to post to.
+func newAlerter(rules []AlertRule, webhook, format string) *alerter {
+	if len(rules) == 0 || webhook == "" {
+		return nil
+	}
+	return &alerter{rules: rules, webhook: webhook, format: format, fired: make([]time.Time, len(rules))}
+}
+
+// alertPostedMsg reports the post of an alert.
+type alertPostedMsg struct {
+	rule string
+	err  error
+}
+
+// check returns a command posting every rule that the entries up to the
+// newest one of logs fire, nil when none does. Windows end at the newest
+// entry's time, so entries without one are not checked. It is nil safe, like
+// sinkForwarder.forward.
+func (a *alerter) check(logs []ParsedLog, source string, healthChecks bool) tea.Cmd {
+	if a == nil || len(logs) == 0 {
+		return nil
+	}
+	newest, ok := eventTime(logs[len(logs)-1])
+	if !ok {
+		return nil
+	}
+
+	var posts []tea.Cmd
+	for i, rule := range a.rules {
+		if !a.fired[i].IsZero() && newest.Sub(a.fired[i]) < rule.window {
+			continue
+		}
+		window := entriesSince(logs, newest.Add(-rule.window))
+		c := rule.count(window, healthChecks)
+		if !rule.fires(c) {
+			continue
+		}
+		a.fired[i] = newest
+		posts = append(posts, a.post(rule.Name, rule.notification(window, c, source, healthChecks)))
+	}
+	return tea.Batch(posts...)
+}
+
+// post returns a command posting the alert's notification.
+func (a *alerter) post(rule string, n notification) tea.Cmd {
+	webhook, format := a.webhook, a.format
+	return func() tea.Msg {
+		return alertPostedMsg{rule: rule, err: notify(context.Background(), webhook, format, n)}
+	}
+}
+
+// entriesSince returns the entries at the end of logs timed at or after
+// start. Entries arrive roughly in order, so the first one before start ends
+// the window; entries without a time inside it are kept.
+func entriesSince(logs []ParsedLog, start time.Time) []ParsedLog {
+	i := len(logs)
+	for i > 0 {
+		if t, ok := eventTime(logs[i-1]); ok && t.Before(start) {
+			break
+		}
+		i--
+	}
+	return logs[i:]
+}
@@ -0,0 +1,140 @@
+// log_viewer/alert_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// alertTestLines returns requests a second apart from start, every failed-th
+// of them a 503 with UF, every one when failed is 1.
+func alertTestLines(start time.Time, n, failed int) []string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		code, flags := 200, "-"
+		if i%failed == 0 {
+			code, flags = 503, "UF"
+		}
+		lines = append(lines, fmt.Sprintf(`{"start_time":%q,"response_code":%d,"response_flags":%q,"upstream_cluster":"outbound|9080||reviews"}`,
+			start.Add(time.Duration(i)*time.Second).Format(time.RFC3339), code, flags))
+	}
+	return lines
+}
+
+func TestAlertRuleCompile(t *testing.T) {
+	tests := []struct {
+		rule    AlertRule
+		wantErr string
+	}{
+		{AlertRule{Name: "5xx", Filter: "status=5xx", Rate: 0.05, Window: "1m"}, ""},
+		{AlertRule{Name: "resets", Filter: "UF", Count: 10, Window: "5m"}, ""},
+		{AlertRule{Filter: "UF", Count: 10, Window: "5m"}, "needs a name"},
+		{AlertRule{Name: "resets", Count: 10, Window: "soon"}, "invalid window"},
+		{AlertRule{Name: "resets", Count: 10, Window: "-1m"}, "invalid window"},
+		{AlertRule{Name: "resets", Window: "1m"}, "needs a count"},
+		{AlertRule{Name: "5xx", Rate: 5, Window: "1m"}, "needs a count"},
+	}
+	for _, tt := range tests {
+		err := tt.rule.compile()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("compile(%+v) unexpected error: %v", tt.rule, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("compile(%+v) error = %v, expected one containing %q", tt.rule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAlertPosts(t *testing.T) {
+	var posted []notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("expected a JSON notification: %v", err)
+		}
+		posted = append(posted, n)
+	}))
+	defer server.Close()
+
+	rule := AlertRule{Name: "5xx rate", Filter: "status=5xx", Rate: 0.2, Window: "1m"}
+	if err := rule.compile(); err != nil {
+		t.Fatal(err)
+	}
+	alerts := newAlerter([]AlertRule{rule}, server.URL, notifyJSON)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Below the rate nothing is posted
+	if cmd := alerts.check(mustParse(t, alertTestLines(start, 30, 10)...), "default/reviews", false); cmd != nil {
+		t.Errorf("expected no alert at 10%% errors")
+	}
+
+	logs := mustParse(t, alertTestLines(start, 30, 2)...)
+	cmd := alerts.check(logs, "default/reviews", false)
+	if cmd == nil {
+		t.Fatalf("expected an alert at 50%% errors")
+	}
+	if msg := cmd().(alertPostedMsg); msg.err != nil || msg.rule != rule.Name {
+		t.Fatalf("expected the alert posted, got %+v", msg)
+	}
+	if len(posted) != 1 {
+		t.Fatalf("expected one post, got %d", len(posted))
+	}
+	n := posted[0]
+	if n.Title != "Alert 5xx rate: default/reviews" || n.Requests != 30 || n.Errors != 15 {
+		t.Errorf("expected the window's figures under the alert's title, got %+v", n)
+	}
+	if len(n.Findings) < 2 || !strings.Contains(n.Findings[0].Message, "15 of 30 requests") || n.Findings[0].Filter != "status=5xx" {
+		t.Fatalf("expected the alert's finding with its filter first, got %+v", n.Findings)
+	}
+	if got := filterLogs(logs, n.Findings[0].Filter); len(got) != 15 {
+		t.Errorf("expected the filter to reproduce the 15 failed requests, got %d", len(got))
+	}
+	if n.Findings[1].Filter == "" {
+		t.Errorf("expected the window's top findings with their filters, got %+v", n.Findings[1:])
+	}
+
+	// The rule stays quiet for a window after firing, then fires again
+	if cmd := alerts.check(mustParse(t, alertTestLines(start, 45, 2)...), "default/reviews", false); cmd != nil {
+		t.Errorf("expected no second alert within the window")
+	}
+	if cmd := alerts.check(mustParse(t, alertTestLines(start, 95, 2)...), "default/reviews", false); cmd == nil {
+		t.Errorf("expected the alert again a window later")
+	}
+
+	if newAlerter([]AlertRule{rule}, "", notifyJSON) != nil || (*alerter)(nil).check(logs, "", false) != nil {
+		t.Errorf("expected no alerts without a webhook")
+	}
+}
+
+func TestAlertWhileFollowing(t *testing.T) {
+	rule := AlertRule{Name: "resets", Filter: "UF", Count: 3, Window: "1m"}
+	if err := rule.compile(); err != nil {
+		t.Fatal(err)
+	}
+	alerts := newAlerter([]AlertRule{rule}, "https://alerts.example.com/hook", notifyJSON)
+	model := Model{following: true, pinned: true, alerts: alerts, logsFrom: "default/reviews", width: 120, height: 40}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, line := range alertTestLines(start, 5, 1) {
+		updated, _ := model.Update(logLineMsg{line: line})
+		model = updated.(Model)
+	}
+	if fired := alerts.fired[0]; !fired.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("expected the rule to fire on the third reset, got %v", fired)
+	}
+
+	updated, _ := model.Update(alertPostedMsg{rule: "resets"})
+	if notice := updated.(Model).notice; notice != "Alert resets posted" {
+		t.Errorf("expected a notice of the post, got %q", notice)
+	}
+	updated, _ = model.Update(alertPostedMsg{rule: "resets", err: fmt.Errorf("webhook returned 500")})
+	if err := updated.(Model).err; err == nil || !strings.Contains(err.Error(), "resets") {
+		t.Errorf("expected the failed post shown, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// log_viewer/audit.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Labels the audit reads from pods.
+const (
+	revisionLabel      = "istio.io/rev"
+	canonicalNameLabel = "service.istio.io/canonical-name"
+)
+
+// podAudit is the sidecar state of one pod.
+type podAudit struct {
+	namespace, pod string
+	workload       string
+	sidecar        bool
+	version        string // Tag of the proxy image, empty without a sidecar
+	revision       string // Istio revision label, empty for the default revision
+	skewed         bool   // Runs another proxy version than most sidecars
+}
+
+// auditReport is the sidecar state of the pods in some namespaces.
+type auditReport struct {
+	pods    []podAudit // Sorted by namespace, workload and pod
+	version string     // Most common proxy version
+}
+
+// auditPod reads the sidecar state of a pod. Sidecars injected as native
+// sidecars are init containers, so both lists are checked.
+func auditPod(pod v1.Pod) podAudit {
+	audit := podAudit{
+		namespace: pod.Namespace,
+		pod:       pod.Name,
+		workload:  workloadName(pod),
+		revision:  pod.Labels[revisionLabel],
+	}
+	for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		if container.Name == sidecarContainer {
+			audit.sidecar = true
+			audit.version = imageTag(container.Image)
+		}
+	}
+	return audit
+}
+
+// workloadName names the workload a pod belongs to, as Istio's telemetry
+// does: the canonical service name, else the app label, else the pod.
+func workloadName(pod v1.Pod) string {
+	for _, label := range []string{canonicalNameLabel, "app.kubernetes.io/name", "app"} {
+		if name := pod.Labels[label]; name != "" {
+			return name
+		}
+	}
+	return pod.Name
+}
+
+// imageTag returns the tag of an image reference, e.g. 1.22.3 for
+// docker.io/istio/proxyv2:1.22.3, or "" when it has none.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); ok {
+		return tag
+	}
+	return ""
+}
+
+// auditPods audits pods and flags the sidecars running another version than
+// most do.
+func auditPods(pods []v1.Pod) auditReport {
+	var report auditReport
+	versions := make(map[string]int)
+	for _, pod := range pods {
+		audit := auditPod(pod)
+		if audit.sidecar {
+			versions[audit.version]++
+		}
+		report.pods = append(report.pods, audit)
+	}
+
+	if ranked := countsByValue(versions); len(ranked) > 0 {
+		report.version = ranked[0]
+	}
+	for i := range report.pods {
+		report.pods[i].skewed = report.pods[i].sidecar && report.pods[i].version != report.version
+	}
+
+	sort.Slice(report.pods, func(i, j int) bool {
+		a, b := report.pods[i], report.pods[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.workload != b.workload {
+			return a.workload < b.workload
+		}
+		return a.pod < b.pod
+	})
+	return report
+}
+
+// writeMarkdown prints the audit as a table, skewed sidecars marked.
+func (r auditReport) writeMarkdown(w io.Writer) {
+	fmt.Fprintln(w, "# Sidecar audit")
+	fmt.Fprintln(w)
+	sidecars, skewed := 0, 0
+	for _, pod := range r.pods {
+		if pod.sidecar {
+			sidecars++
+		}
+		if pod.skewed {
+			skewed++
+		}
+	}
+	fmt.Fprintf(w, "- **Pods:** %d (%d with a sidecar)\n", len(r.pods), sidecars)
+	if sidecars > 0 {
+		fmt.Fprintf(w, "- **Proxy version:** %s (%d skewed)\n", orDash(r.version), skewed)
+	}
+
+	fmt.Fprintln(w, "\n| Namespace | Workload | Pod | Sidecar | Proxy version | Revision | |\n| --- | --- | --- | --- | --- | --- | --- |")
+	for _, pod := range r.pods {
+		sidecar, flag := "no", ""
+		if pod.sidecar {
+			sidecar = "yes"
+		}
+		if pod.skewed {
+			flag = "version skew"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s |\n", pod.namespace, markdownCell(pod.workload), pod.pod,
+			sidecar, markdownCell(orDash(pod.version)), orDash(pod.revision), flag)
+	}
+}
+
+// orDash returns s, or "-" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// runAudit implements `log_viewer cluster-audit [flags] [namespace...]`,
+// auditing the namespace in PLUGIN_NAMESPACE when none is given.
+func runAudit(opts cliOptions, cfg Config) {
+	namespaces := opts.args
+	if len(namespaces) == 0 {
+		namespace := os.Getenv("PLUGIN_NAMESPACE")
+		if namespace == "" {
+			fmt.Fprintln(os.Stderr, "Error: name the namespaces to audit, or set PLUGIN_NAMESPACE")
+			os.Exit(2)
+		}
+		namespaces = []string{namespace}
+	}
+
+	clientset, err := CreateKubeClient(opts.kube)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
+		log.Println("Error creating Kubernetes client:", err)
+		os.Exit(1)
+	}
+
+	var pods []v1.Pod
+	for _, namespace := range namespaces {
+		found, err := k8ssource.ListPods(context.Background(), clientset, namespace, "", 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", explainKubeError(err))
+			log.Println("Error listing pods:", err)
+			os.Exit(1)
+		}
+		pods = append(pods, found...)
+	}
+
+	auditPods(pods).writeMarkdown(os.Stdout)
+}
@@ -0,0 +1,79 @@
+// log_viewer/audit_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func auditTestPod(name string, labels map[string]string, proxyImage string, native bool) v1.Pod {
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "example/app:1"}}},
+	}
+	proxy := v1.Container{Name: sidecarContainer, Image: proxyImage}
+	switch {
+	case proxyImage == "":
+	case native:
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, proxy)
+	default:
+		pod.Spec.Containers = append(pod.Spec.Containers, proxy)
+	}
+	return pod
+}
+
+func TestImageTag(t *testing.T) {
+	tests := map[string]string{
+		"docker.io/istio/proxyv2:1.22.3":                 "1.22.3",
+		"localhost:5000/istio/proxyv2:1.23.0-distroless": "1.23.0-distroless",
+		"gcr.io/istio-release/proxyv2@sha256:abc":        "",
+		"proxyv2": "",
+	}
+	for image, expected := range tests {
+		if tag := imageTag(image); tag != expected {
+			t.Errorf("imageTag(%q) = %q, expected %q", image, tag, expected)
+		}
+	}
+}
+
+func TestAuditPods(t *testing.T) {
+	report := auditPods([]v1.Pod{
+		auditTestPod("reviews-v1-abc", map[string]string{"app": "reviews"}, "docker.io/istio/proxyv2:1.22.3", false),
+		auditTestPod("reviews-v2-def", map[string]string{"app": "reviews", revisionLabel: "canary"}, "docker.io/istio/proxyv2:1.23.0", true),
+		auditTestPod("ratings-v1-ghi", map[string]string{canonicalNameLabel: "ratings", "app": "r"}, "docker.io/istio/proxyv2:1.22.3", false),
+		auditTestPod("migrate-job", nil, "", false),
+	})
+
+	if report.version != "1.22.3" {
+		t.Errorf("auditPods() version = %q, expected 1.22.3", report.version)
+	}
+	var got []string
+	for _, pod := range report.pods {
+		got = append(got, strings.Join([]string{pod.workload, pod.pod, pod.version, pod.revision}, " "))
+		if pod.skewed != (pod.pod == "reviews-v2-def") {
+			t.Errorf("pod %s skewed = %v", pod.pod, pod.skewed)
+		}
+		if pod.sidecar != (pod.pod != "migrate-job") {
+			t.Errorf("pod %s sidecar = %v", pod.pod, pod.sidecar)
+		}
+	}
+	expected := []string{
+		"migrate-job migrate-job  ",
+		"ratings ratings-v1-ghi 1.22.3 ",
+		"reviews reviews-v1-abc 1.22.3 ",
+		"reviews reviews-v2-def 1.23.0 canary",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("auditPods() pods =\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	var out strings.Builder
+	report.writeMarkdown(&out)
+	if !strings.Contains(out.String(), "| default | reviews | reviews-v2-def | yes | 1.23.0 | canary | version skew |") {
+		t.Errorf("writeMarkdown() did not flag the skewed pod:\n%s", out.String())
+	}
+}
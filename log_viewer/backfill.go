@@ -0,0 +1,107 @@
+// log_viewer/backfill.go
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+// Following with a backfill first fetches the last minutes of the log in one
+// request, shown at once, then follows from where the backfill ended. The
+// API only resumes at whole seconds, so the live stream starts by repeating
+// part of the backfill; kubelet timestamps, requested for both, tell the
+// repeated lines apart from new ones.
+
+// fetchBackfill reads the target's log since backfill ago, with kubelet
+// timestamps, and returns the options that follow on from it.
+func (t kubeTarget) fetchBackfill(now time.Time) ([]string, []k8ssource.Option, error) {
+	since := now.Add(-t.backfill)
+	opts := append(append([]k8ssource.Option{}, t.sourceOpts...), k8ssource.WithTimestamps(true))
+	lines, err := FetchLogsFromK8s(t.client, t.namespace, t.pod, t.container, append(opts, k8ssource.WithSinceTime(since))...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if last, ok := lastKubeTimestamp(lines); ok {
+		since = last
+	}
+	return lines, append(opts, k8ssource.WithSinceTime(since)), nil
+}
+
+// lastKubeTimestamp returns the kubelet timestamp of the last line that has one.
+func lastKubeTimestamp(lines []string) (time.Time, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if t, _, ok := splitKubeTimestamp(lines[i]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// overlap recognizes the lines a live stream repeats from the backfill before
+// it. The kubelet writes a container's lines in order, so a streamed line
+// older than the last backfilled one was backfilled too. Lines logged at the
+// same instant as the last one are matched against the backfill, each
+// backfilled line dropping one repeat.
+type overlap struct {
+	last time.Time
+	seen map[string]int // Backfilled lines logged at last
+	done bool           // A newer line arrived, nothing more is repeated
+}
+
+func newOverlap(backfill []string) *overlap {
+	last, ok := lastKubeTimestamp(backfill)
+	if !ok {
+		return &overlap{done: true}
+	}
+	o := &overlap{last: last, seen: make(map[string]int)}
+	for _, line := range backfill {
+		if t, _, ok := splitKubeTimestamp(line); ok && t.Equal(last) {
+			o.seen[line]++
+		}
+	}
+	return o
+}
+
+// repeated reports whether a streamed line repeats a backfilled one.
+func (o *overlap) repeated(line string) bool {
+	if o.done {
+		return false
+	}
+	t, _, ok := splitKubeTimestamp(line)
+	switch {
+	case !ok:
+		return false
+	case t.Before(o.last):
+		return true
+	case t.Equal(o.last) && o.seen[line] > 0:
+		o.seen[line]--
+		return true
+	case t.After(o.last):
+		o.done = true
+	}
+	return false
+}
+
+// dropOverlap forwards the lines of a stream that follows backfill, leaving
+// out the repeated ones. The returned channel closes with lines or ctx.
+func dropOverlap(ctx context.Context, lines <-chan string, backfill []string) <-chan string {
+	o := newOverlap(backfill)
+	kept := make(chan string)
+	go func() {
+		defer close(kept)
+		for line := range lines {
+			if o.repeated(line) {
+				continue
+			}
+			select {
+			case kept <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return kept
+}
@@ -0,0 +1,74 @@
+// log_viewer/backfill_test.go
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDropOverlap(t *testing.T) {
+	backfill := []string{
+		`2024-05-01T12:00:00.100000000Z {"path":"/a"}`,
+		`2024-05-01T12:00:00.500000000Z {"path":"/b"}`,
+		`2024-05-01T12:00:00.500000000Z {"path":"/b"}`,
+	}
+	// Resuming at the whole second repeats the backfilled lines of that
+	// second, before new ones, including one logged at the same instant
+	streamed := make(chan string, 6)
+	for _, line := range []string{
+		`2024-05-01T12:00:00.100000000Z {"path":"/a"}`,
+		`2024-05-01T12:00:00.500000000Z {"path":"/b"}`,
+		`2024-05-01T12:00:00.500000000Z {"path":"/b"}`,
+		`2024-05-01T12:00:00.500000000Z {"path":"/c"}`,
+		`2024-05-01T12:00:00.900000000Z {"path":"/d"}`,
+		`2024-05-01T12:00:00.900000000Z {"path":"/d"}`,
+	} {
+		streamed <- line
+	}
+	close(streamed)
+
+	var kept []string
+	for line := range dropOverlap(context.Background(), streamed, backfill) {
+		kept = append(kept, line)
+	}
+	want := []string{
+		`2024-05-01T12:00:00.500000000Z {"path":"/c"}`,
+		`2024-05-01T12:00:00.900000000Z {"path":"/d"}`,
+		`2024-05-01T12:00:00.900000000Z {"path":"/d"}`,
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("dropOverlap() kept %v, want %v", kept, want)
+	}
+}
+
+func TestOverlapWithoutBackfill(t *testing.T) {
+	o := newOverlap(nil)
+	if o.repeated(`2024-05-01T12:00:00Z {"path":"/a"}`) {
+		t.Error("expected nothing repeated after an empty backfill")
+	}
+}
+
+func TestReceiveStreamShowsBackfill(t *testing.T) {
+	target := kubeTarget{namespace: "default", pod: "reviews-v1", follow: true, parseOpts: defaultParseOptions()}
+	lines := make(chan string)
+	model, _ := Model{target: &target}.receiveStream(streamStartedMsg{
+		target: target,
+		backfill: []string{
+			`2024-05-01T12:00:00.100000000Z {"path":"/a"}`,
+			`2024-05-01T12:00:00.500000000Z {"path":"/b"}`,
+		},
+		lines: lines,
+	})
+	if len(model.filteredLogs) != 2 || model.selectedLogIndex != 1 || !model.following {
+		t.Fatalf("expected the backfill listed with the newest entry selected, got %d entries, index %d",
+			len(model.filteredLogs), model.selectedLogIndex)
+	}
+
+	model = model.appendLine(`2024-05-01T12:00:01.000000000Z {"path":"/c"}`)
+	last := model.logs[len(model.logs)-1]
+	if last.Path() != "/c" || last.LineNumber != 3 || model.selectedLogIndex != 2 {
+		t.Errorf("expected the live entry after the backfill, got %+v at index %d", last, model.selectedLogIndex)
+	}
+}
@@ -0,0 +1,274 @@
+// log_viewer/batch.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// batch summarizes many files, such as a day of rotated gateway logs, with
+// at most -workers files read at a time. Each file's entries are dropped once
+// it is summarized, and only its summary is kept for the combined one. That
+// summary still holds a timestamp and a duration per entry, which the
+// percentiles and traffic gaps are computed from, so memory grows with the
+// entries of the whole batch, if far more slowly than keeping them. -compare,
+// notes and markers need every entry and are not supported.
+
+// batchFile is one file of a batch run.
+type batchFile struct {
+	path       string
+	report     summary
+	slos       []sloResult
+	checkpoint checkpoint // Where the file was read to, with -resume
+	err        error
+}
+
+// batchReport is the outcome of a batch run: every file in the order given,
+// and the summary of all of them together.
+type batchReport struct {
+	files    []batchFile
+	combined summary
+}
+
+// summarizeFiles reads, parses and summarizes each path on its own, with at
+// most workers files at a time. saved holds the checkpoints of a previous run
+// with -resume, nil otherwise.
+func summarizeFiles(paths []string, workers int, saved checkpoints, opts cliOptions, cfg Config) batchReport {
+	jobs := make(chan int)
+	files := make([]batchFile, len(paths))
+	parseOpts := parseOptionsFor(opts, cfg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				files[index] = summarizeFile(paths[index], saved, parseOpts, opts)
+			}
+		}()
+	}
+	for index := range paths {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := batchReport{files: files, combined: summarizeWith(nil, fmt.Sprintf("%d files", len(paths)), opts.healthChecks)}
+	report.combined.slos = evaluateSLOs(opts.slos, nil, opts.healthChecks)
+	for _, file := range files {
+		if file.err == nil {
+			report.combined.merge(file.report)
+			for i := range file.slos {
+				report.combined.slos[i].requests += file.slos[i].requests
+				report.combined.slos[i].failed += file.slos[i].failed
+			}
+		}
+	}
+	report.combined.finish()
+	return report
+}
+
+// summarizeFile summarizes a single file of a batch.
+func summarizeFile(path string, saved checkpoints, parseOpts parseOptions, opts cliOptions) batchFile {
+	file := batchFile{path: path}
+	lines, err := readLogFile(path)
+	if err != nil {
+		file.err = err
+		return file
+	}
+	if saved != nil && path != "-" {
+		lines, file.checkpoint = fileLinesAfter(lines, saved[fileCheckpointKey(path)])
+	}
+
+	var logs []ParsedLog
+	if len(lines) > 0 {
+		if logs, err = parseRawLogsWith(lines, parseOpts.from(path).withOrigin(fileSource(path))); err != nil {
+			file.err = err
+			return file
+		}
+	}
+	file.report = summarizeWith(logs, path, opts.healthChecks)
+	file.slos = evaluateSLOs(opts.slos, logs, opts.healthChecks)
+	return file
+}
+
+// merge adds the figures of other, summarized from other logs, to s. Call
+// finish once everything is merged. Timestamps and durations are appended,
+// not aggregated, so s grows with every entry merged.
+func (s *summary) merge(other summary) {
+	s.entries += other.entries
+	s.requests += other.requests
+	s.healthChecks += other.healthChecks
+	s.errors += other.errors
+	if !other.first.IsZero() && (s.first.IsZero() || other.first.Before(s.first)) {
+		s.first = other.first
+	}
+	if other.last.After(s.last) {
+		s.last = other.last
+	}
+	s.timestamps = append(s.timestamps, other.timestamps...)
+	s.durations = append(s.durations, other.durations...)
+
+	addCounts(s.errorsByFlag, other.errorsByFlag)
+	addCounts(s.errorsByCode, other.errorsByCode)
+	addCounts(s.levels, other.levels)
+	addStats(s.clusters, other.clusters)
+	addStats(s.protocols, other.protocols)
+	for endpoint, durations := range other.endpoints {
+		s.endpoints[endpoint] = append(s.endpoints[endpoint], durations...)
+	}
+	for minute, count := range other.errorsPerMinute {
+		s.errorsPerMinute[minute] += count
+	}
+	// A pool burst across a rotation shows up once in each file
+	s.pools = append(s.pools, other.pools...)
+	s.denials.merge(other.denials)
+	// Each file is checked on its own, so an ID reused across files is missed
+	for id, r := range other.reusedIDs {
+		merged := s.reusedIDs[id]
+		s.reusedIDs[id] = reusedID{requests: merged.requests + r.requests, entries: merged.entries + r.entries}
+	}
+	s.skews = append(s.skews, other.skews...)
+}
+
+// finish sorts what merge appended.
+func (s *summary) finish() {
+	sort.Slice(s.timestamps, func(i, j int) bool { return s.timestamps[i].Before(s.timestamps[j]) })
+	sort.Float64s(s.durations)
+	sort.Slice(s.pools, func(i, j int) bool {
+		if !s.pools[i].start.Equal(s.pools[j].start) {
+			return s.pools[i].start.Before(s.pools[j].start)
+		}
+		return s.pools[i].cluster < s.pools[j].cluster
+	})
+}
+
+// merge adds the denials of other to d.
+func (d *denialStats) merge(other denialStats) {
+	d.count += other.count
+	addCounts(d.byKind, other.byKind)
+	for key, count := range other.byKey {
+		d.byKey[key] += count
+	}
+	d.first = earliest(d.first, other.first)
+	d.firstEntry = earliest(d.firstEntry, other.firstEntry)
+}
+
+// earliest returns the earlier of two times, ignoring zero ones.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func addCounts(to, from map[string]int) {
+	for key, count := range from {
+		to[key] += count
+	}
+}
+
+func addStats(to, from map[string]*clusterStats) {
+	for key, stats := range from {
+		if to[key] == nil {
+			to[key] = &clusterStats{}
+		}
+		to[key].requests += stats.requests
+		to[key].errors += stats.errors
+	}
+}
+
+// writeMarkdown writes the combined summary followed by a table of the
+// files.
+func (r batchReport) writeMarkdown(w io.Writer) {
+	r.combined.writeMarkdown(w)
+
+	fmt.Fprintln(w, "\n## Files\n\n| File | Entries | Requests | Errors | Error rate | p95 | Time range |\n| --- | ---: | ---: | ---: | ---: | ---: | --- |")
+	for _, file := range r.files {
+		if file.err != nil {
+			fmt.Fprintf(w, "| %s | | | | | | %s |\n", markdownCell(file.path), markdownCell(file.err.Error()))
+			continue
+		}
+		s := file.report
+		p95, errorRate, timeRange := "", "", ""
+		if len(s.durations) > 0 {
+			p95 = fmt.Sprintf("%gms", percentile(s.durations, 95))
+		}
+		if s.requests > 0 {
+			errorRate = percent(s.errors, s.requests)
+		}
+		if !s.first.IsZero() {
+			timeRange = activeTimeDisplay.timestamp(s.first) + " to " + activeTimeDisplay.timestamp(s.last)
+		}
+		fmt.Fprintf(w, "| %s | %d | %d | %d | %s | %s | %s |\n", markdownCell(file.path), s.entries, s.requests, s.errors, errorRate, p95, timeRange)
+	}
+}
+
+// runBatch implements `log_viewer batch [flags] file...`.
+func runBatch(opts cliOptions, cfg Config) {
+	if len(opts.args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: log_viewer batch [flags] file...")
+		os.Exit(exitFailure)
+	}
+
+	var saved checkpoints
+	if opts.resume {
+		var err error
+		if saved, err = loadCheckpoints(opts.statePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			log.Println("Error loading checkpoints:", err)
+			os.Exit(exitFailure)
+		}
+		if saved == nil {
+			saved = make(checkpoints)
+		}
+	}
+
+	report := summarizeFiles(opts.args, opts.workers, saved, opts, cfg)
+	failed := 0
+	for _, file := range report.files {
+		if file.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", file.path, file.err)
+			log.Printf("Error summarizing %s: %v", file.path, file.err)
+		}
+	}
+	if failed == len(report.files) {
+		os.Exit(exitFailure)
+	}
+
+	report.combined.links = renderLinks(cfg.Links, linkContext{}.withRange(report.combined.first, report.combined.last))
+	if !opts.quiet {
+		report.writeMarkdown(os.Stdout)
+	}
+
+	if opts.resume {
+		var source logSource
+		for _, file := range report.files {
+			if file.err == nil && file.path != "-" {
+				source.addCheckpoint(fileCheckpointKey(file.path), file.checkpoint)
+			}
+		}
+		if err := commitCheckpoints(opts, source); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			log.Println("Error saving checkpoints:", err)
+			os.Exit(exitFailure)
+		}
+	}
+
+	if violations := report.combined.exceeded(opts.limits); len(violations) > 0 {
+		if !opts.quiet {
+			for _, violation := range violations {
+				fmt.Fprintf(os.Stderr, "Threshold exceeded: %s\n", violation)
+			}
+		}
+		os.Exit(exitThreshold)
+	}
+}
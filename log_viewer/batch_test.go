@@ -0,0 +1,61 @@
+// log_viewer/batch_test.go
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"gateway.log.1": `{"start_time":"2024-05-04T09:00:00Z","response_code":200,"duration":10,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}
+{"start_time":"2024-05-04T09:00:01Z","response_code":503,"response_flags":"UF","duration":30,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}
+`,
+		"gateway.log": `{"start_time":"2024-05-04T10:00:00Z","response_code":200,"duration":20,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}
+`,
+		"garbage.log": "not a log\n",
+	}
+	var paths []string
+	for _, name := range []string{"gateway.log.1", "gateway.log", "garbage.log", "missing.log"} {
+		path := filepath.Join(dir, name)
+		paths = append(paths, path)
+		if contents, ok := files[name]; ok {
+			if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	slos, _ := parseSLOs([]string{"99"})
+	report := summarizeFiles(paths, 2, nil, cliOptions{slos: slos}, Config{})
+	if report.files[0].report.requests != 2 || report.files[1].report.requests != 1 {
+		t.Errorf("expected each file summarized on its own, got %+v", report.files)
+	}
+	if report.files[2].err == nil || report.files[3].err == nil {
+		t.Errorf("expected the unparsable and missing files to fail, got %v and %v", report.files[2].err, report.files[3].err)
+	}
+
+	combined := report.combined
+	if combined.requests != 3 || combined.errors != 1 || combined.clusters["outbound|9080||reviews.bookinfo.svc.cluster.local"].requests != 3 {
+		t.Errorf("combined summary has %d requests, %d errors, expected 3 and 1", combined.requests, combined.errors)
+	}
+	if len(combined.durations) != 3 || combined.durations[2] != 30 || combined.last.Hour() != 10 {
+		t.Errorf("expected sorted durations and the full time range, got %v, %v", combined.durations, combined.last)
+	}
+	if combined.slos[0].requests != 3 || combined.slos[0].failed != 1 {
+		t.Errorf("expected the SLO evaluated over every file, got %+v", combined.slos[0])
+	}
+
+	var out bytes.Buffer
+	report.writeMarkdown(&out)
+	for _, want := range []string{"# Log summary: 4 files", "## Files", "gateway.log.1 | 2 | 2 | 1 | 50.0% |", "missing.log |"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the report, got:\n%s", want, out.String())
+		}
+	}
+}
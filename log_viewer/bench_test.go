@@ -0,0 +1,98 @@
+// log_viewer/bench_test.go
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Performance budget, measured with `go test -bench . -benchmem` on a
+// server-class CPU:
+//
+//   - View: under 16ms at any log count, so scrolling keeps up with key repeat
+//   - filterLogs: under 100ms for 100k entries
+//   - parseRawLogs: under 3s for 100k entries, including the search index;
+//     JSON decoding dominates
+//
+// The 1M entry cases are skipped with -short.
+
+var benchSizes = []int{10_000, 100_000, 1_000_000}
+
+var benchFlags = []string{"-", "-", "-", "-", "UF,URX", "UH", "NR", "UO", "DC"}
+var benchCodes = []int{200, 200, 200, 201, 404, 503, 0, 504}
+
+// syntheticAccessLogs returns n Envoy JSON access log lines with a realistic mix
+// of response codes and flags.
+func syntheticAccessLogs(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(
+			`{"start_time":"2024-11-26T02:%02d:%02d.%03dZ","method":"GET","path":"/api/v1/items/%d","protocol":"HTTP/1.1",`+
+				`"response_code":%d,"response_flags":"%s","duration":%d,"bytes_sent":%d,"bytes_received":0,`+
+				`"upstream_cluster":"outbound|9080||reviews-%d.default.svc.cluster.local","upstream_host":"10.42.%d.%d:9080",`+
+				`"downstream_remote_address":"10.42.0.%d:41972","request_id":"req-%08d","user_agent":"curl/8.5.0"}`,
+			(i/60)%60, i%60, i%1000, i,
+			benchCodes[i%len(benchCodes)], benchFlags[i%len(benchFlags)], i%250, (i*37)%4096,
+			i%7, i%255, (i*13)%255, i%255, i,
+		)
+	}
+	return lines
+}
+
+func benchLogs(b *testing.B, n int) []ParsedLog {
+	b.Helper()
+	logs, err := parseRawLogs(syntheticAccessLogs(n))
+	if err != nil {
+		b.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	return logs
+}
+
+func skipLarge(b *testing.B, n int) {
+	if n >= 1_000_000 && testing.Short() {
+		b.Skip("skipping 1M entries in short mode")
+	}
+}
+
+func BenchmarkParseRawLogs(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			skipLarge(b, n)
+			lines := syntheticAccessLogs(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := parseRawLogs(lines); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFilterLogs(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			skipLarge(b, n)
+			logs := benchLogs(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				filterLogs(logs, "URX")
+			}
+		})
+	}
+}
+
+func BenchmarkView(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			skipLarge(b, n)
+			logs := benchLogs(b, n)
+			model := Model{logs: logs, filteredLogs: logs, selectedLogIndex: n / 2, width: 160, height: 50}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				model.View()
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// log_viewer/checkpoint.go
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// checkpoint is how far a source was read by the last run with -resume.
+type checkpoint struct {
+	Time  time.Time `json:"time,omitempty"`  // Kubelet timestamp of the last line, for Kubernetes sources
+	Lines int       `json:"lines,omitempty"` // Lines read, for files
+}
+
+// checkpoints are kept in the state file by source, see kubeCheckpointKey
+// and fileCheckpointKey.
+type checkpoints map[string]checkpoint
+
+// defaultStatePath returns where checkpoints are kept: the XDG state
+// directory when set, otherwise next to the config file.
+func defaultStatePath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "istio-parsin", "checkpoints.json")
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "istio-parsin", "checkpoints.json")
+}
+
+// kubeCheckpointKey identifies a container's log. The cluster is the API
+// server's address, since context names differ between kubeconfigs.
+func kubeCheckpointKey(cluster, namespace, pod, container string) string {
+	return strings.Join([]string{cluster, namespace, pod, container}, "/")
+}
+
+// fileCheckpointKey identifies a log file by its absolute path.
+func fileCheckpointKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "file:" + path
+}
+
+// loadCheckpoints reads the state file. One that does not exist yet has no
+// checkpoints, so the first run reads everything.
+func loadCheckpoints(path string) (checkpoints, error) {
+	saved := make(checkpoints)
+	if path == "" {
+		return saved, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return saved, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoints: %v", err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoints %s: %v", path, err)
+	}
+	return saved, nil
+}
+
+// saveCheckpoints records updated in the state file, keeping the checkpoints
+// of other sources. The file is read again first so runs checking different
+// sources do not undo each other.
+func saveCheckpoints(path string, updated checkpoints) error {
+	if path == "" {
+		return fmt.Errorf("no state file to save checkpoints in, pass -state")
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	saved, err := loadCheckpoints(path)
+	if err != nil {
+		return err
+	}
+	for key, cp := range updated {
+		saved[key] = cp
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding checkpoints: %v", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("error creating %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// linesAfter drops the lines a Kubernetes source returned that were read
+// before, those with a kubelet timestamp not after the checkpoint's. The
+// returned checkpoint is at the last line kept, or cp when none are new.
+func linesAfter(lines []string, cp checkpoint) ([]string, checkpoint) {
+	var kept []string
+	for _, line := range lines {
+		t, _, ok := splitKubeTimestamp(line)
+		if ok && !t.After(cp.Time) {
+			continue
+		}
+		kept = append(kept, line)
+		if ok {
+			cp.Time = t
+		}
+	}
+	return kept, cp
+}
+
+// fileLinesAfter drops the lines of a file that were read before. A file
+// shorter than the checkpoint was rotated or truncated, so it is read from
+// the start.
+func fileLinesAfter(lines []string, cp checkpoint) ([]string, checkpoint) {
+	if len(lines) < cp.Lines {
+		cp.Lines = 0
+	}
+	kept := lines[cp.Lines:]
+	cp.Lines = len(lines)
+	return kept, cp
+}
@@ -0,0 +1,95 @@
+// log_viewer/checkpoint_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinesAfter(t *testing.T) {
+	lines := []string{
+		`2024-05-04T09:59:05.100Z {"n":1}`,
+		`2024-05-04T09:59:05.200Z {"n":2}`,
+		`2024-05-04T09:59:06.000Z {"n":3}`,
+	}
+	read := checkpoint{Time: time.Date(2024, 5, 4, 9, 59, 5, 200e6, time.UTC)}
+
+	kept, cp := linesAfter(lines, read)
+	if !reflect.DeepEqual(kept, lines[2:]) {
+		t.Errorf("linesAfter() kept %v, expected %v", kept, lines[2:])
+	}
+	if expected := time.Date(2024, 5, 4, 9, 59, 6, 0, time.UTC); !cp.Time.Equal(expected) {
+		t.Errorf("linesAfter() checkpoint = %v, expected %v", cp.Time, expected)
+	}
+
+	kept, cp = linesAfter(lines[:1], cp)
+	if len(kept) != 0 || !cp.Time.Equal(time.Date(2024, 5, 4, 9, 59, 6, 0, time.UTC)) {
+		t.Errorf("linesAfter() with nothing new = %v, %v", kept, cp)
+	}
+}
+
+func TestFileLinesAfter(t *testing.T) {
+	kept, cp := fileLinesAfter([]string{"a", "b", "c"}, checkpoint{Lines: 2})
+	if !reflect.DeepEqual(kept, []string{"c"}) || cp.Lines != 3 {
+		t.Errorf("fileLinesAfter() = %v, %v", kept, cp)
+	}
+
+	// A rotated file is shorter than what was read
+	kept, cp = fileLinesAfter([]string{"x"}, checkpoint{Lines: 3})
+	if !reflect.DeepEqual(kept, []string{"x"}) || cp.Lines != 1 {
+		t.Errorf("fileLinesAfter() after rotation = %v, %v", kept, cp)
+	}
+}
+
+func TestSaveCheckpointsMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "checkpoints.json")
+	if err := saveCheckpoints(path, checkpoints{"file:/a": {Lines: 1}}); err != nil {
+		t.Fatalf("saveCheckpoints() unexpected error: %v", err)
+	}
+	if err := saveCheckpoints(path, checkpoints{"file:/b": {Lines: 2}}); err != nil {
+		t.Fatalf("saveCheckpoints() unexpected error: %v", err)
+	}
+
+	saved, err := loadCheckpoints(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoints() unexpected error: %v", err)
+	}
+	expected := checkpoints{"file:/a": {Lines: 1}, "file:/b": {Lines: 2}}
+	if !reflect.DeepEqual(saved, expected) {
+		t.Errorf("loadCheckpoints() = %v, expected %v", saved, expected)
+	}
+}
+
+func TestBatchInputResume(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "proxy.log")
+	if err := os.WriteFile(logFile, []byte("{\"n\":1}\n{\"n\":2}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := cliOptions{resume: true, statePath: filepath.Join(dir, "checkpoints.json"), args: []string{logFile}}
+
+	lines, source, err := batchInput(opts, Config{})
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("batchInput() = %v, %v, expected both lines", lines, err)
+	}
+	if err := commitCheckpoints(opts, source); err != nil {
+		t.Fatalf("commitCheckpoints() unexpected error: %v", err)
+	}
+
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("{\"n\":3}\n")
+	file.Close()
+
+	lines, _, err = batchInput(opts, Config{})
+	if err != nil || strings.Join(lines, "") != `{"n":3}` {
+		t.Errorf("batchInput() after resuming = %v, %v, expected only the new line", lines, err)
+	}
+}
@@ -0,0 +1,203 @@
+// log_viewer/clockskew.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// When the caller's sidecar and the callee's sidecar both log a hop, the
+// callee's entry should start after the caller's and end before it. One that
+// appears to start before it was called, or end after the caller had its
+// reply, was timed by a clock that disagrees with the caller's, and every
+// latency across the two is off by as much. Assuming the network took as
+// long each way, half the difference between the time before the callee
+// started and the time after it finished is how far its clock is ahead.
+
+// skewTolerance allows for start times logged to the millisecond.
+const skewTolerance = time.Millisecond
+
+// hopTiming is a hop both ends logged, with the apparent network time
+// either side of the callee's entry.
+type hopTiming struct {
+	caller, callee string        // See clockHost
+	before, after  time.Duration // Callee's start after the caller's, caller's end after the callee's
+}
+
+// offset estimates how far the callee's clock is ahead of the caller's.
+func (h hopTiming) offset() time.Duration {
+	return (h.before - h.after) / 2
+}
+
+// negative reports whether the hop appears to take negative time.
+func (h hopTiming) negative() bool {
+	return h.before < -skewTolerance || h.after < -skewTolerance
+}
+
+// clockSkew is how far one node's clock reads ahead of another's, over the
+// hops between them.
+type clockSkew struct {
+	caller, callee string
+	offset         time.Duration // Median over the hops; negative when the callee is behind
+	hops, negative int
+}
+
+func (s clockSkew) String() string {
+	direction := "ahead of"
+	offset := s.offset
+	if offset < 0 {
+		direction, offset = "behind", -offset
+	}
+	return fmt.Sprintf("%s's clock reads about %s %s %s's (%d of %d hops between them took negative time)",
+		s.callee, offset, direction, s.caller, s.negative, s.hops)
+}
+
+// clockHost names whose clock timed an entry: its node, when it was read
+// from Kubernetes, see podFields. Otherwise its pod, if known, stands in for
+// the node, and without one the service.
+func clockHost(log ParsedLog, inbound bool) string {
+	if node, _ := log.StringField(podNodeField); node != "" {
+		return node
+	}
+	if log.Source.Pod != "" {
+		return log.Source.Pod
+	}
+	if inbound {
+		return flowService(log)
+	}
+	return "caller of " + flowService(log)
+}
+
+// requestHops pairs each inbound entry of one request with the outbound
+// entry of the same call by its caller, the closest in time when the service
+// was called more than once.
+func requestHops(entries []ParsedLog) []hopTiming {
+	type timedEntry struct {
+		log        ParsedLog
+		start, end time.Time
+		service    string
+		request    string
+	}
+	var inbound, outbound []timedEntry
+	for _, log := range entries {
+		start, hasTime := eventTime(log)
+		duration, hasDuration := log.Duration()
+		if !hasTime || !hasDuration {
+			continue
+		}
+		entry := timedEntry{log: log, start: start, end: start.Add(duration), service: flowService(log), request: flowRequest(log)}
+		if strings.HasPrefix(log.UpstreamCluster(), "inbound|") {
+			inbound = append(inbound, entry)
+		} else {
+			outbound = append(outbound, entry)
+		}
+	}
+
+	var hops []hopTiming
+	paired := make([]bool, len(outbound))
+	for _, server := range inbound {
+		best := -1
+		for i, client := range outbound {
+			if paired[i] || client.service != server.service || client.request != server.request {
+				continue
+			}
+			if best < 0 || absDuration(client.start.Sub(server.start)) < absDuration(outbound[best].start.Sub(server.start)) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		paired[best] = true
+		client := outbound[best]
+		caller, callee := clockHost(client.log, false), clockHost(server.log, true)
+		if caller == callee {
+			continue
+		}
+		hops = append(hops, hopTiming{
+			caller: caller,
+			callee: callee,
+			before: server.start.Sub(client.start),
+			after:  client.end.Sub(server.end),
+		})
+	}
+	return hops
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// clockSkews returns the nodes whose hops took negative time, with the median
+// offset of their clocks, over every request in logs. Pairs are sorted by
+// how far apart the clocks are, furthest first.
+func clockSkews(logs []ParsedLog) []clockSkew {
+	byID := make(map[string][]ParsedLog)
+	for _, log := range logs {
+		if id := log.RequestID(); id != "" && id != "-" {
+			byID[id] = append(byID[id], log)
+		}
+	}
+
+	type pair struct{ caller, callee string }
+	offsets := make(map[pair][]time.Duration)
+	negative := make(map[pair]int)
+	for _, entries := range byID {
+		if len(entries) < 2 {
+			continue
+		}
+		for _, hop := range requestHops(entries) {
+			key := pair{hop.caller, hop.callee}
+			offsets[key] = append(offsets[key], hop.offset())
+			if hop.negative() {
+				negative[key]++
+			}
+		}
+	}
+
+	var skews []clockSkew
+	for key, count := range negative {
+		hops := offsets[key]
+		sort.Slice(hops, func(i, j int) bool { return hops[i] < hops[j] })
+		skews = append(skews, clockSkew{caller: key.caller, callee: key.callee, offset: hops[len(hops)/2], hops: len(hops), negative: count})
+	}
+	sort.Slice(skews, func(i, j int) bool {
+		a, b := absDuration(skews[i].offset), absDuration(skews[j].offset)
+		if a != b {
+			return a > b
+		}
+		return skews[i].caller+skews[i].callee < skews[j].caller+skews[j].callee
+	})
+	return skews
+}
+
+// writeClockSkews writes a warning line per skewed pair under a waterfall.
+func writeClockSkews(w io.Writer, skews []clockSkew) {
+	if len(skews) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	for _, skew := range skews {
+		fmt.Fprintln(w, heatWarnStyle.Render("Clock skew: "+skew.String()+"; latencies between them are off by as much."))
+	}
+}
+
+// writeSkewMarkdown writes the clock skew section of the summary.
+func writeSkewMarkdown(w io.Writer, skews []clockSkew) {
+	fmt.Fprintln(w, "\n## Clock skew\n\nHops logged by both ends that took negative time, so the clocks of the nodes logging them disagree. Offsets assume the network took as long each way.")
+	fmt.Fprintln(w, "\n| Callee | Caller | Callee's clock | Negative hops |\n| --- | --- | ---: | ---: |")
+	for _, skew := range skews {
+		offset := skew.offset.String()
+		if skew.offset >= 0 {
+			offset = "+" + offset
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %d of %d |\n", markdownCell(skew.callee), markdownCell(skew.caller), offset, skew.negative, skew.hops)
+	}
+}
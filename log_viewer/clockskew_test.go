@@ -0,0 +1,69 @@
+// log_viewer/clockskew_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockSkews(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		// productpage's sidecar calls reviews, whose node is 200ms behind
+		`{"request_id":"a","start_time":"2024-11-26T02:30:00.000Z","method":"GET","path":"/reviews/0","response_code":200,"duration":30,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+		`{"request_id":"a","start_time":"2024-11-26T02:29:59.805Z","method":"GET","path":"/reviews/0","response_code":200,"duration":20,"upstream_cluster":"inbound|9080||","authority":"reviews:9080"}`,
+		`{"request_id":"b","start_time":"2024-11-26T02:30:01.000Z","method":"GET","path":"/reviews/1","response_code":200,"duration":50,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+		`{"request_id":"b","start_time":"2024-11-26T02:30:00.810Z","method":"GET","path":"/reviews/1","response_code":200,"duration":30,"upstream_cluster":"inbound|9080||","authority":"reviews:9080"}`,
+		// ratings' clock agrees
+		`{"request_id":"c","start_time":"2024-11-26T02:30:02.000Z","method":"GET","path":"/ratings/0","response_code":200,"duration":10,"upstream_cluster":"outbound|9080||ratings.default.svc.cluster.local"}`,
+		`{"request_id":"c","start_time":"2024-11-26T02:30:02.002Z","method":"GET","path":"/ratings/0","response_code":200,"duration":6,"upstream_cluster":"inbound|9080||","authority":"ratings:9080"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	for i, pod := range []string{"productpage-v1-abc", "reviews-v1-def", "productpage-v1-abc", "reviews-v1-def", "productpage-v1-abc", "ratings-v1-ghi"} {
+		logs[i].Source.Pod = pod
+	}
+
+	skews := clockSkews(logs)
+	if len(skews) != 1 {
+		t.Fatalf("clockSkews() = %v, expected reviews only", skews)
+	}
+	skew := skews[0]
+	if skew.caller != "productpage-v1-abc" || skew.callee != "reviews-v1-def" || skew.hops != 2 || skew.negative != 2 {
+		t.Errorf("clockSkews() = %+v", skew)
+	}
+	// (-195ms - 205ms) / 2 and (-190ms - 210ms) / 2
+	if skew.offset != -200*time.Millisecond {
+		t.Errorf("expected reviews 200ms behind, got %s", skew.offset)
+	}
+	if !strings.Contains(skew.String(), "reviews-v1-def's clock reads about 200ms behind productpage-v1-abc's") {
+		t.Errorf("String() = %q", skew.String())
+	}
+
+	// Pods on the same node share its clock
+	for i := range logs {
+		logs[i].Fields[podNodeField] = "node-a"
+	}
+	logs[1].Fields[podNodeField] = "node-b"
+	if skews := clockSkews(logs); len(skews) != 1 || skews[0].callee != "node-b" || skews[0].caller != "node-a" || skews[0].hops != 1 {
+		t.Errorf("expected the nodes' clocks compared, got %v", skews)
+	}
+	for i := range logs {
+		delete(logs[i].Fields, podNodeField)
+	}
+
+	var out bytes.Buffer
+	writeClockSkews(&out, clockSkews(requestEntries(logs, "a")))
+	if !strings.Contains(out.String(), "Clock skew: reviews-v1-def") {
+		t.Errorf("expected a warning under the waterfall, got %q", out.String())
+	}
+
+	var report strings.Builder
+	summarize(logs, "test").writeMarkdown(&report)
+	if !strings.Contains(report.String(), "| reviews-v1-def | productpage-v1-abc | -200ms | 2 of 2 |") {
+		t.Errorf("expected the report to list the skewed pods, got:\n%s", report.String())
+	}
+}
@@ -0,0 +1,119 @@
+// log_viewer/clusterprofile.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ClusterProfile bundles the settings of one cluster or environment, such as
+// prod-us or staging, selected with -profile. Flags and PLUGIN_* variables
+// still win over what a profile sets.
+type ClusterProfile struct {
+	Context   string   `json:"context"`   // Kubeconfig context, see -context
+	Contexts  []string `json:"contexts"`  // Clusters of a multi-cluster mesh, see -contexts
+	Namespace string   `json:"namespace"` // Used when PLUGIN_NAMESPACE is not set
+	Theme     string   `json:"theme"`     // Overrides the config's theme
+
+	// Gateways are the ingress workloads as namespace/name, such as
+	// istio-system/istio-ingressgateway, whose pods search and find-request
+	// read along with the namespace's sidecars.
+	Gateways []string `json:"gateways"`
+
+	// Loki and Jaeger are base URLs link templates can use as .Loki and
+	// .Jaeger, so one set of links serves every environment.
+	Loki   string `json:"loki"`
+	Jaeger string `json:"jaeger"`
+}
+
+// gatewayRef is a gateway workload parsed from a profile.
+type gatewayRef struct {
+	namespace, name string
+}
+
+func (g gatewayRef) String() string {
+	return g.namespace + "/" + g.name
+}
+
+// gateways parses the profile's gateways.
+func (p ClusterProfile) gateways() ([]gatewayRef, error) {
+	var refs []gatewayRef
+	for _, gateway := range p.Gateways {
+		namespace, name, ok := strings.Cut(gateway, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("gateway %q is not namespace/name", gateway)
+		}
+		refs = append(refs, gatewayRef{namespace: namespace, name: name})
+	}
+	return refs, nil
+}
+
+// linkEndpoints returns the profile's URLs as link template values.
+func (p ClusterProfile) linkEndpoints() linkContext {
+	endpoints := linkContext{}
+	if p.Loki != "" {
+		endpoints["Loki"] = strings.TrimSuffix(p.Loki, "/")
+	}
+	if p.Jaeger != "" {
+		endpoints["Jaeger"] = strings.TrimSuffix(p.Jaeger, "/")
+	}
+	return endpoints
+}
+
+// profileNames lists the config's profiles by name.
+func (c Config) profileNames() []string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectProfile returns the named profile, or an empty one when name is
+// empty.
+func (c Config) selectProfile(name string) (ClusterProfile, error) {
+	if name == "" {
+		return ClusterProfile{}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return profile, fmt.Errorf("unknown profile %q, the config defines none", name)
+		}
+		return profile, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(c.profileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// applyProfile fills in what the flags and environment left unset from the
+// profile: the kube contexts, namespace and theme, the gateways searched and
+// the URLs links can use.
+func applyProfile(profile ClusterProfile, opts *cliOptions, cfg *Config) error {
+	gateways, err := profile.gateways()
+	if err != nil {
+		return err
+	}
+	opts.gateways = gateways
+
+	if opts.kube.context == "" {
+		opts.kube.context = profile.Context
+	}
+	if len(opts.contexts) == 0 {
+		opts.contexts = profile.Contexts
+	}
+	if profile.Theme != "" {
+		cfg.Theme = profile.Theme
+	}
+	// Every subcommand reads the namespace from the plugin's environment
+	if profile.Namespace != "" && os.Getenv("PLUGIN_NAMESPACE") == "" {
+		if err := os.Setenv("PLUGIN_NAMESPACE", profile.Namespace); err != nil {
+			return err
+		}
+	}
+	linkEndpoints = profile.linkEndpoints()
+	return nil
+}
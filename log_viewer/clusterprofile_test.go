@@ -0,0 +1,102 @@
+// log_viewer/clusterprofile_test.go
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := `{"theme": "dark", "profiles": {
+		"prod-us": {"context": "prod-us-east", "namespace": "bookinfo", "theme": "light",
+			"gateways": ["istio-system/istio-ingressgateway"], "loki": "https://loki.prod.example.com/"},
+		"staging": {"context": "staging"}}}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+	if _, err := cfg.selectProfile("prod-eu"); err == nil || !strings.Contains(err.Error(), "prod-us, staging") {
+		t.Errorf("expected an error listing the profiles, got %v", err)
+	}
+
+	profile, err := cfg.selectProfile("prod-us")
+	if err != nil {
+		t.Fatalf("selectProfile() unexpected error: %v", err)
+	}
+	t.Setenv("PLUGIN_NAMESPACE", "")
+	defer func(previous linkContext) { linkEndpoints = previous }(linkEndpoints)
+
+	// A context given as a flag wins over the profile's
+	opts := cliOptions{kube: kubeClientOptions{context: "kind"}}
+	if err := applyProfile(profile, &opts, &cfg); err != nil {
+		t.Fatalf("applyProfile() unexpected error: %v", err)
+	}
+	if opts.kube.context != "kind" || cfg.Theme != "light" || os.Getenv("PLUGIN_NAMESPACE") != "bookinfo" {
+		t.Errorf("expected the profile's settings filled in, got context %q, theme %q, namespace %q",
+			opts.kube.context, cfg.Theme, os.Getenv("PLUGIN_NAMESPACE"))
+	}
+	if len(opts.gateways) != 1 || opts.gateways[0].String() != "istio-system/istio-ingressgateway" {
+		t.Errorf("unexpected gateways %v", opts.gateways)
+	}
+
+	// Links can use the profile's URLs
+	links := []LinkTemplate{{Name: "Loki", URL: "{{.Loki}}/explore"}, {Name: "Jaeger", URL: "{{.Jaeger}}/search"}}
+	for i := range links {
+		if err := links[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := renderLinks(links, linkContext{})
+	if len(got) != 1 || got[0].URL != "https://loki.prod.example.com/explore" {
+		t.Errorf("expected only the Loki link, got %+v", got)
+	}
+}
+
+func TestInvalidProfileGateway(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"profiles": {"prod": {"gateways": ["istio-ingressgateway"]}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "profile prod") {
+		t.Errorf("expected an error naming the profile, got %v", err)
+	}
+}
+
+func TestSearchTargetsGateways(t *testing.T) {
+	pod := func(namespace, name string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: sidecarContainer}}},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		pod("bookinfo", "reviews-v1-abc"),
+		pod("istio-system", "istio-ingressgateway-5d-xyz"),
+		pod("istio-system", "istio-eastwestgateway-7f-xyz"),
+	)
+
+	gateways := []gatewayRef{{namespace: "istio-system", name: "istio-ingressgateway"}}
+	targets, err := searchTargets(context.Background(), client, []string{"bookinfo"}, gateways)
+	if err != nil {
+		t.Fatalf("searchTargets() unexpected error: %v", err)
+	}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.String())
+	}
+	if strings.Join(names, ",") != "bookinfo/reviews-v1-abc,istio-system/istio-ingressgateway-5d-xyz" {
+		t.Errorf("expected the sidecar and the ingress gateway, got %v", names)
+	}
+}
@@ -0,0 +1,113 @@
+// log_viewer/compare.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// compareSubset is the -compare field naming the subset of an upstream
+// cluster, e.g. v2 in outbound|9080|v2|reviews.default.svc.cluster.local,
+// which is how canaries split by DestinationRule subsets show up.
+const compareSubset = "subset"
+
+// variantStats are the figures compared between variants.
+type variantStats struct {
+	value     string
+	requests  int
+	errors    int
+	durations []float64 // Sorted
+}
+
+// comparison splits request stats by the value of a field, for A/B and
+// canary analysis. Requests without the field are left out.
+type comparison struct {
+	field    string
+	variants []variantStats // Sorted by value, so v1 comes before v2
+}
+
+// compareValue returns the value of field to group a request under, or ""
+// when it has none. source.<label> groups by where requests were logged,
+// e.g. source.pod, user_agent.family and user_agent.version by client, and
+// query.<name> by a query parameter. path is compared without its query.
+func compareValue(log ParsedLog, field string) string {
+	if field == compareSubset {
+		if parts := strings.Split(log.UpstreamCluster(), "|"); len(parts) == 4 {
+			return parts[2]
+		}
+		return ""
+	}
+	if field == compareClientFamily || field == compareClientVersion {
+		return userAgentValue(log, field)
+	}
+	if label, ok := strings.CutPrefix(field, sourcePrefix); ok && isSourceLabel(label) {
+		return log.Source.Label(label)
+	}
+	if name, ok := strings.CutPrefix(field, queryPrefix); ok {
+		return strings.Join(queryParam(log, name), ",")
+	}
+	if field == "path" {
+		return barePath(log)
+	}
+	value, ok := log.Fields[field]
+	if !ok || value == nil {
+		return ""
+	}
+	if s := exportString(value); s != "-" {
+		return s
+	}
+	return ""
+}
+
+// compareBy splits the requests in logs by field.
+func compareBy(logs []ParsedLog, field string) comparison {
+	byValue := make(map[string]*variantStats)
+	for _, entry := range logs {
+		code, isRequest := entry.ResponseCode()
+		value := compareValue(entry, field)
+		if !isRequest || value == "" {
+			continue
+		}
+		variant := byValue[value]
+		if variant == nil {
+			variant = &variantStats{value: value}
+			byValue[value] = variant
+		}
+		variant.requests++
+		if requestFailed(code, entry.Flags()) {
+			variant.errors++
+		}
+		if duration, ok := entry.FloatField("duration"); ok {
+			variant.durations = append(variant.durations, duration)
+		}
+	}
+
+	c := comparison{field: field}
+	for _, variant := range byValue {
+		sort.Float64s(variant.durations)
+		c.variants = append(c.variants, *variant)
+	}
+	sort.Slice(c.variants, func(i, j int) bool { return c.variants[i].value < c.variants[j].value })
+	return c
+}
+
+// writeMarkdown prints the variants side by side.
+func (c comparison) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "\n## Comparison by %s\n\n", markdownCell(c.field))
+	if len(c.variants) == 0 {
+		fmt.Fprintf(w, "No requests have a %s.\n", markdownCell(c.field))
+		return
+	}
+	fmt.Fprintln(w, "| Value | Requests | Errors | Error rate | p50 | p95 | p99 |\n| --- | ---: | ---: | ---: | ---: | ---: | ---: |")
+	for _, v := range c.variants {
+		latency := "| - | - | -"
+		if len(v.durations) > 0 {
+			latency = fmt.Sprintf("| %gms | %gms | %gms",
+				percentile(v.durations, 50), percentile(v.durations, 95), percentile(v.durations, 99))
+		}
+		fmt.Fprintf(w, "| %s | %d | %d | %s %s |\n", markdownCell(v.value), v.requests, v.errors, percent(v.errors, v.requests), latency)
+	}
+}
@@ -0,0 +1,66 @@
+// log_viewer/compare_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareBySubset(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":200,"duration":10,"upstream_cluster":"outbound|9080|v1|reviews.default.svc.cluster.local"}`,
+		`{"response_code":200,"duration":30,"upstream_cluster":"outbound|9080|v1|reviews.default.svc.cluster.local"}`,
+		`{"response_code":503,"duration":90,"response_flags":"UF","upstream_cluster":"outbound|9080|v2|reviews.default.svc.cluster.local"}`,
+		`{"response_code":200,"duration":50,"upstream_cluster":"outbound|9080|v2|reviews.default.svc.cluster.local"}`,
+		`{"response_code":200,"duration":5,"upstream_cluster":"inbound|9080||"}`,
+		`{"message":"not a request"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	c := compareBy(logs, compareSubset)
+	if len(c.variants) != 2 || c.variants[0].value != "v1" || c.variants[1].value != "v2" {
+		t.Fatalf("expected v1 and v2, got %+v", c.variants)
+	}
+
+	var report strings.Builder
+	c.writeMarkdown(&report)
+	for _, row := range []string{
+		"| v1 | 2 | 0 | 0.0% | 10ms | 30ms | 30ms |",
+		"| v2 | 2 | 1 | 50.0% | 50ms | 90ms | 90ms |",
+	} {
+		if !strings.Contains(report.String(), row) {
+			t.Errorf("expected %q in the comparison, got:\n%s", row, report.String())
+		}
+	}
+}
+
+func TestCompareByField(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":200,"canary":true}`,
+		`{"response_code":500,"canary":false}`,
+		`{"response_code":200,"canary":"-"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	var report strings.Builder
+	compareBy(logs, "canary").writeMarkdown(&report)
+	for _, row := range []string{
+		"| false | 1 | 1 | 100.0% | - | - | - |",
+		"| true | 1 | 0 | 0.0% | - | - | - |",
+	} {
+		if !strings.Contains(report.String(), row) {
+			t.Errorf("expected %q in the comparison, got:\n%s", row, report.String())
+		}
+	}
+
+	report.Reset()
+	compareBy(logs, "revision").writeMarkdown(&report)
+	if !strings.Contains(report.String(), "No requests have a revision.") {
+		t.Errorf("expected a note when no request has the field, got:\n%s", report.String())
+	}
+}
@@ -0,0 +1,267 @@
+// log_viewer/completion.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// completeCommand is the hidden subcommand generated scripts call back into
+// for values that depend on the environment, such as kubeconfig contexts.
+const completeCommand = "__complete"
+
+// subcommands are the words accepted as the first argument.
+var subcommands = []string{"exec", "summarize", "batch", "export", "transform", "search", "find-request", "waterfall", "cluster-audit", "generate", "serve", "attach", "mesh", "setup", "completion"}
+
+// completionShells are the shells a completion script can be generated for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// valueCompletion describes how the value of a flag is completed. Flags
+// without one are completed with nothing, so the shell does not offer files
+// for a number or URL.
+type valueCompletion struct {
+	words   func() []string // Fixed choices
+	dynamic string          // Kind passed to __complete to list choices
+	files   bool            // Complete file paths
+}
+
+var valueCompletions = map[string]valueCompletion{
+	"theme":           {words: themeNames},
+	"timezone":        {words: func() []string { return []string{"UTC", "Local"} }},
+	"time-format":     {words: func() []string { return []string{timeFormatShort, timeFormatRFC3339} }},
+	"format":          {words: func() []string { return exportFormats }},
+	"notify-format":   {words: func() []string { return notifyFormats }},
+	"compare":         {words: compareFields},
+	"merge-tie-break": {words: func() []string { return tieBreaks }},
+	"schema":          {words: schemaNames},
+	"context":         {dynamic: "contexts"},
+	"contexts":        {dynamic: "contexts"},
+	"profile":         {dynamic: "profiles"},
+	"config":          {files: true},
+	"kubeconfig":      {files: true},
+	"o":               {files: true},
+	"notes":           {files: true},
+	"socket":          {files: true},
+	"state":           {files: true},
+}
+
+// completionFlag is a flag as a completion script needs to know it.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool // Takes no value
+	value  valueCompletion
+}
+
+// completionFlags lists the flags of every subcommand, from the same flag
+// sets they parse, so new flags are completed without extra work.
+func completionFlags() []completionFlag {
+	seen := make(map[string]bool)
+	var flags []completionFlag
+	for subcommand := range subcommandFlags {
+		var opts cliOptions
+		fs, _ := newFlagSet(subcommand, &opts)
+		fs.VisitAll(func(f *flag.Flag) {
+			if seen[f.Name] {
+				return
+			}
+			seen[f.Name] = true
+			boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+			flags = append(flags, completionFlag{
+				name:   f.Name,
+				usage:  f.Usage,
+				isBool: ok && boolFlag.IsBoolFlag(),
+				value:  valueCompletions[f.Name],
+			})
+		})
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].name < flags[j].name })
+	return flags
+}
+
+// writeCompletion writes the completion script for shell.
+func writeCompletion(w io.Writer, shell, program string) error {
+	flags := completionFlags()
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w, program, flags)
+	case "zsh":
+		return writeZshCompletion(w, program, flags)
+	case "fish":
+		return writeFishCompletion(w, program, flags)
+	}
+	return fmt.Errorf("unknown shell %q, expected one of %s", shell, strings.Join(completionShells, ", "))
+}
+
+func writeBashCompletion(w io.Writer, program string, flags []completionFlag) error {
+	fn := "_" + shellIdentifier(program)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s, load with: source <(%s completion bash)\n", program, program)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	var names []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if f.isBool {
+			continue
+		}
+		fmt.Fprintf(&b, "    -%s|--%s)\n", f.name, f.name)
+		switch {
+		case f.value.words != nil:
+			fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(f.value.words(), " "))
+		case f.value.dynamic != "":
+			fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W \"$(%s %s %s 2>/dev/null)\" -- \"$cur\"))\n", program, completeCommand, f.value.dynamic)
+		case f.value.files:
+			b.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		default:
+			b.WriteString("        COMPREPLY=()\n")
+		}
+		b.WriteString("        return ;;\n")
+	}
+	b.WriteString("    esac\n")
+	fmt.Fprintf(&b, "    if [[ \"$cur\" == -* ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintf(&b, "    elif [[ $COMP_CWORD -eq 1 ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(subcommands, " "))
+	fmt.Fprintf(&b, "    elif [[ \"${COMP_WORDS[1]}\" == completion ]]; then\n        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(completionShells, " "))
+	b.WriteString("    else\n        COMPREPLY=($(compgen -f -- \"$cur\"))\n    fi\n}\n")
+	fmt.Fprintf(&b, "complete -o filenames -F %s %s\n", fn, program)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeZshCompletion(w io.Writer, program string, flags []completionFlag) error {
+	fn := "_" + shellIdentifier(program)
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n# zsh completion for %s, load with: source <(%s completion zsh)\n", program, program, program)
+	fmt.Fprintf(&b, "%s() {\n    _arguments \\\n", fn)
+	for _, f := range flags {
+		spec := fmt.Sprintf("-%s[%s]", f.name, zshEscape(f.usage))
+		if !f.isBool {
+			switch {
+			case f.value.words != nil:
+				spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.value.words(), " "))
+			case f.value.dynamic != "":
+				spec += fmt.Sprintf(":%s:{compadd -- $(%s %s %s 2>/dev/null)}", f.name, program, completeCommand, f.value.dynamic)
+			case f.value.files:
+				spec += fmt.Sprintf(":%s:_files", f.name)
+			default:
+				spec += fmt.Sprintf(":%s: ", f.name)
+			}
+		}
+		fmt.Fprintf(&b, "        '%s' \\\n", strings.ReplaceAll(spec, "'", `'\''`))
+	}
+	fmt.Fprintf(&b, "        '1:command:(%s)' \\\n", strings.Join(subcommands, " "))
+	fmt.Fprintf(&b, "        '*:file:_files'\n}\ncompdef %s %s\n", fn, program)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer, program string, flags []completionFlag) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s, load with: %s completion fish | source\n", program, program)
+	fmt.Fprintf(&b, "complete -c %s -n '__fish_use_subcommand' -a '%s'\n", program, strings.Join(subcommands, " "))
+	fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from completion' -f -a '%s'\n", program, strings.Join(completionShells, " "))
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c %s -o %s -d %s", program, f.name, fishQuote(f.usage))
+		if !f.isBool {
+			switch {
+			case f.value.words != nil:
+				line += fmt.Sprintf(" -x -a %s", fishQuote(strings.Join(f.value.words(), " ")))
+			case f.value.dynamic != "":
+				line += fmt.Sprintf(" -x -a '(%s %s %s 2>/dev/null)'", program, completeCommand, f.value.dynamic)
+			case f.value.files:
+				line += " -r -F"
+			default:
+				line += " -x"
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shellIdentifier turns a program name into something usable as a shell
+// function name.
+func shellIdentifier(program string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, program)
+}
+
+// zshEscape escapes a flag description for an _arguments spec.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// kubeContexts lists the contexts of the kubeconfig the client would load,
+// honoring KUBECONFIG unless kubeconfig is set.
+func kubeContexts(kubeconfig string) ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	config, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+	var names []string
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// runCompletion prints the completion script for the shell named in args.
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: log_viewer completion %s\n", strings.Join(completionShells, "|"))
+		os.Exit(1)
+	}
+	if err := writeCompletion(os.Stdout, args[0], "log_viewer"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runComplete prints one candidate per line for the dynamic completion kind
+// in args. Failures print nothing, since the shell has nowhere to show them.
+func runComplete(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	switch args[0] {
+	case "contexts":
+		names, err := kubeContexts("")
+		if err != nil {
+			log.Println("Completion failed:", err)
+			return
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	case "profiles":
+		cfg, err := loadConfig(defaultConfigPath())
+		if err != nil {
+			log.Println("Completion failed:", err)
+			return
+		}
+		for _, name := range cfg.profileNames() {
+			fmt.Println(name)
+		}
+	}
+}
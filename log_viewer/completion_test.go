@@ -0,0 +1,97 @@
+// log_viewer/completion_test.go
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	tests := []struct {
+		shell    string
+		contains []string
+	}{
+		{"bash", []string{
+			"complete -o filenames -F _log_viewer log_viewer",
+			`-theme|--theme)`,
+			`compgen -W "csv parquet"`,
+			"log_viewer __complete contexts",
+		}},
+		{"zsh", []string{
+			"#compdef log_viewer",
+			`'-no-color[disable colors (also enabled by NO_COLOR)]'`,
+			":context:{compadd -- $(log_viewer __complete contexts 2>/dev/null)}",
+			"'1:command:(exec summarize batch export transform search find-request waterfall cluster-audit generate serve attach mesh setup completion)'",
+		}},
+		{"fish", []string{
+			"complete -c log_viewer -o format -d ",
+			"-x -a 'csv parquet'",
+			"-o notes -d 'file to keep notes on entries in, read by export and summarize too' -r -F",
+			"-x -a '(log_viewer __complete contexts 2>/dev/null)'",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeCompletion(&b, tt.shell, "log_viewer"); err != nil {
+				t.Fatalf("writeCompletion() unexpected error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("expected the %s script to contain %q", tt.shell, want)
+				}
+			}
+			// Boolean flags take no value
+			if tt.shell == "bash" && strings.Contains(b.String(), "-redact|--redact)") {
+				t.Error("expected -redact not to complete a value")
+			}
+
+			if shell, err := exec.LookPath(tt.shell); err == nil {
+				path := filepath.Join(t.TempDir(), "completion")
+				if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args := []string{"-n", path}
+				if tt.shell == "fish" {
+					args = []string{"--no-execute", path}
+				}
+				if out, err := exec.Command(shell, args...).CombinedOutput(); err != nil {
+					t.Errorf("expected the script to parse, got %v: %s", err, out)
+				}
+			}
+		})
+	}
+
+	if err := writeCompletion(&strings.Builder{}, "tcsh", "log_viewer"); err == nil {
+		t.Error("expected an unknown shell to be rejected")
+	}
+}
+
+func TestKubeContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := `apiVersion: v1
+kind: Config
+contexts:
+- name: staging
+  context: {cluster: staging, user: admin}
+- name: prod
+  context: {cluster: prod, user: admin}
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := kubeContexts(path)
+	if err != nil {
+		t.Fatalf("kubeContexts() unexpected error: %v", err)
+	}
+	if want := []string{"prod", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kubeContexts() = %v, want %v", got, want)
+	}
+}
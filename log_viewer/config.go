@@ -0,0 +1,123 @@
+// log_viewer/config.go
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds user settings loaded from the config file.
+type Config struct {
+	Keys  KeyMap `json:"keys"`  // Overrides for the default key bindings
+	Theme string `json:"theme"` // Theme name, overridden by --theme and --no-color
+
+	Timezone   string `json:"timezone"`    // Zone timestamps are shown in, overridden by --timezone
+	TimeFormat string `json:"time_format"` // See selectTimeDisplay, overridden by --time-format
+
+	Multiline MultilineConfig `json:"multiline"` // Joining of stack traces and pretty-printed JSON
+	Merge     MergeConfig     `json:"merge"`     // Interleaving of several sources, see mergeLogs
+	Links     []LinkTemplate  `json:"links"`     // Deep links shown in details and reports
+	Redact    []RedactRule    `json:"redact"`    // Masking applied to every entry, see RedactRule
+
+	Highlights []HighlightRule `json:"highlights"` // Styles and bells for matching entries, see HighlightRule
+	SLOs       []string        `json:"slos"`       // Success rate targets used when --slo is not given, see parseSLO
+	Alerts     []AlertRule     `json:"alerts"`     // Posted to --notify-url while following, see AlertRule
+	JWTFields  []string        `json:"jwt_fields"` // Fields holding bearer tokens whose claims are shown, see tokenClaims
+	PodFields  []string        `json:"pod_fields"` // Pod labels and annotations added to entries from Kubernetes, see podFields
+
+	Profiles       map[string]ClusterProfile `json:"profiles"`        // Per-environment settings selected with --profile
+	DefaultProfile string                    `json:"default_profile"` // Profile used when --profile is not given
+}
+
+// defaultConfigPath returns the config file location, honoring LOG_VIEWER_CONFIG.
+func defaultConfigPath() string {
+	if path := os.Getenv("LOG_VIEWER_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "istio-parsin", "config.json")
+}
+
+// loadConfig reads the config file at path. A missing file is not an error and
+// yields the defaults.
+func loadConfig(path string) (Config, error) {
+	cfg := Config{Keys: defaultKeyMap(), Multiline: defaultMultilineConfig(), JWTFields: defaultJWTFields, PodFields: defaultPodFields}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error reading config %s: %v", path, err)
+	}
+
+	fileCfg := Config{Multiline: cfg.Multiline, JWTFields: cfg.JWTFields, PodFields: cfg.PodFields}
+	if err := json.Unmarshal(data, &fileCfg); err != nil {
+		return cfg, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+
+	keys, err := cfg.Keys.merge(fileCfg.Keys)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid key bindings in %s: %v", path, err)
+	}
+	for i := range fileCfg.Links {
+		if err := fileCfg.Links[i].compile(); err != nil {
+			return cfg, fmt.Errorf("invalid links in %s: %v", path, err)
+		}
+	}
+
+	for i := range fileCfg.Redact {
+		if err := fileCfg.Redact[i].compile(); err != nil {
+			return cfg, fmt.Errorf("invalid redaction in %s: %v", path, err)
+		}
+	}
+
+	for i := range fileCfg.Highlights {
+		if err := fileCfg.Highlights[i].compile(); err != nil {
+			return cfg, fmt.Errorf("invalid highlights in %s: %v", path, err)
+		}
+	}
+
+	for i := range fileCfg.Alerts {
+		if err := fileCfg.Alerts[i].compile(); err != nil {
+			return cfg, fmt.Errorf("invalid alerts in %s: %v", path, err)
+		}
+	}
+
+	if _, err := parseSLOs(fileCfg.SLOs); err != nil {
+		return cfg, fmt.Errorf("invalid slos in %s: %v", path, err)
+	}
+
+	for name, profile := range fileCfg.Profiles {
+		if _, err := profile.gateways(); err != nil {
+			return cfg, fmt.Errorf("invalid profile %s in %s: %v", name, path, err)
+		}
+	}
+
+	cfg.Keys = keys
+	cfg.Theme = fileCfg.Theme
+	cfg.Timezone = fileCfg.Timezone
+	cfg.TimeFormat = fileCfg.TimeFormat
+	cfg.Multiline = fileCfg.Multiline
+	cfg.Links = fileCfg.Links
+	cfg.Redact = fileCfg.Redact
+	cfg.Highlights = fileCfg.Highlights
+	cfg.SLOs = fileCfg.SLOs
+	cfg.Alerts = fileCfg.Alerts
+	cfg.JWTFields = fileCfg.JWTFields
+	cfg.PodFields = fileCfg.PodFields
+	cfg.Profiles = fileCfg.Profiles
+	cfg.DefaultProfile = fileCfg.DefaultProfile
+
+	return cfg, nil
+}
@@ -0,0 +1,55 @@
+// log_viewer/connection.go
+
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envoyConnectionIDPattern matches the [C123] connection tag Envoy prefixes to
+// connection-scoped debug messages.
+var envoyConnectionIDPattern = regexp.MustCompile(`\[C(\d+)\]`)
+
+// extractConnectionID returns the numeric connection ID tagged in an Envoy debug message.
+func extractConnectionID(message string) (float64, bool) {
+	match := envoyConnectionIDPattern.FindStringSubmatch(message)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// connectionID returns the log's connection ID formatted like Envoy's "C123"
+// tag. Access logs carry it as connection_id (%CONNECTION_ID%), either as a
+// number or a string.
+func connectionID(log ParsedLog) (string, bool) {
+	if id, ok := numberString(log.Fields["connection_id"]); ok {
+		return "C" + id, true
+	}
+	if id, ok := log.StringField("connection_id"); ok && id != "" {
+		return "C" + strings.TrimPrefix(id, "C"), true
+	}
+	return "", false
+}
+
+// filterByConnection keeps the logs belonging to a connection, in their original
+// order, so debug lines and the access log entry read as one lifecycle.
+func filterByConnection(logs []ParsedLog, id string) []ParsedLog {
+	if id == "" {
+		return logs
+	}
+
+	var filtered []ParsedLog
+	for _, log := range logs {
+		if logID, ok := connectionID(log); ok && logID == id {
+			filtered = append(filtered, log)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,131 @@
+// log_viewer/crash.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crashReport collects what went wrong when the TUI panics.
+type crashReport struct {
+	mu        sync.Mutex
+	program   *tea.Program // Used to shut down after a panic in View
+	recovered interface{}
+	stack     []byte
+	model     string
+	lastInput string
+	when      time.Time
+}
+
+// record stores the first panic; later ones are usually follow-on failures.
+func (r *crashReport) record(recovered interface{}, stack []byte, model tea.Model, lastInput tea.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recovered != nil {
+		return
+	}
+	r.recovered = recovered
+	r.stack = stack
+	r.model = summarizeModel(model)
+	r.lastInput = fmt.Sprintf("%#v", lastInput)
+	r.when = time.Now()
+}
+
+func (r *crashReport) crashed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recovered != nil
+}
+
+// write saves the report to dir and returns its path.
+func (r *crashReport) write(dir string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "log_viewer crash report\n")
+	fmt.Fprintf(&builder, "Time: %s\n", r.when.Format(time.RFC3339))
+	fmt.Fprintf(&builder, "Panic: %v\n\n", r.recovered)
+	fmt.Fprintf(&builder, "Model:\n%s\n\n", r.model)
+	fmt.Fprintf(&builder, "Last input: %s\n\n", r.lastInput)
+	fmt.Fprintf(&builder, "Stack:\n%s\n", r.stack)
+
+	path := filepath.Join(dir, fmt.Sprintf("log_viewer-crash-%s.txt", r.when.Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(builder.String()), 0o600); err != nil {
+		return "", fmt.Errorf("error writing crash report: %v", err)
+	}
+	return path, nil
+}
+
+// summarizeModel describes the model state without dumping every log.
+func summarizeModel(model tea.Model) string {
+	m, ok := model.(Model)
+	if !ok {
+		return fmt.Sprintf("%T", model)
+	}
+	return fmt.Sprintf(
+		"logs=%d filtered=%d selected=%d size=%dx%d searchMode=%t jumpMode=%t query=%q filter=%q connection=%q following=%t pinned=%t layout=%q",
+		len(m.logs), len(m.filteredLogs), m.selectedLogIndex, m.width, m.height,
+		m.searchMode, m.jumpMode, m.searchQuery, m.activeFilter, m.connectionFilter,
+		m.following, m.pinned, m.layout,
+	)
+}
+
+// crashGuard wraps a model so panics in Update or View are recorded in the
+// report and end the program cleanly, restoring the terminal from alt-screen.
+type crashGuard struct {
+	model     tea.Model
+	lastInput tea.Msg
+	report    *crashReport
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	return g.model.Init()
+}
+
+func (g crashGuard) Update(msg tea.Msg) (next tea.Model, cmd tea.Cmd) {
+	if g.report.crashed() {
+		return g, tea.Quit
+	}
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg, tea.WindowSizeMsg:
+		g.lastInput = msg
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			g.report.record(recovered, debug.Stack(), g.model, g.lastInput)
+			next, cmd = g, tea.Quit
+		}
+	}()
+
+	g.model, cmd = g.model.Update(msg)
+	return g, cmd
+}
+
+func (g crashGuard) View() (view string) {
+	if g.report.crashed() {
+		return ""
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			g.report.record(recovered, debug.Stack(), g.model, g.lastInput)
+			if g.report.program != nil {
+				// View cannot return a command, so quit from outside the event loop
+				go g.report.program.Quit()
+			}
+			view = ""
+		}
+	}()
+
+	return g.model.View()
+}
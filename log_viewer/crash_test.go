@@ -0,0 +1,79 @@
+// log_viewer/crash_test.go
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// panickingModel fails on demand to exercise crashGuard.
+type panickingModel struct {
+	panicInView bool
+}
+
+func (m panickingModel) Init() tea.Cmd { return nil }
+
+func (m panickingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	panic("boom in Update")
+}
+
+func (m panickingModel) View() string {
+	if m.panicInView {
+		panic("boom in View")
+	}
+	return "ok"
+}
+
+func TestCrashGuardUpdate(t *testing.T) {
+	report := &crashReport{}
+	guard := crashGuard{model: panickingModel{}, report: report}
+
+	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+	_, cmd := guard.Update(key)
+	if cmd == nil {
+		t.Fatalf("expected the guard to quit after a panic")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("expected a quit command after a panic")
+	}
+	if !report.crashed() {
+		t.Fatalf("expected the panic to be recorded")
+	}
+
+	path, err := report.write(t.TempDir())
+	if err != nil {
+		t.Fatalf("write() unexpected error: %v", err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{"boom in Update", "Last input:", "panickingModel", "crash_test.go"} {
+		if !strings.Contains(string(contents), part) {
+			t.Errorf("expected crash report to contain %q", part)
+		}
+	}
+}
+
+func TestCrashGuardView(t *testing.T) {
+	report := &crashReport{}
+	guard := crashGuard{model: panickingModel{panicInView: true}, report: report}
+
+	if view := guard.View(); view != "" {
+		t.Errorf("expected an empty view after a panic, got %q", view)
+	}
+	if !report.crashed() {
+		t.Errorf("expected the panic in View to be recorded")
+	}
+}
+
+func TestSummarizeModel(t *testing.T) {
+	summary := summarizeModel(Model{logs: make([]ParsedLog, 3), activeFilter: "UF"})
+	if !strings.Contains(summary, "logs=3") || !strings.Contains(summary, `filter="UF"`) {
+		t.Errorf("unexpected model summary: %s", summary)
+	}
+}
@@ -0,0 +1,317 @@
+// log_viewer/daemon.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// `log_viewer serve` follows a source for as long as it runs and keeps the
+// most recent lines, so the fetch survives TUIs coming and going: any number
+// of `log_viewer attach` clients get the kept lines, then every new one, over
+// a Unix socket. The daemon neither parses nor indexes: it keeps a ring of
+// raw lines, and each client parses the whole backlog from scratch when it
+// attaches.
+//
+// The default socket is private to the user who started serve. For several
+// engineers to share a session on a jump host, serve on a -socket in a
+// directory their group owns, created setgid so the socket gets that group:
+//
+//	mkdir -m 2770 /srv/istio-parsin && chgrp oncall /srv/istio-parsin
+//	log_viewer serve -socket /srv/istio-parsin/checkout.sock
+//	log_viewer attach -socket /srv/istio-parsin/checkout.sock
+
+const (
+	daemonBacklog   = 100_000 // Lines kept for clients that attach later
+	daemonClientLag = 4096    // Lines a client may fall behind before it is dropped
+)
+
+// Permissions of the socket: the default one is the user's alone, one given
+// with -socket lets the group of its directory attach too.
+const (
+	privateSocketMode os.FileMode = 0o600
+	sharedSocketMode  os.FileMode = 0o660
+)
+
+// defaultSocketPath returns where serve listens and attach connects: the
+// user's runtime directory when there is one, a temporary path named by the
+// user's uid otherwise. Either way only that user can reach it, see
+// socketMode.
+func defaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "istio-parsin.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("istio-parsin-%d.sock", os.Getuid()))
+}
+
+// socketMode returns the permissions to serve on path with: private for the
+// default socket, shared for one the user chose.
+func socketMode(path string) os.FileMode {
+	if path == defaultSocketPath() {
+		return privateSocketMode
+	}
+	return sharedSocketMode
+}
+
+// logHub keeps the recent lines of a source and fans new ones out to
+// subscribers.
+type logHub struct {
+	mu      sync.Mutex
+	backlog []string
+	clients map[chan string]bool
+	ended   bool // The source has ended, no more lines will come
+}
+
+func newLogHub() *logHub {
+	return &logHub{clients: make(map[chan string]bool)}
+}
+
+// publish keeps line and sends it to every subscriber. A subscriber too far
+// behind is dropped rather than holding up the source.
+func (h *logHub) publish(line string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.backlog = append(h.backlog, line)
+	if len(h.backlog) > daemonBacklog {
+		// Copy so the dropped lines can be collected
+		h.backlog = append([]string(nil), h.backlog[len(h.backlog)-daemonBacklog/2:]...)
+	}
+	for client := range h.clients {
+		select {
+		case client <- line:
+		default:
+			log.Println("Dropping a daemon client that fell behind")
+			delete(h.clients, client)
+			close(client)
+		}
+	}
+}
+
+// subscribe returns the lines kept so far and a channel of the lines after
+// them. The channel is closed when the source ends.
+func (h *logHub) subscribe() ([]string, <-chan string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	backlog := append([]string(nil), h.backlog...)
+	client := make(chan string, daemonClientLag)
+	if h.ended {
+		close(client)
+	} else {
+		h.clients[client] = true
+	}
+	return backlog, client
+}
+
+// unsubscribe stops sending to a client that went away.
+func (h *logHub) unsubscribe(lines <-chan string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if client == lines {
+			delete(h.clients, client)
+			close(client)
+		}
+	}
+}
+
+// end closes every subscription once the source has no more lines.
+func (h *logHub) end() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ended = true
+	for client := range h.clients {
+		delete(h.clients, client)
+		close(client)
+	}
+}
+
+// serveClient writes the backlog, then new lines, to a client until either
+// side goes away.
+func (h *logHub) serveClient(conn net.Conn) {
+	defer conn.Close()
+	backlog, lines := h.subscribe()
+	defer h.unsubscribe(lines)
+
+	w := bufio.NewWriter(conn)
+	for _, line := range backlog {
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return
+	}
+	for line := range lines {
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return
+		}
+		// Flush once caught up, batching lines that arrive together
+		if len(lines) == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+	w.Flush()
+}
+
+// listenSocket listens on path with mode, replacing a socket left behind by
+// a daemon that is no longer running.
+func listenSocket(path string, mode os.FileMode) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already serving %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error removing stale socket %s: %v", path, err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %v", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("error setting permissions on %s: %v", path, err)
+	}
+	return listener, nil
+}
+
+// serveSource returns the lines to serve: the command given as arguments,
+// piped stdin, or the pod named by the PLUGIN_* variables, followed. Once the
+// lines are closed, the returned function reports why the source ended.
+func serveSource(ctx context.Context, opts cliOptions) (<-chan string, func() error, string, error) {
+	if len(opts.args) > 0 {
+		lines, streamErr, err := startExecSource(ctx, opts.args[0], opts.args[1:]...)
+		return lines, streamErr, opts.args[0], err
+	}
+
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error checking stdin: %v", err)
+	}
+	if stat.Mode()&os.ModeCharDevice == 0 {
+		lines := make(chan string)
+		var scanErr error
+		go func() {
+			defer close(lines)
+			scanner := newLineScanner(os.Stdin)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			scanErr = scanner.Err()
+		}()
+		return lines, func() error { return scanErr }, "stdin", nil
+	}
+
+	namespace, pod, container := os.Getenv("PLUGIN_NAMESPACE"), os.Getenv("PLUGIN_POD"), os.Getenv("PLUGIN_CONTAINER")
+	if namespace == "" || pod == "" || container == "" {
+		return nil, nil, "", fmt.Errorf("no input source: pass a command, pipe logs on stdin, or set PLUGIN_POD, PLUGIN_NAMESPACE and PLUGIN_CONTAINER")
+	}
+	clientset, err := CreateKubeClient(opts.kube)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error creating Kubernetes client: %v", err)
+	}
+	lines, streamErr, err := StreamLogsFromK8s(ctx, clientset, namespace, pod, container, opts.sourceOptions()...)
+	return lines, streamErr, fmt.Sprintf("%s/%s", namespace, pod), err
+}
+
+// runServe implements `log_viewer serve [-socket path] [-- command [args...]]`.
+func runServe(opts cliOptions, _ Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lines, streamErr, name, err := serveSource(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error starting daemon source:", err)
+		os.Exit(1)
+	}
+	listener, err := listenSocket(opts.socketPath, socketMode(opts.socketPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error starting daemon:", err)
+		os.Exit(1)
+	}
+	defer os.Remove(opts.socketPath)
+
+	hub := newLogHub()
+	go func() {
+		for line := range lines {
+			hub.publish(line)
+		}
+		hub.end()
+		if err := streamErr(); err != nil {
+			log.Printf("Source %s ended with %v, still serving its last lines", name, err)
+			return
+		}
+		log.Printf("Source %s ended, still serving its last lines", name)
+	}()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving %s on %s (attach with: log_viewer attach -socket %s)\n", name, opts.socketPath, opts.socketPath)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println("Error accepting client:", err)
+			}
+			return
+		}
+		go hub.serveClient(conn)
+	}
+}
+
+// attachStream connects to a daemon and returns the lines it sends. The
+// channel is closed when the daemon goes away or its source ends; the
+// returned function then reports why.
+func attachStream(path string) (<-chan string, func() error, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no daemon is serving %s, start one with log_viewer serve: %v", path, err)
+	}
+
+	lines := make(chan string)
+	var scanErr error
+	go func() {
+		defer close(lines)
+		defer conn.Close()
+		scanner := newLineScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		scanErr = scanner.Err()
+	}()
+	// Only read once the channel is closed, which orders it after the write
+	return lines, func() error { return scanErr }, nil
+}
+
+// runAttach implements `log_viewer attach [-socket path]`.
+func runAttach(opts cliOptions, cfg Config) {
+	lines, streamErr, err := attachStream(opts.socketPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error attaching to daemon:", err)
+		os.Exit(1)
+	}
+
+	model := newModel(opts, cfg)
+	model.stream = lines
+	model.streamErr = streamErr
+	model.logsFrom = opts.socketPath
+	model.following = true
+	model.pinned = true
+	runTUI(model)
+}
@@ -0,0 +1,189 @@
+// log_viewer/daemon_test.go
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogHub(t *testing.T) {
+	hub := newLogHub()
+	hub.publish("one")
+
+	backlog, lines := hub.subscribe()
+	if !reflect.DeepEqual(backlog, []string{"one"}) {
+		t.Errorf("expected the backlog to hold the earlier line, got %v", backlog)
+	}
+	hub.publish("two")
+	if got := <-lines; got != "two" {
+		t.Errorf("expected the new line, got %q", got)
+	}
+
+	hub.end()
+	if _, open := <-lines; open {
+		t.Error("expected the subscription to close when the source ends")
+	}
+	backlog, lines = hub.subscribe()
+	if _, open := <-lines; open || len(backlog) != 2 {
+		t.Errorf("expected a late client to get the backlog and a closed channel, got %v", backlog)
+	}
+}
+
+func TestLogHubDropsSlowClients(t *testing.T) {
+	hub := newLogHub()
+	_, lines := hub.subscribe()
+	for i := 0; i <= daemonClientLag; i++ {
+		hub.publish("line")
+	}
+	count := 0
+	for range lines {
+		count++
+	}
+	if count != daemonClientLag {
+		t.Errorf("expected the client to be dropped after %d lines, got %d", daemonClientLag, count)
+	}
+}
+
+func TestServeAndAttach(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "d.sock")
+	listener, err := listenSocket(path, socketMode(path))
+	if err != nil {
+		t.Fatalf("listenSocket() unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	if _, err := listenSocket(path, socketMode(path)); err == nil || !strings.Contains(err.Error(), "already serving") {
+		t.Errorf("expected a second daemon on the same socket to be refused, got %v", err)
+	}
+
+	hub := newLogHub()
+	hub.publish(`{"message":"before attach"}`)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go hub.serveClient(conn)
+		}
+	}()
+
+	// Two clients share the session
+	var clients []<-chan string
+	for range 2 {
+		lines, _, err := attachStream(path)
+		if err != nil {
+			t.Fatalf("attachStream() unexpected error: %v", err)
+		}
+		clients = append(clients, lines)
+	}
+	for _, lines := range clients {
+		if got := receive(t, lines); got != `{"message":"before attach"}` {
+			t.Errorf("expected the backlog first, got %q", got)
+		}
+	}
+
+	hub.publish(`{"message":"after attach"}`)
+	for _, lines := range clients {
+		if got := receive(t, lines); got != `{"message":"after attach"}` {
+			t.Errorf("expected the new line, got %q", got)
+		}
+	}
+
+	hub.end()
+	for _, lines := range clients {
+		select {
+		case _, open := <-lines:
+			if open {
+				t.Error("expected the stream to close when the source ends")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the stream to close")
+		}
+	}
+}
+
+func TestListenSocketInSharedDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shared")
+	if err := os.Mkdir(dir, 0o2770); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "checkout.sock")
+	listener, err := listenSocket(path, socketMode(path))
+	if err != nil {
+		t.Fatalf("listenSocket() unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	// The group the directory lets in can connect
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != sharedSocketMode {
+		t.Errorf("expected a socket the group can connect to, got mode %v", info.Mode())
+	}
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	if _, _, err := attachStream(path); err != nil {
+		t.Errorf("attachStream() unexpected error: %v", err)
+	}
+}
+
+func TestDefaultSocketIsPrivate(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	path := defaultSocketPath()
+	if mode := socketMode(path); mode != privateSocketMode {
+		t.Errorf("expected the default socket %s to be the user's alone, got mode %v", path, mode)
+	}
+	if mode := socketMode(filepath.Join(t.TempDir(), "shared.sock")); mode != sharedSocketMode {
+		t.Errorf("expected a chosen socket to be shared with the group, got mode %v", mode)
+	}
+
+	path = filepath.Join(t.TempDir(), "private.sock")
+	listener, err := listenSocket(path, privateSocketMode)
+	if err != nil {
+		t.Fatalf("listenSocket() unexpected error: %v", err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != privateSocketMode {
+		t.Errorf("expected the socket to be the user's alone, got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestListenSocketReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "d.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave the file behind, as a killed daemon would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenSocket(path, socketMode(path))
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced, got %v", err)
+	}
+	listener.Close()
+}
+
+func receive(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a line")
+		return ""
+	}
+}
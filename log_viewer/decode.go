@@ -0,0 +1,235 @@
+// log_viewer/decode.go
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// D decodes the selected entry's fields in a popup: URL-encoded paths and
+// query strings, base64 values and JWTs such as a logged authorization
+// header, so they need not be pasted into other tools. y copies the
+// decoding of the field selected in the popup.
+
+// minBase64Length keeps short words, which are often valid base64, from
+// being decoded.
+const minBase64Length = 8
+
+// decoding is one way a value reads decoded.
+type decoding struct {
+	name string // Such as "URL" or "JWT payload"
+	text string
+}
+
+// decodedField is a field of the entry with its decodings.
+type decodedField struct {
+	field, value string
+	decodings    []decoding
+}
+
+// decodeView is the open popup of decoded fields.
+type decodeView struct {
+	fields   []decodedField
+	selected int
+}
+
+// decodeValue returns the decodings of value, none when it reads the same
+// decoded.
+func decodeValue(value string) []decoding {
+	if header, payload, ok := decodeJWT(value); ok {
+		return []decoding{{"JWT header", header}, {"JWT payload", payload}}
+	}
+	var found []decoding
+	if decoded, ok := decodeURL(value); ok {
+		found = append(found, decoding{"URL", decoded})
+	}
+	if decoded, ok := decodeBase64(value); ok {
+		found = append(found, decoding{"Base64", decoded})
+	}
+	return found
+}
+
+// decodeURL unescapes a path and lists its query parameters one per line.
+func decodeURL(value string) (string, bool) {
+	path, query, hasQuery := strings.Cut(value, "?")
+	decodedPath, err := url.PathUnescape(path)
+	if err != nil {
+		return "", false
+	}
+	if !hasQuery {
+		return decodedPath, decodedPath != value
+	}
+
+	if decodedPath == path && !strings.ContainsAny(query, "%+") {
+		// Nothing is escaped
+		return "", false
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil || len(params) == 0 {
+		return "", false
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{decodedPath}
+	for _, name := range names {
+		for _, v := range params[name] {
+			lines = append(lines, fmt.Sprintf("  %s = %s", name, v))
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not, when the
+// result is readable text.
+func decodeBase64(value string) (string, bool) {
+	if len(value) < minBase64Length {
+		return "", false
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := encoding.DecodeString(value)
+		if err == nil && readable(decoded) {
+			if pretty, ok := prettyJSONBytes(decoded); ok {
+				return pretty, true
+			}
+			return string(decoded), true
+		}
+	}
+	return "", false
+}
+
+// decodeJWT decodes the header and payload of a JWT, see jwtSegments. The
+// signature is not checked.
+func decodeJWT(value string) (header, payload string, ok bool) {
+	headerData, payloadData, ok := jwtSegments(value)
+	if !ok {
+		return "", "", false
+	}
+	if header, ok = prettyJSONBytes(headerData); !ok {
+		return "", "", false
+	}
+	if payload, ok = prettyJSONBytes(payloadData); !ok {
+		return "", "", false
+	}
+	return header, payload, true
+}
+
+// prettyJSONBytes indents data when it is a JSON object.
+func prettyJSONBytes(data []byte) (string, bool) {
+	var doc map[string]interface{}
+	if err := decodeJSON(data, &doc); err != nil {
+		return "", false
+	}
+	pretty, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", false
+	}
+	return string(pretty), true
+}
+
+// readable reports whether data is UTF-8 text without control characters
+// other than line breaks and tabs.
+func readable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// openDecode decodes the selected entry's string fields, or says there is
+// nothing to decode.
+func (m Model) openDecode() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	log := m.filteredLogs[m.selectedLogIndex]
+	names := make([]string, 0, len(log.Fields))
+	for name := range log.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []decodedField
+	for _, name := range names {
+		value, ok := log.Fields[name].(string)
+		if !ok {
+			continue
+		}
+		if decodings := decodeValue(value); len(decodings) > 0 {
+			fields = append(fields, decodedField{field: name, value: value, decodings: decodings})
+		}
+	}
+	if len(fields) == 0 {
+		m.notice = "Nothing to decode in this entry"
+		return m
+	}
+	m.decode = &decodeView{fields: fields}
+	return m
+}
+
+// updateDecode handles keys while the popup is open.
+func (m Model) updateDecode(msg tea.KeyMsg) (Model, tea.Cmd) {
+	view := *m.decode
+	switch m.action(msg.String()) {
+	case actionQuit:
+		return m, tea.Quit
+	case actionCancel, actionDecode:
+		m.decode = nil
+		return m, nil
+	case actionUp:
+		view.selected = max(view.selected-1, 0)
+	case actionDown:
+		view.selected = min(view.selected+1, len(view.fields)-1)
+	case actionCopy:
+		field := view.fields[view.selected]
+		var texts []string
+		for _, d := range field.decodings {
+			texts = append(texts, d.text)
+		}
+		m.notice = fmt.Sprintf("Copied decoded %s to the clipboard", field.field)
+		return m, copyToClipboard(strings.Join(texts, "\n"))
+	}
+	m.decode = &view
+	return m, nil
+}
+
+// renderDecode lists the decodable fields with the selected one's decodings
+// under them.
+func (m Model) renderDecode() string {
+	view := m.decode
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Decoded fields (up/down to choose, y to copy, esc to close)") + "\n")
+	for i, field := range view.fields {
+		line := fmt.Sprintf("%-30s %s", field.field, truncate(field.value, max(m.width-36, 4)))
+		if i == view.selected {
+			b.WriteString(selectedLogStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString(logStyle.Render("  "+line) + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	width := max(m.width-2, minWidth)
+	for _, d := range view.fields[view.selected].decodings {
+		b.WriteString(jsonKeyStyle.Render(d.name) + "\n")
+		b.WriteString(lipgloss.NewStyle().Width(width).Render(jsonStringStyle.Render(d.text)) + "\n\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+// log_viewer/decode_test.go
+
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDecodeValue(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","iss":"https://issuer"}`))
+	jwt := "Bearer " + header + "." + payload + ".c2lnbmF0dXJl"
+
+	tests := []struct {
+		value string
+		want  []string // Names and texts expected in the decodings
+	}{
+		{"/search/caf%C3%A9?q=a%26b+c&page=2", []string{"URL", "/search/café", "q = a&b c", "page = 2"}},
+		{base64.StdEncoding.EncodeToString([]byte("user:secret")), []string{"Base64", "user:secret"}},
+		{jwt, []string{"JWT header", `"alg": "RS256"`, "JWT payload", `"sub": "alice"`}},
+		{"/productpage?u=normal", nil},
+		{"outbound|9080||reviews.bookinfo.svc.cluster.local", nil},
+		{"response", nil},
+		{"1.2.3", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range decodeValue(tt.value) {
+			got = append(got, d.name, d.text)
+		}
+		joined := strings.Join(got, "\n")
+		if len(tt.want) == 0 && len(got) > 0 {
+			t.Errorf("decodeValue(%q) = %q, expected nothing", tt.value, joined)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(joined, want) {
+				t.Errorf("decodeValue(%q) = %q, expected %q in it", tt.value, joined, want)
+			}
+		}
+	}
+}
+
+func TestDecodePopup(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"response_code":200,"path":"/a%20b","authority":"reviews"}`,
+		`{"response_code":200,"path":"/plain"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	var m tea.Model = Model{logs: logs, filteredLogs: logs, width: 100, height: 30}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	if view := m.View(); !strings.Contains(view, "Decoded fields") || !strings.Contains(view, "/a b") {
+		t.Errorf("expected the decoded path in the popup, got:\n%s", view)
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Errorf("expected y to copy the decoding")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	if model := m.(Model); model.decode != nil || model.notice != "Nothing to decode in this entry" {
+		t.Errorf("expected a notice for an entry with nothing to decode, got %q", model.notice)
+	}
+}
@@ -0,0 +1,50 @@
+// log_viewer/decompress.go
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic numbers at the start of compressed log archives. Log shippers write
+// snappy in its framing format, which starts with a stream identifier chunk.
+var (
+	gzipMagic   = []byte{0x1f, 0x8b}
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// decompressed returns a reader of r's uncompressed contents, recognizing
+// gzip, zstd and framed snappy by their magic numbers rather than the file
+// name, so piped archives work too. Anything else is returned as it is. The
+// returned close function releases the decoder.
+func decompressed(r io.Reader) (io.Reader, func(), error) {
+	buffered := bufio.NewReader(r)
+	head, _ := buffered.Peek(len(snappyMagic))
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading gzip: %v", err)
+		}
+		return gz, func() { gz.Close() }, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		// One goroutine is plenty for reading logs line by line
+		zr, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading zstd: %v", err)
+		}
+		return zr, zr.Close, nil
+	case bytes.HasPrefix(head, snappyMagic):
+		return s2.NewReader(buffered), func() {}, nil
+	}
+	return buffered, func() {}, nil
+}
@@ -0,0 +1,50 @@
+// log_viewer/decompress_test.go
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestReadLinesDecompresses(t *testing.T) {
+	input := "{\"n\":1}\n{\"n\":2}\n"
+	compress := map[string]func(w io.Writer) io.WriteCloser{
+		"plain": func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} },
+		"gzip":  func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			zw, _ := zstd.NewWriter(w)
+			return zw
+		},
+		"snappy": func(w io.Writer) io.WriteCloser { return s2.NewWriter(w, s2.WriterSnappyCompat()) },
+	}
+	for name, newWriter := range compress {
+		var archive bytes.Buffer
+		w := newWriter(&archive)
+		if _, err := io.WriteString(w, input); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		lines, err := readLines(&archive)
+		if err != nil {
+			t.Errorf("%s: readLines() unexpected error: %v", name, err)
+			continue
+		}
+		if expected := []string{`{"n":1}`, `{"n":2}`}; !reflect.DeepEqual(lines, expected) {
+			t.Errorf("%s: readLines() = %q, expected %q", name, lines, expected)
+		}
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
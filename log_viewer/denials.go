@@ -0,0 +1,141 @@
+// log_viewer/denials.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Requests Istio refused before they reached the application, by an
+// AuthorizationPolicy (rbac_access_denied, ext_authz_denied) or by
+// RequestAuthentication (jwt_authn_access_denied and the like), are told
+// apart by their response_code_details.
+
+// Denial kinds.
+const (
+	denialAuthz = "authz"
+	denialJWT   = "jwt"
+)
+
+// topDenials is how many identity and path pairs the report lists.
+const topDenials = 10
+
+// Denials started at a specific time when there were none for at least
+// minDenialLead of logs before the first one, and at least minDenials since.
+const (
+	minDenialLead = 5 * time.Minute
+	minDenials    = 3
+)
+
+// principalFields hold the caller's identity in access log formats that
+// record it, e.g. with %DOWNSTREAM_PEER_PRINCIPAL%.
+var principalFields = []string{
+	"downstream_peer_principal", "source_principal", "downstream_peer_uri_san", "principal",
+}
+
+// denialKind returns whether the entry records an authz or JWT denial.
+func denialKind(log ParsedLog) (string, bool) {
+	details := strings.ToLower(getFieldSafely(log.Fields, "response_code_details"))
+	switch {
+	case strings.Contains(details, "jwt"):
+		return denialJWT, true
+	case strings.Contains(details, "rbac_access_denied"), strings.Contains(details, "ext_authz_denied"):
+		return denialAuthz, true
+	}
+	return "", false
+}
+
+// sourcePrincipal returns the caller's identity: its principal when the
+// format records one, otherwise its IP.
+func sourcePrincipal(log ParsedLog) string {
+	for _, field := range principalFields {
+		if principal, ok := log.StringField(field); ok && principal != "" {
+			return principal
+		}
+	}
+	if ip := addressIP(getFieldSafely(log.Fields, "downstream_remote_address")); ip != "" {
+		return ip
+	}
+	return "(unknown)"
+}
+
+// denialKey groups denials by who was denied what.
+type denialKey struct {
+	principal, path, kind string
+}
+
+// denialStats counts the denied requests of some logs.
+type denialStats struct {
+	count      int
+	byKind     map[string]int
+	byKey      map[denialKey]int
+	first      time.Time // Earliest timestamped denial
+	firstEntry time.Time // Earliest timestamped entry, denied or not
+}
+
+// analyzeDenials counts the authz and JWT denials in logs.
+func analyzeDenials(logs []ParsedLog) denialStats {
+	d := denialStats{byKind: make(map[string]int), byKey: make(map[denialKey]int)}
+	for _, entry := range logs {
+		t, hasTime := eventTime(entry)
+		if hasTime && (d.firstEntry.IsZero() || t.Before(d.firstEntry)) {
+			d.firstEntry = t
+		}
+		kind, ok := denialKind(entry)
+		if !ok {
+			continue
+		}
+		d.count++
+		d.byKind[kind]++
+		d.byKey[denialKey{principal: sourcePrincipal(entry), path: barePath(entry), kind: kind}]++
+		if hasTime && (d.first.IsZero() || t.Before(d.first)) {
+			d.first = t
+		}
+	}
+	return d
+}
+
+// onset returns when denials started, if the logs show a time before which
+// there were none.
+func (d denialStats) onset() (time.Time, bool) {
+	if d.count < minDenials || d.first.IsZero() || d.first.Sub(d.firstEntry) < minDenialLead {
+		return time.Time{}, false
+	}
+	return d.first, true
+}
+
+// topKeys returns up to topDenials identity and path pairs, most denied
+// first.
+func (d denialStats) topKeys() []denialKey {
+	counts := make(map[string]int, len(d.byKey))
+	keys := make(map[string]denialKey, len(d.byKey))
+	for key, count := range d.byKey {
+		name := key.principal + "\x00" + key.path + "\x00" + key.kind
+		counts[name], keys[name] = count, key
+	}
+	var top []denialKey
+	for _, name := range countsByValue(counts) {
+		if len(top) == topDenials {
+			break
+		}
+		top = append(top, keys[name])
+	}
+	return top
+}
+
+// writeMarkdown writes the denials section of the summary.
+func (d denialStats) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "\n## Denials\n\n- **Denied:** %d (%d by authorization policy, %d by JWT authentication)\n",
+		d.count, d.byKind[denialAuthz], d.byKind[denialJWT])
+	if start, ok := d.onset(); ok {
+		fmt.Fprintf(w, "- **Started:** %s, after %s without any\n",
+			activeTimeDisplay.timestamp(start), start.Sub(d.firstEntry).Round(time.Second))
+	}
+	fmt.Fprintln(w, "\n| Identity | Path | Kind | Denials |\n| --- | --- | --- | ---: |")
+	for _, key := range d.topKeys() {
+		fmt.Fprintf(w, "| %s | %s | %s | %d |\n", markdownCell(key.principal), markdownCell(key.path), key.kind, d.byKey[key])
+	}
+}
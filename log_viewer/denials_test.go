@@ -0,0 +1,60 @@
+// log_viewer/denials_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeDenials(t *testing.T) {
+	lines := []string{
+		`{"start_time":"2024-05-01T12:00:00Z","response_code":200,"path":"/api/orders"}`,
+		`{"start_time":"2024-05-01T12:10:00Z","response_code":403,"path":"/api/orders?id=1","response_code_details":"rbac_access_denied_matched_policy[none]","downstream_peer_principal":"spiffe://cluster.local/ns/shop/sa/frontend"}`,
+		`{"start_time":"2024-05-01T12:10:05Z","response_code":403,"path":"/api/orders","response_code_details":"rbac_access_denied_matched_policy[none]","downstream_peer_principal":"spiffe://cluster.local/ns/shop/sa/frontend"}`,
+		`{"start_time":"2024-05-01T12:10:09Z","response_code":401,"path":"/api/login","response_code_details":"jwt_authn_access_denied{Jwt_is_missing}","downstream_remote_address":"10.0.0.7:51234"}`,
+		`{"start_time":"2024-05-01T12:10:10Z","response_code":503,"response_code_details":"upstream_reset_before_response_started"}`,
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	d := analyzeDenials(logs)
+	if d.count != 3 || d.byKind[denialAuthz] != 2 || d.byKind[denialJWT] != 1 {
+		t.Fatalf("analyzeDenials() = %d denials %v, expected 2 authz and 1 jwt", d.count, d.byKind)
+	}
+	top := d.topKeys()
+	want := denialKey{principal: "spiffe://cluster.local/ns/shop/sa/frontend", path: "/api/orders", kind: denialAuthz}
+	if len(top) != 2 || top[0] != want || top[1].principal != "10.0.0.7" {
+		t.Errorf("topKeys() = %+v, expected the frontend first, then the caller's IP", top)
+	}
+	if start, ok := d.onset(); !ok || start.Format("15:04:05") != "12:10:00" {
+		t.Errorf("onset() = %v, %v, expected the first denial at 12:10:00", start, ok)
+	}
+
+	var out bytes.Buffer
+	report := summarize(logs, "test")
+	report.writeMarkdown(&out)
+	for _, want := range []string{"## Denials", "| spiffe://cluster.local/ns/shop/sa/frontend | /api/orders | authz | 2 |", "denials started at"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the report, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDenialOnsetNeedsQuietLead(t *testing.T) {
+	lines := []string{
+		`{"start_time":"2024-05-01T12:00:00Z","response_code":403,"response_code_details":"rbac_access_denied_matched_policy[ns[shop]-policy[deny-all]-rule[0]]"}`,
+		`{"start_time":"2024-05-01T12:00:01Z","response_code":403,"response_code_details":"ext_authz_denied"}`,
+		`{"start_time":"2024-05-01T12:20:00Z","response_code":403,"response_code_details":"ext_authz_denied"}`,
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	if start, ok := analyzeDenials(logs).onset(); ok {
+		t.Errorf("onset() = %v, expected none when denials go back to the start of the logs", start)
+	}
+}
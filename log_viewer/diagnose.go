@@ -0,0 +1,148 @@
+// log_viewer/diagnose.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Pool exhaustion heuristics. A burst is at least minPoolFailures symptoms
+// for one cluster, each within poolBurstGap of the previous one.
+const (
+	minPoolFailures = 5
+	poolBurstGap    = time.Minute
+)
+
+// poolSymptom classifies an entry as a sign of an exhausted upstream
+// connection pool: an overflow (UO, the circuit breaker's connection or
+// pending request limit) or a failure to connect (UF).
+func poolSymptom(log ParsedLog) (overflow, connectFailure bool) {
+	details, _ := log.StringField("response_code_details")
+	code, _ := log.ResponseCode()
+	for _, flag := range log.Flags() {
+		switch flag {
+		case "UO":
+			overflow = true
+		case "UF":
+			connectFailure = true
+		}
+	}
+	if code == 503 && strings.Contains(details, "overflow") {
+		overflow = true
+	}
+	if code == 503 && strings.Contains(details, "connection_failure") {
+		connectFailure = true
+	}
+	return overflow, connectFailure
+}
+
+// poolDiagnosis is a burst of pool exhaustion symptoms on one cluster.
+type poolDiagnosis struct {
+	cluster         string
+	start, end      time.Time
+	overflows       int
+	connectFailures int
+}
+
+// String explains the burst in words.
+func (d poolDiagnosis) String() string {
+	when := fmt.Sprintf("between %s and %s", activeTimeDisplay.clock(d.start), activeTimeDisplay.clock(d.end))
+	counts := fmt.Sprintf("%d overflows (UO), %d connection failures (UF)", d.overflows, d.connectFailures)
+	if d.overflows >= d.connectFailures {
+		return fmt.Sprintf("Likely upstream connection pool exhaustion on cluster %s %s: %s. "+
+			"The circuit breaker rejected requests; consider raising the DestinationRule's connectionPool limits or scaling the upstream.",
+			d.cluster, when, counts)
+	}
+	return fmt.Sprintf("Likely upstream connection pool exhaustion on cluster %s %s: %s. "+
+		"New connections could not be opened; check the upstream's health, its connection limits and outlierDetection.",
+		d.cluster, when, counts)
+}
+
+// diagnosePools finds bursts of pool exhaustion symptoms in logs, per
+// upstream cluster, ordered by start time.
+func diagnosePools(logs []ParsedLog) []poolDiagnosis {
+	type symptom struct {
+		at                       time.Time
+		overflow, connectFailure bool
+	}
+	byCluster := make(map[string][]symptom)
+	for _, entry := range logs {
+		overflow, connectFailure := poolSymptom(entry)
+		if !overflow && !connectFailure {
+			continue
+		}
+		t, ok := eventTime(entry)
+		if !ok {
+			continue
+		}
+		cluster := getFieldSafely(entry.Fields, "upstream_cluster")
+		byCluster[cluster] = append(byCluster[cluster], symptom{at: t, overflow: overflow, connectFailure: connectFailure})
+	}
+
+	var found []poolDiagnosis
+	for cluster, symptoms := range byCluster {
+		sort.Slice(symptoms, func(i, j int) bool { return symptoms[i].at.Before(symptoms[j].at) })
+
+		var burst poolDiagnosis
+		count := 0
+		flush := func() {
+			if count >= minPoolFailures {
+				found = append(found, burst)
+			}
+		}
+		for i, s := range symptoms {
+			if i == 0 || s.at.Sub(symptoms[i-1].at) > poolBurstGap {
+				flush()
+				burst = poolDiagnosis{cluster: cluster, start: s.at}
+				count = 0
+			}
+			burst.end = s.at
+			count++
+			if s.overflow {
+				burst.overflows++
+			}
+			if s.connectFailure {
+				burst.connectFailures++
+			}
+		}
+		flush()
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].start.Equal(found[j].start) {
+			return found[i].start.Before(found[j].start)
+		}
+		return found[i].cluster < found[j].cluster
+	})
+	return found
+}
+
+// diagnosisView is the open diagnosis panel.
+type diagnosisView struct {
+	diagnoses []poolDiagnosis
+}
+
+// openDiagnosis analyzes every loaded entry, not just the filtered ones, so
+// a filter cannot hide the burst.
+func (m Model) openDiagnosis() Model {
+	m.diagnosis = &diagnosisView{diagnoses: diagnosePools(m.logs)}
+	return m
+}
+
+// renderDiagnosisView shows the diagnoses over the whole screen.
+func (m Model) renderDiagnosisView() string {
+	header := headerStyle.Render("Diagnosis (esc to close)")
+	if len(m.diagnosis.diagnoses) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, header, logStyle.Render("No signs of connection pool exhaustion."))
+	}
+	var b strings.Builder
+	for _, d := range m.diagnosis.diagnoses {
+		b.WriteString(warnRowStyle(logStyle).Width(max(m.width-2, minWidth)).Render("• "+d.String()) + "\n\n")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, b.String())
+}
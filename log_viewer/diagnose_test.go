@@ -0,0 +1,65 @@
+// log_viewer/diagnose_test.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func diagnoseTestLines() []string {
+	var lines []string
+	// Six overflows on reviews within three minutes, then one much later
+	for _, second := range []int{60, 75, 100, 130, 170, 220, 1200} {
+		lines = append(lines, fmt.Sprintf(`{"start_time":"2024-05-04T12:%02d:%02d.000Z","response_code":503,"response_flags":"UO","upstream_cluster":"outbound|9080||reviews"}`,
+			second/60, second%60))
+	}
+	// Connection failures spotted by their details only
+	for second := 0; second < 5; second++ {
+		lines = append(lines, fmt.Sprintf(`{"start_time":"2024-05-04T12:10:0%d.000Z","response_code":503,"response_flags":"-","response_code_details":"upstream_reset_before_response_started{connection_failure}","upstream_cluster":"outbound|9080||ratings"}`, second))
+	}
+	// Too few to be a burst
+	for second := 0; second < 4; second++ {
+		lines = append(lines, fmt.Sprintf(`{"start_time":"2024-05-04T12:20:0%d.000Z","response_code":503,"response_flags":"UF","upstream_cluster":"outbound|9080||details"}`, second))
+	}
+	return lines
+}
+
+func TestDiagnosePools(t *testing.T) {
+	found := diagnosePools(mustParse(t, diagnoseTestLines()...))
+	if len(found) != 2 {
+		t.Fatalf("diagnosePools() found %d bursts, expected 2: %v", len(found), found)
+	}
+
+	reviews := found[0]
+	if reviews.cluster != "outbound|9080||reviews" || reviews.overflows != 6 || reviews.connectFailures != 0 {
+		t.Errorf("unexpected reviews burst %+v", reviews)
+	}
+	expected := "Likely upstream connection pool exhaustion on cluster outbound|9080||reviews between 12:01:00 and 12:03:40"
+	if !strings.HasPrefix(reviews.String(), expected) || !strings.Contains(reviews.String(), "connectionPool") {
+		t.Errorf("String() = %q, expected it to start with %q and suggest connectionPool limits", reviews.String(), expected)
+	}
+
+	if ratings := found[1]; ratings.cluster != "outbound|9080||ratings" || ratings.connectFailures != 5 {
+		t.Errorf("unexpected ratings burst %+v", ratings)
+	}
+}
+
+func TestOpenDiagnosis(t *testing.T) {
+	logs := mustParse(t, diagnoseTestLines()...)
+	model := Model{logs: logs, filteredLogs: logs[:1], width: 120, height: 30}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	model = updated.(Model)
+	if model.diagnosis == nil || !strings.Contains(model.View(), "ratings") {
+		t.Fatalf("expected d to open the diagnosis of every loaded entry")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).diagnosis != nil {
+		t.Errorf("expected esc to close the diagnosis")
+	}
+}
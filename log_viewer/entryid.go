@@ -0,0 +1,64 @@
+// log_viewer/entryid.go
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EntryID identifies a log entry across sources. Line numbers alone collide
+// as soon as two pods or files are read, so the ID adds the source, and a
+// time to order entries from different sources by.
+//
+// Time is the entry's event time, but never earlier than the previous entry
+// of the same source: entries without a timestamp, or logged out of order,
+// take the time before them. IDs of one source therefore sort in the order
+// the source produced them, and merged sources interleave by time.
+type EntryID struct {
+	Time   time.Time // Event time, see above
+	Source string    // Pod or file the entry came from, empty when unknown
+	Seq    int       // Line number within the source
+}
+
+// Compare orders IDs by time, then source, then position in the source.
+func (id EntryID) Compare(other EntryID) int {
+	if c := id.Time.Compare(other.Time); c != 0 {
+		return c
+	}
+	if c := strings.Compare(id.Source, other.Source); c != 0 {
+		return c
+	}
+	return cmp.Compare(id.Seq, other.Seq)
+}
+
+// String returns the source and line, which are enough to tell entries apart.
+func (id EntryID) String() string {
+	return fmt.Sprintf("%s#%d", id.Source, id.Seq)
+}
+
+// IsZero reports whether id identifies no entry.
+func (id EntryID) IsZero() bool {
+	return id.Seq == 0
+}
+
+// entryID returns the ID of an entry read from source after an entry logged
+// at previous.
+func entryID(log ParsedLog, source string, previous time.Time) EntryID {
+	t, ok := eventTime(log)
+	if !ok || t.Before(previous) {
+		t = previous
+	}
+	return EntryID{Time: t, Source: source, Seq: log.LineNumber}
+}
+
+// assignIDs sets the IDs of logs read, in order, from source.
+func assignIDs(logs []ParsedLog, source string) {
+	var previous time.Time
+	for i := range logs {
+		logs[i].ID = entryID(logs[i], source, previous)
+		previous = logs[i].ID.Time
+	}
+}
@@ -0,0 +1,58 @@
+// log_viewer/entryid_test.go
+
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAssignIDs(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"timestamp":"2024-01-01T00:00:05Z","message":"first"}`,
+		`{"message":"no timestamp"}`,
+		`{"timestamp":"2024-01-01T00:00:02Z","message":"logged late"}`,
+		`{"timestamp":"2024-01-01T00:00:08Z","message":"last"}`,
+	}, parseOptions{source: "default/reviews-v1"})
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	at := func(sec int) time.Time { return time.Date(2024, 1, 1, 0, 0, sec, 0, time.UTC) }
+	want := []EntryID{
+		{Time: at(5), Source: "default/reviews-v1", Seq: 1},
+		{Time: at(5), Source: "default/reviews-v1", Seq: 2},
+		{Time: at(5), Source: "default/reviews-v1", Seq: 3},
+		{Time: at(8), Source: "default/reviews-v1", Seq: 4},
+	}
+	for i, log := range logs {
+		if !log.ID.Time.Equal(want[i].Time) || log.ID.Source != want[i].Source || log.ID.Seq != want[i].Seq {
+			t.Errorf("entry %d: got ID %+v, want %+v", i, log.ID, want[i])
+		}
+	}
+	if !slices.IsSortedFunc(logs, func(a, b ParsedLog) int { return a.ID.Compare(b.ID) }) {
+		t.Error("expected the IDs of one source to sort in source order")
+	}
+}
+
+func TestEntryIDCompare(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2024, 1, 1, 0, 0, sec, 0, time.UTC) }
+	tests := []struct {
+		name string
+		a, b EntryID
+		want int
+	}{
+		{"earlier time first", EntryID{Time: at(1), Source: "b", Seq: 9}, EntryID{Time: at(2), Source: "a", Seq: 1}, -1},
+		{"same line, different sources", EntryID{Time: at(1), Source: "a", Seq: 1}, EntryID{Time: at(1), Source: "b", Seq: 1}, -1},
+		{"same source by line", EntryID{Time: at(1), Source: "a", Seq: 3}, EntryID{Time: at(1), Source: "a", Seq: 2}, 1},
+		{"equal", EntryID{Time: at(1), Source: "a", Seq: 1}, EntryID{Time: at(1), Source: "a", Seq: 1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Compare(tt.b); got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// log_viewer/entrysearch.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Finding within the selected entry searches its raw log, like / inside
+// less: matches are highlighted, and n and N (next_match and previous_match
+// in the keys config) scroll the raw pane from one to the next, for entries
+// too large to read through.
+
+// entryContextLines are shown above the current match.
+const entryContextLines = 2
+
+// entrySearch is the open search within the selected entry.
+type entrySearch struct {
+	query   string
+	editing bool    // Still typing the query
+	entry   EntryID // Entry current counts in; another selection starts over
+	current int     // Index of the current match
+}
+
+// textMatch is where a query matched in a line of text, in bytes.
+type textMatch struct {
+	line       int
+	start, end int
+}
+
+// findInText returns the case-insensitive matches of query in text, in order.
+func findInText(text, query string) []textMatch {
+	if query == "" {
+		return nil
+	}
+	lowerQuery := strings.ToLower(query)
+	var matches []textMatch
+	for i, line := range strings.Split(text, "\n") {
+		haystack, needle := strings.ToLower(line), lowerQuery
+		if len(haystack) != len(line) || len(needle) != len(query) {
+			// Offsets would not line up, so only exact case matches
+			haystack, needle = line, query
+		}
+		for offset := 0; ; {
+			at := strings.Index(haystack[offset:], needle)
+			if at < 0 {
+				break
+			}
+			start := offset + at
+			matches = append(matches, textMatch{line: i, start: start, end: start + len(needle)})
+			offset = start + len(needle)
+		}
+	}
+	return matches
+}
+
+// openEntrySearch starts typing a search within the selected entry.
+func (m Model) openEntrySearch() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	m.entrySearch = &entrySearch{editing: true, entry: m.filteredLogs[m.selectedLogIndex].ID}
+	return m
+}
+
+// updateEntrySearch handles keys while searching within the selected entry.
+// Once the query is entered only the find actions, esc and the find key are
+// taken, the rest move through the entries as usual; handled is false for
+// those.
+func (m Model) updateEntrySearch(msg tea.KeyMsg) (updated Model, cmd tea.Cmd, handled bool) {
+	search := *m.entrySearch
+	if len(m.filteredLogs) > 0 {
+		if id := m.filteredLogs[m.selectedLogIndex].ID; id != search.entry {
+			search.entry, search.current = id, 0
+		}
+	}
+
+	if search.editing {
+		switch msg.Type {
+		case tea.KeyRunes, tea.KeySpace:
+			search.query += string(msg.Runes)
+		case tea.KeyBackspace:
+			if runes := []rune(search.query); len(runes) > 0 {
+				search.query = string(runes[:len(runes)-1])
+			}
+		default:
+			switch m.action(msg.String()) {
+			case actionQuit:
+				return m, tea.Quit, true
+			case actionCancel:
+				m.entrySearch = nil
+				return m, nil, true
+			case actionConfirm:
+				if search.query == "" {
+					m.entrySearch = nil
+					return m, nil, true
+				}
+				search.editing, search.current = false, 0
+			}
+		}
+		m.entrySearch = &search
+		return m, nil, true
+	}
+
+	switch action := m.findAction(msg.String()); action {
+	case actionNextMatch, actionPrevMatch:
+		if len(m.filteredLogs) == 0 {
+			return m, nil, true
+		}
+		if count := len(findInText(rawLogText(m.filteredLogs[m.selectedLogIndex], m.expandJSON), search.query)); count > 0 {
+			step := 1
+			if action == actionPrevMatch {
+				step = count - 1
+			}
+			search.current = (search.current + step) % count
+		}
+	case actionCancel:
+		m.entrySearch = nil
+		return m, nil, true
+	case actionFind:
+		search.editing, search.query = true, ""
+	default:
+		m.entrySearch = &search
+		return m, nil, false
+	}
+	m.entrySearch = &search
+	return m, nil, true
+}
+
+// renderEntrySearch is the overlay line of the search within the entry.
+func (m Model) renderEntrySearch(log ParsedLog) string {
+	search := m.entrySearch
+	if search.editing {
+		return searchStyle.Render("Find in entry: " + search.query)
+	}
+	count := len(findInText(rawLogText(log, m.expandJSON), search.query))
+	if count == 0 {
+		return searchStyle.Render(fmt.Sprintf("Find in entry: %s (no matches, esc to close)", search.query))
+	}
+	return searchStyle.Render(fmt.Sprintf("Find in entry: %s (%d of %d, %s/%s for next/previous, esc to close)",
+		search.query, m.currentMatch(log, count)+1, count,
+		strings.Join(m.boundKeys(actionNextMatch), ","), strings.Join(m.boundKeys(actionPrevMatch), ",")))
+}
+
+// currentMatch returns the index of the current match among count in log.
+func (m Model) currentMatch(log ParsedLog, count int) int {
+	if m.entrySearch.entry != log.ID || m.entrySearch.current >= count {
+		return 0
+	}
+	return m.entrySearch.current
+}
+
+// renderRawLogMatches renders the raw log scrolled to the current match, with
+// every match highlighted.
+func (m Model) renderRawLogMatches(log ParsedLog, width, height int, topBorder bool) string {
+	text := rawLogText(log, m.expandJSON)
+	lines := strings.Split(text, "\n")
+	matches := findInText(text, m.entrySearch.query)
+
+	first := 0
+	current := -1
+	if len(matches) > 0 {
+		current = m.currentMatch(log, len(matches))
+		first = max(matches[current].line-entryContextLines, 0)
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Raw Log") + "\n")
+	next := 0
+	for i, line := range lines {
+		// Matches on lines scrolled past are skipped over
+		for next < len(matches) && matches[next].line < i {
+			next++
+		}
+		if i < first {
+			continue
+		}
+		at := 0
+		for ; next < len(matches) && matches[next].line == i; next++ {
+			match := matches[next]
+			style := matchStyle
+			if next == current {
+				style = activeMatchStyle
+			}
+			b.WriteString(jsonStringStyle.Render(line[at:match.start]) + style.Render(line[match.start:match.end]))
+			at = match.end
+		}
+		b.WriteString(jsonStringStyle.Render(line[at:]) + "\n")
+	}
+	return fitPane(b.String(), width, height, topBorder)
+}
+
+// renderRawPane renders the raw log, with the search within it when one is
+// open.
+func (m Model) renderRawPane(log ParsedLog, width, height int) string {
+	if m.entrySearch != nil && !m.entrySearch.editing {
+		return m.renderRawLogMatches(log, width, height, false)
+	}
+	return renderRawLog(log, width, height, m.expandJSON)
+}
@@ -0,0 +1,112 @@
+// log_viewer/entrysearch_test.go
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFindInText(t *testing.T) {
+	got := findInText("Upstream upstream\nnone\nupstream_cluster", "UPSTREAM")
+	expected := []textMatch{{0, 0, 8}, {0, 9, 17}, {2, 0, 8}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("findInText() = %v, expected %v", got, expected)
+	}
+	if got := findInText("anything", ""); got != nil {
+		t.Errorf("expected no matches for an empty query, got %v", got)
+	}
+}
+
+func TestEntrySearch(t *testing.T) {
+	fields := []string{`"response_code":200`}
+	for i := 0; i < 40; i++ {
+		fields = append(fields, fmt.Sprintf(`"header_%02d":"value %d"`, i, i))
+	}
+	fields = append(fields, `"needle_a":"x"`, `"needle_b":"y"`)
+	logs, err := parseRawLogsWith([]string{"{" + strings.Join(fields, ",") + "}"}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	var m tea.Model = Model{logs: logs, filteredLogs: logs, width: 100, height: 40}
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			}
+			m, _ = m.Update(msg)
+		}
+	}
+
+	press("F", "needle", "enter")
+	view := m.View()
+	if !strings.Contains(view, "Find in entry: needle (1 of 2") || !strings.Contains(view, "needle_a") {
+		t.Errorf("expected the raw log scrolled to the first match, got:\n%s", view)
+	}
+	if strings.Contains(view, "header_00") {
+		t.Errorf("expected the fields before the match scrolled past, got:\n%s", view)
+	}
+
+	press("n")
+	if !strings.Contains(m.View(), "(2 of 2") {
+		t.Errorf("expected n to move to the second match, got:\n%s", m.View())
+	}
+	press("n")
+	if !strings.Contains(m.View(), "(1 of 2") {
+		t.Errorf("expected n to wrap around, got:\n%s", m.View())
+	}
+	press("N")
+	if !strings.Contains(m.View(), "(2 of 2") {
+		t.Errorf("expected N to go back, got:\n%s", m.View())
+	}
+
+	press("esc")
+	if m.(Model).entrySearch != nil || strings.Contains(m.View(), "Find in entry") {
+		t.Errorf("expected esc to close the search")
+	}
+}
+
+func TestEntrySearchKeys(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{`{"response_code":200,"needle_a":"x","needle_b":"y"}`}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	keys, err := defaultKeyMap().merge(KeyMap{actionNextMatch: {"ctrl+n"}})
+	if err != nil {
+		t.Fatalf("merge() unexpected error: %v", err)
+	}
+
+	var m tea.Model = Model{logs: logs, filteredLogs: logs, width: 100, height: 40, keys: keys}
+	for _, key := range []string{"F", "needle"} {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if !strings.Contains(m.View(), "(2 of 2, ctrl+n/N") {
+		t.Errorf("expected the remapped key to move to the next match, got:\n%s", m.View())
+	}
+
+	// Nothing left to search once every entry is filtered out
+	for _, key := range []string{"s", "zzzz"} {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.(Model).filteredLogs) != 0 {
+		t.Fatalf("expected every entry filtered out")
+	}
+	// Used to index the empty view and panic; the search stays as it was
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if after := m.(Model); after.selectedLogIndex != 0 || after.entrySearch == nil || after.entrySearch.current != 1 {
+		t.Errorf("expected the next match key to change nothing on an empty view, got index %d and search %+v",
+			after.selectedLogIndex, after.entrySearch)
+	}
+}
@@ -0,0 +1,134 @@
+// log_viewer/events.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+const (
+	eventTimeout     = 5 * time.Second // Bounds the lookup of events, which should never hold up the logs
+	eventNearby      = time.Minute     // Events this close to the selected entry are highlighted
+	minEventsWidth   = 30              // Narrowest events panel
+	maxEventsWidth   = 60              // Widest events panel
+	eventsWidthShare = 3               // The panel takes a third of the screen between the two
+)
+
+// fetchEvents lists the Kubernetes Events of the target pod and of the
+// workload running it, oldest first.
+func (t kubeTarget) fetchEvents() ([]k8ssource.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+	defer cancel()
+	events, err := k8ssource.ListWorkloadEvents(ctx, t.client, t.namespace, t.pod, 0)
+	return events, explainKubeError(err)
+}
+
+// eventsListedMsg carries the events fetched for the events panel.
+type eventsListedMsg struct {
+	target string // The target they are about, see kubeTarget.String
+	events []k8ssource.Event
+	err    error
+}
+
+// listEvents returns a command fetching the target's events.
+func (t kubeTarget) listEvents() tea.Cmd {
+	return func() tea.Msg {
+		events, err := t.fetchEvents()
+		return eventsListedMsg{target: t.String(), events: events, err: err}
+	}
+}
+
+// eventsPanel is the open events panel, shown beside the entries so bursts
+// of failures can be matched to scheduling, OOM kills or failing probes.
+type eventsPanel struct {
+	loading bool
+	err     error // Why the last refresh failed, shown in the panel
+}
+
+// withEvents records the target's events, also shown as markers.
+func (m Model) withEvents(events []k8ssource.Event) Model {
+	m.events = events
+	m.markers = withEventMarkers(m.markers, eventMarkers(events))
+	return m
+}
+
+// toggleEvents closes the events panel, or opens it and refreshes the events.
+func (m Model) toggleEvents() (Model, tea.Cmd) {
+	if m.eventsPanel != nil {
+		m.eventsPanel = nil
+		return m, nil
+	}
+	if m.target == nil {
+		m.notice = "Events are only available for Kubernetes pods"
+		return m, nil
+	}
+	m.eventsPanel = &eventsPanel{loading: true}
+	return m, m.target.listEvents()
+}
+
+// receiveEvents fills the panel. Events of a pod switched away from are
+// dropped.
+func (m Model) receiveEvents(msg eventsListedMsg) Model {
+	if m.eventsPanel == nil || m.target == nil || msg.target != m.target.String() {
+		return m
+	}
+	if msg.err != nil {
+		m.eventsPanel = &eventsPanel{err: msg.err}
+		return m
+	}
+	m.eventsPanel = &eventsPanel{}
+	return m.withEvents(msg.events)
+}
+
+// eventsWidth returns the width of the events panel beside a screen width
+// wide, or 0 when the entries would be left too narrow.
+func eventsWidth(width int) int {
+	panel := min(max(width/eventsWidthShare, minEventsWidth), maxEventsWidth)
+	if width-panel < minWidth {
+		return 0
+	}
+	return panel
+}
+
+// renderEventsPanel lists the events newest first in a pane width wide.
+// Those around the selected entry's time are highlighted.
+func (m Model) renderEventsPanel(selected ParsedLog, width, height int) string {
+	var builder strings.Builder
+	builder.WriteString(headerStyle.Render(fmt.Sprintf("Events of %s", m.target)) + "\n")
+
+	switch {
+	case m.eventsPanel.err != nil:
+		builder.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.eventsPanel.err)))
+	case m.eventsPanel.loading && len(m.events) == 0:
+		builder.WriteString(logStyle.Render("Loading..."))
+	case len(m.events) == 0:
+		builder.WriteString(logStyle.Render("No recent events."))
+	}
+
+	at, hasTime := eventTime(selected)
+	for i := len(m.events) - 1; i >= 0; i-- {
+		event := m.events[i]
+		title := fmt.Sprintf("%s %s", activeTimeDisplay.clock(event.Time), event.Reason)
+		if event.Count > 1 {
+			title += fmt.Sprintf(" (x%d)", event.Count)
+		}
+
+		style := logStyle
+		if event.Type == "Warning" {
+			style = warnRowStyle(style)
+		}
+		if hasTime && event.Time.Sub(at).Abs() <= eventNearby {
+			style = selectedLogStyle
+		}
+		builder.WriteString(style.Render(title) + "\n")
+		builder.WriteString(jsonNullStyle.Render(fmt.Sprintf("%s/%s", strings.ToLower(event.Kind), event.Name)) + "\n")
+		builder.WriteString(logStyle.Render(event.Message) + "\n\n")
+	}
+	return fitPane(builder.String(), width, height, true)
+}
@@ -0,0 +1,70 @@
+// log_viewer/events_test.go
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventsPanel(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	client := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v1", Namespace: "default"}},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "oom", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "reviews-v1", Namespace: "default"},
+			Type:           "Warning",
+			Reason:         "OOMKilling",
+			Message:        "Memory cgroup out of memory",
+			LastTimestamp:  metav1.NewTime(at),
+		},
+	)
+	target := &kubeTarget{client: client, namespace: "default", pod: "reviews-v1", container: "istio-proxy", parseOpts: defaultParseOptions()}
+	logs := []ParsedLog{{LineNumber: 1, RawLog: `{"path":"/a"}`, Fields: map[string]interface{}{"start_time": "2024-05-01T12:00:00Z"}}}
+	model := Model{target: target, logs: logs, filteredLogs: logs, width: 120, height: 40}
+
+	// E fetches the events and shows them beside the entries
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	if model.eventsPanel == nil || model.eventsPanel.loading || len(model.events) != 1 {
+		t.Fatalf("expected the panel open with the event, got %+v and %v", model.eventsPanel, model.events)
+	}
+	view := model.View()
+	if !strings.Contains(view, "OOMKilling") || !strings.Contains(view, "Log List") {
+		t.Errorf("expected the event next to the list, got:\n%s", view)
+	}
+	if len(model.markers) != 1 || !model.markers[0].Event {
+		t.Errorf("expected the event marked among the entries, got %+v", model.markers)
+	}
+
+	// A narrow screen gives the panel all of it
+	model.width = 60
+	if view := model.View(); !strings.Contains(view, "OOMKilling") || strings.Contains(view, "Log List") {
+		t.Errorf("expected only the panel on a narrow screen, got:\n%s", view)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	if updated.(Model).eventsPanel != nil {
+		t.Errorf("expected E to close the panel")
+	}
+}
+
+func TestEventsPanelStaleTarget(t *testing.T) {
+	model := Model{target: testTarget("ratings-v1"), eventsPanel: &eventsPanel{loading: true}}
+	model = model.receiveEvents(eventsListedMsg{target: "default/reviews-v1", err: errors.New("forbidden")})
+	if model.eventsPanel.err != nil {
+		t.Errorf("expected events of a pod switched away from ignored")
+	}
+
+	model = Model{}
+	if model, _ = model.toggleEvents(); model.eventsPanel != nil || model.notice == "" {
+		t.Errorf("expected a notice without a Kubernetes target")
+	}
+}
@@ -0,0 +1,90 @@
+// log_viewer/exec.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// startExecSource runs the command and streams its stdout and stderr lines on the
+// returned channel. The channel is closed once the command has exited; the
+// returned function then reports a non-zero exit status. Canceling ctx kills the
+// command, which is not reported.
+func startExecSource(ctx context.Context, name string, args ...string) (<-chan string, func() error, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error attaching to stdout: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error attaching to stderr: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("error starting %s: %v", name, err)
+	}
+
+	lines := make(chan string)
+	var exitErr error
+	var wg sync.WaitGroup
+	forward := func(r io.Reader) {
+		defer wg.Done()
+		scanner := newLineScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	wg.Add(2)
+	go forward(stdout)
+	go forward(stderr)
+
+	go func() {
+		// Both pipes must be drained before Wait closes them
+		wg.Wait()
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			exitErr = fmt.Errorf("%s exited: %v", name, err)
+		}
+		close(lines)
+	}()
+	// Only read once the channel is closed, which orders it after the write
+	return lines, func() error { return exitErr }, nil
+}
+
+// runExec implements `log_viewer exec -- <command> [args...]`.
+func runExec(opts cliOptions, cfg Config) {
+	if len(opts.args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: log_viewer exec [flags] -- <command> [args...]")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, streamErr, err := startExecSource(ctx, opts.args[0], opts.args[1:]...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error starting command:", err)
+		os.Exit(1)
+	}
+
+	model := newModel(opts, cfg)
+	model.stream = lines
+	model.streamErr = streamErr
+	model.logsFrom = opts.args[0]
+	model.following = true
+	model.pinned = true
+	runTUI(model)
+}
@@ -0,0 +1,83 @@
+// log_viewer/exec_test.go
+
+package main
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStartExecSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	lines, streamErr, err := startExecSource(context.Background(), "sh", "-c", `echo '{"stream":"stdout"}'; echo '{"stream":"stderr"}' >&2`)
+	if err != nil {
+		t.Fatalf("startExecSource() unexpected error: %v", err)
+	}
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	sort.Strings(got)
+
+	expected := []string{`{"stream":"stderr"}`, `{"stream":"stdout"}`}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("startExecSource() lines = %v, expected %v", got, expected)
+	}
+	if err := streamErr(); err != nil {
+		t.Errorf("expected no error after a clean exit, got %v", err)
+	}
+
+	// A failing command's exit status reaches the stream error
+	lines, streamErr, err = startExecSource(context.Background(), "sh", "-c", "echo partial; exit 3")
+	if err != nil {
+		t.Fatalf("startExecSource() unexpected error: %v", err)
+	}
+	for range lines {
+	}
+	if err := streamErr(); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("expected the exit status, got %v", err)
+	}
+
+	// Killing the command on cancel is not reported
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, streamErr, err = startExecSource(ctx, "sh", "-c", "echo started; sleep 10")
+	if err != nil {
+		t.Fatalf("startExecSource() unexpected error: %v", err)
+	}
+	<-lines
+	cancel()
+	for range lines {
+	}
+	if err := streamErr(); err != nil {
+		t.Errorf("expected no error after canceling, got %v", err)
+	}
+}
+
+func TestExecExitShown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	lines, streamErr, err := startExecSource(context.Background(), "sh", "-c", `echo '{"message":"partial"}'; exit 3`)
+	if err != nil {
+		t.Fatalf("startExecSource() unexpected error: %v", err)
+	}
+	model := Model{stream: lines, streamErr: streamErr, following: true, pinned: true, width: 120, height: 40}
+	for msg := waitForLine(lines)(); ; msg = waitForLine(lines)() {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+		if _, closed := msg.(streamClosedMsg); closed {
+			break
+		}
+	}
+	if model.err == nil || !strings.Contains(model.View(), "exit status 3") {
+		t.Errorf("expected the exit status shown, got err=%v", model.err)
+	}
+}
@@ -0,0 +1,226 @@
+// log_viewer/export.go
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Export formats for -format.
+const (
+	exportCSV     = "csv"
+	exportParquet = "parquet"
+)
+
+var exportFormats = []string{exportCSV, exportParquet}
+
+// columnKind is the type of an exported column, derived from the values seen.
+type columnKind int
+
+const (
+	columnString columnKind = iota // Anything mixed, and nested objects as JSON
+	columnNumber                   // Only numbers
+	columnBool                     // Only booleans
+)
+
+type exportColumn struct {
+	name string
+	kind columnKind
+}
+
+// exportTable flattens logs into rows with one column per field seen in any
+// entry, including fields derived for the detail pane such as
+// kubelet_timestamp, and a noteColumn when an entry has a note. Missing
+// fields are nil. Columns are sorted by name, unless a projection picks them
+// and their order.
+func exportTable(logs []ParsedLog, notes map[string]string, fields projection) ([]exportColumn, []map[string]interface{}) {
+	rows := make([]map[string]interface{}, 0, len(logs))
+	kinds := make(map[string]columnKind)
+	seen := make(map[string]bool)
+
+	for _, entry := range logs {
+		row := detailFields(entry, detectProfile(entry))
+		copied := false
+		set := func(name string, value interface{}) {
+			// detailFields may return the entry's own fields
+			if !copied {
+				row = maps.Clone(row)
+				copied = true
+			}
+			row[name] = value
+		}
+		if note := notes[entry.ID.String()]; note != "" {
+			set(noteColumn, note)
+		}
+		for name := range timeFields {
+			if value, ok := row[name].(string); ok {
+				if shown := displayTimeField(name, value); shown != value {
+					set(name, shown)
+				}
+			}
+		}
+		row = fields.apply(row)
+		rows = append(rows, row)
+
+		for name, value := range row {
+			if value == nil {
+				if _, ok := kinds[name]; !ok {
+					kinds[name] = columnString
+				}
+				continue
+			}
+
+			var kind columnKind
+			switch value.(type) {
+			case json.Number, float64:
+				kind = columnNumber
+			case bool:
+				kind = columnBool
+			default:
+				kind = columnString
+			}
+			if !seen[name] {
+				kinds[name] = kind
+				seen[name] = true
+			} else if kinds[name] != kind {
+				kinds[name] = columnString
+			}
+		}
+	}
+
+	columns := make([]exportColumn, 0, len(kinds))
+	if fields != nil {
+		for _, name := range fields.names() {
+			columns = append(columns, exportColumn{name: name, kind: kinds[name]})
+		}
+		return columns, rows
+	}
+	for name, kind := range kinds {
+		columns = append(columns, exportColumn{name: name, kind: kind})
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].name < columns[j].name })
+	return columns, rows
+}
+
+// exportString formats a value for a string column or a CSV cell. Numbers are
+// never in exponent form, and nested objects are JSON.
+func exportString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number, float64:
+		s, _ := numberString(v)
+		return s
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// writeCSV writes a header row of field names, then one row per entry.
+func writeCSV(w io.Writer, columns []exportColumn, rows []map[string]interface{}) error {
+	writer := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = exportString(row[column.name])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportFormatFor returns the format to write, guessing from the output
+// file's extension when -format is not given.
+func exportFormatFor(format, output string) string {
+	if format != "" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(output), ".parquet") {
+		return exportParquet
+	}
+	return exportCSV
+}
+
+// runExport implements `log_viewer export [-format csv|parquet] [-o file] [file...]`.
+func runExport(opts cliOptions, cfg Config) {
+	lines, source, err := batchInput(opts, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error reading logs to export:", err)
+		os.Exit(1)
+	}
+
+	logs, err := parseBatch(lines, source, opts, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing logs: %v\n", err)
+		log.Println("Error parsing logs:", err)
+		os.Exit(1)
+	}
+
+	notes, err := loadNotes(opts.notesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error loading notes:", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if opts.output != "" && opts.output != "-" {
+		out, err = os.Create(opts.output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			log.Println("Error creating export file:", err)
+			os.Exit(1)
+		}
+	}
+
+	columns, rows := exportTable(logs, notes, opts.projection)
+	if exportFormatFor(opts.exportFormat, opts.output) == exportParquet {
+		err = writeParquet(out, columns, rows)
+	} else {
+		err = writeCSV(out, columns, rows)
+	}
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+		log.Println("Error writing export:", err)
+		os.Exit(1)
+	}
+
+	if err := commitCheckpoints(opts, source); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error saving checkpoints:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+// log_viewer/export_test.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var exportTestLines = []string{
+	`{"response_code":200,"duration":1500000,"healthy":true,"path":"/a,b","route":{"name":"default"}}`,
+	`{"response_code":503,"healthy":false,"path":null,"mixed":"x"}`,
+	`{"response_code":0,"mixed":5}`,
+}
+
+func TestExportTable(t *testing.T) {
+	columns, rows := exportTable(mustParse(t, exportTestLines...), nil, nil)
+	expected := []exportColumn{
+		{"duration", columnNumber},
+		{"healthy", columnBool},
+		{"mixed", columnString},
+		{"path", columnString},
+		{"response_code", columnNumber},
+		{"route", columnString},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("exportTable() columns = %v, expected %v", columns, expected)
+	}
+	if len(rows) != 3 {
+		t.Errorf("exportTable() returned %d rows, expected 3", len(rows))
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	columns, rows := exportTable(mustParse(t, exportTestLines...), nil, nil)
+	var out strings.Builder
+	if err := writeCSV(&out, columns, rows); err != nil {
+		t.Fatalf("writeCSV() unexpected error: %v", err)
+	}
+
+	expected := "duration,healthy,mixed,path,response_code,route\n" +
+		`1500000,true,,"/a,b",200,"{""name"":""default""}"` + "\n" +
+		",false,x,,503,\n" +
+		",,5,,0,\n"
+	if out.String() != expected {
+		t.Errorf("writeCSV() =\n%s\nexpected\n%s", out.String(), expected)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	columns, rows := exportTable(mustParse(t, exportTestLines...), nil, nil)
+	var out bytes.Buffer
+	if err := writeParquet(&out, columns, rows); err != nil {
+		t.Fatalf("writeParquet() unexpected error: %v", err)
+	}
+
+	data := out.Bytes()
+	if !bytes.HasPrefix(data, []byte(parquetMagic)) || !bytes.HasSuffix(data, []byte(parquetMagic)) {
+		t.Fatalf("expected the file to start and end with %s", parquetMagic)
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLength : len(data)-8]
+	for _, name := range []string{"schema", "duration", "response_code", "route"} {
+		if !bytes.Contains(footer, []byte(name)) {
+			t.Errorf("expected the footer to describe column %q", name)
+		}
+	}
+}
+
+func TestRLEBits(t *testing.T) {
+	got := rleBits([]bool{true, true, true, false, true})
+	expected := []byte{3 << 1, 1, 1 << 1, 0, 1 << 1, 1}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("rleBits() = %v, expected %v", got, expected)
+	}
+}
+
+func TestExportFormatFor(t *testing.T) {
+	tests := []struct{ format, output, want string }{
+		{"", "-", exportCSV},
+		{"", "logs.PARQUET", exportParquet},
+		{exportCSV, "logs.parquet", exportCSV},
+	}
+	for _, tt := range tests {
+		if got := exportFormatFor(tt.format, tt.output); got != tt.want {
+			t.Errorf("exportFormatFor(%q, %q) = %q, expected %q", tt.format, tt.output, got, tt.want)
+		}
+	}
+}
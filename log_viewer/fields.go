@@ -0,0 +1,191 @@
+// log_viewer/fields.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Numbers in entries are parsed as json.Number, so large integers such as
+// byte counts and connection IDs keep every digit. Fields set by code may
+// still hold float64. These accessors read either, so no caller has to
+// assert a concrete type. Some pipelines stringify numbers, as in
+// "response_code":"503", so the field accessors take numeric strings too.
+
+// decodeJSON unmarshals data keeping numbers as json.Number.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	// Like json.Unmarshal, reject anything after the value
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid character after top-level value at offset %d", decoder.InputOffset())
+	}
+	return nil
+}
+
+// floatValue reads a number as a float64. Strings are not numbers.
+func floatValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil && !math.IsInf(f, 0)
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// intValue reads a whole number as an int64, exactly when it was logged as an
+// integer.
+func intValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, err := v.Float64()
+		if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// coerceNumber returns a numeric string as the json.Number it spells, and
+// any other value as it is. Envoy's "-" for no value stays a string.
+func coerceNumber(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return value
+	}
+	return json.Number(s)
+}
+
+// numberString formats a number as logged: json.Number verbatim, float64
+// never in exponent form.
+func numberString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// FloatField returns the named field as a float64, if it is a number or a
+// numeric string.
+func (log ParsedLog) FloatField(name string) (float64, bool) {
+	return floatValue(coerceNumber(log.Fields[name]))
+}
+
+// IntField returns the named field as an int64, if it is a whole number or
+// a string of one.
+func (log ParsedLog) IntField(name string) (int64, bool) {
+	return intValue(coerceNumber(log.Fields[name]))
+}
+
+// StringField returns the named field, if it is a string.
+func (log ParsedLog) StringField(name string) (string, bool) {
+	s, ok := log.Fields[name].(string)
+	return s, ok
+}
+
+// Accessors for the access log fields the viewer reads most. Each returns
+// false, or an empty value, when the entry does not log the field.
+
+// ResponseCode returns the HTTP status, 0 when no response was sent.
+func (log ParsedLog) ResponseCode() (int, bool) {
+	code, ok := log.IntField("response_code")
+	return int(code), ok
+}
+
+// Duration returns the time the request took, logged in milliseconds.
+func (log ParsedLog) Duration() (time.Duration, bool) {
+	ms, ok := log.FloatField("duration")
+	return millis(ms), ok
+}
+
+// UpstreamServiceTime returns the time the upstream took, logged in
+// milliseconds as a number or a string; "-" when it never answered.
+func (log ParsedLog) UpstreamServiceTime() (time.Duration, bool) {
+	ms, ok := log.FloatField("upstream_service_time")
+	return millis(ms), ok
+}
+
+// millis converts logged milliseconds to a duration.
+func millis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Flags returns the response flags, none for "-".
+func (log ParsedLog) Flags() []Flag {
+	flags, _ := log.StringField("response_flags")
+	return parseResponseFlags(flags)
+}
+
+// HasFlag reports whether the response flags include flag.
+func (log ParsedLog) HasFlag(flag Flag) bool {
+	return slices.Contains(log.Flags(), flag)
+}
+
+// Method returns the request method.
+func (log ParsedLog) Method() string {
+	return log.text("method")
+}
+
+// Path returns the request path, with its query string.
+func (log ParsedLog) Path() string {
+	return log.text("path")
+}
+
+// RequestID returns the x-request-id.
+func (log ParsedLog) RequestID() string {
+	return log.text("request_id")
+}
+
+// UpstreamCluster returns the Envoy cluster the request went to.
+func (log ParsedLog) UpstreamCluster() string {
+	return log.text("upstream_cluster")
+}
+
+// Level returns the level of an application or control plane log.
+func (log ParsedLog) Level() string {
+	return log.text("level")
+}
+
+// Message returns the free-text message of an application or control plane log.
+func (log ParsedLog) Message() string {
+	return log.text("message")
+}
+
+// text returns a string field, empty when it is missing or logged as "-" or
+// null.
+func (log ParsedLog) text(name string) string {
+	s, _ := log.StringField(name)
+	if s == "-" || s == "null" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+// log_viewer/fields_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeepsLargeIntegers(t *testing.T) {
+	line := `{"bytes_sent":9007199254740993,"connection_id":18446744073709,"response_code":503,"duration":1.5}`
+	for name, input := range map[string][]string{"lines": {line}, "array": {"[" + line + "]"}} {
+		logs, err := parseRawLogs(input)
+		if err != nil || len(logs) != 1 {
+			t.Fatalf("%s: parseRawLogs() = %v, %v", name, logs, err)
+		}
+		log := logs[0]
+		if bytes, ok := log.IntField("bytes_sent"); !ok || bytes != 9007199254740993 {
+			t.Errorf("%s: IntField(bytes_sent) = %d, %v, expected every digit kept", name, bytes, ok)
+		}
+		if got := exportString(log.Fields["bytes_sent"]); got != "9007199254740993" {
+			t.Errorf("%s: exportString() = %q", name, got)
+		}
+		if id, _ := connectionID(log); id != "C18446744073709" {
+			t.Errorf("%s: connectionID() = %q", name, id)
+		}
+		if code, ok := log.IntField("response_code"); !ok || code != 503 {
+			t.Errorf("%s: IntField(response_code) = %d, %v", name, code, ok)
+		}
+		if duration, ok := log.FloatField("duration"); !ok || duration != 1.5 {
+			t.Errorf("%s: FloatField(duration) = %g, %v", name, duration, ok)
+		}
+		if _, ok := log.IntField("duration"); ok {
+			t.Errorf("%s: IntField(duration) read a fraction as an integer", name)
+		}
+	}
+}
+
+func TestFieldAccessors(t *testing.T) {
+	log := ParsedLog{Fields: map[string]interface{}{
+		"set_by_code": float64(200),
+		"number":      json.Number("1e3"),
+		"text":        "503",
+		"padded":      " 12.5 ",
+		"dash":        "-",
+		"missing":     nil,
+	}}
+	if code, ok := log.IntField("set_by_code"); !ok || code != 200 {
+		t.Errorf("IntField() of a float64 = %d, %v", code, ok)
+	}
+	if n, ok := log.IntField("number"); !ok || n != 1000 {
+		t.Errorf("IntField() of 1e3 = %d, %v", n, ok)
+	}
+	// Stringified numbers are read as numbers, Envoy's "-" is not one
+	if code, ok := log.IntField("text"); !ok || code != 503 {
+		t.Errorf("IntField() of \"503\" = %d, %v", code, ok)
+	}
+	if f, ok := log.FloatField("padded"); !ok || f != 12.5 {
+		t.Errorf("FloatField() of \" 12.5 \" = %g, %v", f, ok)
+	}
+	if _, ok := log.FloatField("dash"); ok {
+		t.Errorf("FloatField() read \"-\" as a number")
+	}
+	if s, ok := log.StringField("text"); !ok || s != "503" {
+		t.Errorf("StringField() = %q, %v", s, ok)
+	}
+	if _, ok := log.StringField("missing"); ok {
+		t.Errorf("StringField() of null reported a string")
+	}
+}
+
+func TestDecodeJSONRejectsTrailingData(t *testing.T) {
+	var v map[string]interface{}
+	if err := decodeJSON([]byte(`{"a":1} {"b":2}`), &v); err == nil {
+		t.Errorf("decodeJSON() accepted two values")
+	}
+	if err := decodeJSON([]byte(`{"a":1}  `), &v); err != nil {
+		t.Errorf("decodeJSON() rejected trailing space: %v", err)
+	}
+}
+
+func TestAccessLogAccessors(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":503,"duration":12,"upstream_service_time":"9","response_flags":"UF, URX","method":"GET","path":"/reviews?x=1","request_id":"abc","upstream_cluster":"outbound|9080||reviews","level":"warn","message":"-"}`,
+		`{"response_code":0,"response_flags":"-","upstream_service_time":"-","method":"null"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	request, failed := logs[0], logs[1]
+
+	if code, ok := request.ResponseCode(); !ok || code != 503 {
+		t.Errorf("ResponseCode() = %d, %v, expected 503", code, ok)
+	}
+	if code, ok := failed.ResponseCode(); !ok || code != 0 {
+		t.Errorf("ResponseCode() = %d, %v, expected 0", code, ok)
+	}
+	if d, ok := request.Duration(); !ok || d != 12*time.Millisecond {
+		t.Errorf("Duration() = %v, %v, expected 12ms", d, ok)
+	}
+	if _, ok := failed.Duration(); ok {
+		t.Errorf("Duration() reported a duration that is not logged")
+	}
+	if d, ok := request.UpstreamServiceTime(); !ok || d != 9*time.Millisecond {
+		t.Errorf("UpstreamServiceTime() = %v, %v, expected 9ms", d, ok)
+	}
+	if _, ok := failed.UpstreamServiceTime(); ok {
+		t.Errorf("UpstreamServiceTime() of \"-\" reported a duration")
+	}
+
+	if flags := request.Flags(); !slices.Equal(flags, []Flag{"UF", "URX"}) {
+		t.Errorf("Flags() = %v, expected [UF URX]", flags)
+	}
+	if flags := failed.Flags(); len(flags) != 0 {
+		t.Errorf("Flags() of \"-\" = %v, expected none", flags)
+	}
+	if !request.HasFlag("URX") || request.HasFlag("U") {
+		t.Errorf("HasFlag() matched by substring or missed URX")
+	}
+
+	if request.Method() != "GET" || request.Path() != "/reviews?x=1" || request.RequestID() != "abc" ||
+		request.UpstreamCluster() != "outbound|9080||reviews" || request.Level() != "warn" {
+		t.Errorf("string accessors returned %q %q %q %q %q", request.Method(), request.Path(), request.RequestID(),
+			request.UpstreamCluster(), request.Level())
+	}
+	if request.Message() != "" || failed.Method() != "" {
+		t.Errorf("expected \"-\" and null to read as empty")
+	}
+}
+
+func TestStringlyTypedAccessLog(t *testing.T) {
+	logs, err := parseRawLogs([]string{`{"response_code":"503","duration":"12","bytes_sent":"0","response_flags":"UF"}`})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	if code, ok := logs[0].ResponseCode(); !ok || code != 503 {
+		t.Errorf("ResponseCode() = %d, %v", code, ok)
+	}
+	if duration, ok := logs[0].Duration(); !ok || duration != 12*time.Millisecond {
+		t.Errorf("Duration() = %s, %v", duration, ok)
+	}
+	if s := summarize(logs, "test"); s.serverErrors() != 1 {
+		t.Errorf("expected the stringified 503 counted as a server error")
+	}
+	if value := formatFieldValue("response_code", "503.0"); !strings.Contains(value, "Service Unavailable") {
+		t.Errorf("expected the code explained, got %q", value)
+	}
+}
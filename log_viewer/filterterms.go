@@ -0,0 +1,108 @@
+// log_viewer/filterterms.go
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A filter query is matched against the text of entries, except for terms
+// naming something else an entry must have, such as where it was read from.
+// Terms may come anywhere in the query: "503 source.pod=reviews" keeps the
+// same entries as "source.pod=reviews 503".
+
+// filterTerms is a filter query split into its terms and the text left.
+type filterTerms struct {
+	sources []sourceTerm
+	traffic trafficTerm // The last traffic term
+	status  statusTerm  // The last status term
+	params  []fieldTerm // Query parameters of the path
+	pod     []fieldTerm // Labels and annotations of the pod, see podFields
+	text    string      // Matched against the entry's text, see filterLogs
+}
+
+// parseFilterTerms classifies each space-separated word of query. A word
+// that is not a well-formed term, such as one naming an unknown label, is
+// left in the text.
+func parseFilterTerms(query string) filterTerms {
+	var terms filterTerms
+	var text []string
+	for _, word := range strings.Split(query, " ") {
+		if term, ok := parseSourceTerm(word); ok {
+			terms.sources = append(terms.sources, term)
+			continue
+		}
+		if term, ok := parseTrafficTerm(word); ok {
+			terms.traffic = term
+			continue
+		}
+		if term, ok := parseStatusTerm(word); ok {
+			terms.status = term
+			continue
+		}
+		if term, ok := parseFieldTerm(word, queryPrefix); ok {
+			terms.params = append(terms.params, term)
+			continue
+		}
+		if term, ok := parseFieldTerm(word, podFieldPrefix); ok {
+			terms.pod = append(terms.pod, term)
+			continue
+		}
+		text = append(text, word)
+	}
+	terms.text = strings.Join(text, " ")
+	if terms.any() {
+		terms.text = strings.TrimSpace(terms.text)
+	}
+	return terms
+}
+
+// any reports whether the query has terms besides its text.
+func (t filterTerms) any() bool {
+	return len(t.sources) > 0 || t.traffic.kind != "" || t.status != "" || len(t.params) > 0 || len(t.pod) > 0
+}
+
+// matches reports whether the entry satisfies every term, its text aside.
+func (t filterTerms) matches(log ParsedLog) bool {
+	return matchesSource(log.Source, t.sources) && t.traffic.matches(log) && t.status.matches(log) &&
+		matchesQuery(log, t.params) && matchesPodFields(log, t.pod)
+}
+
+// statusTermPrefix starts a term keeping the responses with one code, e.g.
+// status=503, or of one class, e.g. status=5xx.
+const statusTermPrefix = "status="
+
+// statusTerm is the code or class a status term keeps, empty for no term.
+type statusTerm string
+
+// parseStatusTerm parses a status=<code> or status=<digit>xx term. Anything
+// else is not one, and is matched as text.
+func parseStatusTerm(word string) (statusTerm, bool) {
+	value, ok := strings.CutPrefix(strings.ToLower(word), statusTermPrefix)
+	if !ok {
+		return "", false
+	}
+	if len(value) == 3 && value[0] >= '1' && value[0] <= '5' && value[1:] == "xx" {
+		return statusTerm(value), true
+	}
+	if code, err := strconv.Atoi(value); err != nil || code < 0 || code > 599 || strconv.Itoa(code) != value {
+		return "", false
+	}
+	return statusTerm(value), true
+}
+
+// matches reports whether the term keeps the entry.
+func (t statusTerm) matches(log ParsedLog) bool {
+	if t == "" {
+		return true
+	}
+	code, ok := log.ResponseCode()
+	if !ok {
+		return false
+	}
+	if strings.HasSuffix(string(t), "xx") {
+		return strconv.Itoa(code/100) == string(t[:1])
+	}
+	return strconv.Itoa(code) == string(t)
+}
@@ -0,0 +1,78 @@
+// log_viewer/filterterms_test.go
+
+package main
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFilterTerms(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected filterTerms
+	}{
+		{"503", filterTerms{text: "503"}},
+		{"source.pod=Reviews", filterTerms{sources: []sourceTerm{{"pod", "reviews"}}}},
+		{"source.namespace=default source.pod=reviews 503 UF", filterTerms{sources: []sourceTerm{{"namespace", "default"}, {"pod", "reviews"}}, text: "503 UF"}},
+		{"source.node=a 503", filterTerms{text: "source.node=a 503"}},
+		{"503 source.pod=reviews UF", filterTerms{sources: []sourceTerm{{"pod", "reviews"}}, text: "503 UF"}},
+		{"502 traffic=!External", filterTerms{traffic: trafficTerm{kind: trafficExternal, drop: true}, text: "502"}},
+		{"traffic=mars", filterTerms{text: "traffic=mars"}},
+		{"UF status=5XX", filterTerms{status: "5xx", text: "UF"}},
+		{"status=0 status=503", filterTerms{status: "503"}},
+		{"status=6xx status=0503", filterTerms{text: "status=6xx status=0503"}},
+		{"200 query.tag=a query.user_id=42", filterTerms{params: []fieldTerm{{"tag", "a"}, {"user_id", "42"}}, text: "200"}},
+		{"pod.app=web query.x=1", filterTerms{params: []fieldTerm{{"x", "1"}}, pod: []fieldTerm{{"app", "web"}}}},
+		{"pod.=web", filterTerms{text: "pod.=web"}},
+	}
+	for _, tt := range tests {
+		if got := parseFilterTerms(tt.query); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseFilterTerms(%q) = %+v, expected %+v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestFilterTermsInAnyOrder(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"path":"/a?x=1","response_code":200,"upstream_cluster":"inbound|9080||"}`,
+		`{"path":"/b?x=2","response_code":200,"upstream_cluster":"inbound|9080||"}`,
+	}, defaultParseOptions().withPodFields(map[string]interface{}{"pod.app": "web"}).withOrigin(Source{Pod: "web-abc"}))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	terms := []string{"query.x=1", "pod.app=web", "source.pod=web", "/a"}
+	for i := range terms {
+		query := strings.Join(append(slices.Clone(terms[i:]), terms[:i]...), " ")
+		if got := filterLogs(logs, query); len(got) != 1 {
+			t.Errorf("filterLogs(%q) kept %d entries, expected 1", query, len(got))
+		}
+	}
+}
+
+func TestStatusTerm(t *testing.T) {
+	logs := mustParse(t,
+		`{"response_code":200}`,
+		`{"response_code":503,"response_flags":"UF"}`,
+		`{"response_code":504}`,
+		`{"response_code":0,"response_flags":"UF"}`,
+		`{"message":"no request"}`,
+	)
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"status=5xx", 2},
+		{"status=503", 1},
+		{"status=0 UF", 1},
+		{"status=2xx", 1},
+		{"status=4xx", 0},
+	}
+	for _, tt := range tests {
+		if got := filterLogs(logs, tt.query); len(got) != tt.expected {
+			t.Errorf("filterLogs(%q) kept %d entries, expected %d", tt.query, len(got), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// log_viewer/findrequest.go
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// requestEntries returns the entries of logs logged for requestID, the
+// x-request-id Envoy propagates through the mesh, sorted by time.
+func requestEntries(logs []ParsedLog, requestID string) []ParsedLog {
+	var entries []ParsedLog
+	for _, entry := range logs {
+		if id := entry.RequestID(); id != "" && id == requestID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ID.Compare(entries[j].ID) < 0 })
+	return entries
+}
+
+// findRequestInFiles reads every file and returns the entries of requestID,
+// in the order mergeLogs interleaves the files.
+func findRequestInFiles(paths []string, requestID string, opts parseOptions, mergeOpts mergeOptions) ([]ParsedLog, error) {
+	var sources [][]ParsedLog
+	for _, path := range paths {
+		lines, err := readLogFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		logs, err := parseRawLogsWith(lines, opts.from(path).withOrigin(fileSource(path)))
+		if err != nil {
+			// A file with nothing parsable holds no entries of the request
+			continue
+		}
+		sources = append(sources, logs)
+	}
+	return requestEntries(mergeLogs(sources, mergeOpts), requestID), nil
+}
+
+// loadRequest reads the entries of the request ID in opts.args for the
+// command written in usage, from the files after it or, without files, from
+// the sidecars in PLUGIN_NAMESPACE (a comma-separated list is accepted). It
+// exits the process when there are none.
+func loadRequest(opts cliOptions, cfg Config, usage string) (string, []ParsedLog) {
+	if len(opts.args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: "+usage)
+		os.Exit(2)
+	}
+	requestID, files := opts.args[0], opts.args[1:]
+
+	var entries []ParsedLog
+	if len(files) > 0 {
+		var err error
+		entries, err = findRequestInFiles(files, requestID, parseOptionsFor(opts, cfg), opts.merge)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			log.Println("Error reading logs:", err)
+			os.Exit(1)
+		}
+	} else {
+		namespaces := strings.FieldsFunc(os.Getenv("PLUGIN_NAMESPACE"), func(r rune) bool { return r == ',' })
+		if len(namespaces) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: pass log files, or set PLUGIN_NAMESPACE to search the cluster")
+			os.Exit(2)
+		}
+		results, _ := searchSidecars(opts, cfg, requestID, namespaces)
+		var found []ParsedLog
+		for result := range results {
+			if result.err != nil {
+				fmt.Fprintf(os.Stderr, "Error searching %s: %v\n", result.target, result.err)
+				log.Println("Error searching", result.target, result.err)
+				continue
+			}
+			found = append(found, result.matches...)
+		}
+		entries = requestEntries(found, requestID)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "No entries with request_id %s found\n", requestID)
+		os.Exit(1)
+	}
+	return requestID, entries
+}
+
+// runFindRequest implements `log_viewer find-request [flags] <x-request-id> [file...]`.
+// The entries found, see loadRequest, open in the viewer, oldest first, with
+// a notice when the clocks of the pods that logged them disagree.
+func runFindRequest(opts cliOptions, cfg Config) {
+	_, entries := loadRequest(opts, cfg, "log_viewer find-request [flags] <x-request-id> [file...]")
+
+	model := newModel(opts, cfg)
+	model.logs = entries
+	model.filteredLogs = entries
+	model.overhead = overheadOf(entries)
+	if skews := clockSkews(entries); len(skews) > 0 {
+		model.notice = "Clock skew: " + skews[0].String()
+	}
+	runTUI(model)
+}
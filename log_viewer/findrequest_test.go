@@ -0,0 +1,35 @@
+// log_viewer/findrequest_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindRequestInFiles(t *testing.T) {
+	dir := t.TempDir()
+	gateway := filepath.Join(dir, "gateway.log")
+	reviews := filepath.Join(dir, "reviews.log")
+	os.WriteFile(gateway, []byte(
+		`{"start_time":"2024-05-04T10:00:00.000Z","request_id":"abc","path":"/productpage"}`+"\n"+
+			`{"start_time":"2024-05-04T10:00:00.500Z","request_id":"other","path":"/abc"}`+"\n"), 0o600)
+	os.WriteFile(reviews, []byte(
+		`{"start_time":"2024-05-04T10:00:00.200Z","request_id":"abc","path":"/reviews"}`+"\n"), 0o600)
+
+	entries, err := findRequestInFiles([]string{reviews, gateway}, "abc", defaultParseOptions(), mergeOptions{})
+	if err != nil {
+		t.Fatalf("findRequestInFiles() unexpected error: %v", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Fields["path"].(string))
+	}
+	if len(paths) != 2 || paths[0] != "/productpage" || paths[1] != "/reviews" {
+		t.Errorf("findRequestInFiles() = %v, expected [/productpage /reviews] in time order", paths)
+	}
+	if entries[1].ID.Source != reviews {
+		t.Errorf("expected the reviews entry to keep its source, got %q", entries[1].ID.Source)
+	}
+}
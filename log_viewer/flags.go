@@ -0,0 +1,367 @@
+// log_viewer/flags.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+// cliOptions holds the command-line flags for the viewer.
+type cliOptions struct {
+	configPath      string
+	theme           string
+	noColor         bool
+	timezone        string            // Zone timestamps are shown in, see selectTimeDisplay
+	timeFormat      string            // How timestamps are shown, see selectTimeDisplay
+	timestamps      bool              // Request kubelet timestamps for Kubernetes sources
+	tailLines       int64             // Lines to fetch from the end of each log, -1 for all
+	backfill        time.Duration     // Log shown before following a pod, 0 for none
+	window          time.Duration     // How far back mesh reads
+	sampleSidecars  int               // Sidecars mesh reads besides the gateways
+	markerSpecs     []string          // Times marked among the entries, see parseMarker
+	markers         []marker          // Parsed from markerSpecs by loadSettings, in the display timezone
+	sloSpecs        []string          // Success rate targets, see parseSLO
+	slos            []sloTarget       // Parsed from sloSpecs, or the config, by loadSettings
+	limitBytes      byteSize          // Bytes to fetch from each log, 0 for no limit
+	kube            kubeClientOptions // How to reach the Kubernetes API
+	contexts        []string          // Clusters searched together, see federation
+	profile         string            // Config profile filling in unset options, see ClusterProfile
+	gateways        []gatewayRef      // From the profile, searched along with the sidecars
+	skipAccessCheck bool              // Fetch without verifying RBAC permissions first
+	notifyURL       string            // Webhook summarize findings and alerts are posted to, see AlertRule
+	notifyFormat    string            // Webhook payload format, see notifyFormats
+	exportFormat    string            // Export file format, guessed from output when empty
+	output          string            // Export file, "-" or empty for stdout
+	notesPath       string            // File notes on entries are kept in, empty for the session only
+	redact          bool              // Mask credentials, emails and IPs on top of configured rules
+	reverseDNS      bool              // Name addresses with reverse DNS lookups
+	compareBy       string            // Field summarize splits request stats by
+	socketPath      string            // Unix socket serve listens on and attach connects to
+	quiet           bool              // Write no report, only set the exit code
+	healthChecks    bool              // Count health checks in request stats and SLOs
+	limits          thresholds        // Limits that fail summarize
+	sinks           []string          // Destinations viewed entries are forwarded to, see openSink
+	resume          bool              // Only read what previous runs with -resume did not, see checkpoint
+	statePath       string            // File checkpoints are kept in
+	workers         int               // Sidecars search reads at once, files batch summarizes at once
+	count           int               // Entries generate writes, 0 for no end
+	rate            float64           // Entries per second generate writes, 0 for as fast as possible
+	errorRatio      float64           // Share of generated entries that fail
+	flagMix         string            // Relative weights of generated failures, see parseFlagMix
+	seed            int64             // Seed of generated entries, 0 for a random one
+	mergeSkew       string            // Clock skew tolerated between merged sources, see selectMergeOptions
+	mergeTieBreak   string            // Order of merged entries within the skew, see tieBreaks
+	merge           mergeOptions      // Resolved from the two above and the config by loadSettings
+	schema          string            // Field names of the logs, see selectSchemas
+	fields          string            // Projection shaping export and transform records, see projection
+	projection      projection        // Parsed from fields
+	args            []string          // Positional arguments left after the flags
+}
+
+// parseFlags parses the flags of subcommand, "" for the viewer itself,
+// writing errors and the usage to output.
+func parseFlags(subcommand string, args []string, output io.Writer) (cliOptions, error) {
+	var opts cliOptions
+	fs, err := newFlagSet(subcommand, &opts)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return opts, err
+	}
+	fs.SetOutput(output)
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.kube.qps < 0 || opts.kube.burst < 0 {
+		// Report like the flag package does for its own parse errors
+		err := fmt.Errorf("-qps and -burst must not be negative")
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return opts, err
+	}
+	if fs.Lookup("notify-format") != nil && !slices.Contains(notifyFormats, opts.notifyFormat) {
+		err := fmt.Errorf("unknown -notify-format %q", opts.notifyFormat)
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return opts, err
+	}
+	if opts.exportFormat != "" && !slices.Contains(exportFormats, opts.exportFormat) {
+		err := fmt.Errorf("unknown -format %q", opts.exportFormat)
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return opts, err
+	}
+	if _, err := selectSchemas(opts.schema); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return opts, err
+	}
+	projection, err := parseProjection(opts.fields)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return opts, err
+	}
+	opts.projection = projection
+	opts.args = fs.Args()
+	return opts, nil
+}
+
+// flagGroup defines related flags on fs, for the subcommands that use them.
+type flagGroup func(fs *flag.FlagSet, opts *cliOptions)
+
+// Groups shared by the subcommands showing entries in the viewer.
+var viewerGroups = []flagGroup{displayFlags, parsingFlags, stateFlags, statsFlags, markerFlags, notesFlags, viewerFlags}
+
+// subcommandFlags lists the flags of each subcommand, "" for the viewer
+// itself, besides the common ones. Flags a subcommand would ignore are left
+// out, so passing one is an error rather than silently doing nothing.
+var subcommandFlags = map[string][]flagGroup{
+	"":              append([]flagGroup{kubeFlags, fetchFlags, accessFlags, mergeFlags, backfillFlags, notifyFlags}, viewerGroups...),
+	"exec":          append([]flagGroup{notifyFlags}, viewerGroups...),
+	"attach":        append([]flagGroup{socketFlags, notifyFlags}, viewerGroups...),
+	"find-request":  append([]flagGroup{kubeFlags, fetchFlags, accessFlags, contextsFlags, workersFlags, mergeFlags}, viewerGroups...),
+	"waterfall":     {kubeFlags, fetchFlags, accessFlags, contextsFlags, workersFlags, parsingFlags, mergeFlags},
+	"summarize":     {displayFlags, kubeFlags, fetchFlags, parsingFlags, mergeFlags, stateFlags, resumeFlags, statsFlags, markerFlags, notesFlags, limitFlags, notifyFlags, compareFlags},
+	"batch":         {displayFlags, parsingFlags, stateFlags, resumeFlags, statsFlags, limitFlags, workersFlags},
+	"export":        {displayFlags, kubeFlags, fetchFlags, parsingFlags, mergeFlags, stateFlags, resumeFlags, notesFlags, exportFlags, fieldsFlags},
+	"transform":     {parsingFlags, fieldsFlags},
+	"search":        {kubeFlags, fetchFlags, accessFlags, contextsFlags, workersFlags, parsingFlags},
+	"mesh":          {kubeFlags, fetchFlags, accessFlags, workersFlags, parsingFlags, meshFlags},
+	"serve":         {kubeFlags, fetchFlags, socketFlags},
+	"generate":      {generateFlags},
+	"setup":         {kubeFlags},
+	"cluster-audit": {kubeFlags},
+}
+
+// newFlagSet defines the flags of subcommand on opts: the common ones, which
+// every subcommand reads its config with, and those subcommandFlags lists.
+func newFlagSet(subcommand string, opts *cliOptions) (*flag.FlagSet, error) {
+	groups, ok := subcommandFlags[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("unknown subcommand %q", subcommand)
+	}
+	name := "log_viewer"
+	if subcommand != "" {
+		name += " " + subcommand
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	commonFlags(fs, opts)
+	for _, group := range groups {
+		group(fs, opts)
+	}
+	return fs, nil
+}
+
+func commonFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.configPath, "config", defaultConfigPath(), "path to the config file")
+	fs.StringVar(&opts.profile, "profile", os.Getenv("LOG_VIEWER_PROFILE"), "config profile to use, e.g. prod-us (also set by LOG_VIEWER_PROFILE)")
+}
+
+func displayFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.theme, "theme", "", fmt.Sprintf("color theme (%s)", strings.Join(themeNames(), ", ")))
+	fs.StringVar(&opts.timezone, "timezone", "", "zone to show timestamps in: UTC (default), Local or a name such as Europe/Berlin")
+	fs.StringVar(&opts.timeFormat, "time-format", "", fmt.Sprintf("how to show timestamps: %s (default), %s or a Go layout such as 15:04:05.000", timeFormatShort, timeFormatRFC3339))
+	fs.BoolVar(&opts.noColor, "no-color", os.Getenv("NO_COLOR") != "", "disable colors (also enabled by NO_COLOR)")
+}
+
+func kubeFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.kube.kubeconfig, "kubeconfig", "", "path to the kubeconfig file, overrides KUBECONFIG")
+	fs.StringVar(&opts.kube.context, "context", "", "kubeconfig context to use")
+	fs.StringVar(&opts.kube.as, "as", "", "user to impersonate for Kubernetes requests")
+	fs.Var((*stringList)(&opts.kube.asGroups), "as-group", "group to impersonate, may be repeated")
+	fs.Var((*float32Value)(&opts.kube.qps), "qps", "maximum Kubernetes API requests per second (0 uses the client-go default)")
+	fs.IntVar(&opts.kube.burst, "burst", 0, "maximum burst of Kubernetes API requests (0 uses the client-go default)")
+}
+
+// fetchFlags are the options of reading pod logs, see sourceOptions.
+func fetchFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.timestamps, "timestamps", os.Getenv("PLUGIN_TIMESTAMPS") == "true", "request kubelet timestamps, used when a line has none of its own")
+	fs.Int64Var(&opts.tailLines, "tail", -1, "number of recent lines to fetch from Kubernetes (-1 for all)")
+	fs.Var(&opts.limitBytes, "limit-bytes", "maximum bytes to fetch from Kubernetes, e.g. 10MB (0 for no limit)")
+}
+
+func accessFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.skipAccessCheck, "skip-access-check", false, "do not verify RBAC permissions before fetching logs")
+}
+
+func contextsFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.Var((*stringList)(&opts.contexts), "contexts", "kubeconfig contexts to search together, comma-separated or repeated, for meshes spanning clusters; entries are tagged with their cluster")
+}
+
+func workersFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.IntVar(&opts.workers, "workers", defaultSearchWorkers, "sidecars search reads logs from at once, or files batch summarizes at once")
+}
+
+// parsingFlags are the options of parseOptionsFor.
+func parsingFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.schema, "schema", schemaAuto, fmt.Sprintf("field names the logs use: %s or an Istio version such as 1.4 (auto infers it per entry)", strings.Join(schemaNames(), ", ")))
+	fs.BoolVar(&opts.redact, "redact", false, "mask tokens, authorization headers, emails and IPs, on top of the config's redact rules")
+}
+
+func mergeFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.mergeSkew, "merge-skew", "", "clock skew tolerated between log files read together, e.g. 250ms (default 0)")
+	fs.StringVar(&opts.mergeTieBreak, "merge-tie-break", "", fmt.Sprintf("which of several files' entries within -merge-skew comes first: %s (default, as named) or %s", tieBreakOrder, tieBreakName))
+}
+
+func stateFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.statePath, "state", defaultStatePath(), "file -resume keeps read positions in; layouts per terminal size are kept next to it")
+}
+
+func resumeFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.resume, "resume", false, "summarize and export only what earlier runs with -resume did not read (requests kubelet timestamps)")
+}
+
+func statsFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.Var((*stringList)(&opts.sloSpecs), "slo", "success rate target to report the burn rate of, e.g. 99.9 or reviews=99.9 for clusters containing reviews, may be repeated")
+	fs.BoolVar(&opts.healthChecks, "health-checks", false, "count probes and health checks in summarize's request stats and the SLOs, which leave them out by default")
+}
+
+func markerFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.Var((*stringList)(&opts.markerSpecs), "marker", "mark a time among the entries, e.g. '12:03 rollout reviews-v2' or an RFC 3339 time and a label, may be repeated")
+}
+
+func notesFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.notesPath, "notes", "", "file to keep notes on entries in, read by export and summarize too")
+}
+
+// viewerFlags are the options of the viewer that no report has.
+func viewerFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.reverseDNS, "reverse-dns", false, "name IP addresses in the detail view with reverse DNS lookups")
+	fs.Var((*stringList)(&opts.sinks), "sink", "forward entries matching the filter to file:PATH, exec:COMMAND (run by the shell) or an http(s) URL, may be repeated")
+}
+
+func backfillFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.DurationVar(&opts.backfill, "backfill", 0, "when following a pod, first show its log from this long ago, e.g. 5m (0 for only new lines)")
+}
+
+func socketFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.socketPath, "socket", defaultSocketPath(), "Unix socket for serve and attach, in a directory the group owns to share a session")
+}
+
+// limitFlags are the thresholds that fail summarize and batch.
+func limitFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.BoolVar(&opts.quiet, "quiet", false, "write no summarize report, only exit with 2 when a -max-* threshold is exceeded")
+	fs.IntVar(&opts.limits.errors, "max-errors", -1, "failed requests summarize allows before exiting with 2 (-1 for no limit)")
+	fs.IntVar(&opts.limits.serverErr, "max-5xx", -1, "5xx responses summarize allows before exiting with 2 (-1 for no limit)")
+	fs.Float64Var(&opts.limits.errorRate, "max-error-rate", -1, "fraction of failed requests summarize allows before exiting with 2, e.g. 0.01 (-1 for no limit)")
+}
+
+func notifyFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.notifyURL, "notify-url", "", "webhook summarize findings and the config's alert rules post to (Slack, Teams or generic JSON)")
+	fs.StringVar(&opts.notifyFormat, "notify-format", notifyAuto, fmt.Sprintf("webhook payload format (%s)", strings.Join(notifyFormats, ", ")))
+}
+
+func compareFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.compareBy, "compare", "", fmt.Sprintf("field to compare request stats by in summarize, e.g. %s for upstream cluster subsets, %spod or %s for client SDK releases", compareSubset, sourcePrefix, compareClientVersion))
+}
+
+func exportFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.exportFormat, "format", "", fmt.Sprintf("export format (%s), guessed from -o when empty", strings.Join(exportFormats, ", ")))
+	fs.StringVar(&opts.output, "o", "-", "file to export to, - for stdout")
+}
+
+func fieldsFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.StringVar(&opts.fields, "fields", "", "fields export and transform write, e.g. start_time,response_code,path or {code: .response_code, route: .route.name}")
+}
+
+func meshFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.DurationVar(&opts.window, "window", defaultMeshWindow, "how far back mesh reads the gateways' logs")
+	fs.IntVar(&opts.sampleSidecars, "sample-sidecars", 0, "sidecars mesh reads besides the ingress gateways, spread over the namespaces")
+}
+
+func generateFlags(fs *flag.FlagSet, opts *cliOptions) {
+	fs.IntVar(&opts.count, "count", 1000, "entries generate writes (0 for no end)")
+	fs.Float64Var(&opts.rate, "rate", 0, "entries per second generate writes (0 for as fast as possible)")
+	fs.Float64Var(&opts.errorRatio, "error-ratio", 0.05, "share of generated entries that fail, between 0 and 1")
+	fs.StringVar(&opts.flagMix, "flag-mix", "", fmt.Sprintf("relative weights of generated failures by response flag (default %s)", defaultFlagMix))
+	fs.Int64Var(&opts.seed, "seed", 0, "seed for generate, to repeat its output (0 for a random seed)")
+}
+
+// sourceOptions returns the fetch options for Kubernetes sources.
+func (o cliOptions) sourceOptions() []k8ssource.Option {
+	sourceOpts := []k8ssource.Option{k8ssource.WithTimestamps(o.timestamps)}
+	if o.tailLines >= 0 {
+		sourceOpts = append(sourceOpts, k8ssource.WithTailLines(o.tailLines))
+	}
+	if o.limitBytes > 0 {
+		sourceOpts = append(sourceOpts, k8ssource.WithLimitBytes(int64(o.limitBytes)))
+	}
+	return sourceOpts
+}
+
+// stringList is a flag value collecting every occurrence of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// float32Value is a flag value for the float32 the Kubernetes client takes.
+type float32Value float32
+
+func (f *float32Value) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 32)
+}
+
+func (f *float32Value) Set(value string) error {
+	n, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return err
+	}
+	*f = float32Value(n)
+	return nil
+}
+
+// byteSize is a flag value accepting a byte count with an optional unit, such
+// as 512, 64KB or 10MiB. Decimal and binary units are both treated as powers
+// of 1024, matching how sizes are usually meant on the command line.
+type byteSize int64
+
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// Longest suffixes first so "MB" is not read as "B"
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+func (b *byteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *byteSize) Set(value string) error {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return fmt.Errorf("invalid size %q", value)
+	}
+	*b = byteSize(n * multiplier)
+	return nil
+}
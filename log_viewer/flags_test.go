@@ -0,0 +1,101 @@
+// log_viewer/flags_test.go
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseFlagsRateLimits(t *testing.T) {
+	opts, err := parseFlags("", []string{"-qps", "50", "-burst", "100"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseFlags() unexpected error: %v", err)
+	}
+	if opts.kube.qps != 50 || opts.kube.burst != 100 {
+		t.Errorf("expected qps=50 burst=100, got qps=%v burst=%d", opts.kube.qps, opts.kube.burst)
+	}
+
+	if _, err := parseFlags("", []string{"-qps", "-1"}, io.Discard); err == nil {
+		t.Errorf("expected an error for a negative -qps")
+	}
+	if _, err := parseFlags("summarize", []string{"-notify-format", "pagerduty"}, io.Discard); err == nil {
+		t.Errorf("expected an error for an unknown -notify-format")
+	}
+	if _, err := parseFlags("export", []string{"-format", "xlsx"}, io.Discard); err == nil {
+		t.Errorf("expected an error for an unknown -format")
+	}
+}
+
+func TestSubcommandFlags(t *testing.T) {
+	tests := []struct {
+		subcommand string
+		args       []string
+		wantErr    bool
+	}{
+		{"generate", []string{"-count", "10", "-seed", "1"}, false},
+		{"generate", []string{"-notify-url", "https://hooks.example/x"}, true},
+		{"summarize", []string{"-notify-url", "https://hooks.example/x", "-max-5xx", "0"}, false},
+		{"summarize", []string{"-count", "10"}, true},
+		{"summarize", []string{"-seed", "1"}, true},
+		{"attach", []string{"-socket", "/tmp/log_viewer.sock", "-sink", "exec:cat"}, false},
+		{"attach", []string{"-kubeconfig", "kubeconfig"}, true},
+		{"attach", []string{"-exec", "kubectl"}, true},
+		{"exec", []string{"-backfill", "5m"}, true},
+		{"serve", []string{"-tail", "100", "-socket", "/tmp/log_viewer.sock"}, false},
+		{"serve", []string{"-theme", "dark"}, true},
+		{"", []string{"-backfill", "5m", "-context", "prod"}, false},
+		{"", []string{"-window", "5m"}, true},
+		// Every subcommand reads the config
+		{"cluster-audit", []string{"-config", "config.json", "-profile", "prod"}, false},
+		{"nonsense", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subcommand+strings.Join(tt.args, " "), func(t *testing.T) {
+			_, err := parseFlags(tt.subcommand, tt.args, io.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFlags(%q, %q) error = %v, wantErr %v", tt.subcommand, tt.args, err, tt.wantErr)
+			}
+		})
+	}
+
+	// The flags of the command exec runs are left to it
+	opts, err := parseFlags("exec", []string{"--", "kubectl", "logs", "-f"}, io.Discard)
+	if err != nil || len(opts.args) != 3 {
+		t.Errorf("expected the command's own flags left as arguments, got %q and %v", opts.args, err)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"64KB", 64 << 10, false},
+		{"10MB", 10 << 20, false},
+		{"10mib", 10 << 20, false},
+		{"1 G", 1 << 30, false},
+		{"100B", 100, false},
+		{"", 0, true},
+		{"-1MB", 0, true},
+		{"1.5MB", 0, true},
+		{"99999999999GB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var size byteSize
+			err := size.Set(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && int64(size) != tt.want {
+				t.Errorf("Set(%q) = %d, expected %d", tt.input, size, tt.want)
+			}
+		})
+	}
+}
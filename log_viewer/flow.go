@@ -0,0 +1,311 @@
+// log_viewer/flow.go
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// flowCall is one hop of a request: from one lane (client, ingress or a
+// service) to another, with the replies seen at both ends when the caller's
+// sidecar and the callee's sidecar both logged it.
+type flowCall struct {
+	from, to string
+	request  string // Method and path
+	response string // Code and flags as seen by the caller
+	flags    string // Envoy response flags as seen by the caller, see explainResponseFlags
+	failed   bool   // As requestFailed has it
+	duration float64
+	server   *ParsedLog // The callee's inbound entry, merged into this hop
+	calls    []*flowCall
+
+	start time.Time
+	end   time.Time
+	timed bool
+}
+
+// flowEntry is a log entry of the request with the timing needed to nest it.
+type flowEntry struct {
+	log        ParsedLog
+	start, end time.Time
+	timed      bool
+	inbound    bool
+	service    string
+}
+
+// requestFlow rebuilds the hops of one request from the entries sharing its
+// request ID (Envoy propagates x-request-id through the mesh). Entries are
+// nested by time: a call made while another is in flight was made by that
+// call's service. When the caller's outbound entry and the callee's inbound
+// entry describe the same hop they are merged, so the difference between the
+// two durations is the time spent between the proxies.
+func requestFlow(logs []ParsedLog, requestID string) []*flowCall {
+	var entries []flowEntry
+	for _, log := range logs {
+		if id := log.RequestID(); id != requestID || id == "" {
+			continue
+		}
+		entry := flowEntry{log: log, service: flowService(log)}
+		entry.inbound = strings.HasPrefix(log.UpstreamCluster(), "inbound|")
+		if t, ok := eventTime(log); ok {
+			duration, _ := log.Duration()
+			entry.start = t
+			entry.end = t.Add(duration)
+			entry.timed = true
+		}
+		entries = append(entries, entry)
+	}
+
+	// Outer calls start first, or at the same time and last longer
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.timed || !b.timed || !a.start.Equal(b.start) {
+			return a.timed && b.timed && a.start.Before(b.start)
+		}
+		return a.end.After(b.end)
+	})
+
+	var roots []*flowCall
+	var open []*flowCall // Calls that may still enclose the next entry
+	for _, entry := range entries {
+		// A call started after another ended was not made by it. Entries
+		// without a time are assumed to be nested in the previous one.
+		for len(open) > 0 && entry.timed && open[len(open)-1].timed && open[len(open)-1].end.Before(entry.start) {
+			open = open[:len(open)-1]
+		}
+
+		var parent *flowCall
+		if len(open) > 0 {
+			parent = open[len(open)-1]
+		}
+		if parent != nil && entry.inbound && parent.server == nil && parent.to == entry.service {
+			log := entry.log
+			parent.server = &log
+			continue
+		}
+
+		call := &flowCall{
+			to:       entry.service,
+			request:  flowRequest(entry.log),
+			response: flowResponse(entry.log),
+			start:    entry.start,
+			end:      entry.end,
+			timed:    entry.timed,
+		}
+		call.duration, _ = entry.log.FloatField("duration")
+		call.flags, _ = entry.log.StringField("response_flags")
+		if code, ok := entry.log.ResponseCode(); ok {
+			call.failed = requestFailed(code, entry.log.Flags())
+		}
+
+		switch {
+		case parent != nil:
+			call.from = parent.to
+			parent.calls = append(parent.calls, call)
+		case entry.inbound:
+			// Only the callee logged this hop
+			call.from = "client"
+			roots = append(roots, call)
+		default:
+			// An outbound entry nothing else called was logged by the
+			// gateway the request came in through
+			call.from = "ingress"
+			roots = append(roots, &flowCall{
+				from:     "client",
+				to:       "ingress",
+				request:  call.request,
+				response: call.response,
+				flags:    call.flags,
+				failed:   call.failed,
+				duration: call.duration,
+				calls:    []*flowCall{call},
+				start:    call.start,
+				end:      call.end,
+				timed:    call.timed,
+			})
+			if upstream, ok := entry.log.Fields["upstream_service_time"]; ok {
+				call.duration = parseDurationField(upstream)
+			}
+		}
+		open = append(open, call)
+	}
+	return roots
+}
+
+// parseDurationField reads a millisecond duration logged as a number or a
+// string, as upstream_service_time is.
+func parseDurationField(value interface{}) float64 {
+	if ms, ok := floatValue(value); ok {
+		return ms
+	}
+	switch v := value.(type) {
+	case string:
+		var ms float64
+		fmt.Sscanf(v, "%g", &ms)
+		return ms
+	}
+	return 0
+}
+
+// flowService names the service a hop went to, from the cluster name of
+// outbound hops or the authority of inbound ones.
+func flowService(log ParsedLog) string {
+	parts := strings.Split(log.UpstreamCluster(), "|")
+	if len(parts) == 4 && parts[3] != "" {
+		return strings.SplitN(parts[3], ".", 2)[0]
+	}
+	if authority, _ := log.Fields["authority"].(string); authority != "" {
+		host := authority
+		if h, _, err := net.SplitHostPort(authority); err == nil {
+			host = h
+		}
+		return strings.SplitN(host, ".", 2)[0]
+	}
+	if host := getFieldSafely(log.Fields, "upstream_host"); host != "-" {
+		return host
+	}
+	return "app"
+}
+
+func flowRequest(log ParsedLog) string {
+	return strings.TrimSpace(log.Method() + " " + log.Path())
+}
+
+func flowResponse(log ParsedLog) string {
+	var parts []string
+	if code, ok := log.ResponseCode(); ok {
+		parts = append(parts, fmt.Sprintf("%d", code))
+	}
+	if flags, _ := log.StringField("response_flags"); len(parseResponseFlags(flags)) > 0 {
+		parts = append(parts, flags)
+	}
+	return strings.Join(parts, " ")
+}
+
+// flowArrow is one line of the sequence diagram.
+type flowArrow struct {
+	from, to string
+	label    string
+}
+
+// flowArrows walks the calls in order: each request, the calls it made, then
+// its reply.
+func flowArrows(calls []*flowCall) []flowArrow {
+	var arrows []flowArrow
+	for _, call := range calls {
+		arrows = append(arrows, flowArrow{from: call.from, to: call.to, label: call.request})
+		arrows = append(arrows, flowArrows(call.calls)...)
+
+		reply := strings.TrimSpace(fmt.Sprintf("%s %gms", call.response, call.duration))
+		if call.server != nil {
+			serverDuration, _ := call.server.FloatField("duration")
+			reply += fmt.Sprintf(" (server %gms)", serverDuration)
+		}
+		arrows = append(arrows, flowArrow{from: call.to, to: call.from, label: reply})
+	}
+	return arrows
+}
+
+// renderFlow draws the calls as an ASCII sequence diagram, one lifeline per
+// lane in the order they are first reached.
+func renderFlow(calls []*flowCall) string {
+	arrows := flowArrows(calls)
+	var lanes []string
+	index := make(map[string]int)
+	for _, arrow := range arrows {
+		for _, lane := range []string{arrow.from, arrow.to} {
+			if _, ok := index[lane]; !ok {
+				index[lane] = len(lanes)
+				lanes = append(lanes, lane)
+			}
+		}
+	}
+
+	spacing := 32 // Fits a reply with both durations
+	for _, lane := range lanes {
+		spacing = max(spacing, len([]rune(lane))+4)
+	}
+	center := func(lane string) int { return index[lane]*spacing + spacing/2 }
+	width := len(lanes) * spacing
+
+	lifelines := func() []rune {
+		row := []rune(strings.Repeat(" ", width))
+		for _, lane := range lanes {
+			row[center(lane)] = '|'
+		}
+		return row
+	}
+
+	var rows []string
+	names := []rune(strings.Repeat(" ", width))
+	for _, lane := range lanes {
+		name := []rune(lane)
+		copy(names[center(lane)-len(name)/2:], name)
+	}
+	rows = append(rows, strings.TrimRight(string(names), " "), strings.TrimRight(string(lifelines()), " "))
+
+	for _, arrow := range arrows {
+		row := lifelines()
+		from, to := center(arrow.from), center(arrow.to)
+		label := []rune(arrow.label)
+
+		if from == to {
+			// A service calling itself
+			row = append(row[:from+1], []rune(" <- "+arrow.label)...)
+			rows = append(rows, strings.TrimRight(string(row), " "))
+			continue
+		}
+
+		left, right := min(from, to), max(from, to)
+		for i := left + 1; i < right; i++ {
+			row[i] = '-'
+		}
+		if to > from {
+			row[right-1] = '>'
+		} else {
+			row[left+1] = '<'
+		}
+
+		// The label sits inside the shaft, clear of the head and the lifelines
+		start, end := left+3, right-2
+		if len(label) > end-start {
+			label = []rune(truncate(arrow.label, end-start))
+		}
+		copy(row[start+(end-start-len(label))/2:], label)
+		rows = append(rows, strings.TrimRight(string(row), " "))
+	}
+	rows = append(rows, strings.TrimRight(string(lifelines()), " "))
+	return strings.Join(rows, "\n")
+}
+
+// requestFlowView is the open flow diagram in the TUI.
+type requestFlowView struct {
+	requestID string
+	diagram   string
+}
+
+// openFlow draws the flow of the selected entry's request.
+func (m Model) openFlow() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	requestID := m.filteredLogs[m.selectedLogIndex].RequestID()
+	if requestID == "" {
+		m.err = fmt.Errorf("the selected entry has no request_id to follow")
+		return m
+	}
+	m.flow = &requestFlowView{requestID: requestID, diagram: renderFlow(requestFlow(m.logs, requestID))}
+	return m
+}
+
+// renderFlowView shows the diagram over the whole screen.
+func (m Model) renderFlowView() string {
+	header := headerStyle.Render(fmt.Sprintf("Request flow for %s (esc to close)", m.flow.requestID))
+	return lipgloss.JoinVertical(lipgloss.Left, header, logStyle.Render(m.flow.diagram))
+}
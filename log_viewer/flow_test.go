@@ -0,0 +1,71 @@
+// log_viewer/flow_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var flowTestLines = []string{
+	// Logged by the ingress gateway, the productpage sidecar, then its call to reviews at both ends
+	`{"request_id":"abc","start_time":"2024-11-26T02:30:00.000Z","method":"GET","path":"/productpage","response_code":200,"response_flags":"-","duration":40,"upstream_service_time":"38","upstream_cluster":"outbound|9080||productpage.default.svc.cluster.local"}`,
+	`{"request_id":"abc","start_time":"2024-11-26T02:30:00.001Z","method":"GET","path":"/productpage","response_code":200,"response_flags":"-","duration":37,"upstream_cluster":"inbound|9080||","authority":"productpage:9080"}`,
+	`{"request_id":"abc","start_time":"2024-11-26T02:30:00.005Z","method":"GET","path":"/reviews/0","response_code":503,"response_flags":"UF","duration":25,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+	`{"request_id":"abc","start_time":"2024-11-26T02:30:00.007Z","method":"GET","path":"/reviews/0","response_code":503,"response_flags":"-","duration":20,"upstream_cluster":"inbound|9080||","authority":"reviews:9080"}`,
+	`{"request_id":"other","start_time":"2024-11-26T02:30:00.007Z","method":"GET","path":"/ratings","response_code":200,"duration":1}`,
+}
+
+func TestRequestFlow(t *testing.T) {
+	arrows := flowArrows(requestFlow(mustParse(t, flowTestLines...), "abc"))
+	expected := []flowArrow{
+		{"client", "ingress", "GET /productpage"},
+		{"ingress", "productpage", "GET /productpage"},
+		{"productpage", "reviews", "GET /reviews/0"},
+		{"reviews", "productpage", "503 UF 25ms (server 20ms)"},
+		{"productpage", "ingress", "200 38ms (server 37ms)"},
+		{"ingress", "client", "200 40ms"},
+	}
+	if len(arrows) != len(expected) {
+		t.Fatalf("flowArrows() = %v, expected %v", arrows, expected)
+	}
+	for i := range expected {
+		if arrows[i] != expected[i] {
+			t.Errorf("arrow %d = %+v, expected %+v", i, arrows[i], expected[i])
+		}
+	}
+
+	diagram := renderFlow(requestFlow(mustParse(t, flowTestLines...), "abc"))
+	lines := strings.Split(diagram, "\n")
+	if !strings.Contains(lines[0], "client") || !strings.Contains(lines[0], "reviews") {
+		t.Errorf("expected the lanes in the first line, got %q", lines[0])
+	}
+	if !strings.Contains(diagram, "-GET /reviews/0-") || !strings.Contains(diagram, "<-") {
+		t.Errorf("expected labelled request and reply arrows, got\n%s", diagram)
+	}
+}
+
+func TestOpenFlow(t *testing.T) {
+	logs := mustParse(t, flowTestLines...)
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 30}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	model = updated.(Model)
+	if model.flow == nil || !strings.Contains(model.View(), "Request flow for abc") {
+		t.Fatalf("expected f to open the flow of the selected request")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).flow != nil {
+		t.Errorf("expected esc to close the flow")
+	}
+
+	// Entries without a request ID get a toast instead
+	model = Model{logs: []ParsedLog{{RawLog: "x"}}, filteredLogs: []ParsedLog{{RawLog: "x"}}}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if model = updated.(Model); model.flow != nil || model.err == nil {
+		t.Errorf("expected an error for an entry without a request_id")
+	}
+}
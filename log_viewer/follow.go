@@ -0,0 +1,135 @@
+// log_viewer/follow.go
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logLineMsg carries a single raw line received from a followed source.
+type logLineMsg struct {
+	line   string
+	stream <-chan string // Source of the line, so lines from a replaced stream are dropped
+}
+
+// streamClosedMsg is sent once a followed source has no more lines.
+type streamClosedMsg struct {
+	stream <-chan string
+}
+
+// waitForLine returns a command that blocks until the next line arrives on
+// the stream, so the TUI can keep receiving lines one message at a time.
+func waitForLine(lines <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-lines
+		if !ok {
+			return streamClosedMsg{stream: lines}
+		}
+		return logLineMsg{line: line, stream: lines}
+	}
+}
+
+// multilineIdle is how long a followed entry waits for more continuation
+// lines before it is shown.
+const multilineIdle = 200 * time.Millisecond
+
+// pendingIdleMsg is sent once the followed entry being joined may be complete.
+type pendingIdleMsg struct {
+	stream <-chan string
+	seq    int // Model.pendingSeq when the wait started
+}
+
+// waitForIdle returns a command reporting, after multilineIdle, that no line
+// may have arrived since seq.
+func waitForIdle(stream <-chan string, seq int) tea.Cmd {
+	return tea.Tick(multilineIdle, func(time.Time) tea.Msg {
+		return pendingIdleMsg{stream: stream, seq: seq}
+	})
+}
+
+// receiveLine joins a streamed line into the entry being followed, by the
+// same rules as a fetched log's lines, see lineJoiner. A line that starts
+// another entry adds the previous one, and one still being joined is added
+// once the stream has been idle for multilineIdle. Without a joiner every
+// line is added on its own.
+func (m Model) receiveLine(line string) (Model, tea.Cmd) {
+	if m.multiline == nil {
+		return m.appendLine(line), nil
+	}
+	if m.multiline.continues(m.pending, line) {
+		m.pending = append(m.pending, line)
+	} else {
+		m = m.flushPending()
+		m.pending = []string{line}
+	}
+	m.pendingSeq++
+	if m.idleWait {
+		return m, nil
+	}
+	m.idleWait = true
+	return m, waitForIdle(m.stream, m.pendingSeq)
+}
+
+// receiveIdle adds the entry being joined if no line arrived during the
+// wait, and waits again otherwise.
+func (m Model) receiveIdle(msg pendingIdleMsg) (Model, tea.Cmd) {
+	if msg.stream != m.stream || !m.idleWait {
+		return m, nil
+	}
+	if msg.seq != m.pendingSeq {
+		return m, waitForIdle(m.stream, m.pendingSeq)
+	}
+	m.idleWait = false
+	return m.flushPending(), nil
+}
+
+// flushPending adds the entry being joined, if any.
+func (m Model) flushPending() Model {
+	if len(m.pending) == 0 {
+		return m
+	}
+	for _, chunk := range m.multiline.join(m.pending) {
+		m = m.appendLine(chunk.text)
+	}
+	m.pending = nil
+	return m
+}
+
+// appendLine parses a streamed line and adds it to the model. When the view
+// is pinned, the selection moves to the newest visible entry, mirroring
+// `less +F`; scrolling up unpins until the user presses end/G.
+func (m Model) appendLine(line string) Model {
+	line = strings.TrimSpace(line)
+	// Numbering goes on from a backfill, whose entries may span several lines
+	lineNumber := 1
+	if len(m.logs) > 0 {
+		lineNumber = m.logs[len(m.logs)-1].LineNumber + 1
+	}
+	parsedLog, err := parseLine(line, lineNumber)
+	if err != nil {
+		return m
+	}
+	var previous time.Time
+	if len(m.logs) > 0 {
+		previous = m.logs[len(m.logs)-1].ID.Time
+	}
+	parsedLog.ID = entryID(parsedLog, m.logsFrom, previous)
+	parsedLog.Source = m.origin
+	parsedLog = redactLog(withSchemas(parsedLog, m.schemas), m.redact)
+	parsedLog = withPodFieldsAdded(parsedLog, m.podFields)
+
+	m.logs = append(m.logs, parsedLog)
+	// Only the new entry is filtered, so hidden entries stay hidden
+	if len(m.filter([]ParsedLog{parsedLog})) > 0 {
+		m.filteredLogs = append(m.filteredLogs, parsedLog)
+		m.overhead = m.overhead.add(parsedLog)
+	}
+
+	if m.pinned && len(m.filteredLogs) > 0 {
+		m.selectedLogIndex = len(m.filteredLogs) - 1
+	}
+	return m
+}
@@ -0,0 +1,98 @@
+// log_viewer/fuzz_test.go
+
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Run a target with e.g. `go test -run '^$' -fuzz FuzzParseLine -fuzztime 30s`.
+// Without -fuzz the seed corpus runs as regular tests.
+
+var fuzzSeedLines = []string{
+	`{"response_code":503,"response_flags":"UF,URX","upstream_host":"10.43.236.215:4200"}`,
+	`{"level":"info","message":"Server started"}`,
+	"2024-11-25T19:47:07.374828Z\tinfo\tFLAG: --concurrency=\"0\"",
+	"2024-05-04T09:59:05.028709Z\tinfo\taccess\tconnection complete\tsrc.addr=10.244.0.33:50676 dst.service=\"productpage\" bytes_sent=175",
+	"2024-11-25T19:47:07.374828Z\tdebug\tenvoy connection cc:150\t[C12] closing\tthread=21",
+	"2024-11-26T02:30:58.502123456Z {\"start_time\":\"2024-11-26T02:30:58.502Z\"}",
+	`{"level":"error"}` + "\n\tat com.example.Main(Main.java:1)",
+	`{}`,
+	`{"a":null}`,
+	"\t\t\t",
+}
+
+func FuzzParseLine(f *testing.F) {
+	for _, seed := range fuzzSeedLines {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		parsedLog, err := parseLine(line, 1)
+		if err != nil {
+			return
+		}
+		// Everything downstream of parsing must cope with whatever was parsed
+		detectProfile(parsedLog)
+		eventTime(parsedLog)
+		connectionID(parsedLog)
+		formatLogPreview(parsedLog, 80)
+		renderDetailView(parsedLog, detailExtras{}, 80, 20)
+	})
+}
+
+func FuzzParseRawLogs(f *testing.F) {
+	f.Add(strings.Join(fuzzSeedLines, "\n"))
+	f.Add("[{\"a\":1},{\"b\":2}]")
+	f.Add("{\n  \"a\": {\n    \"b\": [1, 2]\n  }\n}")
+	f.Fuzz(func(t *testing.T, input string) {
+		parseRawLogs(strings.Split(input, "\n"))
+	})
+}
+
+func FuzzParseLogfmt(f *testing.F) {
+	f.Add(`src.addr=10.244.0.33:50676 src.workload="sleep" direction="inbound" bytes_sent=175`)
+	f.Add(`key="unterminated`)
+	f.Add(`key="escaped \" quote\\" bare =value k=`)
+	f.Fuzz(func(t *testing.T, input string) {
+		for key := range parseLogfmt(input) {
+			if key == "" {
+				t.Errorf("parseLogfmt(%q) returned an empty key", input)
+			}
+		}
+	})
+}
+
+func FuzzExplainResponseFlags(f *testing.F) {
+	f.Add("UF,URX")
+	f.Add(" UH , UO ")
+	f.Add(",,,")
+	f.Fuzz(func(t *testing.T, flags string) {
+		explainResponseFlags(flags)
+	})
+}
+
+func FuzzFormatAddress(f *testing.F) {
+	f.Add("10.43.236.215:4200")
+	f.Add("[::1]:8080")
+	f.Add(":")
+	f.Fuzz(func(t *testing.T, value string) {
+		explanation := formatAddress(value)
+		if _, _, err := net.SplitHostPort(value); err != nil && explanation != "" {
+			t.Errorf("formatAddress(%q) = %q for an address without a port", value, explanation)
+		}
+	})
+}
+
+func FuzzTruncate(f *testing.F) {
+	f.Add("héllo wörld", 5)
+	f.Add("", -1)
+	f.Fuzz(func(t *testing.T, input string, maxLen int) {
+		got := truncate(input, maxLen)
+		if utf8.ValidString(input) && utf8.RuneCountInString(got) > maxLen && maxLen >= 0 {
+			t.Errorf("truncate(%q, %d) = %q is longer than %d runes", input, maxLen, got, maxLen)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+// log_viewer/gate.go
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Exit codes of summarize besides 0, so it can gate a pipeline step such as
+// a canary promotion.
+const (
+	exitFailure   = 1 // The logs could not be read or parsed
+	exitThreshold = 2 // A -max-* threshold was exceeded
+)
+
+// thresholds are the limits that make summarize exit with exitThreshold.
+// Negative values disable a limit.
+type thresholds struct {
+	errors    int     // Failed requests, see requestFailed
+	serverErr int     // Responses with a 5xx code
+	errorRate float64 // Failed requests as a fraction of all requests
+}
+
+// exceeded lists every threshold the summary goes over.
+func (s summary) exceeded(t thresholds) []string {
+	var violations []string
+	if t.errors >= 0 && s.errors > t.errors {
+		violations = append(violations, fmt.Sprintf("%d failed requests, more than -max-errors %d", s.errors, t.errors))
+	}
+	if serverErrors := s.serverErrors(); t.serverErr >= 0 && serverErrors > t.serverErr {
+		violations = append(violations, fmt.Sprintf("%d 5xx responses, more than -max-5xx %d", serverErrors, t.serverErr))
+	}
+	if t.errorRate >= 0 && s.requests > 0 {
+		if rate := float64(s.errors) / float64(s.requests); rate > t.errorRate {
+			violations = append(violations, fmt.Sprintf("%.1f%% of requests failed, more than -max-error-rate %g", rate*100, t.errorRate))
+		}
+	}
+	return violations
+}
+
+// serverErrors counts the failed requests answered with a 5xx code.
+func (s summary) serverErrors() int {
+	count := 0
+	for code, n := range s.errorsByCode {
+		if c, err := strconv.Atoi(code); err == nil && c >= 500 && c < 600 {
+			count += n
+		}
+	}
+	return count
+}
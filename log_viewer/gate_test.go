@@ -0,0 +1,46 @@
+// log_viewer/gate_test.go
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExceeded(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":200,"response_flags":"-"}`,
+		`{"response_code":200,"response_flags":"-"}`,
+		`{"response_code":503,"response_flags":"UF"}`,
+		`{"response_code":0,"response_flags":"UC"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	s := summarize(logs, "test")
+	none := thresholds{errors: -1, serverErr: -1, errorRate: -1}
+
+	tests := []struct {
+		name   string
+		limits thresholds
+		want   []string
+	}{
+		{"no limits", none, nil},
+		{"errors within limit", thresholds{errors: 2, serverErr: -1, errorRate: -1}, nil},
+		{"errors over limit", thresholds{errors: 1, serverErr: -1, errorRate: -1},
+			[]string{"2 failed requests, more than -max-errors 1"}},
+		{"5xx over limit", thresholds{errors: -1, serverErr: 0, errorRate: -1},
+			[]string{"1 5xx responses, more than -max-5xx 0"}},
+		{"rate over limit", thresholds{errors: -1, serverErr: -1, errorRate: 0.25},
+			[]string{"50.0% of requests failed, more than -max-error-rate 0.25"}},
+		{"rate within limit", thresholds{errors: -1, serverErr: -1, errorRate: 0.5}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.exceeded(tt.limits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exceeded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
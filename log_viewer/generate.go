@@ -0,0 +1,237 @@
+// log_viewer/generate.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generatedFlag is an error a generated entry can show, with the response
+// Envoy gives for it.
+type generatedFlag struct {
+	code      int
+	details   string
+	transport string // upstream_transport_failure_reason, empty for none
+}
+
+// generatedFlags are the flags -flag-mix accepts. "-" is an error returned
+// by the upstream itself rather than by the proxy.
+var generatedFlags = map[string]generatedFlag{
+	"UF":  {503, "upstream_reset_before_response_started{connection_failure}", "delayed_connect_error:_111"},
+	"UO":  {503, "upstream_reset_before_response_started{overflow}", ""},
+	"URX": {504, "upstream_response_timeout", ""},
+	"UH":  {503, "no_healthy_upstream", ""},
+	"NR":  {404, "route_not_found", ""},
+	"DC":  {0, "downstream_remote_disconnect", ""},
+	"-":   {500, "via_upstream", ""},
+}
+
+// defaultFlagMix is the mix of errors generated without -flag-mix.
+const defaultFlagMix = "UF=3,UO=2,URX=2,-=3"
+
+// generatedRoute is a call between Bookinfo services that generated
+// entries describe.
+type generatedRoute struct {
+	method, path, service, subset string
+	latency                       float64 // Typical upstream time, milliseconds
+}
+
+var generatedRoutes = []generatedRoute{
+	{"GET", "/productpage", "productpage", "v1", 40},
+	{"GET", "/reviews/0", "reviews", "v1", 15},
+	{"GET", "/reviews/0", "reviews", "v2", 25},
+	{"GET", "/reviews/0", "reviews", "v3", 30},
+	{"GET", "/ratings/0", "ratings", "v1", 5},
+	{"GET", "/details/0", "details", "v1", 8},
+	{"POST", "/api/v1/products/0/reviews", "reviews", "v2", 60},
+}
+
+// weightedFlag is a flag with its share of generated errors.
+type weightedFlag struct {
+	flag   string
+	weight float64
+}
+
+// parseFlagMix parses a -flag-mix such as UF=3,UO=1, weights relative to
+// each other.
+func parseFlagMix(mix string) ([]weightedFlag, error) {
+	var flags []weightedFlag
+	for _, item := range strings.Split(mix, ",") {
+		flag, weight, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if _, known := generatedFlags[flag]; !known {
+			var names []string
+			for name := range generatedFlags {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown flag %q in -flag-mix, expected one of %s", flag, strings.Join(names, ", "))
+		}
+		w := 1.0
+		if ok {
+			var err error
+			if w, err = strconv.ParseFloat(weight, 64); err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s in -flag-mix", weight, flag)
+			}
+		}
+		flags = append(flags, weightedFlag{flag: flag, weight: w})
+	}
+	return flags, nil
+}
+
+// logGenerator produces synthetic Istio access log entries in the default
+// JSON format. The same seed gives the same entries.
+type logGenerator struct {
+	rng        *rand.Rand
+	now        time.Time     // start_time of the next entry
+	interval   time.Duration // Time between entries
+	errorRatio float64
+	flags      []weightedFlag
+}
+
+func newLogGenerator(seed int64, start time.Time, interval time.Duration, errorRatio float64, flags []weightedFlag) *logGenerator {
+	return &logGenerator{
+		rng:        rand.New(rand.NewSource(seed)),
+		now:        start,
+		interval:   interval,
+		errorRatio: errorRatio,
+		flags:      flags,
+	}
+}
+
+// pickFlag picks an error's flag by weight.
+func (g *logGenerator) pickFlag() string {
+	var total float64
+	for _, f := range g.flags {
+		total += f.weight
+	}
+	n := g.rng.Float64() * total
+	for _, f := range g.flags {
+		if n < f.weight {
+			return f.flag
+		}
+		n -= f.weight
+	}
+	return g.flags[len(g.flags)-1].flag
+}
+
+// next returns the next entry.
+func (g *logGenerator) next() map[string]interface{} {
+	route := generatedRoutes[g.rng.Intn(len(generatedRoutes))]
+	upstream := route.latency * (0.5 + g.rng.ExpFloat64())
+	overhead := 0.5 + g.rng.Float64()*2
+
+	entry := map[string]interface{}{
+		"start_time":                        g.now.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"method":                            route.method,
+		"path":                              route.path,
+		"protocol":                          "HTTP/1.1",
+		"authority":                         route.service + ":9080",
+		"request_id":                        g.requestID(),
+		"user_agent":                        "Mozilla/5.0",
+		"x_forwarded_for":                   nil,
+		"response_code":                     200,
+		"response_flags":                    "-",
+		"response_code_details":             "via_upstream",
+		"connection_termination_details":    nil,
+		"upstream_transport_failure_reason": nil,
+		"duration":                          int(upstream + overhead),
+		"upstream_service_time":             strconv.Itoa(int(upstream)),
+		"bytes_received":                    0,
+		"bytes_sent":                        200 + g.rng.Intn(4000),
+		"upstream_cluster":                  fmt.Sprintf("outbound|9080|%s|%s.default.svc.cluster.local", route.subset, route.service),
+		"upstream_host":                     fmt.Sprintf("10.244.0.%d:9080", 10+g.rng.Intn(40)),
+		"upstream_local_address":            fmt.Sprintf("10.244.0.5:%d", 30000+g.rng.Intn(30000)),
+		"downstream_local_address":          fmt.Sprintf("10.96.%d.%d:9080", g.rng.Intn(256), 1+g.rng.Intn(254)),
+		"downstream_remote_address":         fmt.Sprintf("10.244.0.5:%d", 30000+g.rng.Intn(30000)),
+		"requested_server_name":             nil,
+		"route_name":                        "default",
+	}
+
+	if len(g.flags) > 0 && g.rng.Float64() < g.errorRatio {
+		flag := g.pickFlag()
+		failure := generatedFlags[flag]
+		entry["response_code"] = failure.code
+		entry["response_flags"] = flag
+		entry["response_code_details"] = failure.details
+		entry["bytes_sent"] = 0
+		if failure.transport != "" {
+			entry["upstream_transport_failure_reason"] = failure.transport
+		}
+		if flag != "-" {
+			// The proxy answered without the upstream
+			entry["upstream_service_time"] = nil
+			entry["duration"] = int(overhead)
+		}
+		if flag == "URX" {
+			entry["duration"] = 15000
+		}
+	}
+
+	g.now = g.now.Add(g.interval)
+	return entry
+}
+
+// requestID returns a UUID-shaped x-request-id.
+func (g *logGenerator) requestID() string {
+	b := make([]byte, 16)
+	g.rng.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// writeGenerated writes count entries as JSON lines, pausing pause between
+// them. A count of zero or less writes until w fails.
+func writeGenerated(w io.Writer, g *logGenerator, count int, pause time.Duration) error {
+	encoder := json.NewEncoder(w)
+	for i := 0; count <= 0 || i < count; i++ {
+		if i > 0 && pause > 0 {
+			time.Sleep(pause)
+		}
+		if err := encoder.Encode(g.next()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runGenerate implements `log_viewer generate [-count n] [-rate n] [-error-ratio r] [-flag-mix UF=3,UO=1] [-seed n]`.
+func runGenerate(opts cliOptions, cfg Config) {
+	if opts.errorRatio < 0 || opts.errorRatio > 1 {
+		fmt.Fprintln(os.Stderr, "Error: -error-ratio must be between 0 and 1")
+		os.Exit(2)
+	}
+	mix := opts.flagMix
+	if mix == "" {
+		mix = defaultFlagMix
+	}
+	flags, err := parseFlagMix(mix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Entries are spaced as if written at -rate, and written that fast too
+	// unless the rate is zero
+	interval, pause := 100*time.Millisecond, time.Duration(0)
+	if opts.rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.rate)
+		pause = interval
+	}
+	seed := opts.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	g := newLogGenerator(seed, time.Now(), interval, opts.errorRatio, flags)
+	if err := writeGenerated(os.Stdout, g, opts.count, pause); err != nil {
+		log.Println("Stopped generating:", err)
+	}
+}
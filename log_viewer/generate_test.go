@@ -0,0 +1,70 @@
+// log_viewer/generate_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlagMix(t *testing.T) {
+	flags, err := parseFlagMix("UF=3, UO,-=0.5")
+	if err != nil {
+		t.Fatalf("parseFlagMix() unexpected error: %v", err)
+	}
+	expected := []weightedFlag{{"UF", 3}, {"UO", 1}, {"-", 0.5}}
+	if len(flags) != len(expected) {
+		t.Fatalf("parseFlagMix() = %v, expected %v", flags, expected)
+	}
+	for i := range expected {
+		if flags[i] != expected[i] {
+			t.Errorf("parseFlagMix()[%d] = %v, expected %v", i, flags[i], expected[i])
+		}
+	}
+
+	for _, mix := range []string{"XX=1", "UF=lots", "UF=-1"} {
+		if _, err := parseFlagMix(mix); err == nil {
+			t.Errorf("parseFlagMix(%q) expected an error", mix)
+		}
+	}
+}
+
+func TestGeneratedLogsParse(t *testing.T) {
+	flags, _ := parseFlagMix("UF=1,URX=1")
+	start := time.Date(2024, 5, 4, 10, 0, 0, 0, time.UTC)
+	var out strings.Builder
+	if err := writeGenerated(&out, newLogGenerator(1, start, time.Second, 0.5, flags), 200, 0); err != nil {
+		t.Fatalf("writeGenerated() unexpected error: %v", err)
+	}
+
+	logs, err := parseRawLogs(strings.Split(strings.TrimSpace(out.String()), "\n"))
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	if len(logs) != 200 {
+		t.Fatalf("expected 200 entries, got %d", len(logs))
+	}
+
+	report := summarize(logs, "generated")
+	if report.requests != 200 {
+		t.Errorf("summarize() counted %d requests, expected 200", report.requests)
+	}
+	if report.errors < 70 || report.errors > 130 {
+		t.Errorf("summarize() counted %d errors, expected about half of 200", report.errors)
+	}
+	for flag := range report.errorsByFlag {
+		if flag != "UF" && flag != "URX" {
+			t.Errorf("generated flag %q outside the mix", flag)
+		}
+	}
+	if last, _ := eventTime(logs[199]); !last.Equal(start.Add(199 * time.Second)) {
+		t.Errorf("last entry at %v, expected entries a second apart", last)
+	}
+
+	var again strings.Builder
+	writeGenerated(&again, newLogGenerator(1, start, time.Second, 0.5, flags), 200, 0)
+	if again.String() != out.String() {
+		t.Error("writeGenerated() with the same seed gave different entries")
+	}
+}
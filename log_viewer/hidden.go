@@ -0,0 +1,120 @@
+// log_viewer/hidden.go
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Hiding takes entries out of the view for the rest of the session, on top
+// of the filters, to whittle away noise already understood: x hides the
+// selected or marked entries, see hideMarked, and H reviews what is hidden
+// so entries can be shown again.
+
+// hiddenView is the open review of hidden entries.
+type hiddenView struct {
+	selected int // Index into hiddenLogs()
+}
+
+// hiddenLogs returns the hidden entries in log order.
+func (m Model) hiddenLogs() []ParsedLog {
+	var hidden []ParsedLog
+	for _, log := range m.logs {
+		if m.excluded[log.ID.String()] {
+			hidden = append(hidden, log)
+		}
+	}
+	return hidden
+}
+
+// openHidden shows the hidden entries, or says there are none.
+func (m Model) openHidden() Model {
+	if len(m.excluded) == 0 {
+		m.notice = "No entries are hidden"
+		return m
+	}
+	m.hidden = &hiddenView{}
+	return m
+}
+
+// unhide shows the entries with the given IDs again, or every hidden entry
+// when none are given. Like hiding it can be undone.
+func (m Model) unhide(ids ...EntryID) Model {
+	m = m.remember()
+	excluded := map[string]bool(nil)
+	if len(ids) > 0 {
+		excluded = maps.Clone(m.excluded)
+		for _, id := range ids {
+			delete(excluded, id.String())
+		}
+	}
+	m.excluded = excluded
+	return m.refilter()
+}
+
+// updateHidden handles keys while the hidden entries are reviewed.
+func (m Model) updateHidden(msg tea.KeyMsg) (Model, tea.Cmd) {
+	hidden := m.hiddenLogs()
+	view := *m.hidden
+
+	switch m.action(msg.String()) {
+	case actionQuit:
+		return m, tea.Quit
+	case actionCancel, actionHidden:
+		m.hidden = nil
+		return m, nil
+	case actionUp:
+		if view.selected > 0 {
+			view.selected--
+		}
+	case actionDown:
+		if view.selected < len(hidden)-1 {
+			view.selected++
+		}
+	case actionConfirm, actionHide:
+		if len(hidden) > 0 {
+			m = m.unhide(hidden[view.selected].ID)
+			view.selected = min(view.selected, len(hidden)-2)
+		}
+	default:
+		if msg.String() == "a" {
+			m = m.unhide()
+		}
+	}
+
+	if len(m.excluded) == 0 {
+		m.hidden = nil
+		return m, nil
+	}
+	m.hidden = &view
+	return m, nil
+}
+
+// renderHidden lists the hidden entries over the whole screen.
+func (m Model) renderHidden() string {
+	hidden := m.hiddenLogs()
+	var builder strings.Builder
+	builder.WriteString(headerStyle.Render(fmt.Sprintf(
+		"%s hidden (enter to show again, a to show all, esc to close)", entryCount(len(hidden)))))
+	builder.WriteString("\n")
+
+	// Keep the selection in view
+	rows := max(m.height-2, 1)
+	start := max(min(m.hidden.selected-rows/2, len(hidden)-rows), 0)
+	end := min(start+rows, len(hidden))
+
+	for i := start; i < end; i++ {
+		line := fmt.Sprintf("%3d: %s", hidden[i].LineNumber, formatLogPreview(hidden[i], max(m.width-10, 1)))
+		if i == m.hidden.selected {
+			builder.WriteString(selectedLogStyle.Render("> " + line))
+		} else {
+			builder.WriteString(logStyle.Render("  " + line))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,54 @@
+// log_viewer/hidden_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHideAndReview(t *testing.T) {
+	keyHide := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+	keyReview := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")}
+
+	// x hides the selected entry, then the two marked ones
+	model := pressKeys(markedModel(t), keyHide, keySpace, keyDown, keySpace, keyHide)
+	if len(model.filteredLogs) != 2 || model.filteredLogs[0].Path() != "/d" {
+		t.Fatalf("expected /a to /c hidden, got %d entries", len(model.filteredLogs))
+	}
+	if !strings.Contains(model.View(), "3 HIDDEN") {
+		t.Errorf("expected the header to count the hidden entries")
+	}
+
+	// A followed line leaves them hidden
+	if followed := model.appendLine(`{"path":"/f","response_code":200}`); len(followed.filteredLogs) != 3 {
+		t.Errorf("expected the followed entry added to the 2 shown, got %d entries", len(followed.filteredLogs))
+	}
+
+	// Hidden entries stay hidden under a new filter
+	model = model.applyFilter("/")
+	if len(model.filteredLogs) != 2 {
+		t.Errorf("expected the filter to keep the entries hidden, got %d", len(model.filteredLogs))
+	}
+
+	// H lists them, enter shows one again
+	model = pressKeys(model, keyReview)
+	if model.hidden == nil || !strings.Contains(model.View(), "3 entries hidden") {
+		t.Fatalf("expected the review listing the hidden entries, got:\n%s", model.View())
+	}
+	model = pressKeys(model, keyDown, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(model.filteredLogs) != 3 || model.excluded["stdin#2"] {
+		t.Fatalf("expected /b shown again, got hidden %v", model.excluded)
+	}
+
+	// a shows the rest and closes the review
+	model = pressKeys(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if model.hidden != nil || len(model.filteredLogs) != 5 {
+		t.Errorf("expected every entry shown again, got %d", len(model.filteredLogs))
+	}
+	if model = pressKeys(model, keyReview); model.hidden != nil || model.notice == "" {
+		t.Errorf("expected a notice that nothing is hidden")
+	}
+}
@@ -0,0 +1,132 @@
+// log_viewer/highlight.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HighlightRule restyles the list rows of entries matching a condition, so
+// critical events stand out in dense streams, and can ring the terminal bell
+// when such an entry streams in:
+//
+//	{"highlights": [
+//	  {"field": "response_code", "equals": "0", "style": ["blink", "reverse"], "bell": true},
+//	  {"field": "response_flags", "contains": "UF", "color": "201", "themes": ["default"]}
+//	]}
+//
+// A rule applies under the themes it names, or under every theme when it
+// names none. The first matching rule replaces the severity coloring.
+type HighlightRule struct {
+	Field    string   `json:"field"`
+	Equals   string   `json:"equals,omitempty"`   // Whole value, numbers as written in the log
+	Contains string   `json:"contains,omitempty"` // Part of the value; neither matches any value
+	Style    []string `json:"style,omitempty"`    // See highlightStyles
+	Color    string   `json:"color,omitempty"`    // Foreground, an ANSI number or #rrggbb
+	Bell     bool     `json:"bell,omitempty"`     // Ring the bell when a matching entry streams in
+	Themes   []string `json:"themes,omitempty"`
+}
+
+// highlightStyles are the decorations a rule can add.
+var highlightStyles = map[string]func(lipgloss.Style, bool) lipgloss.Style{
+	"bold":      lipgloss.Style.Bold,
+	"faint":     lipgloss.Style.Faint,
+	"italic":    lipgloss.Style.Italic,
+	"underline": lipgloss.Style.Underline,
+	"blink":     lipgloss.Style.Blink,
+	"reverse":   lipgloss.Style.Reverse,
+}
+
+// activeHighlights are the rules for the active theme, set by
+// applyHighlights alongside the theme's styles.
+var activeHighlights []HighlightRule
+
+// compile checks the rule.
+func (r *HighlightRule) compile() error {
+	if r.Field == "" {
+		return fmt.Errorf("a highlight needs a field")
+	}
+	if r.Equals != "" && r.Contains != "" {
+		return fmt.Errorf("highlight of %s has both equals and contains", r.Field)
+	}
+	for _, style := range r.Style {
+		if highlightStyles[style] == nil {
+			return fmt.Errorf("highlight of %s has unknown style %q", r.Field, style)
+		}
+	}
+	for _, theme := range r.Themes {
+		if _, ok := themes[theme]; !ok {
+			return fmt.Errorf("highlight of %s names unknown theme %q (available: %v)", r.Field, theme, themeNames())
+		}
+	}
+	return nil
+}
+
+// matches reports whether the entry has the field with a matching value.
+func (r HighlightRule) matches(log ParsedLog) bool {
+	value, ok := log.Fields[r.Field]
+	if !ok || value == nil {
+		return false
+	}
+	s := exportString(value)
+	switch {
+	case r.Equals != "":
+		return s == r.Equals
+	case r.Contains != "":
+		return strings.Contains(s, r.Contains)
+	}
+	return true
+}
+
+// apply restyles a row.
+func (r HighlightRule) apply(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy()
+	if r.Color != "" {
+		style = style.Foreground(lipgloss.Color(r.Color))
+	}
+	for _, name := range r.Style {
+		style = highlightStyles[name](style, true)
+	}
+	return style
+}
+
+// applyHighlights makes the rules for theme the active ones.
+func applyHighlights(rules []HighlightRule, theme Theme) {
+	activeHighlights = nil
+	for _, rule := range rules {
+		if len(rule.Themes) == 0 || slices.Contains(rule.Themes, theme.Name) {
+			activeHighlights = append(activeHighlights, rule)
+		}
+	}
+}
+
+// highlightFor returns the first active rule matching the entry.
+func highlightFor(log ParsedLog) (HighlightRule, bool) {
+	for _, rule := range activeHighlights {
+		if rule.matches(log) {
+			return rule, true
+		}
+	}
+	return HighlightRule{}, false
+}
+
+// bellFor returns a command ringing the terminal bell when one of the
+// entries that just arrived matches a rule asking for it, otherwise nil.
+func bellFor(logs []ParsedLog) tea.Cmd {
+	for _, log := range logs {
+		if rule, ok := highlightFor(log); ok && rule.Bell {
+			return func() tea.Msg {
+				// The renderer owns stdout; stderr reaches the same terminal
+				os.Stderr.WriteString("\a")
+				return nil
+			}
+		}
+	}
+	return nil
+}
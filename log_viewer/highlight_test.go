@@ -0,0 +1,65 @@
+// log_viewer/highlight_test.go
+
+package main
+
+import (
+	"testing"
+)
+
+func TestHighlightRuleCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    HighlightRule
+		wantErr bool
+	}{
+		{"equals", HighlightRule{Field: "response_code", Equals: "0", Style: []string{"blink", "reverse"}}, false},
+		{"presence", HighlightRule{Field: "error", Themes: []string{"mono"}}, false},
+		{"no field", HighlightRule{Equals: "0"}, true},
+		{"equals and contains", HighlightRule{Field: "response_flags", Equals: "UF", Contains: "UF"}, true},
+		{"unknown style", HighlightRule{Field: "error", Style: []string{"sparkle"}}, true},
+		{"unknown theme", HighlightRule{Field: "error", Themes: []string{"solarized"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.compile(); (err != nil) != tt.wantErr {
+				t.Errorf("compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHighlightFor(t *testing.T) {
+	defer applyHighlights(nil, themes["default"])
+
+	logs, err := parseRawLogs([]string{
+		`{"response_code":0,"response_flags":"UF"}`,
+		`{"response_code":503,"response_flags":"UF,URX"}`,
+		`{"response_code":200,"response_flags":"-"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	rules := []HighlightRule{
+		{Field: "response_code", Equals: "0", Style: []string{"blink"}, Bell: true},
+		{Field: "response_flags", Contains: "URX", Themes: []string{"high-contrast"}},
+	}
+
+	applyHighlights(rules, themes["default"])
+	if rule, ok := highlightFor(logs[0]); !ok || rule.Equals != "0" {
+		t.Errorf("highlightFor() = %v, %v, expected the response_code rule", rule, ok)
+	}
+	if _, ok := highlightFor(logs[1]); ok {
+		t.Error("highlightFor() applied a rule for another theme")
+	}
+	if bellFor(logs[1:]) != nil || bellFor(logs) == nil {
+		t.Error("bellFor() should only ring for entries matching a rule with bell")
+	}
+
+	applyHighlights(rules, themes["high-contrast"])
+	if _, ok := highlightFor(logs[1]); !ok {
+		t.Error("highlightFor() did not apply the high-contrast rule under its theme")
+	}
+	if _, ok := highlightFor(logs[2]); ok {
+		t.Error("highlightFor() matched an entry no rule describes")
+	}
+}
@@ -0,0 +1,185 @@
+// log_viewer/histogram.go
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// histogramBuckets is how many bars a histogram has.
+const histogramBuckets = 10
+
+// preferredNumericFields come first in the histogram, the rest follow by name.
+var preferredNumericFields = []string{"duration", "upstream_service_time", "bytes_sent", "bytes_received"}
+
+// numericValue reads a number from a field, which Envoy writes as a number
+// or, for upstream_service_time, as a string.
+func numericValue(value interface{}) (float64, bool) {
+	if f, ok := floatValue(value); ok {
+		return f, true
+	}
+	switch v := value.(type) {
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// numericFields lists the entry's fields holding a number.
+func numericFields(log ParsedLog) []string {
+	var fields []string
+	for _, name := range preferredNumericFields {
+		if _, ok := numericValue(log.Fields[name]); ok {
+			fields = append(fields, name)
+		}
+	}
+	var others []string
+	for name, value := range log.Fields {
+		if _, ok := numericValue(value); ok && !slices.Contains(preferredNumericFields, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(fields, others...)
+}
+
+// histogramView is the open histogram popup.
+type histogramView struct {
+	fields []string // Numeric fields of the selected entry
+	index  int      // Field shown
+}
+
+// openHistogram shows the distribution of the selected entry's numeric
+// fields across the filtered entries.
+func (m Model) openHistogram() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	fields := numericFields(m.filteredLogs[m.selectedLogIndex])
+	if len(fields) == 0 {
+		m.err = fmt.Errorf("the selected entry has no numeric fields")
+		return m
+	}
+	m.histogram = &histogramView{fields: fields}
+	return m
+}
+
+// cycle returns the view showing the next (or previous) numeric field.
+func (h histogramView) cycle(forward bool) *histogramView {
+	step := 1
+	if !forward {
+		step = len(h.fields) - 1
+	}
+	h.index = (h.index + step) % len(h.fields)
+	return &h
+}
+
+// fieldValues returns the field's numeric values across logs, sorted.
+func fieldValues(logs []ParsedLog, field string) []float64 {
+	var values []float64
+	for _, log := range logs {
+		if v, ok := numericValue(log.Fields[field]); ok {
+			values = append(values, v)
+		}
+	}
+	sort.Float64s(values)
+	return values
+}
+
+// histogramBucket is the range of values a bar counts, lower bound included.
+type histogramBucket struct {
+	low, high float64
+	count     int
+}
+
+// bucketValues spreads sorted values over equal-width buckets between their
+// minimum and maximum. Values all alike fill a single bucket.
+func bucketValues(sorted []float64, buckets int) []histogramBucket {
+	if len(sorted) == 0 {
+		return nil
+	}
+	low, high := sorted[0], sorted[len(sorted)-1]
+	if low == high {
+		return []histogramBucket{{low: low, high: high, count: len(sorted)}}
+	}
+	width := (high - low) / float64(buckets)
+	result := make([]histogramBucket, buckets)
+	for i := range result {
+		result[i].low = low + float64(i)*width
+		result[i].high = low + float64(i+1)*width
+	}
+	for _, v := range sorted {
+		i := min(int((v-low)/width), buckets-1)
+		result[i].count++
+	}
+	return result
+}
+
+// histogramBar draws a bar of count out of most in width cells, with eighths
+// of a block for the remainder.
+func histogramBar(count, most, width int) string {
+	if most == 0 || width <= 0 {
+		return ""
+	}
+	eighths := count * width * 8 / most
+	bar := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		bar += string([]rune("▏▎▍▌▋▊▉")[rest-1])
+	}
+	return bar
+}
+
+// renderHistogram draws the distribution of the values with their stats.
+func renderHistogram(sorted []float64, width int) string {
+	if len(sorted) == 0 {
+		return "No numeric values in the filtered entries."
+	}
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d values  min %g  avg %.4g  p50 %g  p90 %g  p99 %g  max %g\n\n",
+		len(sorted), sorted[0], sum/float64(len(sorted)), percentile(sorted, 50), percentile(sorted, 90),
+		percentile(sorted, 99), sorted[len(sorted)-1])
+
+	buckets := bucketValues(sorted, histogramBuckets)
+	most := 0
+	labels := make([]string, len(buckets))
+	labelWidth := 0
+	for i, bucket := range buckets {
+		most = max(most, bucket.count)
+		labels[i] = fmt.Sprintf("%.4g – %.4g", bucket.low, bucket.high)
+		labelWidth = max(labelWidth, len([]rune(labels[i])))
+	}
+	countWidth := len(strconv.Itoa(most))
+	barWidth := width - labelWidth - countWidth - 4
+	for i, bucket := range buckets {
+		label := labels[i] + strings.Repeat(" ", labelWidth-len([]rune(labels[i])))
+		fmt.Fprintf(&b, "%s │%s %d\n", label, histogramBar(bucket.count, most, barWidth), bucket.count)
+	}
+	return b.String()
+}
+
+// renderHistogramView shows the histogram over the whole screen.
+func (m Model) renderHistogramView() string {
+	field := m.histogram.fields[m.histogram.index]
+	header := headerStyle.Render(fmt.Sprintf("Distribution of %s across %d filtered entries (↑↓ to change field, esc to close)",
+		field, len(m.filteredLogs)))
+	body := renderHistogram(fieldValues(m.filteredLogs, field), max(m.width-4, minWidth))
+	if slices.Contains(sizeLatencyFields, field) {
+		if table := renderSizeLatency(sizeLatency(m.filteredLogs)); table != "" {
+			body += "\n" + table
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, logStyle.Render(body))
+}
@@ -0,0 +1,101 @@
+// log_viewer/histogram_test.go
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func histogramTestLines() []string {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf(`{"response_code":200,"duration":%d,"upstream_service_time":"%d","bytes_sent":512,"path":"/reviews"}`, i*10, i*9))
+	}
+	return lines
+}
+
+func TestNumericFields(t *testing.T) {
+	logs := mustParse(t, histogramTestLines()...)
+	expected := []string{"duration", "upstream_service_time", "bytes_sent", "response_code"}
+	if got := numericFields(logs[0]); !slices.Equal(got, expected) {
+		t.Errorf("numericFields() = %v, expected %v", got, expected)
+	}
+}
+
+func TestBucketValues(t *testing.T) {
+	buckets := bucketValues([]float64{0, 1, 2, 5, 9, 10}, 5)
+	var counts []int
+	for _, b := range buckets {
+		counts = append(counts, b.count)
+	}
+	// The maximum falls in the last bucket
+	if expected := []int{2, 1, 1, 0, 2}; !slices.Equal(counts, expected) {
+		t.Errorf("bucketValues() counts = %v, expected %v", counts, expected)
+	}
+
+	if same := bucketValues([]float64{3, 3, 3}, 5); len(same) != 1 || same[0].count != 3 {
+		t.Errorf("bucketValues() of equal values = %+v, expected one bucket of 3", same)
+	}
+}
+
+func TestHistogramBar(t *testing.T) {
+	tests := []struct {
+		count, most, width int
+		expected           string
+	}{
+		{10, 10, 4, "████"},
+		{5, 10, 3, "█▌"},
+		{0, 10, 4, ""},
+		{1, 0, 4, ""},
+	}
+	for _, tt := range tests {
+		if got := histogramBar(tt.count, tt.most, tt.width); got != tt.expected {
+			t.Errorf("histogramBar(%d, %d, %d) = %q, expected %q", tt.count, tt.most, tt.width, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderHistogram(t *testing.T) {
+	out := renderHistogram(fieldValues(mustParse(t, histogramTestLines()...), "duration"), 80)
+	for _, want := range []string{"20 values", "min 10", "avg 105", "p50 100", "p90 180", "p99 200", "max 200", "█"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderHistogram() missing %q in:\n%s", want, out)
+		}
+	}
+	if lines := strings.Count(out, "│"); lines != histogramBuckets {
+		t.Errorf("renderHistogram() drew %d bars, expected %d", lines, histogramBuckets)
+	}
+}
+
+func TestHistogramPopup(t *testing.T) {
+	logs := mustParse(t, histogramTestLines()...)
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 30}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	model = updated.(Model)
+	if model.histogram == nil || !strings.Contains(model.View(), "Distribution of duration") {
+		t.Fatalf("expected h to open the histogram of duration")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if !strings.Contains(updated.(Model).View(), "Distribution of upstream_service_time") {
+		t.Errorf("expected down to show the next numeric field")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if !strings.Contains(updated.(Model).View(), "Distribution of response_code") {
+		t.Errorf("expected up to wrap around to the last numeric field")
+	}
+	if model.histogram.index != 0 {
+		t.Errorf("changing field changed the earlier model's histogram")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).histogram != nil {
+		t.Errorf("expected esc to close the histogram")
+	}
+}
@@ -0,0 +1,123 @@
+// log_viewer/history.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHistory is how many searches are remembered across sessions.
+const maxHistory = 100
+
+// defaultHistoryPath returns where search history is kept: the XDG state
+// directory when set, otherwise next to the config file.
+func defaultHistoryPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "istio-parsin", "history")
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "istio-parsin", "history")
+}
+
+// loadHistory reads past searches, oldest first. History is a convenience,
+// so a missing or unreadable file just starts an empty one.
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Println("Error reading search history:", err)
+		}
+		return nil
+	}
+
+	var history []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return history
+}
+
+// addToHistory returns history with query as the newest entry. A repeated
+// query moves to the end instead of appearing twice.
+func addToHistory(history []string, query string) []string {
+	if query == "" {
+		return history
+	}
+	updated := slices.DeleteFunc(slices.Clone(history), func(past string) bool { return past == query })
+	updated = append(updated, query)
+	if len(updated) > maxHistory {
+		updated = updated[len(updated)-maxHistory:]
+	}
+	return updated
+}
+
+// saveHistory returns a command writing history to path.
+func saveHistory(path string, history []string) tea.Cmd {
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := writeHistory(path, history); err != nil {
+			return failedMsg{err: err}
+		}
+		return nil
+	}
+}
+
+func writeHistory(path string, history []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	data := strings.Join(history, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// recallHistory moves through past searches in the search overlay: up goes
+// back in time, down forward, and past the newest entry the query typed
+// before recalling comes back.
+func (m Model) recallHistory(older bool) Model {
+	if len(m.history) == 0 {
+		return m
+	}
+	if m.historyIndex == 0 {
+		// Not recalling yet, remember what was typed
+		m.historyDraft = m.searchQuery
+	}
+
+	index := m.historyIndex
+	if older {
+		index = min(index+1, len(m.history))
+	} else {
+		index = max(index-1, 0)
+	}
+	m.historyIndex = index
+
+	if index == 0 {
+		m.searchQuery = m.historyDraft
+	} else {
+		m.searchQuery = m.history[len(m.history)-index]
+	}
+	return m
+}
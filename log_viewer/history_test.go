@@ -0,0 +1,90 @@
+// log_viewer/history_test.go
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAddToHistory(t *testing.T) {
+	history := addToHistory([]string{"UF", "reviews", "503"}, "UF")
+	if expected := []string{"reviews", "503", "UF"}; !reflect.DeepEqual(history, expected) {
+		t.Errorf("addToHistory() = %v, expected a repeated query to move to the end: %v", history, expected)
+	}
+
+	history = nil
+	for i := 0; i < maxHistory+5; i++ {
+		history = addToHistory(history, fmt.Sprint(i))
+	}
+	if len(history) != maxHistory || history[0] != "5" {
+		t.Errorf("expected the oldest searches to be dropped, got %d starting at %q", len(history), history[0])
+	}
+}
+
+func TestHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history")
+	if got := loadHistory(path); got != nil {
+		t.Errorf("expected no history before the first save, got %v", got)
+	}
+
+	if err := writeHistory(path, []string{"UF", "reviews"}); err != nil {
+		t.Fatalf("writeHistory() unexpected error: %v", err)
+	}
+	if got := loadHistory(path); !reflect.DeepEqual(got, []string{"UF", "reviews"}) {
+		t.Errorf("loadHistory() = %v, expected the saved searches", got)
+	}
+
+	// A failed save is shown in the TUI rather than logged over it
+	blocked := filepath.Join(path, "history")
+	model, _ := Model{}.Update(saveHistory(blocked, []string{"UF"})())
+	if err := model.(Model).err; err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected the failed save to be shown, got %v", err)
+	}
+}
+
+func TestRecallHistory(t *testing.T) {
+	logs := []ParsedLog{{RawLog: "log1"}}
+	model := Model{logs: logs, filteredLogs: logs, history: []string{"UF", "reviews"}}
+	press := func(keys ...tea.KeyMsg) {
+		for _, key := range keys {
+			updated, _ := model.Update(key)
+			model = updated.(Model)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// j and k type into the query rather than recalling
+	press(runes("s"), runes("k"))
+	if model.searchQuery != "k" {
+		t.Fatalf("expected k to be typed, got %q", model.searchQuery)
+	}
+
+	steps := []struct {
+		key      tea.KeyType
+		expected string
+	}{
+		{tea.KeyUp, "reviews"},
+		{tea.KeyUp, "UF"},
+		{tea.KeyUp, "UF"}, // Stays on the oldest
+		{tea.KeyDown, "reviews"},
+		{tea.KeyDown, "k"}, // Back to what was typed
+	}
+	for i, step := range steps {
+		press(tea.KeyMsg{Type: step.key})
+		if model.searchQuery != step.expected {
+			t.Errorf("step %d: query = %q, expected %q", i, model.searchQuery, step.expected)
+		}
+	}
+
+	// Running a search makes it the newest entry
+	press(tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter})
+	if expected := []string{"reviews", "UF"}; !reflect.DeepEqual(model.history, expected) {
+		t.Errorf("history = %v, expected %v", model.history, expected)
+	}
+}
@@ -0,0 +1,156 @@
+// log_viewer/jump.go
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jump mode moves the selection within the filtered view. Its target is one
+// of:
+//
+//	120       the entry logged on line 120 of the selected entry's source,
+//	          or the next one shown when that line is filtered out
+//	a.log:120 the same in another of the sources merged into the view, named
+//	          as in entry IDs (a.log#120)
+//	#15       the 15th entry of the filtered view
+//	12:03:05  the first entry at or after that time of day, in the -timezone
+//	          timestamps are shown in; 12:03 works too
+//	50%       halfway through the filtered view
+
+// jumpTarget returns the index in logs that query points at. Line numbers
+// only count within one source, so a bare one is looked up in source, that
+// of the selected entry. The error says why nothing matched, for the notice.
+func jumpTarget(logs []ParsedLog, query, source string) (int, error) {
+	query = strings.TrimSpace(query)
+	if len(logs) == 0 {
+		return 0, fmt.Errorf("no entries to jump to")
+	}
+
+	switch {
+	case strings.HasPrefix(query, "#"):
+		n, err := strconv.Atoi(query[1:])
+		if err != nil || n < 1 || n > len(logs) {
+			return 0, fmt.Errorf("no entry %s, %s are shown", query, entryCount(len(logs)))
+		}
+		return n - 1, nil
+	case strings.HasSuffix(query, "%"):
+		p, err := strconv.ParseFloat(strings.TrimSuffix(query, "%"), 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("%s is not a percentage between 0 and 100", query)
+		}
+		return int(math.Round(float64(len(logs)-1) * p / 100)), nil
+	case strings.Contains(query, ":"):
+		if name, line, ok := sourceLine(logs, query); ok {
+			return jumpToLine(logs, name, line)
+		}
+		return jumpToClock(logs, query)
+	}
+
+	line, err := strconv.Atoi(query)
+	if err != nil {
+		return 0, fmt.Errorf("cannot jump to %q: enter a line, #entry, time or percentage", query)
+	}
+	return jumpToLine(logs, source, line)
+}
+
+// sourceLine splits a source:line target, when the source is one of those
+// shown.
+func sourceLine(logs []ParsedLog, query string) (string, int, bool) {
+	i := strings.LastIndex(query, ":")
+	line, err := strconv.Atoi(query[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	name := query[:i]
+	for _, entry := range logs {
+		if entry.ID.Source == name {
+			return name, line, true
+		}
+	}
+	return "", 0, false
+}
+
+// jumpToLine finds the entry of source logged on line, or the next one of
+// source shown.
+func jumpToLine(logs []ParsedLog, source string, line int) (int, error) {
+	next := -1
+	for i, entry := range logs {
+		if entry.ID.Source != source {
+			continue
+		}
+		if entry.LineNumber == line {
+			return i, nil
+		}
+		if entry.LineNumber > line && (next < 0 || entry.LineNumber < logs[next].LineNumber) {
+			next = i
+		}
+	}
+	if next >= 0 {
+		return next, nil
+	}
+	if source != "" {
+		return 0, fmt.Errorf("no entry of %s at or after line %d is shown", source, line)
+	}
+	return 0, fmt.Errorf("no entry at or after line %d is shown", line)
+}
+
+// jumpToClock finds the first entry at or after a time of day. The day is
+// that of the first timestamped entry, or the one after when the time is
+// earlier than it, so 00:05 finds the entries just past midnight.
+func jumpToClock(logs []ParsedLog, query string) (int, error) {
+	var clock time.Time
+	var err error
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if clock, err = time.Parse(layout, query); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a time of day such as 12:03:05", query)
+	}
+
+	var first time.Time
+	for _, entry := range logs {
+		if t, ok := eventTime(entry); ok {
+			first = t.In(activeTimeDisplay.location)
+			break
+		}
+	}
+	if first.IsZero() {
+		return 0, fmt.Errorf("no timestamped entries are shown")
+	}
+
+	target := time.Date(first.Year(), first.Month(), first.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, first.Location())
+	if target.Before(first.Truncate(time.Second)) {
+		target = target.AddDate(0, 0, 1)
+	}
+	for i, entry := range logs {
+		if t, ok := eventTime(entry); ok && !t.Before(target) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no entry at or after %s is shown", query)
+}
+
+// jump moves the selection to the target typed in jump mode, leaving it
+// where it was with a notice when there is none.
+func (m Model) jump(query string) Model {
+	var source string
+	if len(m.filteredLogs) > 0 {
+		source = m.filteredLogs[m.selectedLogIndex].ID.Source
+	}
+	index, err := jumpTarget(m.filteredLogs, query, source)
+	if err != nil {
+		m.notice = err.Error()
+		return m
+	}
+	m = m.remember()
+	m.selectedLogIndex = index
+	m.pinned = false
+	return m
+}
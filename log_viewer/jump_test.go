@@ -0,0 +1,93 @@
+// log_viewer/jump_test.go
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestJumpTarget(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"start_time":"2024-05-04T23:59:%02dZ","response_code":%d}`, 50+i/2, 200+100*(i%2)))
+	}
+	lines = append(lines, `{"start_time":"2024-05-05T00:00:10Z","response_code":300}`)
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	// Every other line, as a filter on the 3xx responses shows them
+	filtered := filterLogs(logs, "300")
+
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"4", 1, false},   // Line 4 itself
+		{"5", 2, false},   // Line 5 is filtered out, the next shown one is 6
+		{"#3", 2, false},  // Counted in the filtered view
+		{"50%", 3, false}, // Of 6 entries
+		{"100%", 5, false},
+		{"23:59:52", 2, false},
+		{"00:00", 5, false}, // Past midnight
+		{"12", 0, true},
+		{"#7", 0, true},
+		{"150%", 0, true},
+		{"noon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := jumpTarget(filtered, tt.query, filtered[0].ID.Source)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("jumpTarget(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("jumpTarget(%q) = %d, expected %d", tt.query, got, tt.want)
+		}
+	}
+
+	model := Model{logs: logs, filteredLogs: filtered, jumpMode: true, searchQuery: "#9"}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m := updated.(Model); m.selectedLogIndex != 0 || m.notice == "" {
+		t.Errorf("expected a notice and the selection kept, got %d, %q", m.selectedLogIndex, m.notice)
+	}
+}
+
+func TestJumpToLineOfSource(t *testing.T) {
+	var logs []ParsedLog
+	for _, name := range []string{"a.log", "b.log"} {
+		parsed, err := parseRawLogsWith([]string{`{"response_code":200}`, `{"response_code":503}`}, defaultParseOptions().from(name))
+		if err != nil {
+			t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+		}
+		logs = append(logs, parsed...)
+	}
+
+	tests := []struct {
+		query, source string
+		want          int
+	}{
+		{"2", "a.log", 1},
+		{"2", "b.log", 3}, // The selected entry's source
+		{"b.log:1", "a.log", 2},
+		{"a.log:2", "b.log", 1},
+	}
+	for _, tt := range tests {
+		if got, err := jumpTarget(logs, tt.query, tt.source); err != nil || got != tt.want {
+			t.Errorf("jumpTarget(%q) from %s = %d, %v, expected %d", tt.query, tt.source, got, err, tt.want)
+		}
+	}
+	if _, err := jumpTarget(logs, "c.log:1", "a.log"); err == nil {
+		t.Errorf("expected an unknown source not to be a line")
+	}
+
+	model := Model{logs: logs, filteredLogs: logs, selectedLogIndex: 2, jumpMode: true, searchQuery: "2"}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m := updated.(Model); m.selectedLogIndex != 3 {
+		t.Errorf("expected line 2 of b.log selected, got index %d", m.selectedLogIndex)
+	}
+}
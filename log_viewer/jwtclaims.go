@@ -0,0 +1,130 @@
+// log_viewer/jwtclaims.go
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// When a header logged in the jwt_fields of the config holds a bearer token,
+// the details show its claims in a Security group, without verifying it.
+// A token that had expired when the request was made is a frequent cause of
+// 401s at the gateway, so that is called out.
+
+// defaultJWTFields are checked for tokens when the config names none.
+var defaultJWTFields = []string{"authorization"}
+
+// jwtClaims are the registered claims of a token worth showing.
+type jwtClaims struct {
+	field    string // Where the token was logged
+	issuer   string
+	subject  string
+	audience []string
+	expires  time.Time // Zero without an exp claim
+}
+
+// jwtSegments returns the decoded header and payload of a JWT, with or
+// without the Bearer prefix of an authorization header.
+func jwtSegments(value string) (header, payload []byte, ok bool) {
+	token := strings.TrimSpace(value)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[0], "="))
+	if err != nil {
+		return nil, nil, false
+	}
+	payload, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, nil, false
+	}
+	return header, payload, true
+}
+
+// jwtPayload decodes the payload of a JWT.
+func jwtPayload(value string) (map[string]interface{}, bool) {
+	_, data, ok := jwtSegments(value)
+	if !ok {
+		return nil, false
+	}
+	var payload map[string]interface{}
+	if err := decodeJSON(data, &payload); err != nil {
+		return nil, false
+	}
+	return payload, true
+}
+
+// tokenClaims reads the claims of the first token logged in fields.
+func tokenClaims(log ParsedLog, fields []string) (jwtClaims, bool) {
+	for _, field := range fields {
+		value, ok := log.Fields[field].(string)
+		if !ok {
+			continue
+		}
+		payload, ok := jwtPayload(value)
+		if !ok {
+			continue
+		}
+		claims := jwtClaims{field: field}
+		claims.issuer, _ = payload["iss"].(string)
+		claims.subject, _ = payload["sub"].(string)
+		switch aud := payload["aud"].(type) {
+		case string:
+			claims.audience = []string{aud}
+		case []interface{}:
+			for _, a := range aud {
+				if s, ok := a.(string); ok {
+					claims.audience = append(claims.audience, s)
+				}
+			}
+		}
+		if exp, ok := payload["exp"].(json.Number); ok {
+			if seconds, err := exp.Float64(); err == nil {
+				claims.expires = time.Unix(int64(seconds), 0)
+			}
+		}
+		return claims, true
+	}
+	return jwtClaims{}, false
+}
+
+// expiredBy returns how long before the request the token had expired, and
+// false when it had not or the entry has no time to tell.
+func (c jwtClaims) expiredBy(log ParsedLog) (time.Duration, bool) {
+	at, ok := eventTime(log)
+	if !ok || c.expires.IsZero() || !at.After(c.expires) {
+		return 0, false
+	}
+	return at.Sub(c.expires), true
+}
+
+// withSecurityGroup returns fields with the claims added as jwt.* fields, and
+// groups with a Security group showing them.
+func (c jwtClaims) withSecurityGroup(log ParsedLog, fields map[string]interface{}, groups []fieldGroup) (map[string]interface{}, []fieldGroup) {
+	withClaims := make(map[string]interface{}, len(fields)+5)
+	for key, value := range fields {
+		withClaims[key] = value
+	}
+	withClaims["jwt.field"] = c.field
+	withClaims["jwt.iss"] = c.issuer
+	withClaims["jwt.sub"] = c.subject
+	withClaims["jwt.aud"] = strings.Join(c.audience, ", ")
+	if !c.expires.IsZero() {
+		exp := activeTimeDisplay.timestamp(c.expires)
+		if by, expired := c.expiredBy(log); expired {
+			exp += fmt.Sprintf(" (expired %s before the request)", by.Round(time.Second))
+		}
+		withClaims["jwt.exp"] = exp
+	}
+
+	security := fieldGroup{name: "Security", fields: []string{"jwt.field", "jwt.iss", "jwt.sub", "jwt.aud", "jwt.exp"}}
+	return withClaims, append(groups[:len(groups):len(groups)], security)
+}
@@ -0,0 +1,53 @@
+// log_viewer/jwtclaims_test.go
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testToken(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return "Bearer " + encode([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encode([]byte(payload)) + ".c2ln"
+}
+
+func TestTokenClaims(t *testing.T) {
+	expires := time.Date(2024, 5, 4, 9, 55, 0, 0, time.UTC)
+	token := testToken(fmt.Sprintf(`{"iss":"https://accounts.example.com","sub":"alice","aud":["api","web"],"exp":%d}`, expires.Unix()))
+	logs, err := parseRawLogsWith([]string{
+		fmt.Sprintf(`{"start_time":"2024-05-04T10:00:00Z","response_code":401,"authorization":%q}`, token),
+		fmt.Sprintf(`{"start_time":"2024-05-04T09:50:00Z","response_code":200,"authorization":%q}`, token),
+		`{"start_time":"2024-05-04T10:00:00Z","response_code":200,"authorization":"Basic dXNlcjpwYXNz"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	claims, ok := tokenClaims(logs[0], defaultJWTFields)
+	if !ok || claims.issuer != "https://accounts.example.com" || claims.subject != "alice" || strings.Join(claims.audience, ",") != "api,web" || !claims.expires.Equal(expires) {
+		t.Fatalf("tokenClaims() = %+v, %v", claims, ok)
+	}
+	if by, expired := claims.expiredBy(logs[0]); !expired || by != 5*time.Minute {
+		t.Errorf("expiredBy() = %v, %v, expected expired 5m before the request", by, expired)
+	}
+	if _, expired := claims.expiredBy(logs[1]); expired {
+		t.Errorf("expected the token valid at 09:50")
+	}
+	if _, ok := tokenClaims(logs[2], defaultJWTFields); ok {
+		t.Errorf("expected no claims for basic auth")
+	}
+	if _, ok := tokenClaims(logs[0], []string{"x-jwt"}); ok {
+		t.Errorf("expected only the configured fields checked")
+	}
+
+	pane := renderDetailPane(logs[0], detailExtras{jwtFields: defaultJWTFields}, 140, 80, false)
+	for _, want := range []string{"Security", "jwt.sub", "alice", "api, web", "(expired 5m0s before the request)"} {
+		if !strings.Contains(pane, want) {
+			t.Errorf("expected %q in the details, got:\n%s", want, pane)
+		}
+	}
+}
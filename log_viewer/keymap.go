@@ -0,0 +1,198 @@
+// log_viewer/keymap.go
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// keyAction names a remappable TUI action.
+type keyAction string
+
+const (
+	actionNone    keyAction = ""
+	actionQuit    keyAction = "quit"
+	actionUp      keyAction = "up"
+	actionDown    keyAction = "down"
+	actionBottom  keyAction = "bottom"
+	actionSearch  keyAction = "search"
+	actionJump    keyAction = "jump"
+	actionCancel  keyAction = "cancel"
+	actionConfirm keyAction = "confirm"
+
+	actionConnection keyAction = "connection"
+	actionRetry      keyAction = "retry"
+	actionPickPod    keyAction = "pick_pod"
+	actionLayout     keyAction = "layout"
+	actionExpand     keyAction = "expand"
+	actionFlow       keyAction = "flow"
+	actionUndo       keyAction = "undo"
+	actionRedo       keyAction = "redo"
+	actionNote       keyAction = "note"
+	actionDiagnose   keyAction = "diagnose"
+	actionHistogram  keyAction = "histogram"
+	actionExportText keyAction = "export_text"
+	actionExportHTML keyAction = "export_html"
+	actionPalette    keyAction = "palette"
+	actionEvents     keyAction = "events"
+	actionMark       keyAction = "mark"
+	actionMarkRange  keyAction = "mark_range"
+	actionCopy       keyAction = "copy"
+	actionHide       keyAction = "hide"
+	actionHidden     keyAction = "hidden"
+	actionFind       keyAction = "find"
+	actionExpandJSON keyAction = "expand_json"
+	actionDecode     keyAction = "decode"
+
+	actionNextMatch keyAction = "next_match"
+	actionPrevMatch keyAction = "previous_match"
+)
+
+// findActions only apply while a search within the selected entry is open,
+// which takes their keys before anything else, so they may share keys with
+// the other actions: n is the next match there and a note everywhere else.
+var findActions = []keyAction{actionNextMatch, actionPrevMatch}
+
+// findScope is every action a search within the entry handles itself.
+var findScope = append([]keyAction{actionCancel, actionFind}, findActions...)
+
+// KeyMap binds each action to one or more key names as reported by Bubble Tea
+// (e.g. "ctrl+c", "up", "G").
+type KeyMap map[keyAction][]string
+
+func defaultKeyMap() KeyMap {
+	return KeyMap{
+		actionQuit:    {"ctrl+c", "q"},
+		actionUp:      {"up", "k"},
+		actionDown:    {"down", "j"},
+		actionBottom:  {"end", "G"},
+		actionSearch:  {"s"},
+		actionJump:    {"/"},
+		actionCancel:  {"esc"},
+		actionConfirm: {"enter"},
+
+		actionConnection: {"c"},
+		actionRetry:      {"r"},
+		actionPickPod:    {"p", "ctrl+p"},
+		actionLayout:     {"v"},
+		actionExpand:     {"e"},
+		actionFlow:       {"f"},
+		actionUndo:       {"u"},
+		actionRedo:       {"ctrl+r"},
+		actionNote:       {"n"},
+		actionDiagnose:   {"d"},
+		actionHistogram:  {"h"},
+		actionExportText: {"w"},
+		actionExportHTML: {"X"},
+		actionPalette:    {"ctrl+k"},
+		actionEvents:     {"E"},
+		actionMark:       {" "},
+		actionMarkRange:  {"V"},
+		actionCopy:       {"y"},
+		actionHide:       {"x"},
+		actionHidden:     {"H"},
+		actionFind:       {"F"},
+		actionExpandJSON: {"J"},
+		actionDecode:     {"D"},
+
+		actionNextMatch: {"n"},
+		actionPrevMatch: {"N"},
+	}
+}
+
+// yieldingKeys are default bindings that give way when the config file binds
+// the same key to another action, so a config moving up to ctrl+p, as in
+// emacs, keeps working.
+var yieldingKeys = KeyMap{
+	actionPickPod: {"ctrl+p"},
+	actionPalette: {"ctrl+k"},
+}
+
+// actionFor returns the action bound to key, or actionNone. Find actions
+// are left to findActionFor.
+func (k KeyMap) actionFor(key string) keyAction {
+	for action, keys := range k {
+		if slices.Contains(findActions, action) {
+			continue
+		}
+		if slices.Contains(keys, key) {
+			return action
+		}
+	}
+	return actionNone
+}
+
+// findActionFor returns the action bound to key while a search within the
+// entry is open: a find action when one has the key, or else actionFor.
+func (k KeyMap) findActionFor(key string) keyAction {
+	for _, action := range findActions {
+		if slices.Contains(k[action], key) {
+			return action
+		}
+	}
+	return k.actionFor(key)
+}
+
+// merge returns a copy of k with the actions present in overrides replaced,
+// rejecting unknown actions and keys bound to more than one action.
+func (k KeyMap) merge(overrides KeyMap) (KeyMap, error) {
+	merged := KeyMap{}
+	for action, keys := range k {
+		merged[action] = keys
+	}
+	for action, keys := range overrides {
+		if _, known := k[action]; !known {
+			return nil, fmt.Errorf("unknown action %q", action)
+		}
+		merged[action] = keys
+	}
+	for action, yielding := range yieldingKeys {
+		if _, overridden := overrides[action]; overridden {
+			continue
+		}
+		merged[action] = slices.DeleteFunc(slices.Clone(merged[action]), func(key string) bool {
+			return slices.Contains(yielding, key) && overrides.actionFor(key) != actionNone
+		})
+	}
+	if err := merged.validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// validate reports every key that is bound to more than one action, either
+// outside a search within the entry or inside one (see findScope).
+func (k KeyMap) validate() error {
+	global, find := map[string][]string{}, map[string][]string{}
+	for action, keys := range k {
+		for _, key := range keys {
+			if !slices.Contains(findActions, action) {
+				global[key] = append(global[key], string(action))
+			}
+			if slices.Contains(findScope, action) {
+				find[key] = append(find[key], string(action))
+			}
+		}
+	}
+
+	var conflicts []string
+	for _, owners := range []map[string][]string{global, find} {
+		for key, actions := range owners {
+			if len(actions) > 1 {
+				sort.Strings(actions)
+				conflict := fmt.Sprintf("%q is bound to %s", key, strings.Join(actions, ", "))
+				if !slices.Contains(conflicts, conflict) {
+					conflicts = append(conflicts, conflict)
+				}
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("conflicting key bindings: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// log_viewer/keymap_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyMapMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides KeyMap
+		key       string
+		expected  keyAction
+		wantErr   string
+	}{
+		{
+			name:      "Remap navigation to emacs keys",
+			overrides: KeyMap{actionUp: {"ctrl+p"}, actionDown: {"ctrl+n"}},
+			key:       "ctrl+p",
+			expected:  actionUp,
+		},
+		{
+			name:      "Replaced bindings are dropped",
+			overrides: KeyMap{actionUp: {"ctrl+p"}},
+			key:       "k",
+			expected:  actionNone,
+		},
+		{
+			name:      "ctrl+p opens the pod picker by default",
+			overrides: KeyMap{actionDown: {"ctrl+n"}},
+			key:       "ctrl+p",
+			expected:  actionPickPod,
+		},
+		{
+			name:      "Conflicting binding",
+			overrides: KeyMap{actionSearch: {"q"}},
+			wantErr:   `"q" is bound to quit, search`,
+		},
+		{
+			name:     "n is a note outside a search within the entry",
+			key:      "n",
+			expected: actionNote,
+		},
+		{
+			name:      "Conflicting binding within the entry search",
+			overrides: KeyMap{actionNextMatch: {"esc"}},
+			wantErr:   `"esc" is bound to cancel, next_match`,
+		},
+		{
+			name:      "Unknown action",
+			overrides: KeyMap{"teleport": {"t"}},
+			wantErr:   `unknown action "teleport"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := defaultKeyMap().merge(tt.overrides)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("merge() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("merge() unexpected error: %v", err)
+			}
+			if got := merged.actionFor(tt.key); got != tt.expected {
+				t.Errorf("actionFor(%q) = %q, expected %q", tt.key, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"keys": {"quit": ["ctrl+x"]}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+
+	model := Model{keys: cfg.Keys}
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd != nil {
+		t.Errorf("expected 'q' to no longer quit")
+	}
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlX}); cmd == nil {
+		t.Errorf("expected ctrl+x to quit")
+	}
+
+	// A missing config file falls back to the defaults
+	cfg, err = loadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("loadConfig() unexpected error for missing file: %v", err)
+	}
+	if cfg.Keys.actionFor("q") != actionQuit {
+		t.Errorf("expected default bindings for a missing config")
+	}
+}
@@ -0,0 +1,190 @@
+// log_viewer/knowledge.go
+
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// failureMode is a known way Istio traffic fails, recognized from a
+// combination of access log fields. Commands help confirm and fix it; they
+// may use {service}, {namespace}, {host} and {authority}, and a command
+// using a value the entry does not have is left out.
+type failureMode struct {
+	name     string
+	matches  func(log ParsedLog) bool
+	explain  string
+	commands []string
+}
+
+// failureModes are checked in order; an entry can show several.
+var failureModes = []failureMode{
+	{
+		name: "mTLS mismatch",
+		matches: func(log ParsedLog) bool {
+			reason := strings.ToLower(getFieldSafely(log.Fields, "upstream_transport_failure_reason"))
+			return strings.Contains(reason, "tls_error") || strings.Contains(reason, "certificate_verify_failed") ||
+				strings.Contains(reason, "wrong_version_number")
+		},
+		explain: "The TLS handshake with the upstream failed. A DestinationRule may set a TLS mode the upstream does not " +
+			"speak, e.g. ISTIO_MUTUAL to a workload without a sidecar or SIMPLE to one expecting mTLS.",
+		commands: []string{
+			"kubectl get destinationrule -A -o wide | grep {service}",
+			"istioctl x describe service {service} -n {namespace}",
+			"kubectl get peerauthentication -n {namespace}",
+		},
+	},
+	{
+		name: "STRICT mTLS rejected plaintext",
+		matches: func(log ParsedLog) bool {
+			return strings.Contains(getFieldSafely(log.Fields, "response_code_details"), "filter_chain_not_found")
+		},
+		explain: "The workload's PeerAuthentication is STRICT but the client sent plaintext, usually because the client " +
+			"has no sidecar. Inject the client or switch the workload to PERMISSIVE while migrating.",
+		commands: []string{
+			"kubectl get peerauthentication -n {namespace} -o yaml",
+			"kubectl get peerauthentication -n istio-system -o yaml",
+		},
+	},
+	{
+		name: "Missing ServiceEntry",
+		matches: func(log ParsedLog) bool {
+			return getFieldSafely(log.Fields, "upstream_cluster") == "BlackHoleCluster"
+		},
+		explain: "The mesh's outboundTrafficPolicy is REGISTRY_ONLY and the destination is not in the registry. " +
+			"Add a ServiceEntry for the external host.",
+		commands: []string{
+			"kubectl get serviceentry -A | grep {authority}",
+			"kubectl get configmap istio -n istio-system -o jsonpath='{.data.mesh}' | grep -A1 outboundTrafficPolicy",
+		},
+	},
+	{
+		name: "No healthy upstream",
+		matches: func(log ParsedLog) bool {
+			return log.HasFlag("UH") || getFieldSafely(log.Fields, "response_code_details") == "no_healthy_upstream"
+		},
+		explain: "The upstream has no ready endpoints: it may be scaled to zero, failing its readiness probe, or all of " +
+			"its endpoints may have been ejected by outlier detection.",
+		commands: []string{
+			"kubectl get endpoints {service} -n {namespace}",
+			"kubectl get pods -n {namespace} -l app={service}",
+			"kubectl get destinationrule -n {namespace} -o yaml | grep -A5 outlierDetection",
+		},
+	},
+	{
+		name: "No route",
+		matches: func(log ParsedLog) bool {
+			return log.HasFlag("NR") || getFieldSafely(log.Fields, "response_code_details") == "route_not_found"
+		},
+		explain: "No route matched the request's host and path. A VirtualService may not cover it, or be bound to " +
+			"another gateway or host.",
+		commands: []string{
+			"istioctl analyze -n {namespace}",
+			"kubectl get virtualservice -A | grep {host}",
+		},
+	},
+	{
+		name: "Connection refused",
+		matches: func(log ParsedLog) bool {
+			return strings.Contains(getFieldSafely(log.Fields, "upstream_transport_failure_reason"), "delayed_connect_error:_111")
+		},
+		explain: "The upstream pod refused the connection: the application is not listening on the port, the " +
+			"Service's targetPort is wrong, or the application is still starting.",
+		commands: []string{
+			"kubectl get service {service} -n {namespace} -o yaml",
+			"kubectl logs -n {namespace} -l app={service} --tail 50",
+		},
+	},
+	{
+		name: "Circuit breaker overflow",
+		matches: func(log ParsedLog) bool {
+			overflow, _ := poolSymptom(log)
+			return overflow
+		},
+		explain: "The DestinationRule's connectionPool limits were reached and Envoy rejected the request without " +
+			"sending it.",
+		commands: []string{
+			"kubectl get destinationrule -n {namespace} -o yaml | grep -A8 connectionPool",
+		},
+	},
+}
+
+// diagnosis is a failure mode an entry shows, with its commands filled in.
+type diagnosis struct {
+	name     string
+	explain  string
+	commands []string
+}
+
+// diagnoseEntry returns the failure modes the entry shows.
+func diagnoseEntry(log ParsedLog) []diagnosis {
+	var found []diagnosis
+	var values map[string]string
+	for _, mode := range failureModes {
+		if !mode.matches(log) {
+			continue
+		}
+		if values == nil {
+			values = commandValues(log)
+		}
+		d := diagnosis{name: mode.name, explain: mode.explain}
+		for _, command := range mode.commands {
+			if filled, ok := fillCommand(command, values); ok {
+				d.commands = append(d.commands, filled)
+			}
+		}
+		found = append(found, d)
+	}
+	return found
+}
+
+// commandValues are the placeholders known for an entry. The service and
+// namespace come from an outbound cluster such as
+// outbound|9080|v1|reviews.default.svc.cluster.local, or else from the
+// authority when it is a cluster-local name.
+func commandValues(log ParsedLog) map[string]string {
+	values := make(map[string]string)
+	authority := getFieldSafely(log.Fields, "authority")
+	if authority != "-" {
+		if host, _, err := net.SplitHostPort(authority); err == nil {
+			authority = host
+		}
+		values["authority"] = authority
+	}
+
+	host := ""
+	if parts := strings.Split(log.UpstreamCluster(), "|"); len(parts) == 4 {
+		host = parts[3]
+	}
+	if host == "" && strings.Contains(authority, ".svc") {
+		host = authority
+	}
+	if host != "" {
+		values["host"] = host
+		labels := strings.Split(host, ".")
+		values["service"] = labels[0]
+		if len(labels) > 2 && labels[2] == "svc" {
+			values["namespace"] = labels[1]
+		}
+	}
+	return values
+}
+
+// fillCommand replaces the placeholders of command, failing when it uses one
+// that is not known. Braces that are not placeholders, such as a jsonpath,
+// are kept.
+func fillCommand(command string, values map[string]string) (string, bool) {
+	for _, name := range []string{"service", "namespace", "host", "authority"} {
+		placeholder := "{" + name + "}"
+		if !strings.Contains(command, placeholder) {
+			continue
+		}
+		value, ok := values[name]
+		if !ok {
+			return "", false
+		}
+		command = strings.ReplaceAll(command, placeholder, value)
+	}
+	return command, true
+}
@@ -0,0 +1,113 @@
+// log_viewer/knowledge_test.go
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected []string
+		commands []string // Expected among the first diagnosis's commands
+	}{
+		{
+			name:     "mTLS mismatch",
+			line:     `{"response_code":503,"response_flags":"UF","upstream_transport_failure_reason":"TLS_error:|268435703:SSL_routines:OPENSSL_internal:WRONG_VERSION_NUMBER","upstream_cluster":"outbound|9080||reviews.shop.svc.cluster.local"}`,
+			expected: []string{"mTLS mismatch"},
+			commands: []string{"istioctl x describe service reviews -n shop", "kubectl get peerauthentication -n shop"},
+		},
+		{
+			name:     "STRICT rejecting plaintext",
+			line:     `{"response_code":0,"response_flags":"NR","response_code_details":"filter_chain_not_found","upstream_cluster":"-","authority":"-"}`,
+			expected: []string{"STRICT mTLS rejected plaintext", "No route"},
+			commands: []string{"kubectl get peerauthentication -n istio-system -o yaml"},
+		},
+		{
+			name:     "missing ServiceEntry",
+			line:     `{"response_code":502,"response_flags":"-","upstream_cluster":"BlackHoleCluster","authority":"api.example.com:443"}`,
+			expected: []string{"Missing ServiceEntry"},
+			commands: []string{"kubectl get serviceentry -A | grep api.example.com"},
+		},
+		{
+			name:     "scaled to zero",
+			line:     `{"response_code":503,"response_flags":"UH","response_code_details":"no_healthy_upstream","upstream_cluster":"outbound|9080|v1|ratings.default.svc.cluster.local"}`,
+			expected: []string{"No healthy upstream"},
+			commands: []string{"kubectl get endpoints ratings -n default"},
+		},
+		{
+			name:     "connection refused",
+			line:     `{"response_code":503,"response_flags":"UF","upstream_transport_failure_reason":"delayed_connect_error:_111","authority":"details.default.svc.cluster.local:9080","upstream_cluster":"-"}`,
+			expected: []string{"Connection refused"},
+			commands: []string{"kubectl get service details -n default -o yaml"},
+		},
+		{
+			name: "healthy",
+			line: `{"response_code":200,"response_flags":"-","upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, err := parseRawLogs([]string{tt.line})
+			if err != nil {
+				t.Fatalf("parseRawLogs() unexpected error: %v", err)
+			}
+			found := diagnoseEntry(logs[0])
+			var names []string
+			for _, d := range found {
+				names = append(names, d.name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Fatalf("diagnoseEntry() = %v, expected %v", names, tt.expected)
+			}
+			for _, command := range tt.commands {
+				if !slices.Contains(found[0].commands, command) {
+					t.Errorf("diagnoseEntry() commands = %q, missing %q", found[0].commands, command)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnoseEntryDropsUnknownPlaceholders(t *testing.T) {
+	logs, err := parseRawLogs([]string{`{"response_code":503,"response_flags":"UH","upstream_cluster":"-","authority":"-"}`})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	found := diagnoseEntry(logs[0])
+	if len(found) != 1 {
+		t.Fatalf("diagnoseEntry() = %v, expected one diagnosis", found)
+	}
+	for _, command := range found[0].commands {
+		if strings.Contains(command, "{service}") || strings.Contains(command, "{namespace}") {
+			t.Errorf("diagnoseEntry() kept unfilled command %q", command)
+		}
+	}
+}
+
+func TestFillCommandKeepsOtherBraces(t *testing.T) {
+	command := "kubectl get configmap istio -o jsonpath='{.data.mesh}' | grep {authority}"
+	filled, ok := fillCommand(command, map[string]string{"authority": "api.example.com"})
+	expected := "kubectl get configmap istio -o jsonpath='{.data.mesh}' | grep api.example.com"
+	if !ok || filled != expected {
+		t.Errorf("fillCommand() = %q, %v, expected %q", filled, ok, expected)
+	}
+}
+
+func TestDetailPaneShowsDiagnosis(t *testing.T) {
+	logs, err := parseRawLogs([]string{`{"response_code":503,"response_flags":"UH","upstream_cluster":"outbound|9080||ratings.default.svc.cluster.local"}`})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	pane := renderDetailPane(logs[0], detailExtras{}, 120, 60, false)
+	for _, want := range []string{"Diagnosis", "No healthy upstream", "kubectl get endpoints ratings -n default"} {
+		if !strings.Contains(pane, want) {
+			t.Errorf("renderDetailPane() missing %q", want)
+		}
+	}
+}
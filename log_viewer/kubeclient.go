@@ -0,0 +1,183 @@
+// log_viewer/kubeclient.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc" // Registers the oidc auth-provider used by older kubeconfigs
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeClientOptions controls how the Kubernetes client is configured. The zero
+// value tries in-cluster config, then the default kubeconfig.
+type kubeClientOptions struct {
+	kubeconfig string   // Kubeconfig path, overrides KUBECONFIG
+	context    string   // Kubeconfig context, overrides current-context
+	as         string   // User to impersonate
+	asGroups   []string // Groups to impersonate, requires as
+	qps        float32  // Client-side rate limit, client-go default when zero
+	burst      int      // Client-side burst, client-go default when zero
+}
+
+// restConfig builds the client config. In-cluster config is only used when no
+// kubeconfig or context was asked for explicitly.
+func (o kubeClientOptions) restConfig() (*rest.Config, error) {
+	var config *rest.Config
+	if o.kubeconfig == "" && o.context == "" {
+		config, _ = rest.InClusterConfig()
+	}
+
+	if config == nil {
+		// The default loading rules honor a KUBECONFIG list separated by
+		// filepath.ListSeparator and fall back to clientcmd.RecommendedHomeFile,
+		// which resolves the home directory portably (including %USERPROFILE% on Windows).
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		loadingRules.ExplicitPath = o.kubeconfig
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: o.context}
+
+		var err error
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+		if err != nil {
+			return nil, withKubeHint(fmt.Errorf("failed to load kubeconfig from %v: %v", loadingRules.GetLoadingPrecedence(), err), err)
+		}
+	}
+
+	if len(o.asGroups) > 0 && o.as == "" {
+		return nil, fmt.Errorf("impersonating groups requires a user, pass -as as well")
+	}
+	if o.as != "" {
+		config.Impersonate = rest.ImpersonationConfig{UserName: o.as, Groups: o.asGroups}
+	}
+	if o.qps > 0 {
+		config.QPS = o.qps
+	}
+	if o.burst > 0 {
+		config.Burst = o.burst
+	}
+	return config, nil
+}
+
+// federation returns how to reach each cluster searched: one per -contexts
+// entry, otherwise only the one -context names.
+func (o cliOptions) federation() []kubeClientOptions {
+	var clusters []kubeClientOptions
+	for _, value := range o.contexts {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			kube := o.kube
+			kube.context = name
+			clusters = append(clusters, kube)
+		}
+	}
+	if len(clusters) == 0 {
+		return []kubeClientOptions{o.kube}
+	}
+	return clusters
+}
+
+// clusterName names the cluster restConfig connects to, for labelling where
+// entries came from: the kubeconfig context, or "in-cluster". It is empty
+// when the kubeconfig cannot be read.
+func (o kubeClientOptions) clusterName() string {
+	if o.kubeconfig == "" && o.context == "" {
+		if _, err := rest.InClusterConfig(); err == nil {
+			return "in-cluster"
+		}
+	}
+	if o.context != "" {
+		return o.context
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	raw, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+// CreateKubeClient initializes a Kubernetes client, supporting both in-cluster and local kubeconfig setups.
+func CreateKubeClient(opts kubeClientOptions) (*kubernetes.Clientset, error) {
+	config, err := opts.restConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	return clientset, nil
+}
+
+// kubeError is a Kubernetes error with a suggestion on how to fix it.
+type kubeError struct {
+	err  error
+	hint string
+}
+
+func (e *kubeError) Error() string {
+	return fmt.Sprintf("%v\nHint: %s", e.err, e.hint)
+}
+
+func (e *kubeError) Unwrap() error {
+	return e.err
+}
+
+// explainKubeError adds a hint to err when the cause is recognized. Exec
+// credential plugins (aws eks get-token, gke-gcloud-auth-plugin, kubelogin)
+// and token refreshes fail deep inside the HTTP transport, so their errors are
+// only recognizable by message.
+func explainKubeError(err error) error {
+	return withKubeHint(err, err)
+}
+
+// withKubeHint attaches the hint for cause to err, which may wrap cause
+// without preserving its type.
+func withKubeHint(err, cause error) error {
+	if err == nil {
+		return nil
+	}
+	var existing *kubeError
+	if errors.As(err, &existing) {
+		return err
+	}
+	if hint := kubeHint(cause); hint != "" {
+		return &kubeError{err: err, hint: hint}
+	}
+	return err
+}
+
+func kubeHint(err error) string {
+	message := err.Error()
+	switch {
+	case clientcmd.IsEmptyConfig(err):
+		return "no kubeconfig was found; set KUBECONFIG, pass -kubeconfig, or run inside a cluster"
+	case clientcmd.IsContextNotFound(err), strings.Contains(message, "context") && strings.Contains(message, "does not exist"):
+		return "list the available contexts with `kubectl config get-contexts` and pass one with -context"
+	case strings.Contains(message, "exec: executable") && strings.Contains(message, "not found"):
+		return "the kubeconfig uses an exec credential plugin that is not on your PATH; install it (e.g. the AWS CLI, gke-gcloud-auth-plugin or kubelogin) or fix the command in the kubeconfig"
+	case strings.Contains(message, "exec: executable") && strings.Contains(message, "failed"):
+		return "the exec credential plugin failed; run it by hand (e.g. `aws eks get-token`) to see why, and log in again if your session expired"
+	case strings.Contains(message, "oidc") && strings.Contains(message, "refresh"):
+		return "the OIDC token could not be refreshed; log in again with your identity provider and retry"
+	case apierrors.IsUnauthorized(err):
+		return "the API server rejected your credentials; they may have expired, so log in again (e.g. `aws sso login` or `gcloud auth login`) and retry"
+	case apierrors.IsForbidden(err):
+		if rule, ok := forbiddenRule(err); ok {
+			return "you are authenticated but missing the RBAC rule " + rule
+		}
+		return "you are authenticated but not allowed to read these logs; reading logs needs get on pods/log in the namespace"
+	case strings.Contains(message, "connection refused") || strings.Contains(message, "no such host") || strings.Contains(message, "i/o timeout"):
+		return "the API server is unreachable; check the -context, your VPN and `kubectl cluster-info`"
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+// log_viewer/kubeclient_test.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: viewer
+  user:
+    token: test-token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: viewer
+- name: prod
+  context:
+    cluster: prod
+    user: viewer
+`
+
+func TestKubeClientOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      kubeClientOptions
+		wantHost  string
+		wantUser  string
+		wantQPS   float32
+		wantBurst int
+		wantErr   bool
+	}{
+		{
+			name:     "Current context",
+			opts:     kubeClientOptions{kubeconfig: path},
+			wantHost: "https://dev.example.com",
+		},
+		{
+			name:     "Context override",
+			opts:     kubeClientOptions{kubeconfig: path, context: "prod"},
+			wantHost: "https://prod.example.com",
+		},
+		{
+			name:      "Impersonation and rate limits",
+			opts:      kubeClientOptions{kubeconfig: path, as: "jane", qps: 50, burst: 100},
+			wantHost:  "https://dev.example.com",
+			wantUser:  "jane",
+			wantQPS:   50,
+			wantBurst: 100,
+		},
+		{
+			name:    "Unknown context",
+			opts:    kubeClientOptions{kubeconfig: path, context: "staging"},
+			wantErr: true,
+		},
+		{
+			name:     "Group impersonation",
+			opts:     kubeClientOptions{kubeconfig: path, as: "jane", asGroups: []string{"sre"}},
+			wantHost: "https://dev.example.com",
+			wantUser: "jane",
+		},
+		{
+			name:    "Groups without a user",
+			opts:    kubeClientOptions{kubeconfig: path, asGroups: []string{"sre"}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing kubeconfig",
+			opts:    kubeClientOptions{kubeconfig: filepath.Join(t.TempDir(), "missing")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.opts.restConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.Host != tt.wantHost {
+				t.Errorf("expected host %q, got %q", tt.wantHost, config.Host)
+			}
+			if config.Impersonate.UserName != tt.wantUser {
+				t.Errorf("expected impersonated user %q, got %q", tt.wantUser, config.Impersonate.UserName)
+			}
+			if config.QPS != tt.wantQPS || config.Burst != tt.wantBurst {
+				t.Errorf("expected QPS=%v burst=%d, got QPS=%v burst=%d", tt.wantQPS, tt.wantBurst, config.QPS, config.Burst)
+			}
+		})
+	}
+}
+
+func TestKubeconfigResolution(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	clusters := write("clusters", testKubeconfig)
+	// The first file naming a current context wins
+	selected := write("selected", "apiVersion: v1\nkind: Config\ncurrent-context: prod\n")
+	home := write("home", strings.Replace(testKubeconfig, "current-context: dev", "current-context: prod", 1))
+
+	// client-go resolves the home kubeconfig once, from HOME or on Windows
+	// USERPROFILE; with HOME cleared nothing else may depend on it
+	defer func(file string) { clientcmd.RecommendedHomeFile = file }(clientcmd.RecommendedHomeFile)
+	t.Setenv("HOME", "")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	tests := []struct {
+		name       string
+		kubeconfig string // KUBECONFIG
+		explicit   string // -kubeconfig
+		homeFile   string
+		wantHost   string
+		wantHint   string
+	}{
+		{
+			name:       "KUBECONFIG lists several files",
+			kubeconfig: strings.Join([]string{filepath.Join(dir, "missing"), selected, clusters}, string(filepath.ListSeparator)),
+			homeFile:   filepath.Join(dir, "missing"),
+			wantHost:   "https://prod.example.com",
+		},
+		{
+			name:     "Home kubeconfig without HOME",
+			homeFile: home,
+			wantHost: "https://prod.example.com",
+		},
+		{
+			name:       "-kubeconfig wins over KUBECONFIG",
+			kubeconfig: selected,
+			explicit:   clusters,
+			homeFile:   home,
+			wantHost:   "https://dev.example.com",
+		},
+		{
+			name:     "No kubeconfig anywhere",
+			homeFile: filepath.Join(dir, "missing"),
+			wantHint: "no kubeconfig was found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", tt.kubeconfig)
+			clientcmd.RecommendedHomeFile = tt.homeFile
+			config, err := kubeClientOptions{kubeconfig: tt.explicit}.restConfig()
+			if tt.wantHint != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantHint) {
+					t.Errorf("restConfig() error = %v, expected a hint containing %q", err, tt.wantHint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("restConfig() unexpected error: %v", err)
+			}
+			if config.Host != tt.wantHost {
+				t.Errorf("expected host %q, got %q", tt.wantHost, config.Host)
+			}
+		})
+	}
+}
+
+func TestExplainKubeError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantHint string
+	}{
+		{"Missing exec plugin", errors.New(`Get "https://example.com": getting credentials: exec: executable aws not found`), "not on your PATH"},
+		{"Failing exec plugin", errors.New("getting credentials: exec: executable gke-gcloud-auth-plugin failed with exit code 1"), "run it by hand"},
+		{"OIDC refresh", errors.New("failed to refresh token: oauth2: cannot fetch token"), ""},
+		{"OIDC provider refresh", errors.New("oidc auth provider: failed to refresh token"), "OIDC token"},
+		{"Unauthorized", fmt.Errorf("error streaming logs: %w", apierrors.NewUnauthorized("Unauthorized")), "rejected your credentials"},
+		{"Forbidden", fmt.Errorf("error streaming logs: %w", apierrors.NewForbidden(pods, "reviews", errors.New("denied"))), "pods/log"},
+		{"Unreachable", errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), "unreachable"},
+		{"Unrecognized", errors.New("something else"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := explainKubeError(tt.err)
+			var hinted *kubeError
+			if tt.wantHint == "" {
+				if errors.As(got, &hinted) {
+					t.Errorf("expected no hint, got %v", got)
+				}
+				return
+			}
+			if !errors.As(got, &hinted) || !strings.Contains(hinted.hint, tt.wantHint) {
+				t.Errorf("expected a hint containing %q, got %v", tt.wantHint, got)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("expected the hinted error to wrap the original")
+			}
+		})
+	}
+
+	if explainKubeError(nil) != nil {
+		t.Errorf("expected nil for a nil error")
+	}
+}
+
+func TestRestConfigHints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := kubeClientOptions{kubeconfig: path, context: "staging"}.restConfig()
+	if err == nil || !strings.Contains(err.Error(), "kubectl config get-contexts") {
+		t.Errorf("expected a hint for an unknown context, got %v", err)
+	}
+}
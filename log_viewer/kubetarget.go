@@ -0,0 +1,295 @@
+// log_viewer/kubetarget.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubeTarget is the pod the TUI loads logs from. Loading happens inside the
+// TUI so a failed fetch can be retried, or another pod picked, without
+// restarting the session.
+type kubeTarget struct {
+	client     kubernetes.Interface
+	namespace  string
+	pod        string
+	container  string
+	follow     bool
+	backfill   time.Duration // Log shown before following, see fetchBackfill
+	sourceOpts []k8ssource.Option
+	parseOpts  parseOptions
+}
+
+func (t kubeTarget) String() string {
+	return fmt.Sprintf("%s/%s", t.namespace, t.pod)
+}
+
+// origin labels the target's entries, in the cluster its parse options name.
+func (t kubeTarget) origin() Source {
+	origin := t.parseOpts.origin
+	origin.Namespace, origin.Pod, origin.Container = t.namespace, t.pod, t.container
+	return origin
+}
+
+// podFields looks up the fields of the target's pod, see podFields.
+func (t kubeTarget) podFields() (map[string]interface{}, error) {
+	return lookupPodFields(t.client, t.namespace, t.pod, t.parseOpts.podFieldNames)
+}
+
+// lookups fetches what load sends along with the logs: the names of peers, the
+// workload's events and the pod's fields. They are niceties, so their failures
+// are joined into one error to show rather than failing the load.
+func (t kubeTarget) lookups() (map[string]string, []k8ssource.Event, map[string]interface{}, error) {
+	peers, peersErr := t.peers()
+	events, eventsErr := t.fetchEvents()
+	fields, fieldsErr := t.podFields()
+	return peers, events, fields, errors.Join(peersErr, eventsErr, fieldsErr)
+}
+
+// source describes where the model's logs come from, for deep links.
+func (m Model) source() logSource {
+	if m.target == nil {
+		return logSource{}
+	}
+	return logSource{name: m.target.String(), namespace: m.target.namespace, pod: m.target.pod, container: m.target.container}
+}
+
+// logsFetchedMsg carries the result of fetching a non-following target.
+type logsFetchedMsg struct {
+	target    kubeTarget
+	gen       int // Model.loadGen when the load started
+	lines     []string
+	peers     map[string]string      // Names of the cluster's IPs, see kubeTarget.peers
+	events    []k8ssource.Event      // About the target's workload, see kubeTarget.events
+	podFields map[string]interface{} // Added to every entry, see podFields
+	lookupErr error                  // Shown once the logs are, see kubeTarget.lookups
+	err       error
+}
+
+// streamStartedMsg carries the result of opening a followed target.
+type streamStartedMsg struct {
+	target    kubeTarget
+	gen       int
+	backfill  []string // Lines from before the stream, shown first
+	lines     <-chan string
+	streamErr func() error
+	stop      context.CancelFunc
+	peers     map[string]string
+	events    []k8ssource.Event
+	podFields map[string]interface{}
+	lookupErr error
+	err       error
+}
+
+// podsListedMsg carries the pods of a namespace offered by the pod picker.
+type podsListedMsg struct {
+	namespace  string
+	pods       []string
+	containers map[string][]string // Containers of each pod
+	err        error
+}
+
+// namespacesListedMsg carries the namespaces offered by the pod picker.
+type namespacesListedMsg struct {
+	namespaces []string
+	err        error
+}
+
+// load returns a command fetching or following the target's logs. Its
+// message carries gen, so one that arrives after a switch is told apart.
+func (t kubeTarget) load(gen int) tea.Cmd {
+	return func() tea.Msg {
+		if t.follow {
+			streamOpts := t.sourceOpts
+			var backfill []string
+			if t.backfill > 0 {
+				var err error
+				if backfill, streamOpts, err = t.fetchBackfill(time.Now()); err != nil {
+					return streamStartedMsg{target: t, gen: gen, err: err}
+				}
+			}
+
+			ctx, stop := context.WithCancel(context.Background())
+			lines, streamErr, err := StreamLogsFromK8s(ctx, t.client, t.namespace, t.pod, t.container, streamOpts...)
+			if err != nil {
+				stop()
+				return streamStartedMsg{target: t, gen: gen, err: err}
+			}
+			if t.backfill > 0 {
+				lines = dropOverlap(ctx, lines, backfill)
+			}
+			peers, events, podFields, lookupErr := t.lookups()
+			return streamStartedMsg{target: t, gen: gen, backfill: backfill, lines: lines, streamErr: streamErr, stop: stop,
+				peers: peers, events: events, podFields: podFields, lookupErr: lookupErr}
+		}
+
+		lines, err := FetchLogsFromK8s(t.client, t.namespace, t.pod, t.container, t.sourceOpts...)
+		if err != nil {
+			return logsFetchedMsg{target: t, gen: gen, err: err}
+		}
+		peers, events, podFields, lookupErr := t.lookups()
+		return logsFetchedMsg{target: t, gen: gen, lines: lines, peers: peers, events: events, podFields: podFields, lookupErr: lookupErr}
+	}
+}
+
+// listPods returns a command listing the pods in namespace, with their
+// containers.
+func (t kubeTarget) listPods(namespace string) tea.Cmd {
+	return func() tea.Msg {
+		pods, err := k8ssource.ListPods(context.Background(), t.client, namespace, "", 0)
+		if err != nil {
+			return podsListedMsg{namespace: namespace, err: explainKubeError(err)}
+		}
+		msg := podsListedMsg{namespace: namespace, containers: make(map[string][]string)}
+		for _, pod := range pods {
+			msg.pods = append(msg.pods, pod.Name)
+			for _, container := range pod.Spec.Containers {
+				msg.containers[pod.Name] = append(msg.containers[pod.Name], container.Name)
+			}
+		}
+		return msg
+	}
+}
+
+// listNamespaces returns a command listing the cluster's namespaces.
+func (t kubeTarget) listNamespaces() tea.Cmd {
+	return func() tea.Msg {
+		namespaces, err := k8ssource.ListNamespaceNames(context.Background(), t.client, 0)
+		return namespacesListedMsg{namespaces: namespaces, err: explainKubeError(err)}
+	}
+}
+
+// retry reloads the target after a failure. A healthy followed stream is left alone.
+func (m Model) retry() (Model, tea.Cmd) {
+	if m.target == nil || m.loading || m.following {
+		return m, nil
+	}
+	m.loading = true
+	m.err = nil
+	return m, m.target.load(m.loadGen)
+}
+
+// switchTarget stops the current stream, if any, and loads another
+// container. The filters carry over, except those that only made sense for
+// the previous target, see carriedFilter.
+func (m Model) switchTarget(namespace, pod, container string) (Model, tea.Cmd) {
+	if m.stopStream != nil {
+		m.stopStream()
+		m.stopStream = nil
+	}
+	target := *m.target
+	target.namespace, target.pod, target.container = namespace, pod, container
+	if target.String() != m.target.String() {
+		// Connection IDs are counted by each pod's Envoy
+		m.connectionFilter = ""
+	}
+	m.activeFilter = carriedFilter(m.activeFilter, target.origin())
+	// Marked and hidden entries are the previous target's
+	m = m.clearMarks()
+	m.excluded = nil
+	m.target = &target
+	m.stream = nil
+	m.pending = nil
+	m.idleWait = false
+	m.following = false
+	m.pinned = false
+	m.loading = true
+	m.loadGen++
+	m.err = nil
+	return m, target.load(m.loadGen)
+}
+
+// stale reports whether a load started before the last switch of target,
+// which arrived after the current target's. Generations rather than targets
+// are compared, so a switch to another container of the same pod, or back to
+// a previous target, still drops the earlier load.
+func (m Model) stale(gen int) bool {
+	return m.target != nil && gen != m.loadGen
+}
+
+// receiveLogs replaces the entries with a freshly fetched target's logs.
+// Errors are kept on the model and shown as a toast.
+func (m Model) receiveLogs(msg logsFetchedMsg) Model {
+	if m.stale(msg.gen) {
+		return m
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+		return m
+	}
+
+	opts := msg.target.parseOpts.from(msg.target.String()).withOrigin(msg.target.origin()).withPodFields(msg.podFields)
+	logs, err := parseRawLogsWith(msg.lines, opts)
+	if err != nil {
+		m.err = fmt.Errorf("error parsing logs from pod %s: %v", msg.target, err)
+		return m
+	}
+	// A refetch of the same pod keeps the selected entry, another pod starts at the top
+	id := m.selectedID()
+	if opts.source != m.logsFrom {
+		id = EntryID{}
+	}
+	m.logs = logs
+	m.logsFrom = opts.source
+	m.origin = opts.origin
+	m.podFields = msg.podFields
+	m = m.withPeers(msg.peers, true)
+	m = m.withEvents(msg.events)
+	m.filteredLogs = m.filter(logs)
+	m.overhead = overheadOf(m.filteredLogs)
+	m = m.selectID(id)
+	m.err = msg.lookupErr
+	return m
+}
+
+// receiveStream starts following a freshly opened target. A stream opened for
+// a target since switched away from is stopped, and one replaced by another
+// of the same target too.
+func (m Model) receiveStream(msg streamStartedMsg) (Model, tea.Cmd) {
+	if m.stale(msg.gen) {
+		if msg.stop != nil {
+			msg.stop()
+		}
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+
+	m.logs = nil
+	m.logsFrom = msg.target.String()
+	m.origin = msg.target.origin()
+	m.podFields = msg.podFields
+	if len(msg.backfill) > 0 {
+		// Nothing parsable in the backfill leaves the stream to start the list
+		opts := msg.target.parseOpts.from(m.logsFrom).withOrigin(m.origin).withPodFields(m.podFields)
+		m.logs, _ = parseRawLogsWith(msg.backfill, opts)
+	}
+	m = m.withPeers(msg.peers, true)
+	m = m.withEvents(msg.events)
+	m.filteredLogs = m.filter(m.logs)
+	m.overhead = overheadOf(m.filteredLogs)
+	m.selectedLogIndex = max(len(m.filteredLogs)-1, 0)
+	if m.stopStream != nil {
+		m.stopStream()
+	}
+	m.stream = msg.lines
+	m.pending = nil
+	m.idleWait = false
+	m.streamErr = msg.streamErr
+	m.stopStream = msg.stop
+	m.following = true
+	m.pinned = true
+	m.err = msg.lookupErr
+	return m, waitForLine(m.stream)
+}
@@ -0,0 +1,220 @@
+// log_viewer/kubetarget_test.go
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testTarget(pods ...string) *kubeTarget {
+	var objects []runtime.Object
+	for _, name := range pods {
+		objects = append(objects, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+	}
+	client := fake.NewSimpleClientset(objects...)
+	return &kubeTarget{client: client, namespace: "default", pod: pods[0], container: "istio-proxy", parseOpts: defaultParseOptions()}
+}
+
+// run feeds msg to the model, then the message produced by the returned command.
+func run(t *testing.T, model Model, msg tea.Msg) Model {
+	t.Helper()
+	updated, cmd := model.Update(msg)
+	if cmd != nil {
+		updated, _ = updated.Update(cmd())
+	}
+	return updated.(Model)
+}
+
+func TestLoadErrorToast(t *testing.T) {
+	model := Model{target: testTarget("reviews-v1"), loading: true, width: 120, height: 40}
+
+	// The fake clientset returns "fake logs", which does not parse
+	model = run(t, model, model.Init()())
+	if model.loading || model.err == nil {
+		t.Fatalf("expected a parse error after loading, got loading=%t err=%v", model.loading, model.err)
+	}
+	view := model.View()
+	if !strings.Contains(view, "default/reviews-v1") || !strings.Contains(view, "'r' to retry") {
+		t.Errorf("expected a toast naming the pod and offering retry, got %q", view)
+	}
+
+	// r retries the same pod
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	model = updated.(Model)
+	if !model.loading || model.err != nil || cmd == nil {
+		t.Errorf("expected r to start loading again, got loading=%t err=%v", model.loading, model.err)
+	}
+
+	// esc dismisses the toast
+	model = Model{target: model.target, err: errors.New("boom"), logs: []ParsedLog{{RawLog: "log1"}}, filteredLogs: []ParsedLog{{RawLog: "log1"}}}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).err != nil {
+		t.Errorf("expected esc to dismiss the toast")
+	}
+}
+
+func TestLoadLookupErrorToast(t *testing.T) {
+	target := testTarget("reviews-v1")
+	target.client.(*fake.Clientset).PrependReactor("list", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	_, _, _, err := target.lookups()
+	if err == nil || !strings.Contains(err.Error(), "pod IPs") || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("expected the failed lookups joined, got %v", err)
+	}
+
+	// The logs load, with the failed lookups shown instead of logged over the TUI
+	model := Model{target: target, loading: true, width: 120, height: 40}
+	model = run(t, model, logsFetchedMsg{target: *target, lines: []string{`{"message":"hello"}`}, lookupErr: err})
+	if model.loading || len(model.logs) != 1 || model.err != err {
+		t.Errorf("expected the logs with the lookup error, got %d entries and err=%v", len(model.logs), model.err)
+	}
+}
+
+func TestPodPicker(t *testing.T) {
+	model := Model{target: testTarget("reviews-v1", "reviews-v2", "ratings-v1"), err: errors.New("boom"), width: 120, height: 40}
+
+	// p lists the namespace's pods with the current one selected
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if model.picker == nil || len(model.picker.items) != 3 {
+		t.Fatalf("expected a picker with 3 pods, got %+v", model.picker)
+	}
+	if !strings.Contains(model.View(), "reviews-v1 (current)") {
+		t.Errorf("expected the current pod to be marked")
+	}
+
+	// Typing filters, and enter loads the selected pod
+	for _, key := range "ratings" {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+		model = updated.(Model)
+	}
+	if visible := model.picker.visible(); len(visible) != 1 || visible[0] != "ratings-v1" {
+		t.Fatalf("expected the filter to leave ratings-v1, got %v", visible)
+	}
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.picker != nil || model.target.pod != "ratings-v1" || !model.loading || cmd == nil {
+		t.Errorf("expected ratings-v1 to be loading, got pod=%s loading=%t", model.target.pod, model.loading)
+	}
+}
+
+func TestPodPickerOtherNamespace(t *testing.T) {
+	withContainers := func(namespace, name string, containers ...string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		for _, container := range containers {
+			pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: container})
+		}
+		return pod
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		withContainers("default", "reviews-v1", "reviews", "istio-proxy"),
+		withContainers("shop", "cart-v1", "cart", "istio-proxy"),
+	)
+	target := &kubeTarget{client: client, namespace: "default", pod: "reviews-v1", container: "istio-proxy", parseOpts: defaultParseOptions()}
+	model := Model{target: target, width: 120, height: 40, activeFilter: "source.pod=reviews 503", connectionFilter: "C12"}
+
+	// ctrl+p opens the picker, tab lists the namespaces
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyCtrlP})
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyTab})
+	if model.picker == nil || model.picker.level != pickNamespace || len(model.picker.items) != 2 {
+		t.Fatalf("expected the namespaces listed, got %+v", model.picker)
+	}
+	if !strings.Contains(model.View(), "default (current)") {
+		t.Errorf("expected the current namespace to be marked")
+	}
+
+	// Picking a namespace lists its pods, and a pod with several containers
+	// lists them with the current container's name selected
+	for _, key := range "shop" {
+		model = run(t, model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+	}
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.picker == nil || model.picker.namespace != "shop" || len(model.picker.items) != 1 {
+		t.Fatalf("expected the pods of shop, got %+v", model.picker)
+	}
+	model = run(t, model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.picker == nil || model.picker.level != pickContainer || model.picker.visible()[model.picker.selected] != "istio-proxy" {
+		t.Fatalf("expected the containers of cart-v1 with istio-proxy selected, got %+v", model.picker)
+	}
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.picker != nil || model.target.String() != "shop/cart-v1" || model.target.container != "istio-proxy" || cmd == nil {
+		t.Fatalf("expected shop/cart-v1 to be loading, got %s container %s", model.target, model.target.container)
+	}
+	if model.activeFilter != "503" || model.connectionFilter != "" {
+		t.Errorf("expected only the filters that still apply to carry over, got %q and %q", model.activeFilter, model.connectionFilter)
+	}
+}
+
+func TestReplacedStreamIgnored(t *testing.T) {
+	old := make(chan string)
+	current := make(chan string)
+	model := Model{stream: current, following: true, pinned: true}
+
+	updated, _ := model.Update(logLineMsg{line: `{"message":"stale"}`, stream: old})
+	updated, _ = updated.Update(streamClosedMsg{stream: old})
+	model = updated.(Model)
+	if len(model.logs) != 0 || !model.following {
+		t.Errorf("expected messages from a replaced stream to be ignored, got %d logs following=%t", len(model.logs), model.following)
+	}
+}
+
+func TestStaleLoadIgnored(t *testing.T) {
+	reviews := kubeTarget{namespace: "default", pod: "reviews-v1"}
+	model, _ := Model{target: &reviews}.switchTarget("default", "ratings-v1", "")
+	ratings := *model.target
+	model = model.receiveLogs(logsFetchedMsg{target: ratings, gen: model.loadGen, lines: []string{`{"path":"/ratings"}`}})
+
+	// The previous pod's slower load arrives last
+	model = model.receiveLogs(logsFetchedMsg{target: reviews, gen: model.loadGen - 1, lines: []string{`{"path":"/reviews"}`}})
+	if len(model.logs) != 1 || model.logs[0].Path() != "/ratings" {
+		t.Errorf("expected the stale load dropped, got %v", model.logs)
+	}
+	stopped := false
+	model, cmd := model.receiveStream(streamStartedMsg{target: reviews, gen: model.loadGen - 1, lines: make(chan string), stop: func() { stopped = true }})
+	if !stopped || cmd != nil || model.stream != nil {
+		t.Errorf("expected the stale stream stopped and dropped, stopped=%t", stopped)
+	}
+
+	// A new stream of the current pod replaces and stops the old one
+	stopped = false
+	model, _ = model.receiveStream(streamStartedMsg{target: ratings, gen: model.loadGen, lines: make(chan string), stop: func() { stopped = true }})
+	model, _ = model.receiveStream(streamStartedMsg{target: ratings, gen: model.loadGen, lines: make(chan string), stop: func() {}})
+	if !stopped {
+		t.Errorf("expected the replaced stream stopped")
+	}
+
+	// Another container of the same pod is another target
+	app := ratings
+	model, _ = model.switchTarget("default", "ratings-v1", "istio-proxy")
+	proxy := *model.target
+	model = model.receiveLogs(logsFetchedMsg{target: proxy, gen: model.loadGen, lines: []string{`{"path":"/proxy"}`}})
+	model = model.receiveLogs(logsFetchedMsg{target: app, gen: model.loadGen - 1, lines: []string{`{"path":"/app"}`}})
+	if len(model.logs) != 1 || model.logs[0].Path() != "/proxy" || model.origin.Container != "istio-proxy" {
+		t.Errorf("expected the other container's load dropped, got %v from %q", model.logs, model.origin.Container)
+	}
+
+	// Switching back drops the earlier load of the same container too
+	earlier := model.loadGen - 1
+	model, _ = model.switchTarget("default", "ratings-v1", "")
+	model = model.receiveLogs(logsFetchedMsg{target: app, gen: earlier, lines: []string{`{"path":"/old"}`}})
+	if !model.loading || model.logs[0].Path() != "/proxy" {
+		t.Errorf("expected the earlier load of the same container dropped, got %v", model.logs)
+	}
+	model = model.receiveLogs(logsFetchedMsg{target: app, gen: model.loadGen, lines: []string{`{"path":"/app"}`}})
+	if model.loading || model.logs[0].Path() != "/app" {
+		t.Errorf("expected the current load shown, got %v", model.logs)
+	}
+}
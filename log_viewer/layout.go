@@ -0,0 +1,122 @@
+// log_viewer/layout.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The smallest terminal the three panes fit in. Anything smaller gets a
+// placeholder asking for a bigger window.
+const (
+	minWidth  = 40
+	minHeight = 12
+)
+
+// Pane heights include their borders.
+const (
+	minListHeight   = 4 // Border, title and one row
+	minRawHeight    = 3 // Title and one line, no top border
+	minDetailHeight = 3
+)
+
+// layoutPreset chooses which panes are shown.
+type layoutPreset string
+
+const (
+	layoutSplit  layoutPreset = "split"  // List, raw log and details stacked
+	layoutList   layoutPreset = "list"   // Only the list, for scanning many entries
+	layoutDetail layoutPreset = "detail" // Only the selected entry's details
+)
+
+var layoutPresets = []layoutPreset{layoutSplit, layoutList, layoutDetail}
+
+// next returns the preset after l, wrapping around. The zero value is split.
+func (l layoutPreset) next() layoutPreset {
+	for i, preset := range layoutPresets {
+		if preset == l {
+			return layoutPresets[(i+1)%len(layoutPresets)]
+		}
+	}
+	return layoutPresets[1]
+}
+
+// paneHeights splits the lines left under the header between the panes.
+type paneHeights struct {
+	list   int
+	raw    int
+	detail int
+}
+
+// splitPanes gives the list a quarter of the space, the raw log as much as it
+// needs up to 30%, and the details the rest. ok is false when even the
+// minimum heights do not fit.
+func splitPanes(available, rawLines int) (paneHeights, bool) {
+	if available < minListHeight+minRawHeight+minDetailHeight {
+		return paneHeights{}, false
+	}
+
+	list := max(available*25/100, minListHeight)
+	raw := min(rawLines+3, available*30/100) // Title, its margin and the bottom border
+	raw = max(raw, minRawHeight)
+	detail := available - list - raw
+	if detail < minDetailHeight {
+		// Take the shortfall from the raw log first, then the list
+		shortfall := minDetailHeight - detail
+		take := min(shortfall, raw-minRawHeight)
+		raw -= take
+		list -= shortfall - take
+		detail = minDetailHeight
+	}
+	return paneHeights{list: list, raw: raw, detail: detail}, true
+}
+
+// renderTooSmall is shown instead of the panes when the terminal is too small.
+func renderTooSmall(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	lines := []string{
+		"Terminal too small",
+		fmt.Sprintf("%dx%d, need %dx%d", width, height, minWidth, minHeight),
+		"Press 'q' to quit",
+	}
+	// errorStyle's padding would waste the few lines there are
+	style := lipgloss.NewStyle().Foreground(errorColor).Bold(true)
+	return clampView(style.Render(strings.Join(lines, "\n")), width, height)
+}
+
+// clampView cuts view to width x height cells, for screens that are a few
+// short lines and not worth laying out.
+func clampView(view string, width, height int) string {
+	return lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(view)
+}
+
+// fitPane renders content in a bordered pane of exactly width x height cells,
+// wrapping long lines and cutting off what does not fit. Panes stacked under
+// another one omit their top border.
+func fitPane(content string, width, height int, topBorder bool) string {
+	innerWidth := width - 4 // Borders and padding
+	innerHeight := height - 1
+	if topBorder {
+		innerHeight--
+	}
+	if innerWidth < 1 || innerHeight < 1 {
+		return ""
+	}
+
+	body := lipgloss.NewStyle().
+		Width(innerWidth).
+		Height(innerHeight).
+		MaxHeight(innerHeight).
+		Render(strings.TrimRight(content, "\n"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(normalColor).
+		BorderTop(topBorder).
+		Padding(0, 1).
+		Render(body)
+}
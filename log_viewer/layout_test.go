@@ -0,0 +1,104 @@
+// log_viewer/layout_test.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestViewFitsTerminal(t *testing.T) {
+	logs := mustParse(t, snapshotLines...)
+	sizes := [][2]int{{0, 0}, {1, 1}, {10, 3}, {39, 30}, {80, 11}, {40, 12}, {41, 13}, {60, 16}, {80, 24}, {120, 40}, {250, 80}}
+
+	models := map[string]Model{
+		"list":   {logs: logs, filteredLogs: logs, selectedLogIndex: 1},
+		"search": {logs: logs, filteredLogs: logs, searchMode: true, searchQuery: "UF"},
+		"toast":  {logs: logs, filteredLogs: logs, err: errors.New(strings.Repeat("connection refused ", 10))},
+		"empty":  {},
+
+		"list_layout":   {logs: logs, filteredLogs: logs, layout: layoutList},
+		"detail_layout": {logs: logs, filteredLogs: logs, layout: layoutDetail},
+		"expanded":      {logs: logs, filteredLogs: logs, selectedLogIndex: 2, expandedList: true},
+	}
+
+	for name, model := range models {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("%s_%dx%d", name, size[0], size[1]), func(t *testing.T) {
+				model.width, model.height = size[0], size[1]
+				view := model.View()
+				if got := lipgloss.Width(view); got > model.width {
+					t.Errorf("view is %d columns wide, terminal has %d", got, model.width)
+				}
+				if got := lipgloss.Height(view); view != "" && got > model.height {
+					t.Errorf("view is %d lines tall, terminal has %d", got, model.height)
+				}
+			})
+		}
+	}
+}
+
+func TestTooSmallPlaceholder(t *testing.T) {
+	logs := mustParse(t, snapshotLines...)
+	model := Model{logs: logs, filteredLogs: logs, width: minWidth - 1, height: 30}
+	if view := model.View(); !strings.Contains(view, "Terminal too small") {
+		t.Errorf("expected the placeholder below the minimum width, got %q", view)
+	}
+
+	model.width, model.height = minWidth, minHeight
+	if view := model.View(); strings.Contains(view, "Terminal too small") {
+		t.Errorf("expected the panes at exactly the minimum size")
+	}
+
+	if view := (Model{}).View(); view != "" {
+		t.Errorf("expected nothing before the first window size, got %q", view)
+	}
+}
+
+func TestSplitPanes(t *testing.T) {
+	for available := 0; available <= 100; available++ {
+		heights, ok := splitPanes(available, 20)
+		if !ok {
+			if available >= minListHeight+minRawHeight+minDetailHeight {
+				t.Errorf("splitPanes(%d) refused a height that fits the minimums", available)
+			}
+			continue
+		}
+		if total := heights.list + heights.raw + heights.detail; total != available {
+			t.Errorf("splitPanes(%d) = %+v, adds up to %d", available, heights, total)
+		}
+		if heights.list < minListHeight || heights.raw < minRawHeight || heights.detail < minDetailHeight {
+			t.Errorf("splitPanes(%d) = %+v, below the minimums", available, heights)
+		}
+	}
+}
+
+func TestLayoutPresets(t *testing.T) {
+	logs := mustParse(t, snapshotLines...)
+	model := Model{logs: logs, filteredLogs: logs, width: 80, height: 24}
+
+	// v cycles split -> list -> detail -> split
+	expected := []layoutPreset{layoutList, layoutDetail, layoutSplit}
+	for _, preset := range expected {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+		model = updated.(Model)
+		if model.layout != preset {
+			t.Errorf("expected layout %q, got %q", preset, model.layout)
+		}
+	}
+
+	// The preset survives a new search
+	model.layout = layoutDetail
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("v")}, {Type: tea.KeyEnter}} {
+		updated, _ = updated.Update(key)
+	}
+	if model = updated.(Model); model.layout != layoutDetail {
+		t.Errorf("expected the detail layout to be kept, got %q", model.layout)
+	}
+}
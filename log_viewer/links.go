@@ -0,0 +1,140 @@
+// log_viewer/links.go
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// LinkTemplate is a configured deep link into an observability stack, such
+// as Grafana Explore, Tempo or Loki. URL is a text/template that can use:
+//
+//	.Start, .End       time range, RFC 3339
+//	.StartMs, .EndMs   time range, Unix milliseconds (what Grafana expects)
+//	.TraceID           trace ID of the entry
+//	.RequestID         Envoy request ID of the entry
+//	.Namespace, .Pod, .Container
+//	.Loki, .Jaeger     base URLs from the selected profile, see ClusterProfile
+//
+// A link whose template uses a value that is not known is left out, so a
+// Tempo link only shows for entries with a trace ID. Values should be passed
+// through urlquery, e.g.
+//
+//	{"name": "Tempo", "url": "https://grafana.example.com/explore?left={\"datasource\":\"tempo\",\"queries\":[{\"query\":\"{{urlquery .TraceID}}\"}]}"}
+type LinkTemplate struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	tmpl *template.Template
+}
+
+// compile parses the URL template.
+func (l *LinkTemplate) compile() error {
+	if l.Name == "" {
+		return fmt.Errorf("link %q has no name", l.URL)
+	}
+	tmpl, err := template.New(l.Name).Option("missingkey=error").Parse(l.URL)
+	if err != nil {
+		return fmt.Errorf("link %s: %v", l.Name, err)
+	}
+	l.tmpl = tmpl
+	return nil
+}
+
+// deepLink is a rendered link.
+type deepLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// linkWindow is how far either side of an entry its links look.
+const linkWindow = 5 * time.Minute
+
+// linkEndpoints are the URLs of the selected profile, which every link can
+// use on top of its context.
+var linkEndpoints linkContext
+
+// linkContext holds the values link templates can use. Only known values are
+// set, so templates using unknown ones fail and are skipped.
+type linkContext map[string]interface{}
+
+// withRange sets the time range.
+func (c linkContext) withRange(start, end time.Time) linkContext {
+	if start.IsZero() || end.IsZero() {
+		return c
+	}
+	c["Start"] = start.UTC().Format(time.RFC3339)
+	c["End"] = end.UTC().Format(time.RFC3339)
+	c["StartMs"] = start.UnixMilli()
+	c["EndMs"] = end.UnixMilli()
+	return c
+}
+
+// withSource sets the namespace, pod and container logs were read from.
+func (c linkContext) withSource(source logSource) linkContext {
+	for key, value := range map[string]string{
+		"Namespace": source.namespace,
+		"Pod":       source.pod,
+		"Container": source.container,
+	} {
+		if value != "" {
+			c[key] = value
+		}
+	}
+	return c
+}
+
+// entryLinkContext returns the values for links about one entry: a window
+// around it, and its trace and request IDs.
+func entryLinkContext(log ParsedLog, source logSource) linkContext {
+	c := linkContext{}.withSource(source)
+	if t, ok := eventTime(log); ok {
+		c.withRange(t.Add(-linkWindow), t.Add(linkWindow))
+	}
+	if traceID := traceID(log); traceID != "" {
+		c["TraceID"] = traceID
+	}
+	if requestID := getFieldSafely(log.Fields, "request_id"); requestID != "-" {
+		c["RequestID"] = requestID
+	}
+	return c
+}
+
+// traceID finds the entry's trace ID in the fields tracers and Envoy commonly
+// log it under.
+func traceID(log ParsedLog) string {
+	for _, field := range []string{"trace_id", "traceId", "traceID", "x_b3_traceid", "x-b3-traceid"} {
+		if value := getFieldSafely(log.Fields, field); value != "-" {
+			return value
+		}
+	}
+	// W3C traceparent: version-traceid-parentid-flags
+	if parts := strings.Split(getFieldSafely(log.Fields, "traceparent"), "-"); len(parts) == 4 {
+		return parts[1]
+	}
+	return ""
+}
+
+// renderLinks renders every link the context has the values for.
+func renderLinks(templates []LinkTemplate, c linkContext) []deepLink {
+	values := make(map[string]interface{}, len(linkEndpoints)+len(c))
+	maps.Copy(values, linkEndpoints)
+	maps.Copy(values, c)
+
+	var links []deepLink
+	for _, l := range templates {
+		if l.tmpl == nil {
+			continue
+		}
+		var url strings.Builder
+		if err := l.tmpl.Execute(&url, values); err != nil {
+			continue
+		}
+		links = append(links, deepLink{Name: l.Name, URL: url.String()})
+	}
+	return links
+}
@@ -0,0 +1,62 @@
+// log_viewer/links_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderLinks(t *testing.T) {
+	templates := []LinkTemplate{
+		{Name: "Loki", URL: `https://grafana/explore?from={{.StartMs}}&to={{.EndMs}}&expr={{urlquery "{pod=\"" .Pod "\"}"}}`},
+		{Name: "Tempo", URL: "https://grafana/trace/{{.TraceID}}"},
+	}
+	for i := range templates {
+		if err := templates[i].compile(); err != nil {
+			t.Fatalf("compile() unexpected error: %v", err)
+		}
+	}
+
+	log := ParsedLog{Fields: map[string]interface{}{
+		"start_time":  "2024-11-26T02:30:00.000Z",
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}}
+	source := logSource{name: "default/reviews-v1", namespace: "default", pod: "reviews-v1"}
+
+	got := renderLinks(templates, entryLinkContext(log, source))
+	expected := []deepLink{
+		{Name: "Loki", URL: "https://grafana/explore?from=1732587900000&to=1732588500000&expr=%7Bpod%3D%22reviews-v1%22%7D"},
+		{Name: "Tempo", URL: "https://grafana/trace/4bf92f3577b34da6a3ce929d0e0e4736"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("renderLinks() = %v, expected %v", got, expected)
+	}
+
+	// Links using values that are not known are left out
+	got = renderLinks(templates, entryLinkContext(ParsedLog{}, logSource{name: "stdin"}))
+	if len(got) != 0 {
+		t.Errorf("renderLinks() = %v, expected no links without a time range, pod or trace ID", got)
+	}
+}
+
+func TestLoadConfigLinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"links":[{"name":"Tempo","url":"https://grafana/trace/{{.TraceID"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "Tempo") {
+		t.Errorf("expected an error naming the invalid link, got %v", err)
+	}
+}
+
+func TestDetailPaneLinks(t *testing.T) {
+	log := ParsedLog{Fields: map[string]interface{}{"response_code": float64(200)}}
+	view := renderDetailView(log, detailExtras{links: []deepLink{{Name: "Loki", URL: "https://grafana/explore"}}}, 100, 40)
+	if !strings.Contains(view, "https://grafana/explore") {
+		t.Errorf("expected the detail pane to show the link, got %q", view)
+	}
+}
@@ -7,19 +7,28 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // ParsedLog represents a single log entry.
 type ParsedLog struct {
-	RawLog     string                 // Full JSON log string
-	Fields     map[string]interface{} // Parsed fields
-	LineNumber int                    // Original line number
+	RawLog        string                 // Full JSON log string
+	Fields        map[string]interface{} // Parsed fields
+	LineNumber    int                    // Original line number
+	ID            EntryID                // Identifies the entry across sources
+	KubeTimestamp time.Time              // Timestamp prefixed by the kubelet, zero if absent
+	Source        Source                 // Where the entry was read from
+
+	// searchText is the lowercased raw log and fields that filterLogs matches
+	// against. Set by indexed; anything that changes RawLog or Fields after
+	// parsing must call indexed again.
+	searchText string
 }
 
 // ParseLog parses a single log line into a ParsedLog struct.
 func ParseLog(line string, lineNumber int) (ParsedLog, error) {
 	var fields map[string]interface{}
-	err := json.Unmarshal([]byte(line), &fields)
+	err := decodeJSON([]byte(line), &fields)
 	if err != nil {
 		return ParsedLog{}, fmt.Errorf("error parsing log line %d: %v", lineNumber, err)
 	}
@@ -30,45 +39,171 @@ func ParseLog(line string, lineNumber int) (ParsedLog, error) {
 	}, nil
 }
 
+// parseOptions configures how raw lines are turned into entries.
+type parseOptions struct {
+	multiline MultilineConfig
+	source    string        // Pod or file the logs come from, recorded in entry IDs
+	origin    Source        // Labels of the same, recorded on every entry
+	redact    []RedactRule  // Masking applied to every entry
+	schemas   []fieldSchema // Field names renamed to the defaults, see withSchemas
+
+	podFieldNames []string               // Pod labels and annotations to look up, see podFields
+	podFields     map[string]interface{} // Added to every entry, see withPodFields
+}
+
+func defaultParseOptions() parseOptions {
+	return parseOptions{multiline: defaultMultilineConfig(), schemas: fieldSchemas}
+}
+
+// parseOptionsFor returns the parse options set by the flags and config file.
+func parseOptionsFor(opts cliOptions, cfg Config) parseOptions {
+	// parseFlags has already rejected unknown schemas
+	schemas, _ := selectSchemas(opts.schema)
+	return parseOptions{multiline: cfg.Multiline, redact: redactRulesFor(opts, cfg), schemas: schemas, podFieldNames: cfg.PodFields}
+}
+
+// from returns the options for logs read from source.
+func (o parseOptions) from(source string) parseOptions {
+	o.source = source
+	return o
+}
+
 // parseRawLogs processes raw log lines into a slice of ParsedLog structs.
 func parseRawLogs(rawLogs []string) ([]ParsedLog, error) {
+	return parseRawLogsWith(rawLogs, defaultParseOptions())
+}
+
+// parseRawLogsWith is parseRawLogs with explicit parsing options.
+func parseRawLogsWith(rawLogs []string, opts parseOptions) ([]ParsedLog, error) {
 	var parsedLogs []ParsedLog
 
-	// Try to parse the entire input as a JSON array
+	// Try to parse the entire input as a JSON array. Joining every line is
+	// expensive for large inputs, so only try when the input opens an array.
 	var logsArray []map[string]interface{}
-	rawInput := strings.Join(rawLogs, "\n")
-	if err := json.Unmarshal([]byte(rawInput), &logsArray); err == nil {
+	if startsJSONArray(rawLogs) {
+		rawInput := strings.Join(rawLogs, "\n")
+		if err := decodeJSON([]byte(rawInput), &logsArray); err != nil {
+			logsArray = nil
+		}
+	}
+	if logsArray != nil {
 		for i, log := range logsArray {
 			rawLog, err := json.Marshal(log)
 			if err != nil {
 				return nil, fmt.Errorf("error marshalling log entry %d: %v", i+1, err)
 			}
-			parsedLogs = append(parsedLogs, ParsedLog{
+			parsedLogs = append(parsedLogs, redactLog(withSchemas(ParsedLog{
 				RawLog:     string(rawLog),
 				Fields:     log,
 				LineNumber: i + 1,
-			})
+			}.indexed(), opts.schemas), opts.redact))
 		}
+		assignIDs(parsedLogs, opts.source)
+		setSource(parsedLogs, opts.origin)
+		addPodFields(parsedLogs, opts.podFields)
 		return parsedLogs, nil
 	}
 
-	// Fall back to parsing each line individually
-	for i, line := range rawLogs {
-		if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
-			parsedLog, err := ParseLog(line, i+1)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error parsing log line %d: %v\n", i+1, err)
-				continue
-			}
-			parsedLogs = append(parsedLogs, parsedLog)
-		} else {
-			fmt.Fprintf(os.Stderr, "Skipping non-JSON log line %d: %s\n", i+1, line)
+	// Fall back to parsing each entry individually, after joining multi-line entries
+	chunks, err := joinMultiline(rawLogs, opts.multiline)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		parsedLog, err := parseLine(chunk.text, chunk.lineNumber)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping log line %d: %v\n", chunk.lineNumber, err)
+			continue
 		}
+		parsedLogs = append(parsedLogs, redactLog(withSchemas(parsedLog, opts.schemas), opts.redact))
 	}
 
 	if len(parsedLogs) == 0 {
-		return nil, fmt.Errorf("no valid JSON logs found")
+		return nil, fmt.Errorf("no valid logs found")
 	}
 
+	assignIDs(parsedLogs, opts.source)
+	setSource(parsedLogs, opts.origin)
+	addPodFields(parsedLogs, opts.podFields)
 	return parsedLogs, nil
 }
+
+// setSource records where the logs were read from on each of them.
+func setSource(logs []ParsedLog, origin Source) {
+	for i := range logs {
+		logs[i].Source = origin
+	}
+}
+
+// parseLine parses a single line as JSON or, failing that, as an Istio text log.
+// A timestamp prefix added by the kubelet (`kubectl logs --timestamps`) is
+// stripped from the raw log and kept as the entry's KubeTimestamp.
+func parseLine(line string, lineNumber int) (ParsedLog, error) {
+	kubeTimestamp, body, hasTimestamp := splitKubeTimestamp(line)
+	if hasTimestamp {
+		line = body
+	}
+
+	// Entries joined from several lines keep the continuation (e.g. a stack
+	// trace) in a field of their own
+	head, continuation, multiline := strings.Cut(line, "\n")
+
+	var parsedLog ParsedLog
+	var err error
+	if strings.HasPrefix(head, "{") && strings.HasSuffix(head, "}") {
+		parsedLog, err = ParseLog(head, lineNumber)
+	} else {
+		parsedLog, err = ParseTextLog(head, lineNumber)
+	}
+	if err != nil {
+		return ParsedLog{}, err
+	}
+
+	if multiline {
+		parsedLog.RawLog = line
+		parsedLog.Fields["stack_trace"] = continuation
+	}
+	parsedLog.KubeTimestamp = kubeTimestamp
+	return parsedLog.indexed(), nil
+}
+
+// splitKubeTimestamp splits the "<RFC3339Nano> " prefix the kubelet adds when
+// timestamps are requested from the log API.
+func splitKubeTimestamp(line string) (time.Time, string, bool) {
+	prefix, body, found := strings.Cut(line, " ")
+	if !found {
+		return time.Time{}, line, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	return timestamp, body, true
+}
+
+// eventTime returns when the logged event happened, preferring timestamps in
+// the log body and falling back to the kubelet timestamp.
+func eventTime(log ParsedLog) (time.Time, bool) {
+	for _, key := range []string{"start_time", "timestamp", "time", "ts"} {
+		if value, ok := log.Fields[key].(string); ok && value != "" {
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	if !log.KubeTimestamp.IsZero() {
+		return log.KubeTimestamp, true
+	}
+	return time.Time{}, false
+}
+
+// startsJSONArray reports whether the first non-blank line opens a JSON array.
+func startsJSONArray(rawLogs []string) bool {
+	for _, line := range rawLogs {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			return strings.HasPrefix(trimmed, "[")
+		}
+	}
+	return false
+}
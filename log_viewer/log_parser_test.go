@@ -2,7 +2,11 @@
 package main
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseRawLogs(t *testing.T) {
@@ -158,3 +162,200 @@ func equalFields(a, b map[string]interface{}) bool {
 	}
 	return true
 }
+
+func TestParseTextLog(t *testing.T) {
+	ztunnelLine := "2024-05-04T09:59:05.028709Z\tinfo\taccess\tconnection complete\t" +
+		`src.addr=10.244.0.33:50676 src.workload="sleep-7656cf8794-r7zb9" src.identity="spiffe://cluster.local/ns/default/sa/sleep" ` +
+		`dst.hbone_addr="10.244.0.29:9080" dst.service="productpage.default.svc.cluster.local" direction="inbound" bytes_sent=175 duration="1ms"`
+
+	tests := []struct {
+		name     string
+		line     string
+		expected map[string]interface{}
+		profile  logProfile
+		wantErr  bool
+	}{
+		{
+			name: "istiod log without scope",
+			line: "2024-11-25T19:47:07.374828Z\tinfo\tFLAG: --concurrency=\"0\"",
+			expected: map[string]interface{}{
+				"timestamp": "2024-11-25T19:47:07.374828Z",
+				"level":     "info",
+				"message":   `FLAG: --concurrency="0"`,
+			},
+			profile: profileGeneric,
+		},
+		{
+			name: "ztunnel access log",
+			line: ztunnelLine,
+			expected: map[string]interface{}{
+				"timestamp":      "2024-05-04T09:59:05.028709Z",
+				"level":          "info",
+				"scope":          "access",
+				"message":        "connection complete",
+				"src.addr":       "10.244.0.33:50676",
+				"src.workload":   "sleep-7656cf8794-r7zb9",
+				"src.identity":   "spiffe://cluster.local/ns/default/sa/sleep",
+				"dst.hbone_addr": "10.244.0.29:9080",
+				"dst.service":    "productpage.default.svc.cluster.local",
+				"direction":      "inbound",
+				"bytes_sent":     json.Number("175"),
+				"duration":       "1ms",
+			},
+			profile: profileZtunnel,
+		},
+		{
+			name:    "Not a text log",
+			line:    "invalid json",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTextLog(tt.line, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTextLog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !equalFields(got.Fields, tt.expected) {
+				t.Errorf("ParseTextLog() fields = %v, expected %v", got.Fields, tt.expected)
+			}
+			if profile := detectProfile(got); profile != tt.profile {
+				t.Errorf("detectProfile() = %s, expected %s", profile, tt.profile)
+			}
+		})
+	}
+}
+
+func TestWaypointDetailFields(t *testing.T) {
+	log := ParsedLog{Fields: map[string]interface{}{
+		"upstream_cluster": "inbound-vip|9080|http|reviews.default.svc.cluster.local",
+	}}
+
+	profile := detectProfile(log)
+	if profile != profileWaypoint {
+		t.Fatalf("detectProfile() = %s, expected %s", profile, profileWaypoint)
+	}
+
+	fields := detailFields(log, profile)
+	if fields["waypoint.service"] != "reviews.default.svc.cluster.local" || fields["waypoint.port"] != "9080" {
+		t.Errorf("detailFields() = %v, expected waypoint service and port", fields)
+	}
+	if _, ok := log.Fields["waypoint.service"]; ok {
+		t.Errorf("detailFields() must not modify the log's own fields")
+	}
+}
+
+func TestGenericDetailGroups(t *testing.T) {
+	log := ParsedLog{Fields: map[string]interface{}{
+		"msg":     "cache refreshed",
+		"level":   "info",
+		"ts":      "2024-11-26T02:30:58Z",
+		"user_id": "42",
+		"keys":    float64(12),
+	}}
+
+	profile := detectProfile(log)
+	if profile != profileGeneric {
+		t.Fatalf("detectProfile() = %s, expected %s", profile, profileGeneric)
+	}
+
+	groups := detailGroupsFor(profile, log.Fields)
+	expected := []fieldGroup{
+		{name: "Event", fields: []string{"ts", "level", "msg"}},
+		{name: "Fields", fields: []string{"keys", "user_id"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("detailGroupsFor() = %v, expected %v", groups, expected)
+	}
+
+	// A single access log field is enough to keep the Istio layout
+	log.Fields["response_code"] = float64(200)
+	if profile := detectProfile(log); profile != profileSidecar {
+		t.Errorf("detectProfile() = %s, expected %s", profile, profileSidecar)
+	}
+}
+
+func TestKubeTimestampPrefix(t *testing.T) {
+	body := `{"level":"info","message":"no timestamp here"}`
+	parsedLog, err := parseLine("2024-11-26T02:30:58.502123456Z "+body, 1)
+	if err != nil {
+		t.Fatalf("parseLine() unexpected error: %v", err)
+	}
+	if parsedLog.RawLog != body {
+		t.Errorf("expected the kubelet prefix to be stripped from RawLog, got %s", parsedLog.RawLog)
+	}
+
+	got, ok := eventTime(parsedLog)
+	expected := time.Date(2024, 11, 26, 2, 30, 58, 502123456, time.UTC)
+	if !ok || !got.Equal(expected) {
+		t.Errorf("eventTime() = %v, %v, expected the kubelet timestamp %v", got, ok, expected)
+	}
+
+	// A timestamp in the body wins over the kubelet prefix
+	parsedLog, err = parseLine(`2024-11-26T02:30:58Z {"start_time":"2024-11-26T02:30:00.000Z"}`, 1)
+	if err != nil {
+		t.Fatalf("parseLine() unexpected error: %v", err)
+	}
+	got, _ = eventTime(parsedLog)
+	if got.Minute() != 30 || got.Second() != 0 {
+		t.Errorf("eventTime() = %v, expected start_time from the body", got)
+	}
+}
+
+func TestParseMultilineEntries(t *testing.T) {
+	rawLogs := []string{
+		`{"level":"error","message":"request failed"}`,
+		`java.lang.IllegalStateException: boom`,
+		`	at com.example.Handler.handle(Handler.java:42)`,
+		`	at com.example.Server.run(Server.java:7)`,
+		`{`,
+		`  "level": "info",`,
+		`  "message": "pretty {printed}"`,
+		`}`,
+		`{"level":"info","message":"single line"}`,
+	}
+
+	got, err := parseRawLogs(rawLogs)
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %v", len(got), got)
+	}
+
+	trace, _ := got[0].Fields["stack_trace"].(string)
+	if !strings.Contains(trace, "IllegalStateException") || !strings.Contains(trace, "Server.java:7") {
+		t.Errorf("expected stack frames in stack_trace, got %q", trace)
+	}
+
+	if got[1].LineNumber != 5 || got[1].Fields["message"] != "pretty {printed}" {
+		t.Errorf("expected pretty-printed JSON from line 5, got line %d with %v", got[1].LineNumber, got[1].Fields)
+	}
+	if got[2].LineNumber != 9 {
+		t.Errorf("expected the last entry to keep line number 9, got %d", got[2].LineNumber)
+	}
+
+	// Disabling the stage parses every line on its own
+	got, err = parseRawLogsWith(rawLogs, parseOptions{multiline: MultilineConfig{Disabled: true}})
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 single-line entries with joining disabled, got %d", len(got))
+	}
+}
+
+// mustParse parses fixture lines with the default options, failing the test
+// when they do not parse.
+func mustParse(t *testing.T, lines ...string) []ParsedLog {
+	t.Helper()
+	logs, err := parseRawLogs(lines)
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	return logs
+}
@@ -3,18 +3,16 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
-	v1 "k8s.io/api/core/v1"
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Utility function to get environment variable with a fallback value
@@ -34,12 +32,8 @@ func detectInput() ([]string, bool, error) {
 
 	// Check if there's piped input
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		scanner := bufio.NewScanner(os.Stdin)
-		var lines []string
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
+		lines, err := readLines(os.Stdin)
+		if err != nil {
 			return nil, false, fmt.Errorf("error reading stdin: %v", err)
 		}
 		log.Println("Stdin detected with input:", lines)
@@ -49,76 +43,236 @@ func detectInput() ([]string, bool, error) {
 	return nil, false, nil
 }
 
-func getInputSource(envVarName, defaultValue string) ([]string, error) {
-	lines, stdinDetected, err := detectInput()
+// FetchLogsFromK8s retrieves logs for a specific pod and container from Kubernetes.
+// Extra options (timestamps, tail, byte limit) are passed through to the source.
+func FetchLogsFromK8s(clientset kubernetes.Interface, namespace, podName, containerName string, extra ...k8ssource.Option) ([]string, error) {
+	source, err := k8ssource.NewSource(append([]k8ssource.Option{
+		k8ssource.WithClient(clientset),
+		k8ssource.WithNamespace(namespace),
+		k8ssource.WithPod(podName),
+		k8ssource.WithContainer(containerName),
+	}, extra...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	if stdinDetected {
-		return lines, nil
+	lines, err := source.Collect(context.TODO())
+	if err != nil {
+		return nil, explainKubeError(err)
 	}
 
-	envValue := getEnvWithFallback(envVarName, defaultValue)
-	return []string{envValue}, nil
+	logs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		logs = append(logs, line.Text)
+	}
+	return logs, nil
 }
 
-// CreateKubeClient initializes a Kubernetes client, supporting both in-cluster and local kubeconfig setups.
-func CreateKubeClient() (*kubernetes.Clientset, error) {
-	// Try in-cluster configuration first
-	config, err := rest.InClusterConfig()
+// StreamLogsFromK8s follows logs for a specific pod and container, sending each line on the returned channel.
+// The channel is closed when the stream ends or ctx is canceled; the returned function then reports why.
+func StreamLogsFromK8s(ctx context.Context, clientset kubernetes.Interface, namespace, podName, containerName string, extra ...k8ssource.Option) (<-chan string, func() error, error) {
+	source, err := k8ssource.NewSource(append([]k8ssource.Option{
+		k8ssource.WithClient(clientset),
+		k8ssource.WithNamespace(namespace),
+		k8ssource.WithPod(podName),
+		k8ssource.WithContainer(containerName),
+		k8ssource.WithFollow(true),
+	}, extra...)...)
 	if err != nil {
-		// Fall back to local kubeconfig
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			home := os.Getenv("HOME")
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
-		}
+		return nil, nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	stream, err := source.Stream(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+		return nil, nil, explainKubeError(err)
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for line := range stream {
+			select {
+			case lines <- line.Text:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	streamErr := func() error {
+		return explainKubeError(source.Err())
 	}
-	return clientset, nil
+	return lines, streamErr, nil
 }
 
-// FetchLogsFromK8s retrieves logs for a specific pod and container from Kubernetes.
-func FetchLogsFromK8s(clientset *kubernetes.Clientset, namespace, podName, containerName string) ([]string, error) {
-	podLogOptions := &v1.PodLogOptions{
-		Container: containerName,
+// loadSettings parses flags, loads the config file and profile, applies the
+// theme and time display and resolves the merge options, markers and SLOs,
+// exiting the process on invalid input.
+func loadSettings(subcommand string, args []string) (cliOptions, Config) {
+	opts, err := parseFlags(subcommand, args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
 	}
 
-	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, podLogOptions)
-	logStream, err := req.Stream(context.TODO())
+	cfg, err := loadConfig(opts.configPath)
 	if err != nil {
-		return nil, fmt.Errorf("error streaming logs from pod: %v", err)
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		log.Println("Error loading config:", err)
+		os.Exit(1)
 	}
-	defer logStream.Close()
 
-	var buf bytes.Buffer
-	_, err = buf.ReadFrom(logStream)
+	profileName := opts.profile
+	if profileName == "" {
+		profileName = cfg.DefaultProfile
+	}
+	profile, err := cfg.selectProfile(profileName)
+	if err == nil {
+		err = applyProfile(profile, &opts, &cfg)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error reading log stream: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Split logs into lines
-	logLines := bytes.Split(buf.Bytes(), []byte("\n"))
-	var logs []string
-	for _, line := range logLines {
-		logs = append(logs, string(line))
+	themeName := opts.theme
+	if themeName == "" {
+		themeName = cfg.Theme
 	}
+	theme, err := selectTheme(themeName, opts.noColor)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	applyTheme(theme)
+	applyHighlights(cfg.Highlights, theme)
 
-	return logs, nil
+	zone, format := opts.timezone, opts.timeFormat
+	if zone == "" {
+		zone = cfg.Timezone
+	}
+	if format == "" {
+		format = cfg.TimeFormat
+	}
+	display, err := selectTimeDisplay(zone, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	activeTimeDisplay = display
+
+	skew, tieBreak := opts.mergeSkew, opts.mergeTieBreak
+	if skew == "" {
+		skew = cfg.Merge.Skew
+	}
+	if tieBreak == "" {
+		tieBreak = cfg.Merge.TieBreak
+	}
+	if opts.merge, err = selectMergeOptions(skew, tieBreak); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Clock times are in the zone the user reads timestamps in
+	if opts.markers, err = parseMarkers(opts.markerSpecs, display.location); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	specs := opts.sloSpecs
+	if len(specs) == 0 {
+		specs = cfg.SLOs
+	}
+	if opts.slos, err = parseSLOs(specs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return opts, cfg
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "exec":
+			runExec(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "summarize":
+			runSummarize(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "batch":
+			runBatch(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "export":
+			runExport(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "generate":
+			runGenerate(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "cluster-audit":
+			runAudit(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "find-request":
+			runFindRequest(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "waterfall":
+			runWaterfall(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "search":
+			runSearch(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "transform":
+			runTransform(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "serve":
+			runServe(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "attach":
+			runAttach(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "mesh":
+			runMesh(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "setup":
+			runSetup(loadSettings(os.Args[1], os.Args[2:]))
+			return
+		case "completion":
+			runCompletion(os.Args[2:])
+			return
+		case completeCommand:
+			runComplete(os.Args[2:])
+			return
+		}
+	}
+
+	opts, cfg := loadSettings("", os.Args[1:])
+
+	// Log files named on the command line are merged by time
+	if len(opts.args) > 0 {
+		lines, source, err := batchInput(opts, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			log.Println("Error reading log files:", err)
+			os.Exit(1)
+		}
+		parsedLogs, err := parseBatch(lines, source, opts, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing logs: %v\n", err)
+			log.Println("Error parsing logs:", err)
+			os.Exit(1)
+		}
+		model := newModel(opts, cfg)
+		model.logs = parsedLogs
+		model.filteredLogs = parsedLogs
+		model.overhead = overheadOf(parsedLogs)
+		runTUI(model)
+		return
+	}
+
 	// Check for stdin input first
-	rawLogs, err := getInputSource("MY_ENV_VAR", "default_value")
+	rawLogs, stdinDetected, err := detectInput()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		log.Println("Error getting input source:", err)
@@ -126,52 +280,143 @@ func main() {
 	}
 
 	// If no stdin input is detected, check for Kubernetes environment variables
-	if len(rawLogs) == 0 {
+	if !stdinDetected {
 		podName := os.Getenv("PLUGIN_POD")
 		namespace := os.Getenv("PLUGIN_NAMESPACE")
 		containerName := os.Getenv("PLUGIN_CONTAINER")
+		follow := getEnvWithFallback("PLUGIN_FOLLOW", "false") == "true"
 
 		if podName != "" && namespace != "" && containerName != "" {
 			log.Println("Using Kubernetes mode with pod:", podName, "namespace:", namespace, "container:", containerName)
-			clientset, err := CreateKubeClient()
+			clientset, err := CreateKubeClient(opts.kube)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
 				log.Println("Error creating Kubernetes client:", err)
 				os.Exit(1)
 			}
 
-			rawLogs, err = FetchLogsFromK8s(clientset, namespace, podName, containerName)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
-				log.Println("Error fetching logs:", err)
-				os.Exit(1)
+			if !opts.skipAccessCheck {
+				if err := checkAccess(context.TODO(), clientset, requiredRules(namespace, false)); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					log.Println("Access check failed:", err)
+					os.Exit(1)
+				}
+				reportOptionalAccess(context.TODO(), clientset, optionalRules(namespace, true))
+			}
+
+			// Fetch errors from here on are shown in the TUI, where they can be retried
+			target := &kubeTarget{
+				client:     clientset,
+				namespace:  namespace,
+				pod:        podName,
+				container:  containerName,
+				follow:     follow,
+				backfill:   opts.backfill,
+				sourceOpts: opts.sourceOptions(),
+				parseOpts:  parseOptionsFor(opts, cfg).withOrigin(Source{Cluster: opts.kube.clusterName()}),
 			}
+			model := newModel(opts, cfg)
+			model.target = target
+			model.loading = true
+			runTUI(model)
+			return
 		} else {
 			log.Println("No Kubernetes environment variables set and no stdin input detected")
 			fmt.Fprintf(os.Stderr, "No input source detected\n")
+			if _, err := os.Stat(opts.configPath); errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(os.Stderr, "First run? `log_viewer setup` writes a config for your cluster")
+			}
 			os.Exit(1)
 		}
 	}
 
 	log.Println("Raw logs:", rawLogs)
 
-	parsedLogs, err := parseRawLogs(rawLogs)
+	parsedLogs, err := parseRawLogsWith(rawLogs, parseOptionsFor(opts, cfg).from("stdin"))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing logs: %v\n", err)
 		log.Println("Error parsing logs:", err)
 		os.Exit(1)
 	}
 
-	model := Model{
-		logs:         parsedLogs,
-		filteredLogs: parsedLogs,
+	log.Println("Starting TUI with logs:", parsedLogs)
+	model := newModel(opts, cfg)
+	model.logs = parsedLogs
+	model.filteredLogs = parsedLogs
+	model.overhead = overheadOf(parsedLogs)
+	runTUI(model)
+}
+
+// newModel returns an empty model with the user's settings, search history
+// and notes.
+func newModel(opts cliOptions, cfg Config) Model {
+	notes, err := loadNotes(opts.notesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error loading notes:", err)
+		os.Exit(1)
+	}
+
+	sinks, err := startSinks(opts.sinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error opening sinks:", err)
+		os.Exit(1)
 	}
 
-	log.Println("Starting TUI with logs:", parsedLogs)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	multiline, err := newLineJoiner(cfg.Multiline)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error reading multiline config:", err)
+		os.Exit(1)
+	}
+
+	historyPath := defaultHistoryPath()
+	layoutsPath := defaultLayoutsPath(opts.statePath)
+	return Model{
+		keys:         cfg.Keys,
+		links:        cfg.Links,
+		jwtFields:    cfg.JWTFields,
+		redact:       redactRulesFor(opts, cfg),
+		schemas:      parseOptionsFor(opts, cfg).schemas,
+		multiline:    multiline,
+		reverseDNS:   opts.reverseDNS,
+		history:      loadHistory(historyPath),
+		historyPath:  historyPath,
+		layouts:      loadLayouts(layoutsPath),
+		layoutsPath:  layoutsPath,
+		notes:        notes,
+		notesPath:    opts.notesPath,
+		sinks:        sinks,
+		alerts:       newAlerter(cfg.Alerts, opts.notifyURL, opts.notifyFormat),
+		markers:      opts.markers,
+		slos:         opts.slos,
+		healthChecks: opts.healthChecks,
+	}
+}
+
+// runTUI starts the Bubble Tea program and exits the process if it fails.
+// A panic while running leaves a crash report instead of a corrupted terminal.
+func runTUI(model Model) {
+	report := &crashReport{}
+	p := tea.NewProgram(crashGuard{model: model, report: report}, tea.WithAltScreen())
+	report.program = p
+
 	if err := p.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting TUI: %v\n", err)
 		log.Println("Error starting TUI:", err)
 		os.Exit(1)
 	}
+	model.sinks.close()
+
+	if report.crashed() {
+		path, err := report.write(os.TempDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log_viewer crashed unexpectedly and the crash report could not be saved: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "log_viewer crashed unexpectedly. A crash report was written to %s\n", path)
+		fmt.Fprintln(os.Stderr, "Please attach it when filing an issue.")
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,146 @@
+// log_viewer/marker.go
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+// marker is a labeled point in time shown among the entries, such as a
+// deploy, so anomalies in the logs can be matched to what changed.
+type marker struct {
+	Time  time.Time
+	Label string
+	Clock bool // Only a time of day was given, see resolveMarkers
+	Event bool // From a Kubernetes Event, replaced when the target reloads
+}
+
+// parseMarker reads a -marker value: a time, RFC 3339 or a clock time such
+// as 12:03 or 12:03:30 in the display timezone, then the label, as in
+// "12:03 rollout reviews-v2".
+func parseMarker(value string, location *time.Location) (marker, error) {
+	at, label, _ := strings.Cut(strings.TrimSpace(value), " ")
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return marker{}, fmt.Errorf("invalid marker %q, expected a time and a label such as \"12:03 rollout reviews-v2\"", value)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, at); err == nil {
+		return marker{Time: t, Label: label}, nil
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, at, location); err == nil {
+			return marker{Time: t, Label: label, Clock: true}, nil
+		}
+	}
+	return marker{}, fmt.Errorf("invalid marker time %q, expected RFC 3339 or a clock time such as 12:03", at)
+}
+
+// parseMarkers reads every -marker value.
+func parseMarkers(values []string, location *time.Location) ([]marker, error) {
+	var markers []marker
+	for _, value := range values {
+		m, err := parseMarker(value, location)
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, m)
+	}
+	return markers, nil
+}
+
+// resolveMarkers returns the markers in time order, with clock times taken on
+// the day of the first timestamped entry. Clock times are dropped when no
+// entry has a timestamp to give them a day.
+func resolveMarkers(markers []marker, logs []ParsedLog) []marker {
+	var day time.Time
+	for _, entry := range logs {
+		if t, ok := eventTime(entry); ok {
+			day = t
+			break
+		}
+	}
+
+	resolved := make([]marker, 0, len(markers))
+	for _, m := range markers {
+		if m.Clock {
+			if day.IsZero() {
+				continue
+			}
+			local := day.In(m.Time.Location())
+			m.Time = time.Date(local.Year(), local.Month(), local.Day(),
+				m.Time.Hour(), m.Time.Minute(), m.Time.Second(), 0, m.Time.Location())
+			m.Clock = false
+		}
+		resolved = append(resolved, m)
+	}
+	slices.SortStableFunc(resolved, func(a, b marker) int { return a.Time.Compare(b.Time) })
+	return resolved
+}
+
+// withEventMarkers replaces the markers taken from Kubernetes Events by
+// events, keeping those given on the command line.
+func withEventMarkers(markers, events []marker) []marker {
+	kept := slices.DeleteFunc(slices.Clone(markers), func(m marker) bool { return m.Event })
+	return append(kept, events...)
+}
+
+// eventMarkers labels events like "ScalingReplicaSet deployment/reviews-v2:
+// Scaled up replica set reviews-v2-5d to 1".
+func eventMarkers(events []k8ssource.Event) []marker {
+	markers := make([]marker, 0, len(events))
+	for _, event := range events {
+		label := fmt.Sprintf("%s %s/%s: %s", event.Reason, strings.ToLower(event.Kind), event.Name, event.Message)
+		if event.Count > 1 {
+			label += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		markers = append(markers, marker{Time: event.Time, Label: label, Event: true})
+	}
+	return markers
+}
+
+// markersBefore returns the markers shown above entry i of logs: those after
+// the previous timestamped entry, up to entry i's own time. Entry len(logs)
+// stands for the end of the list, which takes the markers after every entry.
+// An entry without a timestamp has none above it.
+func markersBefore(markers []marker, logs []ParsedLog, i int) []marker {
+	if len(markers) == 0 {
+		return nil
+	}
+	var upper time.Time
+	if i < len(logs) {
+		var ok bool
+		if upper, ok = eventTime(logs[i]); !ok {
+			return nil
+		}
+	}
+	var lower time.Time
+	for j := min(i, len(logs)) - 1; j >= 0; j-- {
+		if t, ok := eventTime(logs[j]); ok {
+			lower = t
+			break
+		}
+	}
+
+	var found []marker
+	for _, m := range markers {
+		if (lower.IsZero() || m.Time.After(lower)) && (upper.IsZero() || !m.Time.After(upper)) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// renderMarker draws a marker as a rule of its own in a pane width wide.
+func renderMarker(m marker, width int) string {
+	inner := width - 4 // Borders and padding, as in fitPane
+	line := fmt.Sprintf("── %s %s ", activeTimeDisplay.clock(m.Time), m.Label)
+	if pad := inner - len([]rune(line)); pad > 0 {
+		line += strings.Repeat("─", pad)
+	}
+	return markerStyle.Render(truncate(line, inner))
+}
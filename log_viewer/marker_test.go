@@ -0,0 +1,131 @@
+// log_viewer/marker_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+func TestParseMarker(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	tests := []struct {
+		value   string
+		label   string
+		clock   bool
+		wantErr bool
+	}{
+		{"12:03 rollout reviews-v2", "rollout reviews-v2", true, false},
+		{"12:03:30  rollout", "rollout", true, false},
+		{"2024-05-01T12:03:00Z rollout", "rollout", false, false},
+		{"12:03", "", false, true},
+		{"noon rollout", "", false, true},
+	}
+	for _, tt := range tests {
+		m, err := parseMarker(tt.value, berlin)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMarker(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (m.Label != tt.label || m.Clock != tt.clock) {
+			t.Errorf("parseMarker(%q) = %+v, want label %q, clock %t", tt.value, m, tt.label, tt.clock)
+		}
+	}
+}
+
+func TestResolveMarkers(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	markers, err := parseMarkers([]string{"2024-05-01T09:00:00Z restart", "12:03 rollout reviews-v2"}, berlin)
+	if err != nil {
+		t.Fatalf("parseMarkers() unexpected error: %v", err)
+	}
+	logs := []ParsedLog{{Fields: map[string]interface{}{"start_time": "2024-05-01T10:00:00Z"}}}
+
+	got := resolveMarkers(markers, logs)
+	rollout := time.Date(2024, 5, 1, 10, 3, 0, 0, time.UTC)
+	if len(got) != 2 || got[0].Label != "restart" || !got[1].Time.Equal(rollout) {
+		t.Errorf("expected the restart then the rollout at %s, got %+v", rollout, got)
+	}
+	if got := resolveMarkers(markers, nil); len(got) != 1 {
+		t.Errorf("expected clock times dropped without a day to put them on, got %+v", got)
+	}
+}
+
+func TestEventMarkers(t *testing.T) {
+	events := eventMarkers([]k8ssource.Event{{
+		Time: time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC), Kind: "Deployment", Name: "reviews-v2",
+		Reason: "ScalingReplicaSet", Message: "Scaled up replica set reviews-v2-5d to 1", Count: 1,
+	}, {
+		Kind: "Pod", Name: "reviews-v2-abc", Reason: "Unhealthy", Message: "Readiness probe failed", Count: 3,
+	}})
+	if events[0].Label != "ScalingReplicaSet deployment/reviews-v2: Scaled up replica set reviews-v2-5d to 1" {
+		t.Errorf("unexpected label %q", events[0].Label)
+	}
+	if !strings.HasSuffix(events[1].Label, "(x3)") {
+		t.Errorf("expected repeated events counted, got %q", events[1].Label)
+	}
+
+	// A reload replaces the events but keeps the markers given as flags
+	markers := withEventMarkers([]marker{{Label: "rollout"}, {Label: "old", Event: true}}, events)
+	if len(markers) != 3 || markers[0].Label != "rollout" || markers[1].Label != events[0].Label {
+		t.Errorf("expected the flag marker and the new events, got %+v", markers)
+	}
+}
+
+func TestRenderLogListMarkers(t *testing.T) {
+	var logs []ParsedLog
+	for i, at := range []string{"12:00:00", "12:05:00", "12:10:00"} {
+		logs = append(logs, ParsedLog{
+			LineNumber: i + 1,
+			RawLog:     `{"path":"/a"}`,
+			Fields:     map[string]interface{}{"start_time": "2024-05-01T" + at + "Z", "path": "/a"},
+		})
+	}
+	markers := []marker{
+		{Time: time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC), Label: "rollout reviews-v2"},
+		{Time: time.Date(2024, 5, 1, 12, 20, 0, 0, time.UTC), Label: "rollback"},
+	}
+
+	list := renderLogList(logs, listAnnotations{markers: markers}, 2, 80, 20, false)
+	rollout, second := strings.Index(list, "rollout reviews-v2"), strings.Index(list, "  2:")
+	if rollout < 0 || second < 0 || rollout > second || strings.Index(list, "  1:") > rollout {
+		t.Errorf("expected the rollout between the first and second entries:\n%s", list)
+	}
+	if rollback := strings.Index(list, "rollback"); rollback < strings.Index(list, "3:") {
+		t.Errorf("expected the rollback after the last entry:\n%s", list)
+	}
+
+	// Markers never push the selected entry out of a short list
+	if list := renderLogList(logs, listAnnotations{markers: markers}, 2, 80, 6, false); !strings.Contains(list, "▶") {
+		t.Errorf("expected the selection kept in view:\n%s", list)
+	}
+}
+
+func TestSummaryMarkers(t *testing.T) {
+	var lines []string
+	for _, at := range []string{"12:01", "12:04", "12:06", "12:07"} {
+		lines = append(lines, `{"start_time":"2024-05-01T`+at+`:00Z","response_code":503,"response_flags":"UF"}`)
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	s := summarize(logs, "test")
+	s.markers = []marker{{Time: time.Date(2024, 5, 1, 12, 5, 30, 0, time.UTC), Label: "rollout reviews-v2"}}
+
+	var report bytes.Buffer
+	s.writeMarkdown(&report)
+	if !strings.Contains(report.String(), "| 2024-05-01T12:05:30Z | rollout reviews-v2 | 2 | 2 |") {
+		t.Errorf("expected the errors either side of the rollout, got:\n%s", report.String())
+	}
+}
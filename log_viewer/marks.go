@@ -0,0 +1,213 @@
+// log_viewer/marks.go
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Marks pick several entries for bulk operations: space marks or unmarks the
+// selected entry, and V pressed at both ends marks a range. The marked
+// entries are copied with y, hidden with x, and exported or noted together
+// from the command palette. Like notes, marks are keyed by EntryID.String().
+
+// clipboardOut is where the clipboard escape sequence is written, the
+// terminal running the TUI.
+var clipboardOut io.Writer = os.Stdout
+
+// toggleMark marks the selected entry, or unmarks it.
+func (m Model) toggleMark() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	key := m.selectedID().String()
+	marked := maps.Clone(m.marked)
+	if marked == nil {
+		marked = make(map[string]bool)
+	}
+	if marked[key] {
+		delete(marked, key)
+	} else {
+		marked[key] = true
+	}
+	m.marked = marked
+	return m
+}
+
+// markRange starts a range at the selected entry, or marks every listed
+// entry from the start of the range to the selected one.
+func (m Model) markRange() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	if m.rangeStart == nil {
+		id := m.selectedID()
+		m.rangeStart = &id
+		return m
+	}
+
+	from, to := indexOfID(m.filteredLogs, *m.rangeStart), m.selectedLogIndex
+	if from > to {
+		from, to = to, from
+	}
+	marked := maps.Clone(m.marked)
+	if marked == nil {
+		marked = make(map[string]bool)
+	}
+	for _, log := range m.filteredLogs[from : to+1] {
+		marked[log.ID.String()] = true
+	}
+	m.marked = marked
+	m.rangeStart = nil
+	return m
+}
+
+// clearMarks unmarks every entry.
+func (m Model) clearMarks() Model {
+	m.marked = nil
+	m.rangeStart = nil
+	return m
+}
+
+// markedLogs returns the marked entries in log order, or the selected entry
+// when none are marked.
+func (m Model) markedLogs() []ParsedLog {
+	if len(m.marked) == 0 {
+		if len(m.filteredLogs) == 0 {
+			return nil
+		}
+		return []ParsedLog{m.filteredLogs[m.selectedLogIndex]}
+	}
+	var logs []ParsedLog
+	for _, log := range m.logs {
+		if m.marked[log.ID.String()] {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+// rawLines joins the entries as they were logged, one per line.
+func rawLines(logs []ParsedLog) string {
+	var builder strings.Builder
+	for _, log := range logs {
+		builder.WriteString(log.RawLog)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// copyMarked puts the marked entries, or the selected one, on the clipboard.
+func (m Model) copyMarked() (Model, tea.Cmd) {
+	logs := m.markedLogs()
+	if len(logs) == 0 {
+		return m, nil
+	}
+	m.notice = fmt.Sprintf("Copied %s to the clipboard", entryCount(len(logs)))
+	return m, copyToClipboard(rawLines(logs))
+}
+
+// copyToClipboard returns a command setting the terminal's clipboard with an
+// OSC 52 escape sequence, which works over SSH and in most terminals.
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprintf(clipboardOut, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+		return nil
+	}
+}
+
+// exportMarked writes the marked entries, or the selected one, as they were
+// logged to a file in the working directory named after the time.
+func (m Model) exportMarked(now time.Time) tea.Cmd {
+	logs := m.markedLogs()
+	if len(logs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		path := fmt.Sprintf("log_viewer-entries-%s.log", now.Format("20060102-150405"))
+		if err := os.WriteFile(path, []byte(rawLines(logs)), 0o644); err != nil {
+			return viewExportedMsg{err: fmt.Errorf("error exporting entries: %v", err)}
+		}
+		return viewExportedMsg{what: entryCount(len(logs)), path: path}
+	}
+}
+
+// noteMarked opens the note prompt for every marked entry at once, such as
+// to bookmark them under one label.
+func (m Model) noteMarked() Model {
+	logs := m.markedLogs()
+	if len(logs) == 0 {
+		return m
+	}
+	editor := noteEditor{text: m.notes[logs[0].ID.String()]}
+	for _, log := range logs {
+		editor.ids = append(editor.ids, log.ID)
+	}
+	m.note = &editor
+	return m
+}
+
+// hideMarked hides the marked entries, or the selected one, from the view.
+// Like a filter it can be undone.
+func (m Model) hideMarked() Model {
+	logs := m.markedLogs()
+	if len(logs) == 0 {
+		return m
+	}
+	m = m.remember()
+	excluded := maps.Clone(m.excluded)
+	if excluded == nil {
+		excluded = make(map[string]bool)
+	}
+	for _, log := range logs {
+		excluded[log.ID.String()] = true
+	}
+	m.excluded = excluded
+	m = m.clearMarks()
+	return m.refilter()
+}
+
+// withoutExcluded drops the hidden entries from logs.
+func withoutExcluded(logs []ParsedLog, excluded map[string]bool) []ParsedLog {
+	if len(excluded) == 0 {
+		return logs
+	}
+	var kept []ParsedLog
+	for _, log := range logs {
+		if !excluded[log.ID.String()] {
+			kept = append(kept, log)
+		}
+	}
+	return kept
+}
+
+// entryCount returns e.g. "1 entry" or "3 entries".
+func entryCount(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
+
+// markCommands are the palette's bulk operations on the marked entries.
+func (m Model) markCommands() []paletteCommand {
+	if len(m.marked) == 0 {
+		return nil
+	}
+	count := entryCount(len(m.marked))
+	return []paletteCommand{
+		{title: "Copy " + count, run: func(m Model) (Model, tea.Cmd) { return m.copyMarked() }},
+		{title: "Export " + count, run: func(m Model) (Model, tea.Cmd) { return m, m.exportMarked(time.Now()) }},
+		{title: "Note on " + count, run: func(m Model) (Model, tea.Cmd) { return m.noteMarked(), nil }},
+		{title: "Hide " + count, run: func(m Model) (Model, tea.Cmd) { return m.hideMarked(), nil }},
+		{title: "Clear marks", run: func(m Model) (Model, tea.Cmd) { return m.clearMarks(), nil }},
+	}
+}
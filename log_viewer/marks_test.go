@@ -0,0 +1,132 @@
+// log_viewer/marks_test.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func markedModel(t *testing.T) Model {
+	t.Helper()
+	var lines []string
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		lines = append(lines, `{"path":"`+path+`"}`)
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions().from("stdin"))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	return Model{logs: logs, filteredLogs: logs, width: 120, height: 40}
+}
+
+func pressKeys(model Model, keys ...tea.KeyMsg) Model {
+	for _, key := range keys {
+		updated, _ := model.Update(key)
+		model = updated.(Model)
+	}
+	return model
+}
+
+var (
+	keySpace = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+	keyRange = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("V")}
+)
+
+func TestMarkEntries(t *testing.T) {
+	model := markedModel(t)
+
+	// Space marks /a, V at /c and again at /e marks /c to /e
+	model = pressKeys(model, keySpace, keyDown, keyDown, keyRange, keyDown, keyDown, keyRange)
+	var paths []string
+	for _, log := range model.markedLogs() {
+		paths = append(paths, log.Path())
+	}
+	if strings.Join(paths, ",") != "/a,/c,/d,/e" {
+		t.Fatalf("expected /a and /c to /e marked, got %v", paths)
+	}
+	if view := model.View(); !strings.Contains(view, "4 MARKED") || !strings.Contains(view, " •  1:") {
+		t.Errorf("expected the marks shown, got:\n%s", view)
+	}
+
+	// Space on a marked entry unmarks it
+	if model = pressKeys(model, keySpace); len(model.marked) != 3 {
+		t.Errorf("expected /e unmarked, got %v", model.marked)
+	}
+
+	// Space still types while searching
+	model = pressKeys(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}, keySpace)
+	if model.searchQuery != " " || len(model.marked) != 3 {
+		t.Errorf("expected space typed into the search, got %q", model.searchQuery)
+	}
+}
+
+func TestCopyMarked(t *testing.T) {
+	var out bytes.Buffer
+	defer func(previous io.Writer) { clipboardOut = previous }(clipboardOut)
+	clipboardOut = &out
+
+	// Without marks the selected entry is copied
+	model := markedModel(t)
+	model, cmd := model.perform(actionCopy)
+	cmd()
+	if want := base64.StdEncoding.EncodeToString([]byte(`{"path":"/a"}` + "\n")); out.String() != "\x1b]52;c;"+want+"\x07" {
+		t.Errorf("expected /a copied with OSC 52, got %q", out.String())
+	}
+	if !strings.Contains(model.notice, "1 entry") {
+		t.Errorf("expected a notice, got %q", model.notice)
+	}
+}
+
+func TestExportMarked(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	model := pressKeys(markedModel(t), keySpace, keyDown, keySpace)
+	msg := model.exportMarked(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))().(viewExportedMsg)
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		t.Fatalf("expected the entries written: %v", err)
+	}
+	if string(data) != "{\"path\":\"/a\"}\n{\"path\":\"/b\"}\n" {
+		t.Errorf("unexpected export %q", data)
+	}
+	updated, _ := model.Update(msg)
+	if notice := updated.(Model).notice; !strings.HasPrefix(notice, "2 entries exported to") {
+		t.Errorf("unexpected notice %q", notice)
+	}
+}
+
+func TestNoteAndHideMarked(t *testing.T) {
+	model := pressKeys(markedModel(t), keySpace, keyDown, keySpace)
+
+	model = model.noteMarked()
+	model = typePalette(model, "known")
+	model = pressKeys(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.notes["stdin#1"] != "known" || model.notes["stdin#2"] != "known" {
+		t.Fatalf("expected both marked entries noted, got %v", model.notes)
+	}
+
+	model = model.hideMarked()
+	if len(model.filteredLogs) != 3 || len(model.marked) != 0 || model.filteredLogs[0].Path() != "/c" {
+		t.Fatalf("expected /a and /b hidden and the marks cleared, got %d entries", len(model.filteredLogs))
+	}
+	if model = model.undo(); len(model.filteredLogs) != 5 {
+		t.Errorf("expected undo to show the hidden entries again, got %d", len(model.filteredLogs))
+	}
+}
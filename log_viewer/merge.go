@@ -0,0 +1,128 @@
+// log_viewer/merge.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tie-breaks choose between entries of different sources logged within the
+// skew tolerance of each other, whose timestamps cannot be trusted to order
+// them.
+const (
+	tieBreakOrder = "order" // The source named first, e.g. the client before the server
+	tieBreakName  = "name"  // The source whose name sorts first
+)
+
+var tieBreaks = []string{tieBreakOrder, tieBreakName}
+
+// MergeConfig is how entries of several sources are interleaved, overridden
+// by -merge-skew and -merge-tie-break.
+type MergeConfig struct {
+	Skew     string `json:"skew"`      // Clock skew tolerated between sources, e.g. 250ms
+	TieBreak string `json:"tie_break"` // See tieBreaks
+}
+
+// mergeOptions are the parsed MergeConfig.
+type mergeOptions struct {
+	skew     time.Duration
+	tieBreak string
+}
+
+// selectMergeOptions validates the skew tolerance and tie-break. Empty values
+// select no tolerance and the order sources were named in.
+func selectMergeOptions(skew, tieBreak string) (mergeOptions, error) {
+	opts := mergeOptions{tieBreak: tieBreakOrder}
+	if skew != "" {
+		d, err := time.ParseDuration(skew)
+		if err != nil || d < 0 {
+			return opts, fmt.Errorf("invalid merge skew %q, expected a duration such as 250ms", skew)
+		}
+		opts.skew = d
+	}
+	if tieBreak != "" {
+		if !isTieBreak(tieBreak) {
+			return opts, fmt.Errorf("unknown merge tie-break %q, expected one of %s", tieBreak, strings.Join(tieBreaks, ", "))
+		}
+		opts.tieBreak = tieBreak
+	}
+	return opts, nil
+}
+
+func isTieBreak(name string) bool {
+	for _, tieBreak := range tieBreaks {
+		if tieBreak == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeLogs interleaves the entries of several sources by event time. Each
+// source is in the order it was produced, which the merge keeps. The next
+// entry is the earliest of the sources' next entries, except that entries
+// within opts.skew of the earliest are treated as logged at the same time
+// and the tie-break picks among them.
+//
+// IDs are moved forward where needed so that they sort in the merged order:
+// the list, selection, stats and exports all see the same order.
+func mergeLogs(sources [][]ParsedLog, opts mergeOptions) []ParsedLog {
+	total := 0
+	for _, logs := range sources {
+		total += len(logs)
+	}
+	merged := make([]ParsedLog, 0, total)
+	next := make([]int, len(sources))
+
+	for len(merged) < total {
+		earliest := -1
+		for i, logs := range sources {
+			if next[i] < len(logs) && (earliest < 0 || logs[next[i]].ID.Time.Before(sources[earliest][next[earliest]].ID.Time)) {
+				earliest = i
+			}
+		}
+		horizon := sources[earliest][next[earliest]].ID.Time.Add(opts.skew)
+
+		pick := earliest
+		for i, logs := range sources {
+			if next[i] >= len(logs) || logs[next[i]].ID.Time.After(horizon) {
+				continue
+			}
+			if tiesBefore(logs[next[i]].ID, i, sources[pick][next[pick]].ID, pick, opts.tieBreak) {
+				pick = i
+			}
+		}
+
+		entry := sources[pick][next[pick]]
+		next[pick]++
+		if len(merged) > 0 {
+			entry.ID = idAfter(entry.ID, merged[len(merged)-1].ID)
+		}
+		merged = append(merged, entry)
+	}
+	return merged
+}
+
+// tiesBefore reports whether the entry with id, from the source at index,
+// wins a tie against other, from the source at otherIndex.
+func tiesBefore(id EntryID, index int, other EntryID, otherIndex int, tieBreak string) bool {
+	if tieBreak == tieBreakName && id.Source != other.Source {
+		return id.Source < other.Source
+	}
+	return index < otherIndex
+}
+
+// idAfter returns id, moved to just after previous when it would sort before
+// it. The nanosecond added is far below what the viewer shows.
+func idAfter(id, previous EntryID) EntryID {
+	if id.Compare(previous) > 0 {
+		return id
+	}
+	id.Time = previous.Time
+	if id.Compare(previous) <= 0 {
+		id.Time = id.Time.Add(time.Nanosecond)
+	}
+	return id
+}
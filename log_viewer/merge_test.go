@@ -0,0 +1,121 @@
+// log_viewer/merge_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeSource parses entries logged at the given offsets in milliseconds,
+// each with its source and line as its path.
+func mergeSource(t *testing.T, name string, offsets ...int) []ParsedLog {
+	t.Helper()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var lines []string
+	for i, offset := range offsets {
+		start := base.Add(time.Duration(offset) * time.Millisecond).Format(time.RFC3339Nano)
+		lines = append(lines, `{"start_time":"`+start+`","path":"`+name+`#`+string(rune('1'+i))+`"}`)
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions().from(name))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	return logs
+}
+
+func mergedPaths(logs []ParsedLog) []string {
+	var paths []string
+	for _, log := range logs {
+		paths = append(paths, log.Path())
+	}
+	return paths
+}
+
+func TestMergeLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts mergeOptions
+		want []string
+	}{
+		{"by time", mergeOptions{}, []string{"server#1", "client#1", "server#2", "client#2"}},
+		{"skew favors the first source", mergeOptions{skew: 20 * time.Millisecond}, []string{"client#1", "server#1", "client#2", "server#2"}},
+		{"skew with names", mergeOptions{skew: 20 * time.Millisecond, tieBreak: tieBreakName}, []string{"client#1", "server#1", "client#2", "server#2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mergeSource(t, "client", 10, 100)
+			server := mergeSource(t, "server", 0, 95)
+			merged := mergeLogs([][]ParsedLog{client, server}, tt.opts)
+			if got := mergedPaths(merged); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("mergeLogs() = %v, want %v", got, tt.want)
+			}
+			if !sort.SliceIsSorted(merged, func(i, j int) bool { return merged[i].ID.Compare(merged[j].ID) < 0 }) {
+				t.Errorf("expected IDs in merged order, got %v", merged)
+			}
+		})
+	}
+}
+
+func TestMergeLogsTieBreak(t *testing.T) {
+	b := mergeSource(t, "b", 0)
+	a := mergeSource(t, "a", 0)
+	if got := mergedPaths(mergeLogs([][]ParsedLog{b, a}, mergeOptions{tieBreak: tieBreakOrder})); !reflect.DeepEqual(got, []string{"b#1", "a#1"}) {
+		t.Errorf("expected the source named first to win, got %v", got)
+	}
+	if got := mergedPaths(mergeLogs([][]ParsedLog{b, a}, mergeOptions{tieBreak: tieBreakName})); !reflect.DeepEqual(got, []string{"a#1", "b#1"}) {
+		t.Errorf("expected the source sorting first to win, got %v", got)
+	}
+}
+
+func TestSelectMergeOptions(t *testing.T) {
+	opts, err := selectMergeOptions("", "")
+	if err != nil || opts != (mergeOptions{tieBreak: tieBreakOrder}) {
+		t.Errorf("selectMergeOptions() = %+v, %v, want no skew in order", opts, err)
+	}
+	opts, err = selectMergeOptions("250ms", tieBreakName)
+	if err != nil || opts != (mergeOptions{skew: 250 * time.Millisecond, tieBreak: tieBreakName}) {
+		t.Errorf("selectMergeOptions() = %+v, %v", opts, err)
+	}
+	for _, args := range [][2]string{{"soon", ""}, {"-1s", ""}, {"", "random"}} {
+		if _, err := selectMergeOptions(args[0], args[1]); err == nil {
+			t.Errorf("selectMergeOptions(%q, %q) expected an error", args[0], args[1])
+		}
+	}
+}
+
+func TestParseBatchMergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	client := filepath.Join(dir, "client.log")
+	server := filepath.Join(dir, "server.log")
+	writeFile := func(path string, lines ...string) {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(client,
+		`{"start_time":"2024-05-01T12:00:00.010Z","path":"/a"}`,
+		`{"start_time":"2024-05-01T12:00:00.100Z","path":"/c"}`)
+	writeFile(server, `{"start_time":"2024-05-01T12:00:00.050Z","path":"/b"}`)
+
+	opts := cliOptions{args: []string{client, server}}
+	lines, source, err := batchInput(opts, Config{})
+	if err != nil {
+		t.Fatalf("batchInput() unexpected error: %v", err)
+	}
+	logs, err := parseBatch(lines, source, opts, Config{})
+	if err != nil {
+		t.Fatalf("parseBatch() unexpected error: %v", err)
+	}
+	if got := mergedPaths(logs); !reflect.DeepEqual(got, []string{"/a", "/b", "/c"}) {
+		t.Fatalf("parseBatch() = %v, expected the files interleaved by time", got)
+	}
+	if logs[1].ID.Source != server || logs[1].Source.File != server || logs[1].ID.Seq != 1 {
+		t.Errorf("expected the server entry to keep its file and line, got %+v", logs[1])
+	}
+}
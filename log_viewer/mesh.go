@@ -0,0 +1,311 @@
+// log_viewer/mesh.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The mesh overview samples the access logs of every ingress gateway, and
+// optionally of some sidecars, over a short window and shows how each
+// namespace's services answered, to find the one melting down.
+
+// defaultMeshWindow is how far back the mesh overview reads.
+const defaultMeshWindow = 5 * time.Minute
+
+// responseClasses are the heatmap's columns; none is a request that got no
+// response, such as a reset or a timeout.
+var responseClasses = []string{"2xx", "3xx", "4xx", "5xx", "none"}
+
+// heatShades fill a cell by its share of the namespace's requests.
+var heatShades = []string{"·", "░", "▒", "▓", "█"}
+
+// unknownNamespace collects requests whose destination is not a cluster-local
+// service, such as to external hosts.
+const unknownNamespace = "(unknown)"
+
+// responseClass returns the column of an access log entry.
+func responseClass(log ParsedLog) (string, bool) {
+	code, ok := log.ResponseCode()
+	switch {
+	case !ok:
+		return "", false
+	case code == 0:
+		return "none", true
+	case code >= 200 && code < 600:
+		return fmt.Sprintf("%dxx", code/100), true
+	}
+	return "", false
+}
+
+// destinationNamespace returns the namespace of the service a request went
+// to: from the outbound cluster or authority, or for inbound requests the
+// namespace of the pod that logged them.
+func destinationNamespace(log ParsedLog) string {
+	if namespace := commandValues(log)["namespace"]; namespace != "" {
+		return namespace
+	}
+	if strings.HasPrefix(log.UpstreamCluster(), "inbound|") && log.Source.Namespace != "" {
+		return log.Source.Namespace
+	}
+	return unknownNamespace
+}
+
+// meshHeatmap counts requests by destination namespace and response class.
+type meshHeatmap struct {
+	counts  map[string]map[string]int
+	window  time.Duration
+	sampled int // Pods read
+}
+
+// buildHeatmap counts the access log entries of logs.
+func buildHeatmap(logs []ParsedLog) meshHeatmap {
+	h := meshHeatmap{counts: make(map[string]map[string]int)}
+	for _, entry := range logs {
+		class, ok := responseClass(entry)
+		if !ok {
+			continue
+		}
+		namespace := destinationNamespace(entry)
+		if h.counts[namespace] == nil {
+			h.counts[namespace] = make(map[string]int)
+		}
+		h.counts[namespace][class]++
+	}
+	return h
+}
+
+// failureRate is the share of a namespace's requests that failed on the
+// server side or got no response.
+func (h meshHeatmap) failureRate(namespace string) float64 {
+	row := h.counts[namespace]
+	total := 0
+	for _, count := range row {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(row["5xx"]+row["none"]) / float64(total)
+}
+
+// namespaces lists the rows, the most failing first.
+func (h meshHeatmap) namespaces() []string {
+	var namespaces []string
+	for namespace := range h.counts {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		a, b := h.failureRate(namespaces[i]), h.failureRate(namespaces[j])
+		if a != b {
+			return a > b
+		}
+		return namespaces[i] < namespaces[j]
+	})
+	return namespaces
+}
+
+// heatCell draws a count shaded by its share of total, colored by class.
+func heatCell(class string, count, total int) string {
+	shade := heatShades[0]
+	if count > 0 {
+		shade = heatShades[1+min(count*(len(heatShades)-1)/total, len(heatShades)-2)]
+	}
+	style := jsonNumberStyle
+	switch {
+	case count == 0:
+		style = jsonNullStyle
+	case class == "5xx" || class == "none":
+		style = heatErrorStyle
+	case class == "4xx":
+		style = heatWarnStyle
+	}
+	return style.Render(fmt.Sprintf("%s %6d", shade, count))
+}
+
+// render writes the heatmap as a table.
+func (h meshHeatmap) render(w io.Writer) {
+	fmt.Fprintf(w, "%s\n\n", headerStyle.Render(fmt.Sprintf(
+		"Responses by destination namespace over the last %s, from %d pods", h.window, h.sampled)))
+	namespaces := h.namespaces()
+	if len(namespaces) == 0 {
+		fmt.Fprintln(w, "No access log entries in the window.")
+		return
+	}
+
+	nameWidth := len("Namespace")
+	for _, namespace := range namespaces {
+		nameWidth = max(nameWidth, len(namespace))
+	}
+	fmt.Fprintf(w, "%-*s", nameWidth, "Namespace")
+	for _, class := range responseClasses {
+		fmt.Fprintf(w, "  %8s", class)
+	}
+	fmt.Fprintf(w, "  %8s\n", "failing")
+
+	for _, namespace := range namespaces {
+		row := h.counts[namespace]
+		total := 0
+		for _, count := range row {
+			total += count
+		}
+		fmt.Fprintf(w, "%-*s", nameWidth, namespace)
+		for _, class := range responseClasses {
+			fmt.Fprintf(w, "  %s", heatCell(class, row[class], total))
+		}
+		rate := fmt.Sprintf("%7.1f%%", 100*h.failureRate(namespace))
+		if row["5xx"]+row["none"] > 0 {
+			rate = heatErrorStyle.Render(rate)
+		}
+		fmt.Fprintf(w, "  %s\n", rate)
+	}
+}
+
+// hasSidecar reports whether the pod runs the Istio proxy.
+func hasSidecar(pod v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == sidecarContainer {
+			return true
+		}
+	}
+	return false
+}
+
+// isGateway reports whether the pod is an ingress gateway: labeled as one,
+// or a pod of one of the profile's gateways.
+func isGateway(pod v1.Pod, gateways []gatewayRef) bool {
+	if pod.Labels["istio"] == "ingressgateway" {
+		return true
+	}
+	for _, gateway := range gateways {
+		if pod.Namespace == gateway.namespace && strings.HasPrefix(pod.Name, gateway.name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// meshTargets lists the ingress gateway pods, then up to sidecars other pods
+// with a sidecar, taken from each namespace in turn so the sample spreads
+// over the mesh.
+func meshTargets(ctx context.Context, client kubernetes.Interface, gateways []gatewayRef, sidecars int) ([]searchTarget, error) {
+	pods, err := k8ssource.ListPods(ctx, client, "", "", 0)
+	if err != nil {
+		return nil, explainKubeError(err)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	var targets []searchTarget
+	byNamespace := make(map[string][]searchTarget)
+	var namespaces []string
+	for _, pod := range pods {
+		if !hasSidecar(pod) {
+			continue
+		}
+		target := searchTarget{namespace: pod.Namespace, pod: pod.Name}
+		if isGateway(pod, gateways) {
+			targets = append(targets, target)
+			continue
+		}
+		if !slices.Contains(namespaces, pod.Namespace) {
+			namespaces = append(namespaces, pod.Namespace)
+		}
+		byNamespace[pod.Namespace] = append(byNamespace[pod.Namespace], target)
+	}
+
+	for round := 0; sidecars > 0; round++ {
+		took := false
+		for _, namespace := range namespaces {
+			if sidecars > 0 && round < len(byNamespace[namespace]) {
+				targets = append(targets, byNamespace[namespace][round])
+				sidecars--
+				took = true
+			}
+		}
+		if !took {
+			break
+		}
+	}
+	return targets, nil
+}
+
+// runMesh implements `log_viewer mesh [flags]`, the mesh-wide heatmap of
+// responses over the last -window.
+func runMesh(opts cliOptions, cfg Config) {
+	clientset, err := CreateKubeClient(opts.kube)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
+		log.Println("Error creating Kubernetes client:", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	targets, err := meshTargets(ctx, clientset, opts.gateways, opts.sampleSidecars)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error listing pods:", err)
+		os.Exit(1)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no ingress gateways found, name them in a profile or pass -sample-sidecars")
+		os.Exit(1)
+	}
+	if err := withTargetPodFields(ctx, clientset, targets, cfg.PodFields); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if !opts.skipAccessCheck {
+		var checked []string
+		var optional []optionalRule
+		for _, target := range targets {
+			if slices.Contains(checked, target.namespace) {
+				continue
+			}
+			checked = append(checked, target.namespace)
+			if err := checkAccess(ctx, clientset, requiredRules(target.namespace, false)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				log.Println("Access check failed:", err)
+				os.Exit(1)
+			}
+			optional = append(optional, optionalRules(target.namespace, false)...)
+		}
+		reportOptionalAccess(ctx, clientset, optional)
+	}
+
+	sourceOpts := append(opts.sourceOptions(), k8ssource.WithSince(opts.window))
+	fetch := func(ctx context.Context, target searchTarget) ([]string, error) {
+		return FetchLogsFromK8s(clientset, target.namespace, target.pod, sidecarContainer, sourceOpts...)
+	}
+	parseOpts := parseOptionsFor(opts, cfg).withOrigin(Source{Cluster: opts.kube.clusterName()})
+
+	var logs []ParsedLog
+	for result := range searchPods(ctx, targets, "", opts.workers, fetch, parseOpts) {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", result.target, result.err)
+			log.Println("Error reading", result.target, result.err)
+			continue
+		}
+		logs = append(logs, result.matches...)
+	}
+
+	heatmap := buildHeatmap(logs)
+	heatmap.window, heatmap.sampled = opts.window, len(targets)
+	heatmap.render(os.Stdout)
+}
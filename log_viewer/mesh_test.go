@@ -0,0 +1,75 @@
+// log_viewer/mesh_test.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMeshHeatmap(t *testing.T) {
+	lines := []string{
+		`{"response_code":200,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}`,
+		`{"response_code":200,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}`,
+		`{"response_code":503,"upstream_cluster":"outbound|8080||checkout.payments.svc.cluster.local"}`,
+		`{"response_code":0,"upstream_cluster":"outbound|8080||checkout.payments.svc.cluster.local"}`,
+		`{"response_code":404,"authority":"api.example.com"}`,
+		`{"level":"info","msg":"not an access log"}`,
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	heatmap := buildHeatmap(logs)
+	if got := heatmap.namespaces(); strings.Join(got, ",") != "payments,(unknown),bookinfo" {
+		t.Errorf("expected the failing namespace first, got %v", got)
+	}
+	if heatmap.counts["payments"]["5xx"] != 1 || heatmap.counts["payments"]["none"] != 1 || heatmap.counts["bookinfo"]["2xx"] != 2 {
+		t.Errorf("unexpected counts %v", heatmap.counts)
+	}
+
+	var out bytes.Buffer
+	heatmap.render(&out)
+	if !strings.Contains(out.String(), "100.0%") || !strings.Contains(out.String(), "█      2") {
+		t.Errorf("expected the failure rate and shaded counts, got:\n%s", out.String())
+	}
+}
+
+func TestMeshTargets(t *testing.T) {
+	pod := func(namespace, name string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: sidecarContainer}}},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		pod("istio-system", "istio-ingressgateway-5d-xyz", map[string]string{"istio": "ingressgateway"}),
+		pod("edge", "public-gw-7f-abc", nil),
+		pod("bookinfo", "reviews-v1-a", nil),
+		pod("bookinfo", "reviews-v2-b", nil),
+		pod("payments", "checkout-c", nil),
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "no-sidecar", Namespace: "payments"}},
+	)
+
+	gateways := []gatewayRef{{namespace: "edge", name: "public-gw"}}
+	targets, err := meshTargets(context.Background(), client, gateways, 2)
+	if err != nil {
+		t.Fatalf("meshTargets() unexpected error: %v", err)
+	}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.String())
+	}
+	// Both gateways, then one sidecar from each namespace
+	want := "edge/public-gw-7f-abc,istio-system/istio-ingressgateway-5d-xyz,bookinfo/reviews-v1-a,payments/checkout-c"
+	if strings.Join(names, ",") != want {
+		t.Errorf("meshTargets() = %v, expected %s", names, want)
+	}
+}
@@ -0,0 +1,183 @@
+// log_viewer/multiline.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultContinuationPattern matches indented lines and the usual Java, Go and
+// Python stack trace lines, including exception headers like
+// "java.lang.IllegalStateException: boom".
+const defaultContinuationPattern = `^(\s+\S|at |Caused by:|\.\.\. \d+ more|Traceback |goroutine \d+ \[|[\w$.]+(Exception|Error)(: |$))`
+
+// MultilineConfig controls how continuation lines are joined into the entry
+// that precedes them.
+type MultilineConfig struct {
+	Disabled            bool   `json:"disabled"`             // Parse every line on its own
+	ContinuationPattern string `json:"continuation_pattern"` // Regex for lines that continue the previous entry
+	MaxLines            int    `json:"max_lines"`            // Upper bound on lines joined into one entry
+}
+
+func defaultMultilineConfig() MultilineConfig {
+	return MultilineConfig{
+		ContinuationPattern: defaultContinuationPattern,
+		MaxLines:            500,
+	}
+}
+
+// logChunk is one logical log entry made of one or more physical lines.
+type logChunk struct {
+	text       string
+	lineNumber int // Line number of the first physical line
+}
+
+// joinMultiline groups physical lines into logical entries: pretty-printed JSON
+// objects are joined until their braces balance, and lines matching the
+// continuation pattern are appended to the previous entry.
+func joinMultiline(lines []string, cfg MultilineConfig) ([]logChunk, error) {
+	joiner, err := newLineJoiner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return joiner.join(lines), nil
+}
+
+// lineJoiner applies a MultilineConfig, with its pattern compiled once so
+// followed lines can be checked as they arrive.
+type lineJoiner struct {
+	disabled     bool
+	continuation *regexp.Regexp
+	maxLines     int
+}
+
+func newLineJoiner(cfg MultilineConfig) (*lineJoiner, error) {
+	if cfg.Disabled {
+		return &lineJoiner{disabled: true}, nil
+	}
+
+	pattern := cfg.ContinuationPattern
+	if pattern == "" {
+		pattern = defaultContinuationPattern
+	}
+	continuation, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continuation pattern %q: %v", pattern, err)
+	}
+	maxLines := cfg.MaxLines
+	if maxLines <= 0 {
+		maxLines = defaultMultilineConfig().MaxLines
+	}
+	return &lineJoiner{continuation: continuation, maxLines: maxLines}, nil
+}
+
+// join groups lines into logical entries, see joinMultiline.
+func (j *lineJoiner) join(lines []string) []logChunk {
+	if j.disabled {
+		chunks := make([]logChunk, len(lines))
+		for i, line := range lines {
+			chunks[i] = logChunk{text: line, lineNumber: i + 1}
+		}
+		return chunks
+	}
+
+	var chunks []logChunk
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Pretty-printed JSON: keep reading until the document is balanced
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "{") && jsonDepth(trimmed) > 0 {
+			joined, consumed := joinJSON(lines[i:], j.maxLines)
+			if consumed > 1 {
+				chunks = append(chunks, logChunk{text: joined, lineNumber: i + 1})
+				i += consumed - 1
+				continue
+			}
+		}
+
+		last := len(chunks) - 1
+		if last >= 0 && j.continuation.MatchString(line) &&
+			strings.Count(chunks[last].text, "\n") < j.maxLines-1 {
+			chunks[last].text += "\n" + line
+			continue
+		}
+
+		chunks = append(chunks, logChunk{text: line, lineNumber: i + 1})
+	}
+
+	return chunks
+}
+
+// continues reports whether line belongs to the entry whose lines arrived so
+// far, by the same rules as join: it continues a JSON document still open, or
+// matches the continuation pattern, up to the line limit.
+func (j *lineJoiner) continues(entry []string, line string) bool {
+	if j.disabled || len(entry) == 0 || len(entry) >= j.maxLines {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimSpace(entry[0]), "{") {
+		depth := 0
+		for _, previous := range entry {
+			depth += jsonDepth(previous)
+		}
+		if depth > 0 {
+			return true
+		}
+	}
+	return j.continuation.MatchString(line)
+}
+
+// joinJSON joins lines until they form a balanced JSON document, returning the
+// compacted document and the number of lines used. It returns consumed == 1 if
+// no valid document could be formed.
+func joinJSON(lines []string, maxLines int) (string, int) {
+	var builder strings.Builder
+	depth := 0
+	for i, line := range lines {
+		if i >= maxLines {
+			break
+		}
+		builder.WriteString(line)
+		builder.WriteString("\n")
+		depth += jsonDepth(line)
+		if depth > 0 {
+			continue
+		}
+
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(builder.String())); err != nil {
+			break
+		}
+		return compacted.String(), i + 1
+	}
+	return lines[0], 1
+}
+
+// jsonDepth returns the net change in brace/bracket nesting across a line,
+// ignoring characters inside JSON strings.
+func jsonDepth(line string) int {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inString:
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+		}
+	}
+	return depth
+}
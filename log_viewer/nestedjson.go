@@ -0,0 +1,64 @@
+// log_viewer/nestedjson.go
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Applications often log a JSON document as a string, such as a "message"
+// holding a serialized request, which reads as one line of escaped quotes.
+// With J the raw log and details show those documents expanded instead.
+
+// embeddedJSON returns the object or array a string holds, if it holds one.
+// Strings holding a bare number or quoted string are left alone.
+func embeddedJSON(value string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) < 2 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	var doc interface{}
+	if err := decodeJSON([]byte(trimmed), &doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// expandEmbeddedJSON returns v with every string holding a JSON document
+// replaced by the document, itself expanded, since documents nest.
+func expandEmbeddedJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if doc, ok := embeddedJSON(v); ok {
+			return expandEmbeddedJSON(doc)
+		}
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			expanded[key] = expandEmbeddedJSON(value)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, value := range v {
+			expanded[i] = expandEmbeddedJSON(value)
+		}
+		return expanded
+	}
+	return v
+}
+
+// prettyEmbeddedJSON indents the document value holds, expanded, under a
+// field of the detail pane.
+func prettyEmbeddedJSON(value string) (string, bool) {
+	doc, ok := embeddedJSON(value)
+	if !ok {
+		return "", false
+	}
+	pretty, err := json.MarshalIndent(expandEmbeddedJSON(doc), "  ", "  ")
+	if err != nil {
+		return "", false
+	}
+	return "  " + string(pretty), true
+}
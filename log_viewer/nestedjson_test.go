@@ -0,0 +1,50 @@
+// log_viewer/nestedjson_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEmbeddedJSON(t *testing.T) {
+	for value, want := range map[string]bool{
+		`{"user":"alice"}`: true,
+		` [1, 2] `:         true,
+		`{not json}`:       false,
+		`"quoted"`:         false,
+		`42`:               false,
+	} {
+		if _, ok := embeddedJSON(value); ok != want {
+			t.Errorf("embeddedJSON(%q) = %v, expected %v", value, ok, want)
+		}
+	}
+}
+
+func TestExpandEmbeddedJSON(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"level":"info","message":"{\"request\":{\"id\":7,\"body\":\"{\\\"sku\\\":\\\"A-1\\\"}\"}}"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	if raw := rawLogText(logs[0], false); !strings.Contains(raw, `\"request\"`) {
+		t.Errorf("expected the message left escaped, got:\n%s", raw)
+	}
+	// Documents nest, so the body inside the message is expanded too
+	if raw := rawLogText(logs[0], true); !strings.Contains(raw, `"sku": "A-1"`) || strings.Contains(raw, `\"`) {
+		t.Errorf("expected the message expanded, got:\n%s", raw)
+	}
+
+	var m tea.Model = Model{logs: logs, filteredLogs: logs, width: 120, height: 50, layout: layoutDetail}
+	if view := m.View(); !strings.Contains(view, "(JSON, press 'J' to expand)") {
+		t.Errorf("expected the embedded JSON pointed out, got:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	if view := m.View(); !strings.Contains(view, `"id": 7`) {
+		t.Errorf("expected the message expanded in the details, got:\n%s", view)
+	}
+}
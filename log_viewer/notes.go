@@ -0,0 +1,147 @@
+// log_viewer/notes.go
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Notes are free text attached to entries during an analysis, such as "the
+// rollout started here", keyed by EntryID.String(). They last for the
+// session, or across sessions and into export and summarize with -notes.
+
+// noteColumn is the export column holding notes. The underscore keeps it
+// apart from a field the log itself might call note.
+const noteColumn = "_note"
+
+// loadNotes reads the notes saved at path. A file that does not exist yet
+// has none.
+func loadNotes(path string) (map[string]string, error) {
+	notes := make(map[string]string)
+	if path == "" {
+		return notes, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return notes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading notes: %v", err)
+	}
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("error parsing notes %s: %v", path, err)
+	}
+	return notes, nil
+}
+
+// saveNotes returns a command writing notes to path.
+func saveNotes(path string, notes map[string]string) tea.Cmd {
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := writeNotes(path, notes); err != nil {
+			return failedMsg{err: err}
+		}
+		return nil
+	}
+}
+
+func writeNotes(path string, notes map[string]string) error {
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding notes: %v", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("error creating %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// notedEntry is an entry with the note attached to it.
+type notedEntry struct {
+	log  ParsedLog
+	note string
+}
+
+// notedEntries returns the entries of logs that have a note, in log order.
+func notedEntries(logs []ParsedLog, notes map[string]string) []notedEntry {
+	var noted []notedEntry
+	for _, log := range logs {
+		if note := notes[log.ID.String()]; note != "" {
+			noted = append(noted, notedEntry{log: log, note: note})
+		}
+	}
+	return noted
+}
+
+// noteEditor is the open note prompt for an entry, or for every marked one.
+type noteEditor struct {
+	ids  []EntryID
+	text string
+}
+
+// openNoteEditor starts editing the selected entry's note.
+func (m Model) openNoteEditor() Model {
+	if len(m.filteredLogs) == 0 {
+		return m
+	}
+	id := m.filteredLogs[m.selectedLogIndex].ID
+	m.note = &noteEditor{ids: []EntryID{id}, text: m.notes[id.String()]}
+	return m
+}
+
+// updateNoteEditor handles keys while a note is being written. Notes are
+// free text, so every letter types, including those bound to actions.
+func (m Model) updateNoteEditor(msg tea.KeyMsg) (Model, tea.Cmd) {
+	editor := *m.note
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		editor.text += string(msg.Runes)
+		m.note = &editor
+		return m, nil
+	case tea.KeyBackspace:
+		if runes := []rune(editor.text); len(runes) > 0 {
+			editor.text = string(runes[:len(runes)-1])
+		}
+		m.note = &editor
+		return m, nil
+	}
+
+	switch m.action(msg.String()) {
+	case actionQuit:
+		return m, tea.Quit
+	case actionCancel:
+		m.note = nil
+	case actionConfirm:
+		m.note = nil
+		// Clearing the text removes the note
+		notes := maps.Clone(m.notes)
+		if notes == nil {
+			notes = make(map[string]string)
+		}
+		for _, id := range editor.ids {
+			if text := strings.TrimSpace(editor.text); text != "" {
+				notes[id.String()] = text
+			} else {
+				delete(notes, id.String())
+			}
+		}
+		m.notes = notes
+		return m, saveNotes(m.notesPath, m.notes)
+	}
+	return m, nil
+}
@@ -0,0 +1,129 @@
+// log_viewer/notes_test.go
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNotesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session", "notes.json")
+
+	notes, err := loadNotes(path)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("loadNotes() on a missing file = %v, %v; expected no notes", notes, err)
+	}
+
+	if err := writeNotes(path, map[string]string{"stdin#3": "rollout started"}); err != nil {
+		t.Fatalf("writeNotes() unexpected error: %v", err)
+	}
+	notes, err = loadNotes(path)
+	if err != nil || notes["stdin#3"] != "rollout started" {
+		t.Errorf("loadNotes() = %v, %v; expected the saved note", notes, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadNotes(path); err == nil {
+		t.Error("expected an error for a malformed notes file")
+	}
+}
+
+func TestNoteEditor(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"message":"deploying"}`,
+		`{"message":"first error"}`,
+	}, parseOptions{source: "stdin"})
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	model := Model{logs: logs, filteredLogs: logs, selectedLogIndex: 1}
+	press := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			updated, _ := model.Update(msg)
+			model = updated.(Model)
+		}
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			if r == ' ' {
+				press(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+				continue
+			}
+			press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// Keys bound to actions, like s and q, type into the note
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	typeText("rollout starts")
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.note != nil {
+		t.Fatal("expected enter to close the note prompt")
+	}
+	if got := model.notes["stdin#2"]; got != "rollout starts" {
+		t.Fatalf("expected the note on line 2, got notes %v", model.notes)
+	}
+
+	list := renderLogList(model.filteredLogs, listAnnotations{notes: model.notes}, model.selectedLogIndex, 80, 10, false)
+	if !strings.Contains(list, "2:✎") || strings.Contains(list, "1:✎") {
+		t.Errorf("expected only line 2 to be marked as noted, got:\n%s", list)
+	}
+
+	// Reopening shows the note, esc leaves it unchanged
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if model.note == nil || model.note.text != "rollout starts" {
+		t.Fatalf("expected the prompt to start with the existing note, got %+v", model.note)
+	}
+	press(tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyEsc})
+	if got := model.notes["stdin#2"]; got != "rollout starts" {
+		t.Errorf("expected esc to keep the note, got %q", got)
+	}
+
+	// Saving an empty note removes it
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	for range "rollout starts" {
+		press(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := model.notes["stdin#2"]; ok {
+		t.Errorf("expected an empty note to be removed, got notes %v", model.notes)
+	}
+}
+
+func TestNotesInExportAndSummary(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"timestamp":"2024-01-01T00:00:00Z","message":"deploying"}`,
+		`{"timestamp":"2024-01-01T00:01:00Z","message":"first error"}`,
+	}, parseOptions{source: "stdin"})
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	notes := map[string]string{"stdin#2": "rollout started"}
+
+	columns, rows := exportTable(logs, notes, nil)
+	var hasColumn bool
+	for _, column := range columns {
+		hasColumn = hasColumn || column == exportColumn{noteColumn, columnString}
+	}
+	if !hasColumn || rows[1][noteColumn] != "rollout started" || rows[0][noteColumn] != nil {
+		t.Errorf("expected a %s column with the note on the second row, got %v %v", noteColumn, columns, rows)
+	}
+	if _, ok := logs[1].Fields[noteColumn]; ok {
+		t.Error("expected exporting not to add the note to the entry's fields")
+	}
+
+	s := summarize(logs, "stdin")
+	s.notes = notedEntries(logs, notes)
+	var report strings.Builder
+	s.writeMarkdown(&report)
+	if !strings.Contains(report.String(), "## Notes\n\n- **Line 2 (2024-01-01T00:01:00Z):** rollout started\n") {
+		t.Errorf("expected the note in the report, got:\n%s", report.String())
+	}
+}
@@ -0,0 +1,177 @@
+// log_viewer/notify.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhook payload formats for -notify-format.
+const (
+	notifyAuto  = "auto"  // Chosen from the webhook URL's host
+	notifySlack = "slack" // Slack incoming webhook
+	notifyTeams = "teams" // Microsoft Teams incoming webhook (MessageCard)
+	notifyJSON  = "json"  // The notification itself, for generic receivers
+)
+
+var notifyFormats = []string{notifyAuto, notifySlack, notifyTeams, notifyJSON}
+
+// maxNotifyFindings keeps chat messages short; the full report is on stdout.
+const maxNotifyFindings = 5
+
+// notifyTimeout bounds the webhook request so a slow receiver cannot hang a
+// scripted summarize, or leave an alert unreported.
+const notifyTimeout = 10 * time.Second
+
+// notification is what gets posted to a webhook. It is also the generic JSON
+// payload, so its field names are part of the output format.
+type notification struct {
+	Title    string            `json:"title"`
+	Source   string            `json:"source"`
+	Start    *time.Time        `json:"start,omitempty"`
+	End      *time.Time        `json:"end,omitempty"`
+	Entries  int               `json:"entries"`
+	Requests int               `json:"requests"`
+	Errors   int               `json:"errors"`
+	Findings []notifiedFinding `json:"findings"`
+	Links    []deepLink        `json:"links,omitempty"`
+}
+
+// notifiedFinding is a finding plus the TUI search query reproducing it.
+type notifiedFinding struct {
+	Message string `json:"message"`
+	Filter  string `json:"filter,omitempty"`
+}
+
+// notification returns the summary's headline figures and top findings.
+func (s summary) notification() notification {
+	n := notification{
+		Title:    "Log summary: " + s.source,
+		Source:   s.source,
+		Entries:  s.entries,
+		Requests: s.requests,
+		Errors:   s.errors,
+		Findings: []notifiedFinding{},
+		Links:    s.links,
+	}
+	if !s.first.IsZero() {
+		first, last := s.first.UTC(), s.last.UTC()
+		n.Start, n.End = &first, &last
+	}
+	for _, f := range s.anomalies() {
+		if len(n.Findings) == maxNotifyFindings {
+			break
+		}
+		n.Findings = append(n.Findings, notifiedFinding{Message: f.message, Filter: f.filter})
+	}
+	return n
+}
+
+// text renders the notification as the markdown-ish text chat tools accept.
+func (n notification) text() string {
+	var builder strings.Builder
+	if n.Start != nil {
+		fmt.Fprintf(&builder, "%s to %s\n", activeTimeDisplay.timestamp(*n.Start), activeTimeDisplay.timestamp(*n.End))
+	}
+	fmt.Fprintf(&builder, "%d entries, %d requests, %d errors\n", n.Entries, n.Requests, n.Errors)
+	if len(n.Findings) == 0 {
+		builder.WriteString("No anomalies found.")
+	}
+	for _, f := range n.Findings {
+		builder.WriteString("\n• " + f.Message)
+		if f.Filter != "" {
+			fmt.Fprintf(&builder, " Filter: `%s`", f.Filter)
+		}
+	}
+	for _, link := range n.Links {
+		fmt.Fprintf(&builder, "\n%s: %s", link.Name, link.URL)
+	}
+	return builder.String()
+}
+
+// notifyFormatFor resolves the auto format from the webhook's host.
+func notifyFormatFor(webhook, format string) string {
+	if format != notifyAuto && format != "" {
+		return format
+	}
+	parsed, err := url.Parse(webhook)
+	if err != nil {
+		return notifyJSON
+	}
+	host := parsed.Hostname()
+	switch {
+	case host == "hooks.slack.com":
+		return notifySlack
+	case strings.HasSuffix(host, ".webhook.office.com"), host == "outlook.office.com":
+		return notifyTeams
+	}
+	return notifyJSON
+}
+
+// webhookPayload encodes the notification for the receiver's format.
+func webhookPayload(n notification, format string) ([]byte, error) {
+	switch format {
+	case notifySlack:
+		return json.Marshal(map[string]string{
+			"text": fmt.Sprintf("*%s*\n%s", n.Title, n.text()),
+		})
+	case notifyTeams:
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  n.Title,
+			"title":    n.Title,
+			"text":     strings.ReplaceAll(n.text(), "\n", "\n\n"), // Teams needs blank lines for breaks
+		})
+	case notifyJSON:
+		return json.Marshal(n)
+	}
+	return nil, fmt.Errorf("unknown notification format %q (%s)", format, strings.Join(notifyFormats, ", "))
+}
+
+// notify posts the notification to the webhook.
+func notify(ctx context.Context, webhook, format string, n notification) error {
+	payload, err := webhookPayload(n, notifyFormatFor(webhook, format))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %v", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to webhook: %v", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// withoutURL strips the URL from err. Webhook URLs embed their credentials,
+// so they must not end up in error messages or the log file.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+// log_viewer/notify_test.go
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotifyFormatFor(t *testing.T) {
+	tests := []struct {
+		webhook string
+		format  string
+		want    string
+	}{
+		{"https://hooks.slack.com/services/T0/B0/secret", notifyAuto, notifySlack},
+		{"https://contoso.webhook.office.com/webhookb2/secret", notifyAuto, notifyTeams},
+		{"https://alerts.example.com/hook", notifyAuto, notifyJSON},
+		{"https://hooks.slack.com/services/T0/B0/secret", notifyJSON, notifyJSON},
+	}
+
+	for _, tt := range tests {
+		if got := notifyFormatFor(tt.webhook, tt.format); got != tt.want {
+			t.Errorf("notifyFormatFor(%q, %q) = %q, expected %q", tt.webhook, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var received notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON request, got %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("error decoding payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	s := summary{source: "default/reviews-v1", requests: 25, errors: 5, errorsByFlag: map[string]int{"UF": 5}}
+	if err := notify(context.Background(), server.URL, notifyAuto, s.notification()); err != nil {
+		t.Fatalf("notify() unexpected error: %v", err)
+	}
+	if received.Source != "default/reviews-v1" || received.Errors != 5 || len(received.Findings) != 1 {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+	if finding := received.Findings[0]; !strings.HasPrefix(finding.Message, "Error rate is 20.0%") || finding.Filter != "UF" {
+		t.Errorf("expected the error rate finding filtering on UF, got %+v", finding)
+	}
+}
+
+func TestNotifyErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "invalid_token")
+	}))
+	defer server.Close()
+
+	err := notify(context.Background(), server.URL+"/secret", notifySlack, notification{})
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: invalid_token") {
+		t.Errorf("expected the webhook's status and body, got %v", err)
+	}
+
+	// Connection errors must not leak the webhook URL
+	server.Close()
+	err = notify(context.Background(), server.URL+"/secret", notifyJSON, notification{})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected an error without the URL, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+// log_viewer/palette.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paletteCommand is one entry of the command palette: a key action, shown
+// with its keys, or a command only the palette offers.
+type paletteCommand struct {
+	title  string
+	action keyAction                      // actionNone for palette-only commands
+	run    func(m Model) (Model, tea.Cmd) // Set for palette-only commands
+}
+
+// paletteActions are the key actions the palette lists, in order.
+var paletteActions = []struct {
+	action keyAction
+	title  string
+}{
+	{actionSearch, "Search entries"},
+	{actionJump, "Jump to a line, entry, time or percentage"},
+	{actionFind, "Find in the selected entry"},
+	{actionBottom, "Go to newest entry"},
+	{actionConnection, "Filter by the selected entry's connection"},
+	{actionLayout, "Change layout"},
+	{actionExpand, "Toggle expanded rows"},
+	{actionExpandJSON, "Toggle expanded JSON in string fields"},
+	{actionFlow, "Show request flow"},
+	{actionDiagnose, "Diagnose failures"},
+	{actionHistogram, "Show histogram"},
+	{actionNote, "Edit note on entry"},
+	{actionUndo, "Undo"},
+	{actionRedo, "Redo"},
+	{actionExportText, "Export view as text"},
+	{actionExportHTML, "Export view as HTML"},
+	{actionRetry, "Reload logs"},
+	{actionMark, "Mark or unmark entry"},
+	{actionMarkRange, "Mark a range of entries"},
+	{actionCopy, "Copy entry to the clipboard"},
+	{actionDecode, "Decode URL, base64 and JWT fields"},
+	{actionHide, "Hide entry"},
+	{actionHidden, "Review hidden entries"},
+	{actionPickPod, "Switch namespace, pod or container"},
+	{actionEvents, "Toggle Kubernetes events panel"},
+	{actionQuit, "Quit"},
+}
+
+// trafficPresets are the filters keeping, or hiding, one kind of traffic.
+var trafficPresets = []struct {
+	kind  string
+	title string
+}{
+	{trafficExternal, "Show external and egress traffic only"},
+	{trafficEastWest, "Show east-west traffic only"},
+	{"!" + trafficHealthCheck, "Hide health checks"},
+}
+
+// paletteCommands returns what the palette offers in the current state.
+// Saved filters are the search history, newest first.
+func (m Model) paletteCommands() []paletteCommand {
+	var commands []paletteCommand
+	for _, entry := range paletteActions {
+		if (entry.action == actionRetry || entry.action == actionPickPod || entry.action == actionEvents) && m.target == nil {
+			continue
+		}
+		commands = append(commands, paletteCommand{title: entry.title, action: entry.action})
+	}
+
+	if m.following {
+		title := "Pause auto-scroll"
+		if !m.pinned {
+			title = "Resume auto-scroll"
+		}
+		commands = append(commands, paletteCommand{title: title, run: func(m Model) (Model, tea.Cmd) {
+			if m.pinned {
+				m.pinned = false
+				return m, nil
+			}
+			return m.perform(actionBottom)
+		}})
+	}
+	commands = append(commands, m.markCommands()...)
+	if len(m.excluded) > 0 {
+		commands = append(commands, paletteCommand{title: "Show all hidden entries", run: func(m Model) (Model, tea.Cmd) {
+			return m.unhide(), nil
+		}})
+	}
+	for _, preset := range trafficPresets {
+		query := trafficTermPrefix + preset.kind
+		commands = append(commands, paletteCommand{title: preset.title, run: func(m Model) (Model, tea.Cmd) {
+			return m.applyFilter(query), nil
+		}})
+	}
+	if m.activeFilter != "" {
+		commands = append(commands, paletteCommand{title: "Clear filter", run: func(m Model) (Model, tea.Cmd) {
+			return m.applyFilter(""), nil
+		}})
+	}
+	for i := len(m.history) - 1; i >= 0; i-- {
+		query := m.history[i]
+		commands = append(commands, paletteCommand{title: "Apply filter: " + query, run: func(m Model) (Model, tea.Cmd) {
+			return m.applyFilter(query), nil
+		}})
+	}
+	return commands
+}
+
+// applyFilter replaces the active filter, as confirming a search does.
+func (m Model) applyFilter(query string) Model {
+	m = m.remember()
+	m.activeFilter = query
+	return m.refilter()
+}
+
+// commandPalette is the open palette. Typing narrows the commands by fuzzy
+// match.
+type commandPalette struct {
+	commands []paletteCommand
+	query    string
+	selected int // Index into visible()
+}
+
+// visible returns the commands matching the query, best matches first and
+// otherwise in their listed order.
+func (p commandPalette) visible() []paletteCommand {
+	if p.query == "" {
+		return p.commands
+	}
+	type match struct {
+		command paletteCommand
+		score   int
+	}
+	var matches []match
+	for _, command := range p.commands {
+		if score, ok := fuzzyScore(command.title, p.query); ok {
+			matches = append(matches, match{command, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	visible := make([]paletteCommand, len(matches))
+	for i, match := range matches {
+		visible[i] = match.command
+	}
+	return visible
+}
+
+// fuzzyScore reports whether the letters of query appear in order in text,
+// ignoring case, and scores the match: letters following each other or
+// starting a word count most, so "exp" ranks "Export view as text" above
+// "Toggle expanded rows".
+func fuzzyScore(text, query string) (int, bool) {
+	runes := []rune(strings.ToLower(text))
+	score, previous := 0, -2
+	i := 0
+	for _, q := range strings.ToLower(query) {
+		for i < len(runes) && runes[i] != q {
+			i++
+		}
+		if i == len(runes) {
+			return 0, false
+		}
+		switch {
+		case i == previous+1:
+			score += 3
+		case i == 0 || !unicode.IsLetter(runes[i-1]):
+			score += 2
+		default:
+			score++
+		}
+		previous = i
+		i++
+	}
+	return score, true
+}
+
+// openPalette lists the commands.
+func (m Model) openPalette() Model {
+	m.palette = &commandPalette{commands: m.paletteCommands()}
+	return m
+}
+
+// updatePalette handles keys while the palette is open.
+func (m Model) updatePalette(msg tea.KeyMsg) (Model, tea.Cmd) {
+	palette := *m.palette
+	visible := palette.visible()
+
+	// Letters always go to the query, so only keys like arrows and enter act
+	if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+		palette.query += string(msg.Runes)
+		palette.selected = 0
+		m.palette = &palette
+		return m, nil
+	}
+
+	switch m.action(msg.String()) {
+	case actionQuit:
+		return m, tea.Quit
+	case actionCancel, actionPalette:
+		m.palette = nil
+		return m, nil
+	case actionUp:
+		if palette.selected > 0 {
+			palette.selected--
+		}
+	case actionDown:
+		if palette.selected < len(visible)-1 {
+			palette.selected++
+		}
+	case actionConfirm:
+		if len(visible) == 0 {
+			return m, nil
+		}
+		m.palette = nil
+		command := visible[palette.selected]
+		if command.run != nil {
+			return command.run(m)
+		}
+		return m.perform(command.action)
+	default:
+		if msg.Type == tea.KeyBackspace && len(palette.query) > 0 {
+			palette.query = palette.query[:len(palette.query)-1]
+			palette.selected = 0
+		}
+	}
+
+	m.palette = &palette
+	return m, nil
+}
+
+// boundKeys returns the keys bound to action, for display.
+func (m Model) boundKeys(action keyAction) []string {
+	if m.keys == nil {
+		return defaultKeyMap()[action]
+	}
+	return m.keys[action]
+}
+
+// renderPalette draws the palette over the whole screen.
+func (m Model) renderPalette() string {
+	palette := m.palette
+	var builder strings.Builder
+	builder.WriteString(headerStyle.Render("Commands (type to filter, enter to run, esc to cancel)"))
+	builder.WriteString("\n")
+	builder.WriteString(searchStyle.Render("> " + palette.query))
+	builder.WriteString("\n")
+
+	visible := palette.visible()
+	if len(visible) == 0 {
+		builder.WriteString(errorStyle.Render("No commands match."))
+		return builder.String()
+	}
+
+	// Keep the selection in view
+	rows := max(m.height-3, 1)
+	start := max(min(palette.selected-rows/2, len(visible)-rows), 0)
+	end := min(start+rows, len(visible))
+
+	for i := start; i < end; i++ {
+		line := visible[i].title
+		if keys := m.boundKeys(visible[i].action); visible[i].action != actionNone && len(keys) > 0 {
+			line = fmt.Sprintf("%-45s %s", line, strings.Join(keys, ", "))
+		}
+		if i == palette.selected {
+			builder.WriteString(selectedLogStyle.Render("> " + line))
+		} else {
+			builder.WriteString(logStyle.Render("  " + line))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
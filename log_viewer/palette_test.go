@@ -0,0 +1,85 @@
+// log_viewer/palette_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("Show histogram", "exp"); ok {
+		t.Errorf("expected no match without the letters in order")
+	}
+	export, ok := fuzzyScore("Export view as text", "exp")
+	expand, ok2 := fuzzyScore("Toggle expanded rows", "exp")
+	if !ok || !ok2 || export <= expand {
+		t.Errorf("expected a word-start run to score higher, got %d and %d", export, expand)
+	}
+}
+
+func typePalette(model Model, query string) Model {
+	for _, r := range query {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(Model)
+	}
+	return model
+}
+
+func TestCommandPalette(t *testing.T) {
+	logs := []ParsedLog{
+		ParsedLog{RawLog: `{"path":"/a"}`, Fields: map[string]interface{}{"path": "/a"}}.indexed(),
+		ParsedLog{RawLog: `{"path":"/b"}`, Fields: map[string]interface{}{"path": "/b"}}.indexed(),
+	}
+	model := Model{logs: logs, filteredLogs: logs, history: []string{"/a"}, width: 120, height: 40}
+
+	// ctrl+k lists the actions with their keys
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	model = updated.(Model)
+	if model.palette == nil || !strings.Contains(model.View(), "Change layout") {
+		t.Fatalf("expected the palette to list the layout action")
+	}
+	if strings.Contains(model.View(), "Switch namespace") {
+		t.Errorf("expected no pod switching without a Kubernetes target")
+	}
+
+	// Typing narrows the list, enter runs the best match
+	model = typePalette(model, "layout")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.palette != nil || model.layout != layoutSplit.next() {
+		t.Fatalf("expected the layout to change, got %q", model.layout)
+	}
+
+	// Saved filters are offered from the search history
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	model = typePalette(updated.(Model), "apply")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.activeFilter != "/a" || len(model.filteredLogs) != 1 {
+		t.Fatalf("expected the saved filter applied, got %q with %d entries", model.activeFilter, len(model.filteredLogs))
+	}
+
+	// Undo reverts it like a typed search
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	if model = updated.(Model); model.activeFilter != "" {
+		t.Errorf("expected undo to clear the applied filter, got %q", model.activeFilter)
+	}
+}
+
+func TestPaletteToggleFollow(t *testing.T) {
+	model := Model{following: true, pinned: true}.openPalette()
+	model = typePalette(model, "pause")
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model = updated.(Model); model.pinned {
+		t.Fatalf("expected auto-scroll paused")
+	}
+
+	model = typePalette(model.openPalette(), "resume")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model = updated.(Model); !model.pinned {
+		t.Errorf("expected auto-scroll resumed")
+	}
+}
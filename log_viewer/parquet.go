@@ -0,0 +1,327 @@
+// log_viewer/parquet.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// writeParquet writes the table as an uncompressed Parquet file with one row
+// group and one optional column per field, the subset of the format pandas,
+// DuckDB and Spark all read. It is small enough not to be worth a dependency
+// with its own compression codecs.
+//
+// See https://github.com/apache/parquet-format for the layout.
+func writeParquet(w io.Writer, columns []exportColumn, rows []map[string]interface{}) error {
+	out := &countingWriter{w: w}
+	if _, err := out.Write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+
+	var chunks []parquetChunk
+	var totalSize int64
+	for _, column := range columns {
+		offset := out.n
+		page, numValues := parquetPage(column, rows)
+		header := parquetPageHeader(len(page), numValues)
+		if _, err := out.Write(header); err != nil {
+			return err
+		}
+		if _, err := out.Write(page); err != nil {
+			return err
+		}
+		size := int64(len(header) + len(page))
+		chunks = append(chunks, parquetChunk{column: column, offset: offset, size: size, numValues: int64(len(rows))})
+		totalSize += size
+	}
+
+	footer := parquetFileMetadata(columns, chunks, int64(len(rows)), totalSize)
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], uint32(len(footer)))
+	for _, part := range [][]byte{footer, trailer[:], []byte(parquetMagic)} {
+		if _, err := out.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const parquetMagic = "PAR1"
+
+// Values from parquet.thrift.
+const (
+	parquetBoolean   = 0
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+	parquetUTF8     = 0 // ConvertedType
+
+	parquetPlain = 0 // Encoding
+	parquetRLE   = 3
+
+	parquetDataPage = 0
+)
+
+func parquetType(kind columnKind) int32 {
+	switch kind {
+	case columnNumber:
+		return parquetDouble
+	case columnBool:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+type parquetChunk struct {
+	column    exportColumn
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// parquetPage encodes a column as a v1 data page: definition levels (1 for a
+// value, 0 for null) then the non-null values in plain encoding.
+func parquetPage(column exportColumn, rows []map[string]interface{}) ([]byte, int) {
+	levels := make([]bool, len(rows))
+	var values bytes.Buffer
+	var bits []bool
+
+	for i, row := range rows {
+		value, ok := row[column.name]
+		if !ok || value == nil {
+			continue
+		}
+		levels[i] = true
+
+		switch column.kind {
+		case columnNumber:
+			// Number columns hold only numbers, see exportTable
+			number, _ := floatValue(value)
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(number))
+			values.Write(buf[:])
+		case columnBool:
+			bits = append(bits, value.(bool))
+		default:
+			s := exportString(value)
+			var length [4]byte
+			binary.LittleEndian.PutUint32(length[:], uint32(len(s)))
+			values.Write(length[:])
+			values.WriteString(s)
+		}
+	}
+	if column.kind == columnBool {
+		// Booleans are bit-packed, least significant bit first
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, bit := range bits {
+			if bit {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	encodedLevels := rleBits(levels)
+	page := make([]byte, 4, 4+len(encodedLevels)+values.Len())
+	binary.LittleEndian.PutUint32(page, uint32(len(encodedLevels)))
+	page = append(page, encodedLevels...)
+	page = append(page, values.Bytes()...)
+	return page, len(rows)
+}
+
+// rleBits encodes 1-bit values with the RLE half of Parquet's RLE/bit-packing
+// hybrid: each run is its length, shifted left once, then the value's byte.
+func rleBits(values []bool) []byte {
+	var out []byte
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if values[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+func parquetPageHeader(pageSize, numValues int) []byte {
+	var t thriftWriter
+	t.i32(1, parquetDataPage)
+	t.i32(2, int32(pageSize)) // Uncompressed
+	t.i32(3, int32(pageSize)) // Compressed
+	t.beginStruct(5)          // DataPageHeader
+	t.i32(1, int32(numValues))
+	t.i32(2, parquetPlain)
+	t.i32(3, parquetRLE) // Definition levels
+	t.i32(4, parquetRLE) // Repetition levels, none for flat columns
+	t.endStruct()
+	t.stop()
+	return t.buf
+}
+
+func parquetFileMetadata(columns []exportColumn, chunks []parquetChunk, numRows, totalSize int64) []byte {
+	var t thriftWriter
+	t.i32(1, 1) // Version
+
+	t.beginList(2, thriftStruct, len(columns)+1) // Schema, root first
+	t.binary(4, "schema")
+	t.i32(5, int32(len(columns)))
+	t.endListStruct()
+	for _, column := range columns {
+		t.i32(1, parquetType(column.kind))
+		t.i32(3, parquetOptional)
+		t.binary(4, column.name)
+		if column.kind == columnString {
+			t.i32(6, parquetUTF8)
+		}
+		t.endListStruct()
+	}
+	t.endList()
+
+	t.i64(3, numRows)
+
+	t.beginList(4, thriftStruct, 1) // Row groups
+	t.beginList(1, thriftStruct, len(chunks))
+	for _, chunk := range chunks {
+		t.i64(2, chunk.offset)
+		t.beginStruct(3) // ColumnMetaData
+		t.i32(1, parquetType(chunk.column.kind))
+		t.beginList(2, thriftI32, 2)
+		t.listI32(parquetPlain)
+		t.listI32(parquetRLE)
+		t.endList()
+		t.beginList(3, thriftBinary, 1)
+		t.listBinary(chunk.column.name)
+		t.endList()
+		t.i32(4, 0) // Uncompressed
+		t.i64(5, chunk.numValues)
+		t.i64(6, chunk.size)
+		t.i64(7, chunk.size)
+		t.i64(9, chunk.offset)
+		t.endStruct()
+		t.endListStruct()
+	}
+	t.endList()
+	t.i64(2, totalSize)
+	t.i64(3, numRows)
+	t.endListStruct()
+	t.endList()
+
+	t.binary(6, "istio-parsin log_viewer")
+	t.stop()
+	return t.buf
+}
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol, as far as Parquet's
+// metadata needs it. Field IDs are delta encoded against the last field of
+// the struct being written, so nested structs keep a stack of them.
+type thriftWriter struct {
+	buf       []byte
+	lastField int16
+	stack     []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.lastField; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	t.lastField = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.listBinary(v)
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.stack = append(t.stack, t.lastField)
+	t.lastField = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.lastField = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// beginList starts a list field. Struct elements are written as their fields
+// followed by endListStruct.
+func (t *thriftWriter) beginList(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xf0|elemType)
+		t.buf = binary.AppendUvarint(t.buf, uint64(size))
+	}
+	t.stack = append(t.stack, t.lastField)
+	t.lastField = 0
+}
+
+func (t *thriftWriter) endList() {
+	t.lastField = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) endListStruct() {
+	t.stop()
+	t.lastField = 0
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) listBinary(v string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
+
+// countingWriter tracks the offset of what is written, which the Parquet
+// footer records for every column.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,301 @@
+// log_viewer/parquet_test.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	// Many columns need the long form of the schema's list header
+	var wide []exportColumn
+	wideRow := make(map[string]interface{})
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("field_%02d", i)
+		wide = append(wide, exportColumn{name: name, kind: columnString})
+		wideRow[name] = name
+	}
+
+	columns, rows := exportTable(mustParse(t,
+		`{"response_code":200,"route":"reviews","retry":true,"upstream":{"host":"10.0.0.1"}}`,
+		`{"response_code":503,"retry":false}`,
+		`{"route":"ratings","retry":true}`,
+		`{"response_code":0.5,"route":""}`,
+	), nil, nil)
+	tests := []struct {
+		name    string
+		columns []exportColumn
+		rows    []map[string]interface{}
+	}{
+		{"entries with nulls", columns, rows},
+		{"wide", wide, []map[string]interface{}{wideRow, {}}},
+		{"empty", columns, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeParquet(&out, tt.columns, tt.rows); err != nil {
+				t.Fatalf("writeParquet() unexpected error: %v", err)
+			}
+			gotColumns, gotRows := readParquet(t, out.Bytes())
+
+			if !reflect.DeepEqual(gotColumns, tt.columns) {
+				t.Errorf("expected the schema %v, got %v", tt.columns, gotColumns)
+			}
+			if len(gotRows) != len(tt.rows) {
+				t.Fatalf("expected %d rows, got %d", len(tt.rows), len(gotRows))
+			}
+			for i, row := range tt.rows {
+				for _, column := range tt.columns {
+					want := parquetValue(column, row[column.name])
+					if got := gotRows[i][column.name]; got != want {
+						t.Errorf("row %d column %s = %#v, expected %#v", i, column.name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// parquetValue is how a value of the table reads back: numbers as float64,
+// anything in a string column as its text, missing values as nil.
+func parquetValue(column exportColumn, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch column.kind {
+	case columnNumber:
+		number, _ := floatValue(value)
+		return number
+	case columnBool:
+		return value
+	}
+	return exportString(value)
+}
+
+// readParquet decodes a file writeParquet wrote: the footer's schema and row
+// groups, then each column chunk's data page. It reads the subset of the
+// format the writer uses and fails the test on anything else.
+func readParquet(t *testing.T, data []byte) ([]exportColumn, []map[string]interface{}) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte(parquetMagic)) || !bytes.HasSuffix(data, []byte(parquetMagic)) {
+		t.Fatalf("expected the file to start and end with %s", parquetMagic)
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{t: t, buf: data[len(data)-8-footerLength : len(data)-8]}
+	metadata := footer.readStruct()
+	if len(footer.buf) != 0 {
+		t.Fatalf("expected the footer to hold the file metadata only, %d bytes left", len(footer.buf))
+	}
+
+	schema := metadata[2].([]interface{})
+	if root := schema[0].(thriftFields); root[5] != int64(len(schema)-1) {
+		t.Fatalf("expected the root to have %d children, got %v", len(schema)-1, root[5])
+	}
+	var columns []exportColumn
+	for _, element := range schema[1:] {
+		element := element.(thriftFields)
+		if element[3] != int64(parquetOptional) {
+			t.Fatalf("expected optional columns, got %v", element)
+		}
+		column := exportColumn{name: string(element[4].([]byte))}
+		switch element[1] {
+		case int64(parquetDouble):
+			column.kind = columnNumber
+		case int64(parquetBoolean):
+			column.kind = columnBool
+		case int64(parquetByteArray):
+			if element[6] != int64(parquetUTF8) {
+				t.Fatalf("expected column %s to be UTF-8, got %v", column.name, element[6])
+			}
+		default:
+			t.Fatalf("unexpected type of column %s: %v", column.name, element[1])
+		}
+		columns = append(columns, column)
+	}
+
+	numRows := metadata[3].(int64)
+	rows := make([]map[string]interface{}, numRows)
+	for i := range rows {
+		rows[i] = make(map[string]interface{})
+	}
+	var read int64
+	for _, group := range metadata[4].([]interface{}) {
+		group := group.(thriftFields)
+		groupRows := group[3].(int64)
+		chunks := group[1].([]interface{})
+		if len(chunks) != len(columns) {
+			t.Fatalf("expected a chunk per column, got %d", len(chunks))
+		}
+		for i, chunk := range chunks {
+			meta := chunk.(thriftFields)[3].(thriftFields)
+			if meta[4] != int64(0) || meta[5] != groupRows {
+				t.Fatalf("expected %d uncompressed values in chunk %d, got %v", groupRows, i, meta)
+			}
+			values := readParquetPage(t, data[meta[9].(int64):], columns[i], int(groupRows))
+			for j, value := range values {
+				if value != nil {
+					rows[read+int64(j)][columns[i].name] = value
+				}
+			}
+		}
+		read += groupRows
+	}
+	if read != numRows {
+		t.Fatalf("expected the row groups to hold %d rows, got %d", numRows, read)
+	}
+	return columns, rows
+}
+
+// readParquetPage decodes the v1 data page at the start of data, with nil
+// for values whose definition level is 0.
+func readParquetPage(t *testing.T, data []byte, column exportColumn, numValues int) []interface{} {
+	t.Helper()
+	r := &thriftReader{t: t, buf: data}
+	header := r.readStruct()
+	dataHeader := header[5].(thriftFields)
+	if header[1] != int64(parquetDataPage) || dataHeader[1] != int64(numValues) || dataHeader[2] != int64(parquetPlain) {
+		t.Fatalf("expected a plain data page of %d values, got %v", numValues, header)
+	}
+	page := r.buf[:header[3].(int64)]
+
+	levelsLength := binary.LittleEndian.Uint32(page)
+	levels := readRLEBits(t, page[4:4+levelsLength], numValues)
+	page = page[4+levelsLength:]
+
+	values := make([]interface{}, numValues)
+	bit := 0
+	for i, defined := range levels {
+		if !defined {
+			continue
+		}
+		switch column.kind {
+		case columnNumber:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		case columnBool:
+			values[i] = page[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		default:
+			length := binary.LittleEndian.Uint32(page)
+			values[i] = string(page[4 : 4+length])
+			page = page[4+length:]
+		}
+	}
+	if column.kind == columnBool {
+		page = page[(bit+7)/8:]
+	}
+	if len(page) != 0 {
+		t.Fatalf("expected column %s to hold %d values, %d bytes left", column.name, numValues, len(page))
+	}
+	return values
+}
+
+// readRLEBits decodes 1-bit values in the RLE/bit-packing hybrid.
+func readRLEBits(t *testing.T, data []byte, n int) []bool {
+	t.Helper()
+	var values []bool
+	for len(data) > 0 {
+		header, size := binary.Uvarint(data)
+		data = data[size:]
+		if header&1 != 0 {
+			t.Fatalf("unexpected bit-packed run")
+		}
+		for i := uint64(0); i < header>>1; i++ {
+			values = append(values, data[0] == 1)
+		}
+		data = data[1:]
+	}
+	if len(values) != n {
+		t.Fatalf("expected %d definition levels, got %d", n, len(values))
+	}
+	return values
+}
+
+// thriftFields is a decoded struct, by field ID.
+type thriftFields map[int16]interface{}
+
+// thriftReader decodes the Thrift compact protocol, as far as the Parquet
+// metadata writeParquet writes needs it. Integers decode to int64, binary
+// to []byte, lists to []interface{}.
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.t.Fatalf("unexpected end of Thrift data")
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.t.Fatalf("invalid Thrift varint")
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.t.Fatalf("invalid Thrift varint")
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) readStruct() thriftFields {
+	fields := make(thriftFields)
+	var last int16
+	for {
+		b := r.byte()
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.readValue(b & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		v := r.buf[:n]
+		r.buf = r.buf[n:]
+		return v
+	case thriftList:
+		b := r.byte()
+		size := uint64(b >> 4)
+		if size == 15 {
+			size = r.uvarint()
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.t.Fatalf("unexpected Thrift type %d", typ)
+	return nil
+}
@@ -0,0 +1,169 @@
+// log_viewer/peers.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+// Addresses in the detail view are shown with the pod or service they belong
+// to, e.g. "10.1.2.3:9080 → reviews-v2-abc (default)". Kubernetes sources
+// look IPs up in the cluster; with -reverse-dns, addresses are also looked up
+// in DNS, which is off by default as it sends them to the resolver.
+
+const (
+	peerTimeout      = 10 * time.Second // For listing the cluster's pods and services
+	dnsTimeout       = 2 * time.Second  // For each reverse lookup
+	maxDNSLookups    = 256              // Addresses looked up per batch of logs
+	dnsLookupWorkers = 16
+)
+
+// addressFields hold IP addresses, with or without a port.
+var addressFields = []string{
+	"downstream_local_address", "downstream_remote_address", "upstream_host", "upstream_local_address",
+	"src.addr", "dst.addr", "dst.hbone_addr",
+}
+
+// peersResolvedMsg carries names for IP addresses. An empty name records an
+// address that was looked up without finding anything.
+type peersResolvedMsg struct {
+	peers map[string]string
+}
+
+// addressIP returns the IP of an address like 10.1.2.3:80, [::1]:80 or a bare
+// IP, or "" when it is not one.
+func addressIP(value string) string {
+	host := value
+	if h, _, err := net.SplitHostPort(value); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
+
+// peerName returns what the address in value belongs to, or "".
+func peerName(value string, peers map[string]string) string {
+	if ip := addressIP(value); ip != "" {
+		return peers[ip]
+	}
+	return ""
+}
+
+// peers maps the IPs of the cluster's pods and services to their names, for
+// load to send along with the logs. Without permission to list them
+// cluster-wide, the target's namespace is tried.
+func (t kubeTarget) peers() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), peerTimeout)
+	defer cancel()
+
+	found, err := k8ssource.ListPeers(ctx, t.client, "", 0)
+	if err != nil {
+		found, err = k8ssource.ListPeers(ctx, t.client, t.namespace, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error resolving pod IPs: %v", explainKubeError(err))
+	}
+	peers := make(map[string]string, len(found))
+	for ip, peer := range found {
+		peers[ip] = peer.String()
+	}
+	return peers, nil
+}
+
+// resolveAddresses returns a command looking up, in reverse DNS, the addresses
+// in the logs that have not been looked up yet. It is nil without -reverse-dns.
+func (m Model) resolveAddresses() tea.Cmd {
+	if !m.reverseDNS {
+		return nil
+	}
+	var ips []string
+	seen := make(map[string]bool)
+	for _, entry := range m.logs {
+		for _, field := range addressFields {
+			value, _ := entry.Fields[field].(string)
+			ip := addressIP(value)
+			if _, known := m.peers[ip]; ip == "" || known || seen[ip] {
+				continue
+			}
+			seen[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+	if len(ips) > maxDNSLookups {
+		ips = ips[:maxDNSLookups]
+	}
+	return func() tea.Msg {
+		return peersResolvedMsg{peers: reverseLookup(ips, net.DefaultResolver.LookupAddr)}
+	}
+}
+
+// reverseLookup looks ips up concurrently, recording an empty name for those
+// without one.
+func reverseLookup(ips []string, lookup func(ctx context.Context, addr string) ([]string, error)) map[string]string {
+	peers := make(map[string]string, len(ips))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	work := make(chan string)
+	for range min(dnsLookupWorkers, len(ips)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range work {
+				ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+				names, err := lookup(ctx, ip)
+				cancel()
+				name := ""
+				if err == nil && len(names) > 0 {
+					name = strings.TrimSuffix(names[0], ".")
+				}
+				mu.Lock()
+				peers[ip] = name
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ip := range ips {
+		work <- ip
+	}
+	close(work)
+	wg.Wait()
+	return peers
+}
+
+// receivePeers adds names found in DNS to those known.
+func (m Model) receivePeers(msg peersResolvedMsg) Model {
+	return m.withPeers(msg.peers, false)
+}
+
+// withPeers adds names to those known. Names replace known ones only when
+// authoritative, as a fresh listing of the cluster is.
+func (m Model) withPeers(names map[string]string, authoritative bool) Model {
+	if len(names) == 0 {
+		return m
+	}
+	peers := maps.Clone(m.peers)
+	if peers == nil {
+		peers = make(map[string]string, len(names))
+	}
+	for ip, name := range names {
+		if authoritative || peers[ip] == "" {
+			peers[ip] = name
+		}
+	}
+	m.peers = peers
+	return m
+}
@@ -0,0 +1,85 @@
+// log_viewer/peers_test.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAddressIP(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"10.1.2.3:9080", "10.1.2.3"},
+		{"10.1.2.3", "10.1.2.3"},
+		{"[fd00::3]:80", "fd00::3"},
+		{"reviews:9080", ""},
+		{"-", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := addressIP(tt.value); got != tt.want {
+			t.Errorf("addressIP(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReverseLookup(t *testing.T) {
+	lookup := func(_ context.Context, addr string) ([]string, error) {
+		if addr == "10.1.2.3" {
+			return []string{"10-1-2-3.reviews.default.svc.cluster.local."}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	peers := reverseLookup([]string{"10.1.2.3", "10.9.9.9"}, lookup)
+	if peers["10.1.2.3"] != "10-1-2-3.reviews.default.svc.cluster.local" {
+		t.Errorf("expected the name without its trailing dot, got %q", peers["10.1.2.3"])
+	}
+	if name, ok := peers["10.9.9.9"]; !ok || name != "" {
+		t.Errorf("expected a miss to be recorded so it is not looked up again, got %q, %t", name, ok)
+	}
+
+	model := Model{logs: []ParsedLog{{Fields: map[string]interface{}{"upstream_host": "10.9.9.9:80"}}}, peers: peers}
+	if model.resolveAddresses() != nil {
+		t.Error("expected no lookups without -reverse-dns")
+	}
+	model.reverseDNS = true
+	if model.resolveAddresses() != nil {
+		t.Error("expected addresses already looked up not to be looked up again")
+	}
+}
+
+func TestPeersFromCluster(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-abc", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "10.1.2.3"},
+	}
+	target := kubeTarget{client: fake.NewSimpleClientset(pod), namespace: "default", pod: "productpage-v1", parseOpts: defaultParseOptions()}
+
+	msg := target.load(0)().(logsFetchedMsg)
+	if msg.peers["10.1.2.3"] != "reviews-v2-abc (default)" {
+		t.Fatalf("expected the load to name the cluster's pod IPs, got %v", msg.peers)
+	}
+
+	// The fake clientset's "fake logs" do not parse, so show a real entry
+	model := Model{target: &target}.withPeers(msg.peers, true)
+	log, err := ParseLog(`{"upstream_host":"10.1.2.3:9080","downstream_remote_address":"10.7.7.7:5000","response_code":200}`, 1)
+	if err != nil {
+		t.Fatalf("ParseLog() unexpected error: %v", err)
+	}
+	view := renderDetailView(log, detailExtras{peers: model.peers}, 120, 60)
+	if !strings.Contains(view, "10.1.2.3:9080 → reviews-v2-abc (default)") {
+		t.Errorf("expected the upstream host to be named, got:\n%s", view)
+	}
+	if strings.Contains(view, "10.7.7.7:5000 →") {
+		t.Errorf("expected an unknown address to be shown as is, got:\n%s", view)
+	}
+}
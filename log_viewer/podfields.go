@@ -0,0 +1,135 @@
+// log_viewer/podfields.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Entries read from Kubernetes get the labels their pod is deployed with,
+// or failing a label an annotation, named in the pod_fields of the config,
+// as pod.<name> fields. So `-compare pod.version` breaks errors down by
+// version, and a pod.team=<team> filter term keeps one team's entries.
+// pod.node and pod.zone, the node the pod runs on and its topology zone,
+// are always added, so `-compare pod.zone` shows a zonal network issue or
+// locality load balancing sending traffic across zones, even with an empty
+// pod_fields. Looking pods up is best effort: without the access to get
+// them, entries are read as before.
+
+// podFieldPrefix starts the names of the fields and filter terms.
+const podFieldPrefix = "pod."
+
+// Fields of where the pod runs.
+const (
+	podNodeField = podFieldPrefix + "node"
+	podZoneField = podFieldPrefix + "zone"
+)
+
+// defaultPodFields are looked up when the config names none.
+var defaultPodFields = []string{"app", "version", "team"}
+
+// podFields returns the fields for the pod's labels and annotations among
+// names and where it runs, nil when nothing is known.
+func podFields(metadata k8ssource.PodMetadata, names []string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(names)+2)
+	for _, name := range names {
+		value, ok := metadata.Labels[name]
+		if !ok {
+			value, ok = metadata.Annotations[name]
+		}
+		if ok {
+			fields[podFieldPrefix+name] = value
+		}
+	}
+	if metadata.Node != "" {
+		fields[podNodeField] = metadata.Node
+	}
+	if metadata.Zone != "" {
+		fields[podZoneField] = metadata.Zone
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// withPodFields returns the options for logs of a pod with fields, added to
+// every entry.
+func (o parseOptions) withPodFields(fields map[string]interface{}) parseOptions {
+	o.podFields = fields
+	return o
+}
+
+// addPodFields adds fields to each entry, keeping a field the entry logged
+// itself, and indexes them for search.
+func addPodFields(logs []ParsedLog, fields map[string]interface{}) {
+	for i := range logs {
+		logs[i] = withPodFieldsAdded(logs[i], fields)
+	}
+}
+
+// withPodFieldsAdded returns the entry with fields added, see addPodFields.
+func withPodFieldsAdded(entry ParsedLog, fields map[string]interface{}) ParsedLog {
+	if len(fields) == 0 {
+		return entry
+	}
+	// The parsed fields may be shared, so add to a copy
+	entry.Fields = maps.Clone(entry.Fields)
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]interface{}, len(fields))
+	}
+	for name, value := range fields {
+		if _, ok := entry.Fields[name]; !ok {
+			entry.Fields[name] = value
+		}
+	}
+	return entry.indexed()
+}
+
+// lookupPodFields returns the fields for one pod.
+func lookupPodFields(client kubernetes.Interface, namespace, pod string, names []string) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), peerTimeout)
+	defer cancel()
+	metadata, err := k8ssource.GetPodMetadata(ctx, client, namespace, pod)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up pod labels: %v", explainKubeError(err))
+	}
+	return podFields(metadata, names), nil
+}
+
+// withTargetPodFields sets the fields of each target's pod, listing each
+// namespace's pods once. Targets in a namespace that cannot be listed are left
+// without them, and the errors returned.
+func withTargetPodFields(ctx context.Context, client kubernetes.Interface, targets []searchTarget, names []string) error {
+	var errs []error
+	byNamespace := make(map[string]map[string]k8ssource.PodMetadata)
+	for i, target := range targets {
+		pods, listed := byNamespace[target.namespace]
+		if !listed {
+			var err error
+			if pods, err = k8ssource.ListPodMetadata(ctx, client, target.namespace, 0); err != nil {
+				errs = append(errs, fmt.Errorf("error looking up pod labels in %s: %v", target.namespace, explainKubeError(err)))
+			}
+			byNamespace[target.namespace] = pods
+		}
+		targets[i].podFields = podFields(pods[target.pod], names)
+	}
+	return errors.Join(errs...)
+}
+
+// matchesPodFields reports whether the entry's pod fields satisfy every
+// pod.<name>=<value> term.
+func matchesPodFields(log ParsedLog, terms []fieldTerm) bool {
+	for _, term := range terms {
+		if value, _ := log.StringField(podFieldPrefix + term.name); value != term.value {
+			return false
+		}
+	}
+	return true
+}
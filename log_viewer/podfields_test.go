@@ -0,0 +1,135 @@
+// log_viewer/podfields_test.go
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodFields(t *testing.T) {
+	metadata := k8ssource.PodMetadata{
+		Labels:      map[string]string{"app": "reviews", "version": "v2", "pod-template-hash": "5d"},
+		Annotations: map[string]string{"team": "storefront", "version": "ignored"},
+	}
+	fields := podFields(metadata, defaultPodFields)
+	expected := map[string]interface{}{"pod.app": "reviews", "pod.version": "v2", "pod.team": "storefront"}
+	if len(fields) != len(expected) {
+		t.Errorf("podFields() = %v, expected %v", fields, expected)
+	}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("podFields()[%s] = %v, expected %v", name, fields[name], value)
+		}
+	}
+	if fields := podFields(k8ssource.PodMetadata{}, defaultPodFields); fields != nil {
+		t.Errorf("expected no fields for a pod without labels, got %v", fields)
+	}
+
+	// Where the pod runs is added along with the named fields
+	fields = podFields(k8ssource.PodMetadata{Node: "node-a", Zone: "us-east-1a"}, defaultPodFields)
+	if len(fields) != 2 || fields[podNodeField] != "node-a" || fields[podZoneField] != "us-east-1a" {
+		t.Errorf("podFields() = %v, expected the node and zone", fields)
+	}
+	// An empty pod_fields leaves out the labels only
+	fields = podFields(k8ssource.PodMetadata{Node: "node-a", Zone: "us-east-1a", Labels: map[string]string{"app": "reviews"}}, []string{})
+	if len(fields) != 2 || fields[podNodeField] != "node-a" || fields[podZoneField] != "us-east-1a" {
+		t.Errorf("expected the node and zone without names, got %v", fields)
+	}
+}
+
+func TestPodFieldsOnEntries(t *testing.T) {
+	parse := func(version string) []ParsedLog {
+		t.Helper()
+		opts := defaultParseOptions().withPodFields(map[string]interface{}{"pod.version": version, "pod.app": "reviews"})
+		logs, err := parseRawLogsWith([]string{
+			`{"response_code":200,"upstream_cluster":"inbound|9080||"}`,
+			`{"response_code":503,"upstream_cluster":"inbound|9080||","pod.app":"logged"}`,
+		}, opts)
+		if err != nil {
+			t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+		}
+		return logs
+	}
+	logs := append(parse("v1"), parse("v2")...)
+
+	if logs[0].Fields["pod.version"] != "v1" || logs[1].Fields["pod.app"] != "logged" {
+		t.Errorf("expected the pod fields added without replacing logged ones, got %v and %v", logs[0].Fields, logs[1].Fields)
+	}
+	if got := filterLogs(logs, "pod.version=v2"); len(got) != 2 {
+		t.Errorf("expected the pod.version term to keep 2 entries, got %d", len(got))
+	}
+	if got := filterLogs(logs, "pod.version=v2 503"); len(got) != 1 {
+		t.Errorf("expected the rest of the query to narrow the term, got %d", len(got))
+	}
+	if got := filterLogs(logs, "503 pod.version=v2"); len(got) != 1 {
+		t.Errorf("expected the term to apply after the text, got %d", len(got))
+	}
+	if got := filterLogs(logs, "storefront"); len(got) != 0 {
+		t.Errorf("expected no matches, got %d", len(got))
+	}
+	if got := filterLogs(logs, "v1"); len(got) != 2 {
+		t.Errorf("expected the pod fields to be searched, got %d", len(got))
+	}
+
+	comparison := compareBy(logs, "pod.version")
+	if len(comparison.variants) != 2 || comparison.variants[1].value != "v2" {
+		t.Errorf("expected requests split by version, got %+v", comparison.variants)
+	}
+
+	model := Model{podFields: map[string]interface{}{"pod.version": "v3"}}
+	model = model.appendLine(`{"response_code":200}`)
+	if model.logs[0].Fields["pod.version"] != "v3" {
+		t.Errorf("expected followed entries to get the pod fields, got %v", model.logs[0].Fields)
+	}
+
+	// The entry the fields are added to is left as it was
+	original := logs[0]
+	added := withPodFieldsAdded(original, map[string]interface{}{"pod.team": "storefront"})
+	if _, ok := original.Fields["pod.team"]; ok || added.Fields["pod.team"] != "storefront" {
+		t.Errorf("expected the fields added to a copy, got %v and %v", original.Fields, added.Fields)
+	}
+}
+
+func TestTargetPodFields(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "reviews-v2-abc", Namespace: "bookinfo", Labels: map[string]string{"app": "reviews", "version": "v2"},
+	}})
+	targets := []searchTarget{{namespace: "bookinfo", pod: "reviews-v2-abc"}, {namespace: "bookinfo", pod: "gone"}}
+	if err := withTargetPodFields(context.Background(), client, targets, defaultPodFields); err != nil {
+		t.Fatalf("withTargetPodFields() unexpected error: %v", err)
+	}
+	if targets[0].podFields["pod.version"] != "v2" || targets[1].podFields != nil {
+		t.Errorf("expected the fields of the listed pod only, got %v and %v", targets[0].podFields, targets[1].podFields)
+	}
+
+	// Without pod fields named, the node and zone are still looked up
+	zoned := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-abc", Namespace: "bookinfo", Labels: map[string]string{"version": "v2"}},
+			Spec: v1.PodSpec{NodeName: "node-a"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{v1.LabelTopologyZone: "us-east-1a"}}},
+	)
+	unnamed := []searchTarget{{namespace: "bookinfo", pod: "reviews-v2-abc"}}
+	withTargetPodFields(context.Background(), zoned, unnamed, nil)
+	looked, err := lookupPodFields(zoned, "bookinfo", "reviews-v2-abc", []string{})
+	if err != nil {
+		t.Fatalf("lookupPodFields() unexpected error: %v", err)
+	}
+	for _, fields := range []map[string]interface{}{unnamed[0].podFields, looked} {
+		if len(fields) != 2 || fields[podNodeField] != "node-a" || fields[podZoneField] != "us-east-1a" {
+			t.Errorf("expected the node and zone without pod fields, got %v", fields)
+		}
+	}
+
+	result := searchPod(context.Background(), targets[0], "", func(context.Context, searchTarget) ([]string, error) {
+		return []string{`{"response_code":200}`}, nil
+	}, defaultParseOptions())
+	if len(result.matches) != 1 || result.matches[0].Fields["pod.app"] != "reviews" {
+		t.Errorf("expected searched entries to get the pod fields, got %v", result.matches)
+	}
+}
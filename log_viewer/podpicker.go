@@ -0,0 +1,256 @@
+// log_viewer/podpicker.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerLevel is what the picker lists.
+type pickerLevel int
+
+const (
+	pickPod pickerLevel = iota
+	pickNamespace
+	pickContainer
+)
+
+// podPicker switches the session to another pod, in the target's namespace
+// or, after tab, in another one. A pod running several containers is
+// followed by a choice of container. Typing narrows the list.
+type podPicker struct {
+	level      pickerLevel
+	namespace  string              // Namespace pods are listed from
+	pod        string              // Pod containers are listed of
+	items      []string            // Namespaces, pods or containers, by level
+	containers map[string][]string // Containers of each listed pod
+	query      string
+	selected   int // Index into visible()
+	loading    bool
+}
+
+// visible returns the items matching the typed query.
+func (p podPicker) visible() []string {
+	if p.query == "" {
+		return p.items
+	}
+	var matches []string
+	for _, item := range p.items {
+		if strings.Contains(item, p.query) {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
+
+// current returns the item the target is on at the picker's level, if it is
+// listed.
+func (p podPicker) current(target *kubeTarget) string {
+	switch {
+	case p.level == pickNamespace:
+		return target.namespace
+	case p.level == pickPod && p.namespace == target.namespace:
+		return target.pod
+	case p.level == pickContainer && p.namespace == target.namespace && p.pod == target.pod:
+		return target.container
+	}
+	return ""
+}
+
+// withItems returns the picker listing items, with the target's own selected.
+// Containers of another pod preselect the one named like the target's, such
+// as the sidecar.
+func (p podPicker) withItems(items []string, target *kubeTarget) podPicker {
+	p.items, p.query, p.selected, p.loading = items, "", 0, false
+	preferred := p.current(target)
+	if p.level == pickContainer {
+		preferred = target.container
+	}
+	for i, item := range items {
+		if item == preferred {
+			p.selected = i
+		}
+	}
+	return p
+}
+
+// openPodPicker shows the picker and starts listing the pods of the target's
+// namespace.
+func (m Model) openPodPicker() (Model, tea.Cmd) {
+	if m.target == nil {
+		return m, nil
+	}
+	m.picker = &podPicker{namespace: m.target.namespace, loading: true}
+	return m, m.target.listPods(m.target.namespace)
+}
+
+// receivePods fills the picker, preselecting the current pod.
+func (m Model) receivePods(msg podsListedMsg) Model {
+	if m.picker == nil || m.picker.level != pickPod || m.picker.namespace != msg.namespace {
+		return m
+	}
+	if msg.err != nil {
+		m.picker = nil
+		m.err = msg.err
+		return m
+	}
+
+	picker := m.picker.withItems(msg.pods, m.target)
+	picker.containers = msg.containers
+	m.picker = &picker
+	return m
+}
+
+// receiveNamespaces fills the picker, preselecting the current namespace.
+func (m Model) receiveNamespaces(msg namespacesListedMsg) Model {
+	if m.picker == nil || m.picker.level != pickNamespace {
+		return m
+	}
+	if msg.err != nil {
+		m.picker = nil
+		m.err = msg.err
+		return m
+	}
+
+	picker := m.picker.withItems(msg.namespaces, m.target)
+	m.picker = &picker
+	return m
+}
+
+// updatePodPicker handles keys while the picker is open.
+func (m Model) updatePodPicker(msg tea.KeyMsg) (Model, tea.Cmd) {
+	picker := *m.picker
+	visible := picker.visible()
+
+	// Letters always go to the filter, so only keys like arrows and enter act
+	if msg.Type == tea.KeyRunes {
+		picker.query += string(msg.Runes)
+		picker.selected = 0
+		m.picker = &picker
+		return m, nil
+	}
+
+	switch m.action(msg.String()) {
+	case actionQuit:
+		return m, tea.Quit
+	case actionCancel:
+		m.picker = nil
+		return m, nil
+	case actionUp:
+		if picker.selected > 0 {
+			picker.selected--
+		}
+	case actionDown:
+		if picker.selected < len(visible)-1 {
+			picker.selected++
+		}
+	case actionConfirm:
+		if picker.loading || len(visible) == 0 {
+			return m, nil
+		}
+		return m.pick(picker, visible[picker.selected])
+	default:
+		switch {
+		case msg.Type == tea.KeyTab && picker.level != pickNamespace:
+			m.picker = &podPicker{level: pickNamespace, loading: true}
+			return m, m.target.listNamespaces()
+		case msg.Type == tea.KeyBackspace && len(picker.query) > 0:
+			picker.query = picker.query[:len(picker.query)-1]
+			picker.selected = 0
+		}
+	}
+
+	m.picker = &picker
+	return m, nil
+}
+
+// pick acts on the chosen item: a namespace lists its pods, a pod with
+// several containers lists them, and otherwise the session switches.
+func (m Model) pick(picker podPicker, item string) (Model, tea.Cmd) {
+	switch picker.level {
+	case pickNamespace:
+		m.picker = &podPicker{namespace: item, loading: true}
+		return m, m.target.listPods(item)
+	case pickPod:
+		containers := picker.containers[item]
+		if len(containers) > 1 {
+			next := podPicker{level: pickContainer, namespace: picker.namespace, pod: item}.withItems(containers, m.target)
+			m.picker = &next
+			return m, nil
+		}
+		container := m.target.container
+		if len(containers) == 1 {
+			container = containers[0]
+		}
+		m.picker = nil
+		return m.switchTarget(picker.namespace, item, container)
+	}
+	m.picker = nil
+	return m.switchTarget(picker.namespace, picker.pod, item)
+}
+
+// renderPodPicker draws the picker over the whole screen.
+func (m Model) renderPodPicker() string {
+	picker := m.picker
+	var title string
+	switch picker.level {
+	case pickNamespace:
+		title = "Pick a namespace (type to filter, enter to list its pods, esc to cancel)"
+	case pickPod:
+		title = fmt.Sprintf("Pick a pod in namespace %s (type to filter, enter to load, tab for namespaces, esc to cancel)", picker.namespace)
+	case pickContainer:
+		title = fmt.Sprintf("Pick a container of %s/%s (type to filter, enter to load, tab for namespaces, esc to cancel)", picker.namespace, picker.pod)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(headerStyle.Render(title))
+	builder.WriteString("\n")
+	builder.WriteString(searchStyle.Render("Filter: " + picker.query))
+	builder.WriteString("\n")
+
+	if picker.loading {
+		builder.WriteString(logStyle.Render("Loading..."))
+		return builder.String()
+	}
+
+	visible := picker.visible()
+	if len(visible) == 0 {
+		builder.WriteString(errorStyle.Render("Nothing matches."))
+		return builder.String()
+	}
+
+	// Keep the selection in view
+	rows := m.height - 3
+	if rows < 1 {
+		rows = 1
+	}
+	start := picker.selected - rows/2
+	if start > len(visible)-rows {
+		start = len(visible) - rows
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + rows
+	if end > len(visible) {
+		end = len(visible)
+	}
+
+	current := picker.current(m.target)
+	for i := start; i < end; i++ {
+		line := visible[i]
+		if line == current {
+			line += " (current)"
+		}
+		if i == picker.selected {
+			builder.WriteString(selectedLogStyle.Render("> " + line))
+		} else {
+			builder.WriteString(logStyle.Render("  " + line))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
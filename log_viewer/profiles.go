@@ -0,0 +1,172 @@
+// log_viewer/profiles.go
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// logProfile identifies the kind of proxy (or application) that produced a
+// log entry, which decides how its details are laid out.
+type logProfile string
+
+const (
+	profileSidecar  logProfile = "sidecar"  // Envoy sidecar or gateway access log
+	profileWaypoint logProfile = "waypoint" // Ambient waypoint proxy access log
+	profileZtunnel  logProfile = "ztunnel"  // Ambient ztunnel connection log
+	profileGeneric  logProfile = "app"      // Structured application or control plane log
+)
+
+// fieldGroup is a titled set of fields shown together in the detail view.
+type fieldGroup struct {
+	name   string
+	fields []string
+}
+
+var sidecarGroups = []fieldGroup{
+	{"Request Info", []string{
+		"start_time", "kubelet_timestamp", "method", "protocol", "authority", "path",
+		"request_id", "user_agent", "client_ip", "x_forwarded_for",
+	}},
+	{"Response Info", []string{
+		"response_code", "response_code_details", "response_flags",
+		"duration", "bytes_sent", "bytes_received",
+	}},
+	{"Upstream Info", []string{
+		"upstream_cluster", "upstream_host", "upstream_local_address",
+		"upstream_service_time", "upstream_transport_failure_reason",
+	}},
+	{"Downstream Info", []string{
+		"downstream_local_address", "downstream_remote_address",
+		"requested_server_name", "route_name", "connection_id",
+	}},
+}
+
+var waypointGroups = append([]fieldGroup{
+	{"Waypoint Info", []string{
+		"waypoint.service", "waypoint.port", "waypoint.protocol",
+	}},
+}, sidecarGroups...)
+
+var ztunnelGroups = []fieldGroup{
+	{"Connection Info", []string{
+		"timestamp", "kubelet_timestamp", "level", "scope", "message", "direction",
+		"duration", "bytes_sent", "bytes_recv", "error",
+	}},
+	{"Source", []string{
+		"src.addr", "src.workload", "src.namespace", "src.identity",
+	}},
+	{"Destination", []string{
+		"dst.addr", "dst.hbone_addr", "dst.service", "dst.workload",
+		"dst.namespace", "dst.identity",
+	}},
+}
+
+// accessLogFields mark an Envoy access log; any one of them is enough.
+var accessLogFields = []string{"response_flags", "upstream_cluster", "response_code", "upstream_host"}
+
+// genericLeadFields are shown first, in this order, for application logs.
+var genericLeadFields = []string{
+	"timestamp", "time", "ts", "kubelet_timestamp", "level", "scope", "logger", "caller", "message", "msg", "error",
+}
+
+// detectProfile guesses which proxy produced the log from the fields present.
+func detectProfile(log ParsedLog) logProfile {
+	for key := range log.Fields {
+		if strings.HasPrefix(key, "src.") || strings.HasPrefix(key, "dst.") {
+			return profileZtunnel
+		}
+	}
+	if cluster, ok := log.Fields["upstream_cluster"].(string); ok && strings.HasPrefix(cluster, "inbound-vip|") {
+		return profileWaypoint
+	}
+	for _, field := range accessLogFields {
+		if _, ok := log.Fields[field]; ok {
+			return profileSidecar
+		}
+	}
+	return profileGeneric
+}
+
+// detailGroupsFor returns the detail layout for a profile. Application logs
+// have no fixed schema, so their layout is built from the fields present.
+func detailGroupsFor(profile logProfile, fields map[string]interface{}) []fieldGroup {
+	switch profile {
+	case profileWaypoint:
+		return withQueryField(withTLSGroup(waypointGroups, fields), fields)
+	case profileZtunnel:
+		return ztunnelGroups
+	case profileGeneric:
+		return genericGroups(fields)
+	}
+	return withQueryField(withTLSGroup(sidecarGroups, fields), fields)
+}
+
+// genericGroups shows the well-known fields of an application log first and
+// every other field after them, sorted by name.
+func genericGroups(fields map[string]interface{}) []fieldGroup {
+	event := fieldGroup{name: "Event"}
+	lead := make(map[string]bool, len(genericLeadFields))
+	for _, field := range genericLeadFields {
+		lead[field] = true
+		if _, ok := fields[field]; ok {
+			event.fields = append(event.fields, field)
+		}
+	}
+
+	rest := fieldGroup{name: "Fields"}
+	for field := range fields {
+		if !lead[field] {
+			rest.fields = append(rest.fields, field)
+		}
+	}
+	sort.Strings(rest.fields)
+
+	var groups []fieldGroup
+	for _, group := range []fieldGroup{event, rest} {
+		if len(group.fields) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// detailFields returns the fields to show for a log, including values derived
+// for its profile and the path split from its query, see splitPathQuery. The
+// log's own fields are never modified.
+func detailFields(log ParsedLog, profile logProfile) map[string]interface{} {
+	class := trafficClass(log)
+	bare, params, hasQuery := splitPathQuery(log.Path())
+	if profile != profileWaypoint && log.KubeTimestamp.IsZero() && class == "" && !hasQuery {
+		return log.Fields
+	}
+
+	fields := make(map[string]interface{}, len(log.Fields)+4)
+	for key, value := range log.Fields {
+		fields[key] = value
+	}
+
+	if !log.KubeTimestamp.IsZero() {
+		fields["kubelet_timestamp"] = log.KubeTimestamp.Format(time.RFC3339Nano)
+	}
+	if class != "" {
+		fields["traffic_class"] = class
+	}
+	if hasQuery {
+		fields["path"] = bare
+		fields["query"] = params
+	}
+
+	// Waypoint clusters look like inbound-vip|9080|http|reviews.default.svc.cluster.local
+	if cluster, ok := log.Fields["upstream_cluster"].(string); ok && profile == profileWaypoint {
+		parts := strings.Split(cluster, "|")
+		if len(parts) == 4 {
+			fields["waypoint.port"] = parts[1]
+			fields["waypoint.protocol"] = parts[2]
+			fields["waypoint.service"] = parts[3]
+		}
+	}
+	return fields
+}
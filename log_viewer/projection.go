@@ -0,0 +1,109 @@
+// log_viewer/projection.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// projectedField is one output field of a projection.
+type projectedField struct {
+	name string // Key in the output record
+	path string // Field to read, see lookupPath
+}
+
+// projection shapes the records written by export and transform, set with
+// -fields. It is either a comma-separated list of fields,
+//
+//	start_time,response_code,path
+//
+// or a jq-like object construction that can rename and reach into nested
+// objects:
+//
+//	{start_time, code: .response_code, route: .route.name}
+//
+// Fields missing from an entry are null. A nil projection keeps every field.
+type projection []projectedField
+
+// parseProjection parses a -fields value.
+func parseProjection(expr string) (projection, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(expr, "{") {
+		if !strings.HasSuffix(expr, "}") {
+			return nil, fmt.Errorf("projection %q opens an object it does not close", expr)
+		}
+		expr = expr[1 : len(expr)-1]
+	}
+
+	var p projection
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(expr, ",") {
+		name, path, renamed := strings.Cut(item, ":")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !renamed {
+			path = name
+			name = strings.TrimPrefix(name, ".")
+		}
+		path = strings.TrimPrefix(path, ".")
+		if name == "" || path == "" {
+			return nil, fmt.Errorf("projection %q has an empty field", expr)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("projection %q names %s twice", expr, name)
+		}
+		seen[name] = true
+		p = append(p, projectedField{name: name, path: path})
+	}
+	return p, nil
+}
+
+// names returns the output keys in the order they were given.
+func (p projection) names() []string {
+	names := make([]string, len(p))
+	for i, field := range p {
+		names[i] = field.name
+	}
+	return names
+}
+
+// apply returns the projected record. A nil projection returns fields as is.
+func (p projection) apply(fields map[string]interface{}) map[string]interface{} {
+	if p == nil {
+		return fields
+	}
+	record := make(map[string]interface{}, len(p))
+	for _, field := range p {
+		record[field.name], _ = lookupPath(fields, field.path)
+	}
+	return record
+}
+
+// lookupPath reads a field by name or, failing that, by a dotted path into
+// nested objects. Names are tried first since flattened fields such as
+// src.addr contain dots themselves.
+func lookupPath(fields map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := fields[path]; ok {
+		return value, true
+	}
+	for i := strings.Index(path, "."); i >= 0; i = nextDot(path, i) {
+		if nested, ok := fields[path[:i]].(map[string]interface{}); ok {
+			if value, ok := lookupPath(nested, path[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// nextDot returns the index of the first dot in path after index i, or -1.
+func nextDot(path string, i int) int {
+	next := strings.Index(path[i+1:], ".")
+	if next < 0 {
+		return -1
+	}
+	return i + 1 + next
+}
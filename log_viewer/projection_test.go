@@ -0,0 +1,79 @@
+// log_viewer/projection_test.go
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProjection(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected projection
+		wantErr  bool
+	}{
+		{"", nil, false},
+		{"start_time, response_code,path", projection{
+			{"start_time", "start_time"}, {"response_code", "response_code"}, {"path", "path"},
+		}, false},
+		{"{start_time, code: .response_code, route: .route.name}", projection{
+			{"start_time", "start_time"}, {"code", "response_code"}, {"route", "route.name"},
+		}, false},
+		{".src.addr", projection{{"src.addr", "src.addr"}}, false},
+		{"{path", nil, true},
+		{"path,,code", nil, true},
+		{"path, path", nil, true},
+	}
+	for _, tt := range tests {
+		p, err := parseProjection(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseProjection(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(p, tt.expected) {
+			t.Errorf("parseProjection(%q) = %v, expected %v", tt.expr, p, tt.expected)
+		}
+	}
+}
+
+func TestProjectionApply(t *testing.T) {
+	p, err := parseProjection("{code: .response_code, route: .route.name, src.addr, missing}")
+	if err != nil {
+		t.Fatalf("parseProjection() unexpected error: %v", err)
+	}
+	fields := map[string]interface{}{
+		"response_code": 200.0,
+		"route":         map[string]interface{}{"name": "default"},
+		"src.addr":      "10.0.0.1:80",
+		"path":          "/",
+	}
+	expected := map[string]interface{}{
+		"code":     200.0,
+		"route":    "default",
+		"src.addr": "10.0.0.1:80",
+		"missing":  nil,
+	}
+	if record := p.apply(fields); !reflect.DeepEqual(record, expected) {
+		t.Errorf("apply() = %v, expected %v", record, expected)
+	}
+}
+
+func TestExportTableProjection(t *testing.T) {
+	p, err := parseProjection("response_code,path,route.name")
+	if err != nil {
+		t.Fatalf("parseProjection() unexpected error: %v", err)
+	}
+	columns, rows := exportTable(mustParse(t, exportTestLines...), nil, p)
+	expected := []exportColumn{
+		{"response_code", columnNumber},
+		{"path", columnString},
+		{"route.name", columnString},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("exportTable() columns = %v, expected %v", columns, expected)
+	}
+	if rows[0]["route.name"] != "default" {
+		t.Errorf("exportTable() first row = %v, expected route.name default", rows[0])
+	}
+}
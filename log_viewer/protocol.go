@@ -0,0 +1,64 @@
+// log_viewer/protocol.go
+
+package main
+
+import "strings"
+
+// Protocol names used for badges and stats.
+const (
+	protocolHTTP1 = "HTTP/1.1"
+	protocolHTTP2 = "HTTP/2"
+	protocolHTTP3 = "HTTP/3"
+	protocolGRPC  = "gRPC"
+)
+
+// requestProtocol returns the application protocol of an access log entry,
+// or "" for TCP and entries that do not log one. gRPC is told apart from
+// plain HTTP/2 by its status or content type, when logged.
+func requestProtocol(log ParsedLog) string {
+	if status, _ := log.Fields["grpc_status"].(string); status != "" && status != "-" {
+		return protocolGRPC
+	}
+	if contentType, _ := log.Fields["content_type"].(string); strings.HasPrefix(contentType, "application/grpc") {
+		return protocolGRPC
+	}
+
+	protocol, _ := log.Fields["protocol"].(string)
+	switch strings.ToUpper(protocol) {
+	case "HTTP/1.0", "HTTP/1.1":
+		return protocolHTTP1
+	case "HTTP/2", "HTTP/2.0":
+		return protocolHTTP2
+	case "HTTP/3":
+		return protocolHTTP3
+	}
+	return ""
+}
+
+// protocolBadge is the short form shown in the list.
+func protocolBadge(protocol string) string {
+	switch protocol {
+	case protocolHTTP1:
+		return "h1"
+	case protocolHTTP2:
+		return "h2"
+	case protocolHTTP3:
+		return "h3"
+	case protocolGRPC:
+		return "grpc"
+	}
+	return ""
+}
+
+// explainProtocol notes what the downstream protocol says about the hop.
+func explainProtocol(value string) string {
+	switch strings.ToUpper(value) {
+	case "HTTP/1.0", "HTTP/1.1":
+		return "not upgraded; sidecars only upgrade to HTTP/2 with h2UpgradePolicy: UPGRADE"
+	case "HTTP/2", "HTTP/2.0":
+		return "multiplexed"
+	case "HTTP/3":
+		return "QUIC"
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+// log_viewer/protocol_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestProtocol(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]interface{}
+		want   string
+		badge  string
+	}{
+		{"HTTP/1.1", map[string]interface{}{"protocol": "HTTP/1.1"}, protocolHTTP1, "h1"},
+		{"HTTP/2", map[string]interface{}{"protocol": "HTTP/2"}, protocolHTTP2, "h2"},
+		{"HTTP/3", map[string]interface{}{"protocol": "HTTP/3"}, protocolHTTP3, "h3"},
+		{"gRPC by status", map[string]interface{}{"protocol": "HTTP/2", "grpc_status": "0"}, protocolGRPC, "grpc"},
+		{"gRPC by content type", map[string]interface{}{"protocol": "HTTP/2", "content_type": "application/grpc+proto"}, protocolGRPC, "grpc"},
+		{"unset gRPC status", map[string]interface{}{"protocol": "HTTP/2", "grpc_status": "-"}, protocolHTTP2, "h2"},
+		{"TCP", map[string]interface{}{"protocol": "-", "upstream_cluster": "outbound|3306||mysql"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := ParsedLog{Fields: tt.fields}
+			got := requestProtocol(log)
+			if got != tt.want || protocolBadge(got) != tt.badge {
+				t.Errorf("requestProtocol() = %q (badge %q), want %q (badge %q)", got, protocolBadge(got), tt.want, tt.badge)
+			}
+			if preview := formatLogPreview(log, 80); tt.badge != "" && !strings.Contains(preview, tt.badge) {
+				t.Errorf("expected the list preview to show %s, got %q", tt.badge, preview)
+			}
+		})
+	}
+}
+
+func TestSummarizeByProtocol(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":200,"protocol":"HTTP/2","duration":5}`,
+		`{"response_code":503,"protocol":"HTTP/2","response_flags":"UF","duration":5}`,
+		`{"response_code":200,"protocol":"HTTP/1.1","duration":5}`,
+		`{"response_code":200,"protocol":"HTTP/2","grpc_status":"0","duration":5}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	var report strings.Builder
+	summarize(logs, "stdin").writeMarkdown(&report)
+	for _, row := range []string{
+		"| HTTP/2 | 2 | 50.0% | 1 | 50.0% |",
+		"| HTTP/1.1 | 1 | 25.0% | 0 | 0.0% |",
+		"| gRPC | 1 | 25.0% | 0 | 0.0% |",
+	} {
+		if !strings.Contains(report.String(), row) {
+			t.Errorf("expected the protocol table to contain %q, got:\n%s", row, report.String())
+		}
+	}
+}
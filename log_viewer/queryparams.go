@@ -0,0 +1,131 @@
+// log_viewer/queryparams.go
+
+package main
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The details, transform and exports show a path's query string as a nested
+// query field, one decoded value per parameter and a list for repeated ones,
+// leaving path without it. Filters match parameters with query.<name>=<value>
+// terms, and -compare query.<name> groups requests by one.
+
+// queryPrefix starts filter terms and -compare values naming a parameter.
+const queryPrefix = "query."
+
+// splitPathQuery splits path into the path proper and its parsed query
+// parameters. ok is false when path has no query string.
+func splitPathQuery(path string) (bare string, params map[string]interface{}, ok bool) {
+	bare, rawQuery, hasQuery := strings.Cut(path, "?")
+	if !hasQuery {
+		return path, nil, false
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil && len(values) == 0 {
+		return path, nil, false
+	}
+	params = make(map[string]interface{}, len(values))
+	for name, vs := range values {
+		if len(vs) == 1 {
+			params[name] = vs[0]
+			continue
+		}
+		list := make([]interface{}, len(vs))
+		for i, v := range vs {
+			list[i] = v
+		}
+		params[name] = list
+	}
+	return bare, params, true
+}
+
+// barePath returns the entry's path without its query string.
+func barePath(log ParsedLog) string {
+	path, _, _ := strings.Cut(log.Path(), "?")
+	return path
+}
+
+// queryParam returns the values of the named parameter of the entry's path.
+func queryParam(log ParsedLog, name string) []string {
+	_, rawQuery, ok := strings.Cut(log.Path(), "?")
+	if !ok {
+		return nil
+	}
+	values, _ := url.ParseQuery(rawQuery)
+	return values[name]
+}
+
+// formatQuery shows parsed parameters on one line, sorted by name.
+func formatQuery(params map[string]interface{}) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		switch v := params[name].(type) {
+		case []interface{}:
+			for _, each := range v {
+				pairs = append(pairs, name+"="+exportString(each))
+			}
+		default:
+			pairs = append(pairs, name+"="+exportString(v))
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+// withQueryField returns groups with query shown after path when fields has
+// one, so paths without a query string get no empty row.
+func withQueryField(groups []fieldGroup, fields map[string]interface{}) []fieldGroup {
+	if _, ok := fields["query"]; !ok {
+		return groups
+	}
+	withQuery := make([]fieldGroup, 0, len(groups))
+	for _, group := range groups {
+		if i := slices.Index(group.fields, "path"); i >= 0 {
+			group.fields = slices.Insert(slices.Clone(group.fields), i+1, "query")
+		}
+		withQuery = append(withQuery, group)
+	}
+	return withQuery
+}
+
+// fieldTerm keeps entries with a value for a name, such as a query
+// parameter of the path.
+type fieldTerm struct {
+	name, value string
+}
+
+// parseFieldTerm parses a <prefix><name>=<value> term.
+func parseFieldTerm(word, prefix string) (fieldTerm, bool) {
+	name, value, ok := strings.Cut(strings.TrimPrefix(word, prefix), "=")
+	if !strings.HasPrefix(word, prefix) || !ok || name == "" {
+		return fieldTerm{}, false
+	}
+	return fieldTerm{name: name, value: value}, true
+}
+
+// matchesQuery reports whether the entry's path satisfies every
+// query.<name>=<value> term. Values are compared decoded and exactly, so
+// "query.user_id=42" does not keep 420.
+func matchesQuery(log ParsedLog, terms []fieldTerm) bool {
+	for _, term := range terms {
+		found := false
+		for _, value := range queryParam(log, term.name) {
+			if value == term.value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+// log_viewer/queryparams_test.go
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var queryTestLines = []string{
+	`{"method":"GET","path":"/api/users?user_id=42&tag=a&tag=b%20c","response_code":200}`,
+	`{"method":"GET","path":"/api/users?user_id=420","response_code":200}`,
+	`{"method":"GET","path":"/api/users","response_code":404}`,
+}
+
+func TestSplitPathQuery(t *testing.T) {
+	bare, params, ok := splitPathQuery("/api/users?user_id=42&tag=a&tag=b%20c")
+	if !ok || bare != "/api/users" {
+		t.Fatalf("splitPathQuery() = %q, %v, expected /api/users", bare, ok)
+	}
+	expected := map[string]interface{}{"user_id": "42", "tag": []interface{}{"a", "b c"}}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("splitPathQuery() params = %v, expected %v", params, expected)
+	}
+	if got := formatQuery(params); got != "tag=a tag=b c user_id=42" {
+		t.Errorf("formatQuery() = %q", got)
+	}
+	if _, _, ok := splitPathQuery("/api/users"); ok {
+		t.Error("expected a path without a query string not to split")
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	logs := mustParse(t, queryTestLines...)
+	got := filterLogs(logs, "query.user_id=42")
+	if len(got) != 1 || !strings.Contains(got[0].RawLog, "user_id=42&") {
+		t.Errorf("expected only user_id 42 to be kept, got %v", got)
+	}
+	if got := filterLogs(logs, "query.tag=b c"); len(got) != 0 {
+		// The space ends the term, leaving "c" to search for as text
+		t.Errorf("expected no matches, got %d", len(got))
+	}
+	if got := filterLogs(logs, "query.tag=a query.user_id=42 200"); len(got) != 1 {
+		t.Errorf("expected every term and the rest of the query to apply, got %d", len(got))
+	}
+	if got := filterLogs(logs, "200 query.user_id=42 query.tag=a"); len(got) != 1 {
+		t.Errorf("expected the terms to apply after the text, got %d", len(got))
+	}
+	if got := filterLogs(logs, "query.user_id=7"); len(got) != 0 {
+		t.Errorf("expected no matches for another value, got %d", len(got))
+	}
+}
+
+func TestQueryFields(t *testing.T) {
+	logs := mustParse(t, queryTestLines...)
+	fields := transformFields(logs[0])
+	if fields["path"] != "/api/users" {
+		t.Errorf("expected path without its query, got %v", fields["path"])
+	}
+	if query, ok := fields["query"].(map[string]interface{}); !ok || query["user_id"] != "42" {
+		t.Errorf("expected the parsed query, got %v", fields["query"])
+	}
+	if logs[0].Path() != "/api/users?user_id=42&tag=a&tag=b%20c" {
+		t.Error("expected the log's own path to be left alone")
+	}
+	if _, ok := transformFields(logs[2])["query"]; ok {
+		t.Error("expected no query field for a path without one")
+	}
+
+	if got := compareValue(logs[1], "path"); got != "/api/users" {
+		t.Errorf("compareValue(path) = %q, expected the path without its query", got)
+	}
+	if got := compareValue(logs[1], "query.user_id"); got != "420" {
+		t.Errorf("compareValue(query.user_id) = %q, expected 420", got)
+	}
+
+	detail := renderDetailPane(logs[0], detailExtras{}, 120, 60, false)
+	if !strings.Contains(detail, "tag=a tag=b c user_id=42") {
+		t.Errorf("expected the details to show the parameters, got:\n%s", detail)
+	}
+}
@@ -0,0 +1,180 @@
+// log_viewer/rbac.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rbacRule is a single permission the viewer needs.
+type rbacRule struct {
+	verb        string
+	resource    string
+	subresource string
+	namespace   string
+}
+
+func (r rbacRule) resourceName() string {
+	if r.subresource == "" {
+		return r.resource
+	}
+	return r.resource + "/" + r.subresource
+}
+
+// String formats the rule as it would appear in a Role, or in a ClusterRole
+// for cluster-scoped resources.
+func (r rbacRule) String() string {
+	if r.namespace == "" {
+		return fmt.Sprintf(`apiGroups: [""], resources: [%q], verbs: [%q] cluster-wide`, r.resourceName(), r.verb)
+	}
+	return fmt.Sprintf(`apiGroups: [""], resources: [%q], verbs: [%q] in namespace %q`, r.resourceName(), r.verb, r.namespace)
+}
+
+// requiredRules lists what the viewer cannot read logs without. The features
+// built on top of them need more, see optionalRules.
+func requiredRules(namespace string, listPods bool) []rbacRule {
+	rules := []rbacRule{{verb: "get", resource: "pods", subresource: "log", namespace: namespace}}
+	if listPods {
+		rules = append(rules, rbacRule{verb: "list", resource: "pods", namespace: namespace})
+	}
+	return rules
+}
+
+// optionalRule is a permission a feature needs. Without it the feature is
+// left out and logs are read as before.
+type optionalRule struct {
+	rbacRule
+	feature string
+}
+
+// optionalRules lists what the enabled features do against the API on top
+// of requiredRules: the TUI's, when tui is set, and looking up the pod's
+// fields and where it runs.
+func optionalRules(namespace string, tui bool) []optionalRule {
+	var rules []optionalRule
+	if tui {
+		rules = append(rules,
+			optionalRule{rbacRule{verb: "list", resource: "pods", namespace: namespace}, "naming addresses after pods"},
+			optionalRule{rbacRule{verb: "list", resource: "services", namespace: namespace}, "naming addresses after services"},
+			optionalRule{rbacRule{verb: "get", resource: "pods", namespace: namespace}, "events of the pod's workload"},
+			optionalRule{rbacRule{verb: "list", resource: "events", namespace: namespace}, "events of the pod's workload"},
+			optionalRule{rbacRule{verb: "list", resource: "namespaces"}, "picking pods in other namespaces"},
+		)
+	}
+	return append(rules,
+		optionalRule{rbacRule{verb: "get", resource: "pods", namespace: namespace}, "pod.* fields"},
+		optionalRule{rbacRule{verb: "list", resource: "pods", namespace: namespace}, "pod.* fields"},
+		optionalRule{rbacRule{verb: "get", resource: "nodes"}, "pod.zone"},
+	)
+}
+
+// allowed asks the API server whether the current (or impersonated) user
+// holds rule.
+func allowed(ctx context.Context, client kubernetes.Interface, rule rbacRule) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   rule.namespace,
+				Verb:        rule.verb,
+				Resource:    rule.resource,
+				Subresource: rule.subresource,
+			},
+		},
+	}
+	result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+// missingOptional returns a line per feature left out for want of one of
+// rules, naming the rule. Each rule is asked about once; those the server
+// cannot answer for are assumed held.
+func missingOptional(ctx context.Context, client kubernetes.Interface, rules []optionalRule) []string {
+	var missing []string
+	held := make(map[rbacRule]bool)
+	for _, rule := range rules {
+		ok, asked := held[rule.rbacRule]
+		if !asked {
+			var err error
+			ok, err = allowed(ctx, client, rule.rbacRule)
+			ok = ok || err != nil
+			held[rule.rbacRule] = ok
+		}
+		if ok {
+			continue
+		}
+		line := fmt.Sprintf("%s needs %s", rule.feature, rule.rbacRule)
+		if !slices.Contains(missing, line) {
+			missing = append(missing, line)
+		}
+	}
+	return missing
+}
+
+// reportOptionalAccess warns about the features the user lacks the access
+// for, before the logs are read without them.
+func reportOptionalAccess(ctx context.Context, client kubernetes.Interface, rules []optionalRule) {
+	for _, line := range missingOptional(ctx, client, rules) {
+		fmt.Fprintf(os.Stderr, "Warning: left out, %s\n", line)
+		log.Println("Missing optional permission:", line)
+	}
+}
+
+// checkAccess asks the API server whether the current (or impersonated) user
+// holds every rule, returning an error naming the missing ones. When the
+// server cannot answer, the check is skipped and the fetch itself reports any
+// problem.
+func checkAccess(ctx context.Context, client kubernetes.Interface, rules []rbacRule) error {
+	var missing []string
+	for _, rule := range rules {
+		ok, err := allowed(ctx, client, rule)
+		if err != nil {
+			return nil
+		}
+		if !ok {
+			missing = append(missing, rule.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return &kubeError{
+			err:  fmt.Errorf("missing RBAC permissions:\n  %s", strings.Join(missing, "\n  ")),
+			hint: "ask a cluster admin for a Role with these rules, or pass -skip-access-check to try anyway",
+		}
+	}
+	return nil
+}
+
+// forbiddenPattern matches the rule the API server names in a Forbidden error.
+var forbiddenPattern = regexp.MustCompile(`cannot (\w+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)")?`)
+
+// forbiddenRule extracts the missing rule from a Forbidden error.
+func forbiddenRule(err error) (string, bool) {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || !apierrors.IsForbidden(err) {
+		return "", false
+	}
+	match := forbiddenPattern.FindStringSubmatch(status.Status().Message)
+	if match == nil {
+		return "", false
+	}
+	rule := fmt.Sprintf(`apiGroups: [%q], resources: [%q], verbs: [%q]`, match[3], match[2], match[1])
+	if match[4] != "" {
+		rule += fmt.Sprintf(" in namespace %q", match[4])
+	}
+	return rule, true
+}
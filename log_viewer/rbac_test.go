@@ -0,0 +1,120 @@
+// log_viewer/rbac_test.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// reviewClient answers access reviews by allowing only the given resources.
+func reviewClient(allowed ...string) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		resource := attributes.Verb + " " + attributes.Resource
+		if attributes.Subresource != "" {
+			resource += "/" + attributes.Subresource
+		}
+		for _, allow := range allowed {
+			review.Status.Allowed = review.Status.Allowed || allow == resource
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+func TestCheckAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		listPods    bool
+		wantMissing []string
+	}{
+		{"Single pod allowed", []string{"get pods/log"}, false, nil},
+		{"Single pod denied", nil, false, []string{`resources: ["pods/log"], verbs: ["get"]`}},
+		{"Selector needs list", []string{"get pods/log"}, true, []string{`resources: ["pods"], verbs: ["list"]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccess(context.Background(), reviewClient(tt.allowed...), requiredRules("default", tt.listPods))
+			if len(tt.wantMissing) == 0 {
+				if err != nil {
+					t.Errorf("checkAccess() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected missing permissions %v", tt.wantMissing)
+			}
+			for _, rule := range tt.wantMissing {
+				if !strings.Contains(err.Error(), rule) {
+					t.Errorf("expected %q in %v", rule, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMissingOptional(t *testing.T) {
+	client := reviewClient("get pods/log", "list pods", "get pods", "list events")
+	missing := missingOptional(context.Background(), client, optionalRules("default", true))
+	expected := []string{
+		`naming addresses after services needs apiGroups: [""], resources: ["services"], verbs: ["list"] in namespace "default"`,
+		`picking pods in other namespaces needs apiGroups: [""], resources: ["namespaces"], verbs: ["list"] cluster-wide`,
+		`pod.zone needs apiGroups: [""], resources: ["nodes"], verbs: ["get"] cluster-wide`,
+	}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("missingOptional() = %q, expected %q", missing, expected)
+	}
+
+	// Reading logs alone asks only for the pod lookups
+	for _, rule := range optionalRules("default", false) {
+		if rule.feature != "pod.* fields" && rule.feature != "pod.zone" {
+			t.Errorf("expected only the pod lookups without the TUI, got %v", rule)
+		}
+	}
+	// Nor does the check stand in the way when reviews fail
+	failing := fake.NewSimpleClientset()
+	failing.PrependReactor("create", "selfsubjectaccessreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("the server could not find the requested resource")
+	})
+	if missing := missingOptional(context.Background(), failing, optionalRules("default", true)); len(missing) != 0 {
+		t.Errorf("expected nothing reported without answers, got %q", missing)
+	}
+}
+
+func TestForbiddenRule(t *testing.T) {
+	forbidden := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusForbidden,
+		Reason:  metav1.StatusReasonForbidden,
+		Message: `pods "reviews" is forbidden: User "jane" cannot get resource "pods/log" in API group "" in the namespace "default"`,
+	}}
+
+	rule, ok := forbiddenRule(fmt.Errorf("error streaming logs from pod default/reviews: %w", forbidden))
+	if !ok {
+		t.Fatalf("expected a rule from %v", forbidden)
+	}
+	expected := `apiGroups: [""], resources: ["pods/log"], verbs: ["get"] in namespace "default"`
+	if rule != expected {
+		t.Errorf("forbiddenRule() = %s, expected %s", rule, expected)
+	}
+
+	if hint := kubeHint(forbidden); !strings.Contains(hint, expected) {
+		t.Errorf("expected the forbidden hint to name the rule, got %q", hint)
+	}
+}
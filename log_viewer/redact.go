@@ -0,0 +1,166 @@
+// log_viewer/redact.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces whatever a redaction rule matches.
+const redactedValue = "[REDACTED]"
+
+// RedactRule masks sensitive data before it is shown, searched, exported or
+// reported. A rule either masks the whole value of fields with a name, at any
+// depth, or masks the matches of a regular expression in every value:
+//
+//	{"redact": [{"field": "x-api-key"}, {"pattern": "session=[0-9a-f]+"}]}
+type RedactRule struct {
+	Field   string `json:"field,omitempty"`   // Field name, case and - versus _ do not matter
+	Pattern string `json:"pattern,omitempty"` // Regular expression
+
+	re *regexp.Regexp
+}
+
+// compile checks the rule and parses its pattern.
+func (r *RedactRule) compile() error {
+	if (r.Field == "") == (r.Pattern == "") {
+		return fmt.Errorf("a redaction rule needs either a field or a pattern")
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("redaction pattern %q: %v", r.Pattern, err)
+		}
+		r.re = re
+	}
+	return nil
+}
+
+// defaultRedactRules are what -redact masks: credentials in headers and
+// fields, bearer tokens and JWTs anywhere, email addresses and IPv4
+// addresses. Ports are kept, they rarely identify anyone.
+func defaultRedactRules() []RedactRule {
+	rules := []RedactRule{
+		{Field: "authorization"},
+		{Field: "proxy-authorization"},
+		{Field: "cookie"},
+		{Field: "set-cookie"},
+		{Field: "x-api-key"},
+		{Field: "access_token"},
+		{Field: "password"},
+		{Pattern: `(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`},
+		{Pattern: `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`},
+		{Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`},
+		{Pattern: `\b(?:\d{1,3}\.){3}\d{1,3}\b`},
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			panic(err)
+		}
+	}
+	return rules
+}
+
+// redactRulesFor returns the configured rules, plus the defaults with -redact.
+func redactRulesFor(opts cliOptions, cfg Config) []RedactRule {
+	if !opts.redact {
+		return cfg.Redact
+	}
+	return append(defaultRedactRules(), cfg.Redact...)
+}
+
+func redactFieldName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+// redactLog returns log with the rules applied to its fields and raw text.
+// A JSON entry that changed gets its raw log re-encoded from the masked
+// fields, other entries have the masked values replaced in their text.
+func redactLog(log ParsedLog, rules []RedactRule) ParsedLog {
+	if len(rules) == 0 {
+		return log
+	}
+	fields := make(map[string]string)
+	for _, rule := range rules {
+		if rule.Field != "" {
+			fields[redactFieldName(rule.Field)] = rule.Field
+		}
+	}
+
+	var masked []string // Whole values masked by field rules
+	redacted, changed := redactValue(log.Fields, false, fields, rules, &masked)
+	raw := redactText(log.RawLog, rules)
+	if !changed && raw == log.RawLog {
+		return log
+	}
+
+	log.Fields, _ = redacted.(map[string]interface{})
+	if strings.HasPrefix(strings.TrimSpace(log.RawLog), "{") {
+		if data, err := json.Marshal(log.Fields); err == nil {
+			raw = string(data)
+		}
+	} else {
+		for _, value := range masked {
+			raw = strings.ReplaceAll(raw, value, redactedValue)
+		}
+	}
+	log.RawLog = raw
+	return log.indexed()
+}
+
+// redactValue masks value, or the whole of it when mask is set, recording the
+// strings masked by field rules. Maps and slices are copied, never changed in
+// place.
+func redactValue(value interface{}, mask bool, fields map[string]string, rules []RedactRule, masked *[]string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		changed := false
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			_, maskChild := fields[redactFieldName(key)]
+			redacted, childChanged := redactValue(child, mask || maskChild, fields, rules, masked)
+			out[key] = redacted
+			changed = changed || childChanged
+		}
+		return out, changed
+	case []interface{}:
+		changed := false
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			redacted, childChanged := redactValue(child, mask, fields, rules, masked)
+			out[i] = redacted
+			changed = changed || childChanged
+		}
+		return out, changed
+	case nil:
+		return v, false
+	case string:
+		if mask {
+			if v == "" || v == "-" {
+				// Nothing to hide, and "-" is Envoy's unset
+				return v, false
+			}
+			*masked = append(*masked, v)
+			return redactedValue, true
+		}
+		redacted := redactText(v, rules)
+		return redacted, redacted != v
+	}
+	if mask {
+		return redactedValue, true
+	}
+	return value, false
+}
+
+// redactText masks the matches of the pattern rules in s.
+func redactText(s string, rules []RedactRule) string {
+	for _, rule := range rules {
+		if rule.re != nil {
+			s = rule.re.ReplaceAllString(s, redactedValue)
+		}
+	}
+	return s
+}
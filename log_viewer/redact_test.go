@@ -0,0 +1,104 @@
+// log_viewer/redact_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactLog(t *testing.T) {
+	line := `{"authority":"reviews:9080","downstream_remote_address":"10.1.2.3:51234","request_headers":{"Authorization":"Basic dXNlcjpwYXNz"},` +
+		`"user_agent":"curl","path":"/login?email=jane@example.com","x_forwarded_for":"bearer abc.def-123","response_code":200}`
+	logs, err := parseRawLogsWith([]string{line}, parseOptions{redact: defaultRedactRules()})
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	log := logs[0]
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"downstream_remote_address", "[REDACTED]:51234"},
+		{"path", "/login?email=[REDACTED]"},
+		{"x_forwarded_for", "[REDACTED]"},
+		{"authority", "reviews:9080"},
+		{"user_agent", "curl"},
+	}
+	for _, tt := range tests {
+		if got := log.Fields[tt.field]; got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+	headers, _ := log.Fields["request_headers"].(map[string]interface{})
+	if got := headers["Authorization"]; got != redactedValue {
+		t.Errorf("expected the nested Authorization header to be masked, got %q", got)
+	}
+
+	for _, secret := range []string{"10.1.2.3", "jane@example.com", "dXNlcjpwYXNz", "abc.def-123"} {
+		if strings.Contains(log.RawLog, secret) {
+			t.Errorf("expected %q to be masked in the raw log, got %s", secret, log.RawLog)
+		}
+		if filterLogs(logs, secret) != nil {
+			t.Errorf("expected searching for %q to find nothing", secret)
+		}
+	}
+	if log.Fields["response_code"] != json.Number("200") {
+		t.Errorf("expected numbers to be left alone, got %v", log.Fields["response_code"])
+	}
+}
+
+func TestRedactTextLog(t *testing.T) {
+	rules := []RedactRule{{Pattern: `token=\w+`}}
+	if err := rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	original, err := ParseTextLog("2024-01-01T00:00:00.000000Z\tinfo\tads\tfetched token=s3cr3t from 10.0.0.1", 1)
+	if err != nil {
+		t.Fatalf("ParseTextLog() unexpected error: %v", err)
+	}
+	redacted := redactLog(original, rules)
+	if strings.Contains(redacted.RawLog, "s3cr3t") || !strings.Contains(redacted.RawLog, "10.0.0.1") {
+		t.Errorf("expected only the token masked in the raw text, got %q", redacted.RawLog)
+	}
+	if !strings.Contains(original.RawLog, "s3cr3t") {
+		t.Error("expected the original entry to be left unchanged")
+	}
+}
+
+func TestRedactRuleConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"field", `{"redact": [{"field": "x-api-key"}]}`, false},
+		{"pattern", `{"redact": [{"pattern": "session=[0-9a-f]+"}]}`, false},
+		{"neither", `{"redact": [{}]}`, true},
+		{"both", `{"redact": [{"field": "a", "pattern": "b"}]}`, true},
+		{"bad pattern", `{"redact": [{"pattern": "("}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(cfg.Redact) != 1 {
+				t.Errorf("expected one redaction rule, got %d", len(cfg.Redact))
+			}
+		})
+	}
+
+	if rules := redactRulesFor(cliOptions{}, Config{}); len(rules) != 0 {
+		t.Errorf("expected no redaction without -redact or rules, got %d rules", len(rules))
+	}
+}
@@ -0,0 +1,159 @@
+// log_viewer/responseflags.go
+
+package main
+
+import "strings"
+
+// Flag is one of Envoy's response flags, such as UF or URX.
+type Flag string
+
+// flagExplanations describe the flags in words.
+var flagExplanations = map[Flag]string{
+	"UH":   "upstream unhealthy",
+	"UF":   "upstream connection failure",
+	"UO":   "upstream overflow",
+	"NR":   "no route configured",
+	"URX":  "upstream retry limit exceeded",
+	"UT":   "upstream request timeout",
+	"DC":   "downstream connection termination",
+	"LH":   "local service failed health check",
+	"UR":   "upstream retry",
+	"UC":   "upstream connection termination",
+	"DT":   "downstream request timeout",
+	"LR":   "local service rejected",
+	"RL":   "rate limited",
+	"UAEX": "unauthorized external service",
+	"RLSE": "rate limited service error",
+	"IH":   "invalid HTTP response",
+	"SI":   "stream idle timeout",
+	"DPE":  "downstream protocol error",
+	"UPE":  "upstream protocol error",
+	"NC":   "no cluster found",
+	"DI":   "delay injected",
+	"OM":   "overload manager",
+	"DF":   "DNS resolution failed",
+}
+
+// flagTags name the flags in a word or two, for list rows.
+var flagTags = map[Flag]string{
+	"UH":   "no-healthy",
+	"UF":   "conn-fail",
+	"UO":   "overflow",
+	"NR":   "no-route",
+	"URX":  "retry-limit",
+	"UT":   "timeout",
+	"DC":   "client-gone",
+	"LH":   "local-unhealthy",
+	"UR":   "retry",
+	"UC":   "upstream-closed",
+	"DT":   "client-timeout",
+	"LR":   "local-rejected",
+	"RL":   "rate-limited",
+	"UAEX": "unauthorized",
+	"RLSE": "ratelimit-err",
+	"IH":   "invalid-resp",
+	"SI":   "idle-timeout",
+	"DPE":  "client-proto",
+	"UPE":  "upstream-proto",
+	"NC":   "no-cluster",
+	"DI":   "delayed",
+	"OM":   "overload",
+	"DF":   "dns-fail",
+}
+
+// parseResponseFlags splits a response_flags value, "-" having none.
+// Matching whole flags keeps UF from matching inside another flag.
+func parseResponseFlags(flags string) []Flag {
+	var result []Flag
+	for _, flag := range strings.Split(flags, ",") {
+		if flag = strings.TrimSpace(flag); flag != "" && flag != "-" {
+			result = append(result, Flag(flag))
+		}
+	}
+	return result
+}
+
+// Explain describes the flag, or returns "" for one it does not know.
+func (f Flag) Explain() string {
+	return flagExplanations[f]
+}
+
+// IsError reports whether the flag means the request failed. A client
+// hanging up (DC) or injected delay (DI) does not.
+func (f Flag) IsError() bool {
+	return f != "DC" && f != "DI"
+}
+
+// IsTimeout reports whether the flag means a timeout ran out.
+func (f Flag) IsTimeout() bool {
+	switch f {
+	case "UT", "DT", "SI":
+		return true
+	}
+	return false
+}
+
+// IsOverload reports whether the flag means the request was shed for lack of
+// capacity rather than broken: no healthy upstream, circuit breaking or rate
+// limiting.
+func (f Flag) IsOverload() bool {
+	switch f {
+	case "UH", "UO", "RL", "RLSE", "OM":
+		return true
+	}
+	return false
+}
+
+// severity ranks the flag: failures above capacity shedding above flags
+// that do not mean the request failed.
+func (f Flag) severity() int {
+	switch {
+	case f.IsOverload():
+		return 1
+	case f.IsError():
+		return 2
+	}
+	return 0
+}
+
+// mostSevereFlag returns the known flag of flags that says most about the
+// failure, the first of equals.
+func mostSevereFlag(flags []Flag) (Flag, bool) {
+	var found Flag
+	for _, flag := range flags {
+		if flagTags[flag] != "" && (found == "" || flag.severity() > found.severity()) {
+			found = flag
+		}
+	}
+	return found, found != ""
+}
+
+// taggedResponseFlags returns a response_flags value with the most severe
+// flag tagged, as in "UF→conn-fail,URX", and the tag itself. Values without a
+// known flag come back as they are, with an empty tag.
+func taggedResponseFlags(flags string) (string, string) {
+	flag, ok := mostSevereFlag(parseResponseFlags(flags))
+	if !ok {
+		return flags, ""
+	}
+	tag := string(flag) + "→" + flagTags[flag]
+	parts := strings.Split(flags, ",")
+	for i, part := range parts {
+		if strings.TrimSpace(part) == string(flag) {
+			parts[i] = tag
+			break
+		}
+	}
+	return strings.Join(parts, ","), tag
+}
+
+// explainResponseFlags describes each known flag of a response_flags value.
+func explainResponseFlags(flags string) string {
+	var explanations []string
+	for _, flag := range parseResponseFlags(flags) {
+		if explanation := flag.Explain(); explanation != "" {
+			explanations = append(explanations, explanation)
+		}
+	}
+	return strings.Join(explanations, ", ")
+}
@@ -0,0 +1,85 @@
+// log_viewer/responseflags_test.go
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseResponseFlags(t *testing.T) {
+	tests := map[string][]Flag{
+		"-":          nil,
+		"":           nil,
+		"UF":         {"UF"},
+		"UF,URX":     {"UF", "URX"},
+		" UH , UO ,": {"UH", "UO"},
+	}
+	for input, expected := range tests {
+		if got := parseResponseFlags(input); !slices.Equal(got, expected) {
+			t.Errorf("parseResponseFlags(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+}
+
+func TestFlagClassification(t *testing.T) {
+	tests := []struct {
+		flag                       Flag
+		isError, timeout, overload bool
+	}{
+		{"UF", true, false, false},
+		{"UT", true, true, false},
+		{"SI", true, true, false},
+		{"UO", true, false, true},
+		{"RL", true, false, true},
+		{"DC", false, false, false},
+		{"DI", false, false, false},
+	}
+	for _, tt := range tests {
+		if tt.flag.IsError() != tt.isError || tt.flag.IsTimeout() != tt.timeout || tt.flag.IsOverload() != tt.overload {
+			t.Errorf("%s: IsError() = %v, IsTimeout() = %v, IsOverload() = %v, expected %v, %v, %v", tt.flag,
+				tt.flag.IsError(), tt.flag.IsTimeout(), tt.flag.IsOverload(), tt.isError, tt.timeout, tt.overload)
+		}
+	}
+	if Flag("UF").Explain() != "upstream connection failure" || Flag("XYZ").Explain() != "" {
+		t.Errorf("Explain() did not describe UF or described an unknown flag")
+	}
+}
+
+func TestSeverityMatchesWholeFlags(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":503,"response_flags":"UO"}`,
+		`{"response_code":503,"response_flags":"UH,UF"}`,
+		`{"response_code":200,"response_flags":"DC"}`,
+		`{"response_code":200,"response_flags":"-"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	expected := []string{severityWarn, severityError, severityInfo, severityInfo}
+	for i, log := range logs {
+		if got := severityOf(log); got != expected[i] {
+			t.Errorf("severityOf(%s) = %s, expected %s", log.RawLog, got, expected[i])
+		}
+	}
+}
+
+func TestTaggedResponseFlags(t *testing.T) {
+	tests := []struct {
+		flags, tagged, tag string
+	}{
+		{"UF", "UF→conn-fail", "UF→conn-fail"},
+		{"UH,UF", "UH,UF→conn-fail", "UF→conn-fail"},
+		{"DC,UO", "DC,UO→overflow", "UO→overflow"},
+		{"DC", "DC→client-gone", "DC→client-gone"},
+		{"URX", "URX→retry-limit", "URX→retry-limit"},
+		{"LH", "LH→local-unhealthy", "LH→local-unhealthy"},
+		{"XYZ", "XYZ", ""},
+		{"-", "-", ""},
+	}
+	for _, tt := range tests {
+		if tagged, tag := taggedResponseFlags(tt.flags); tagged != tt.tagged || tag != tt.tag {
+			t.Errorf("taggedResponseFlags(%q) = %q, %q, expected %q, %q", tt.flags, tagged, tag, tt.tagged, tt.tag)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// log_viewer/reusedids.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Envoy generates an x-request-id for each request entering the mesh and
+// every hop of the request logs it, so the entries sharing an ID are one
+// request. A client that sends the same ID with every request, or an
+// application that forwards the ID of one request into an unrelated one,
+// breaks that: traces and find-request mix requests together. Entries that
+// requestFlow cannot nest under one call were made by distinct requests.
+
+// topReusedIDs is how many reused request IDs the report lists.
+const topReusedIDs = 10
+
+// reusedID counts what was logged under a request ID distinct requests used.
+type reusedID struct {
+	requests int // Distinct requests, the calls requestFlow has at its root
+	entries  int
+}
+
+// reusedRequestIDs returns the request IDs logged by more than one request.
+// Only access log entries with a time and duration are nested, so reuse only
+// shows between requests that did not overlap.
+func reusedRequestIDs(logs []ParsedLog) map[string]reusedID {
+	byID := make(map[string][]ParsedLog)
+	for _, log := range logs {
+		id := log.RequestID()
+		if id == "" || id == "-" {
+			continue
+		}
+		_, isRequest := log.ResponseCode()
+		_, hasDuration := log.Duration()
+		if _, hasTime := eventTime(log); isRequest && hasDuration && hasTime {
+			byID[id] = append(byID[id], log)
+		}
+	}
+
+	reused := make(map[string]reusedID)
+	for id, entries := range byID {
+		if len(entries) < 2 {
+			continue
+		}
+		if roots := requestFlow(entries, id); len(roots) > 1 {
+			reused[id] = reusedID{requests: len(roots), entries: len(entries)}
+		}
+	}
+	return reused
+}
+
+// topReused returns up to topReusedIDs of the reused IDs, those used by the
+// most requests first.
+func topReused(reused map[string]reusedID) []string {
+	counts := make(map[string]int, len(reused))
+	for id, r := range reused {
+		counts[id] = r.requests
+	}
+	ids := countsByValue(counts)
+	if len(ids) > topReusedIDs {
+		ids = ids[:topReusedIDs]
+	}
+	return ids
+}
+
+// reusedFinding calls out the reused IDs, filtering on the most reused.
+func reusedFinding(reused map[string]reusedID) finding {
+	top := topReused(reused)[0]
+	return finding{
+		message: fmt.Sprintf("%d request IDs were reused by distinct requests, `%s` by %d; tracing cannot tell them apart.",
+			len(reused), top, reused[top].requests),
+		filter: top,
+	}
+}
+
+// writeReusedMarkdown writes the reused request IDs section of the summary.
+func writeReusedMarkdown(w io.Writer, reused map[string]reusedID) {
+	fmt.Fprintf(w, "\n## Reused request IDs\n\n%d request IDs were sent by more than one request, by misbehaving clients or applications forwarding x-request-id.\n", len(reused))
+	fmt.Fprintln(w, "\n| Request ID | Requests | Entries |\n| --- | ---: | ---: |")
+	for _, id := range topReused(reused) {
+		fmt.Fprintf(w, "| %s | %d | %d |\n", markdownCell(id), reused[id].requests, reused[id].entries)
+	}
+}
@@ -0,0 +1,41 @@
+// log_viewer/reusedids_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReusedRequestIDs(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		// One request through the mesh: every hop shares its ID
+		`{"request_id":"abc","start_time":"2024-11-26T02:30:00.000Z","method":"GET","path":"/productpage","response_code":200,"duration":40,"upstream_cluster":"outbound|9080||productpage.default.svc.cluster.local"}`,
+		`{"request_id":"abc","start_time":"2024-11-26T02:30:00.001Z","method":"GET","path":"/productpage","response_code":200,"duration":37,"upstream_cluster":"inbound|9080||","authority":"productpage:9080"}`,
+		`{"request_id":"abc","start_time":"2024-11-26T02:30:00.005Z","method":"GET","path":"/reviews/0","response_code":200,"duration":25,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+		// A client sending the same ID every time
+		`{"request_id":"fixed","start_time":"2024-11-26T02:30:01.000Z","method":"GET","path":"/a","response_code":200,"duration":5,"upstream_cluster":"inbound|9080||","authority":"ratings:9080"}`,
+		`{"request_id":"fixed","start_time":"2024-11-26T02:30:02.000Z","method":"GET","path":"/b","response_code":200,"duration":5,"upstream_cluster":"inbound|9080||","authority":"ratings:9080"}`,
+		`{"request_id":"fixed","start_time":"2024-11-26T02:30:03.000Z","method":"POST","path":"/c","response_code":500,"duration":5,"upstream_cluster":"inbound|9080||","authority":"ratings:9080"}`,
+		// Application logs mentioning the ID are not requests
+		`{"request_id":"abc","time":"2024-11-26T02:30:09.000Z","level":"info","message":"done"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	reused := reusedRequestIDs(logs)
+	if len(reused) != 1 || reused["fixed"] != (reusedID{requests: 3, entries: 3}) {
+		t.Fatalf("reusedRequestIDs() = %v, expected only fixed reused by 3 requests", reused)
+	}
+
+	s := summarize(logs, "test")
+	var report strings.Builder
+	s.writeMarkdown(&report)
+	if !strings.Contains(report.String(), "## Reused request IDs") || !strings.Contains(report.String(), "| fixed | 3 | 3 |") {
+		t.Errorf("expected the report to list the reused ID, got:\n%s", report.String())
+	}
+	if !strings.Contains(report.String(), "Filter: `fixed`") {
+		t.Errorf("expected an anomaly filtering on the reused ID, got:\n%s", report.String())
+	}
+}
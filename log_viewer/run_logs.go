@@ -3,54 +3,34 @@
 package main
 
 import (
-	"context"
-	"fmt"
+	"bufio"
 	"io"
 
-	v1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
 )
 
-// RunLogCommand fetches logs from a Kubernetes pod/container.
-func RunLogCommand(podName, namespace, containerName string) ([]string, error) {
-	// Create Kubernetes client
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, fmt.Errorf("error creating in-cluster config: %v", err)
-	}
+// newLineScanner returns a scanner that reassembles complete lines, the same
+// way lines fetched from Kubernetes are, see k8ssource.NewLineScanner.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	return k8ssource.NewLineScanner(r)
+}
 
-	clientset, err := kubernetes.NewForConfig(config)
+// readLines reads r to the end and returns its complete lines, decompressing
+// it first when it is an archive, see decompressed.
+func readLines(r io.Reader) ([]string, error) {
+	r, closeReader, err := decompressed(r)
 	if err != nil {
-		return nil, fmt.Errorf("error creating Kubernetes client: %v", err)
+		return nil, err
 	}
+	defer closeReader()
 
-	// Fetch logs from Kubernetes API
-	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, &v1.PodLogOptions{
-		Container: containerName,
-		Follow:    false, // Set to true for streaming logs
-	})
-
-	logStream, err := req.Stream(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("error streaming logs: %v", err)
+	var lines []string
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
 	}
-	defer logStream.Close()
-
-	var logs []string
-	buffer := make([]byte, 2000)
-	for {
-		n, err := logStream.Read(buffer)
-		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("error reading log stream: %v", err)
-		}
-		if n == 0 {
-			break
-		}
-
-		logLines := string(buffer[:n])
-		logs = append(logs, logLines)
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
-
-	return logs, nil
+	return lines, nil
 }
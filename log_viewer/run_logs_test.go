@@ -0,0 +1,29 @@
+// log_viewer/run_logs_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadLinesReassemblesChunks(t *testing.T) {
+	longLine := `{"path":"/` + strings.Repeat("a", 100*1024) + `"}`
+	input := `{"message":"first"}` + "\n" + longLine + "\n" + `{"message":"last"}`
+
+	// OneByteReader splits every read, like a slow log stream
+	lines, err := readLines(iotest.OneByteReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("readLines() unexpected error: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	if lines[1] != longLine {
+		t.Errorf("expected the long line to be reassembled intact, got %d bytes", len(lines[1]))
+	}
+	if lines[2] != `{"message":"last"}` {
+		t.Errorf("expected the final unterminated line, got %q", lines[2])
+	}
+}
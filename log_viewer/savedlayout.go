@@ -0,0 +1,137 @@
+// log_viewer/savedlayout.go
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The layout preset and row mode are remembered per terminal size bucket, so
+// a laptop screen and a wide monitor each come back the way they were left.
+// The panes split the space by fixed ratios and the list has no columns or
+// sort order of its own, so there is nothing else to remember.
+
+// Terminal size buckets: widths under narrowWidth are narrow and under
+// wideWidth medium, heights under tallHeight short.
+const (
+	narrowWidth = 100
+	wideWidth   = 160
+	tallHeight  = 40
+)
+
+// savedLayout is the UI state remembered for one terminal size bucket.
+type savedLayout struct {
+	Layout   layoutPreset `json:"layout,omitempty"`
+	Expanded bool         `json:"expanded,omitempty"`
+}
+
+// terminalBucket names the size bucket of a width x height terminal, such as
+// "wide-tall".
+func terminalBucket(width, height int) string {
+	size := "narrow"
+	switch {
+	case width >= wideWidth:
+		size = "wide"
+	case width >= narrowWidth:
+		size = "medium"
+	}
+	if height >= tallHeight {
+		return size + "-tall"
+	}
+	return size + "-short"
+}
+
+// defaultLayoutsPath returns where layouts are kept, next to the -state
+// checkpoints file.
+func defaultLayoutsPath(statePath string) string {
+	if statePath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(statePath), "layouts.json")
+}
+
+// loadLayouts reads the saved layouts by terminal size bucket. Like history
+// they are a convenience, so a missing or unreadable file starts afresh.
+func loadLayouts(path string) map[string]savedLayout {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Println("Error reading layouts:", err)
+		}
+		return nil
+	}
+	var layouts map[string]savedLayout
+	if err := json.Unmarshal(data, &layouts); err != nil {
+		log.Printf("Error reading layouts from %s: %v", path, err)
+		return nil
+	}
+	return layouts
+}
+
+// saveLayouts returns a command writing layouts to path.
+func saveLayouts(path string, layouts map[string]savedLayout) tea.Cmd {
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := writeLayouts(path, layouts); err != nil {
+			return failedMsg{err: err}
+		}
+		return nil
+	}
+}
+
+func writeLayouts(path string, layouts map[string]savedLayout) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(layouts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding layouts: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// resize records the terminal's size and, on moving to another size bucket,
+// restores the layout last used in it.
+func (m Model) resize(width, height int) Model {
+	m.width, m.height = width, height
+	bucket := terminalBucket(width, height)
+	if bucket == m.sizeBucket {
+		return m
+	}
+	m.sizeBucket = bucket
+	if saved, ok := m.layouts[bucket]; ok {
+		m.layout = saved.Layout
+		m.expandedList = saved.Expanded
+	}
+	return m
+}
+
+// rememberLayout saves the current layout for the terminal's size bucket.
+func (m Model) rememberLayout() (Model, tea.Cmd) {
+	if m.sizeBucket == "" {
+		// No size yet, so no bucket to keep it for
+		return m, nil
+	}
+	layouts := make(map[string]savedLayout, len(m.layouts)+1)
+	for bucket, saved := range m.layouts {
+		layouts[bucket] = saved
+	}
+	layouts[m.sizeBucket] = savedLayout{Layout: m.layout, Expanded: m.expandedList}
+	m.layouts = layouts
+	return m, saveLayouts(m.layoutsPath, layouts)
+}
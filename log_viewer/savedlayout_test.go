@@ -0,0 +1,54 @@
+// log_viewer/savedlayout_test.go
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTerminalBucket(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          string
+	}{
+		{80, 24, "narrow-short"},
+		{120, 40, "medium-tall"},
+		{200, 30, "wide-short"},
+	}
+	for _, tt := range tests {
+		if got := terminalBucket(tt.width, tt.height); got != tt.want {
+			t.Errorf("terminalBucket(%d, %d) = %q, expected %q", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutRememberedPerTerminalSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layouts.json")
+	var m tea.Model = Model{layoutsPath: path}
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+
+	var cmd tea.Cmd
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if cmd == nil {
+		t.Fatal("expected the layout to be saved")
+	}
+	cmd()
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	cmd()
+
+	// A smaller window has nothing saved and keeps what is shown
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if got := m.(Model).layout; got != layoutDetail {
+		t.Fatalf("layout = %q, expected %q", got, layoutDetail)
+	}
+
+	// A new session in the big window comes back as it was left
+	restored := Model{layouts: loadLayouts(path)}.resize(200, 50)
+	if restored.layout != layoutList || !restored.expandedList {
+		t.Errorf("restored layout %q, expanded %v, expected %q, expanded", restored.layout, restored.expandedList, layoutList)
+	}
+}
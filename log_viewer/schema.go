@@ -0,0 +1,166 @@
+// log_viewer/schema.go
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// fieldSchema names the fields of access logs written by one producer or
+// Istio release differently from Istio's default JSON format, which filters,
+// detail groups and reports read. Entries are renamed as they are parsed, so
+// the rest of the viewer only ever sees the default names; the raw log keeps
+// the original ones, so searching for either works.
+type fieldSchema struct {
+	name    string
+	aliases map[string]string // Producer's field name to the default one
+
+	// markers tell the schema's entries apart when it is inferred, so that
+	// application logs with a url or user-agent field are not renamed. A
+	// schema selected with -schema has none and applies to every entry.
+	markers []string
+}
+
+// fieldSchemas are the known schemas, tried in this order when inferred.
+var fieldSchemas = []fieldSchema{
+	{
+		// Mixer's accesslog entries, Istio 1.4 and earlier
+		name:    "mixer",
+		markers: []string{"responseCode", "responseFlags"},
+		aliases: map[string]string{
+			"httpAuthority":       "authority",
+			"url":                 "path",
+			"requestId":           "request_id",
+			"responseCode":        "response_code",
+			"responseFlags":       "response_flags",
+			"userAgent":           "user_agent",
+			"xForwardedFor":       "x_forwarded_for",
+			"requestedServerName": "requested_server_name",
+			"receivedBytes":       "bytes_received",
+			"sentBytes":           "bytes_sent",
+		},
+	},
+	{
+		// OpenTelemetry attribute names, current and older, as the
+		// OpenTelemetry access log provider is often configured with
+		name:    "otel",
+		markers: []string{"http.response.status_code", "http.status_code"},
+		aliases: map[string]string{
+			"http.request.method":       "method",
+			"http.method":               "method",
+			"url.path":                  "path",
+			"http.target":               "path",
+			"http.response.status_code": "response_code",
+			"http.status_code":          "response_code",
+			"user_agent.original":       "user_agent",
+			"http.user_agent":           "user_agent",
+			"server.address":            "authority",
+			"http.host":                 "authority",
+			"http.request.body.size":    "bytes_received",
+			"http.response.body.size":   "bytes_sent",
+		},
+	},
+	{
+		// Header names, as custom formats built from %REQ(...)% often use
+		name:    "envoy",
+		markers: []string{"x-request-id", ":authority", ":path"},
+		aliases: map[string]string{
+			":method":                       "method",
+			":path":                         "path",
+			":authority":                    "authority",
+			"x-request-id":                  "request_id",
+			"x-forwarded-for":               "x_forwarded_for",
+			"user-agent":                    "user_agent",
+			"x-envoy-upstream-service-time": "upstream_service_time",
+		},
+	},
+}
+
+// Schema choices besides the names of fieldSchemas.
+const (
+	schemaAuto  = "auto"  // Infer the schema of each entry from its fields
+	schemaIstio = "istio" // Istio's default format, nothing renamed
+)
+
+// schemaNames lists the values -schema accepts by name.
+func schemaNames() []string {
+	names := []string{schemaAuto, schemaIstio}
+	for _, schema := range fieldSchemas {
+		names = append(names, schema.name)
+	}
+	return names
+}
+
+// selectSchemas returns the schemas entries are renamed by for -schema: all
+// of them for auto, none for istio, or the one named. An Istio version such
+// as 1.4 selects the schema that release wrote.
+func selectSchemas(choice string) ([]fieldSchema, error) {
+	switch choice {
+	case "", schemaAuto:
+		return fieldSchemas, nil
+	case schemaIstio:
+		return nil, nil
+	}
+	for _, schema := range fieldSchemas {
+		if schema.name == choice {
+			schema.markers = nil
+			return []fieldSchema{schema}, nil
+		}
+	}
+
+	var major, minor int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(choice, "v"), "%d.%d", &major, &minor); err == nil && major == 1 {
+		if minor <= 4 {
+			return selectSchemas("mixer")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown -schema %q, expected an Istio version such as 1.22 or one of %s", choice, strings.Join(schemaNames(), ", "))
+}
+
+// withSchemas renames the entry's fields the schemas know to the default
+// names. A field already under its default name is left alone, as is the
+// alias then.
+func withSchemas(log ParsedLog, schemas []fieldSchema) ParsedLog {
+	fields, copied := log.Fields, false
+	for _, schema := range schemas {
+		if !schema.matches(fields) {
+			continue
+		}
+		for alias, name := range schema.aliases {
+			value, ok := fields[alias]
+			if !ok {
+				continue
+			}
+			if _, taken := fields[name]; taken {
+				continue
+			}
+			// The parsed fields may be shared, so rename in a copy
+			if !copied {
+				fields, copied = maps.Clone(fields), true
+			}
+			delete(fields, alias)
+			fields[name] = value
+		}
+	}
+	if !copied {
+		return log
+	}
+	log.Fields = fields
+	return log.indexed()
+}
+
+// matches reports whether the schema applies to an entry with fields.
+func (s fieldSchema) matches(fields map[string]interface{}) bool {
+	if len(s.markers) == 0 {
+		return true
+	}
+	for _, marker := range s.markers {
+		if _, ok := fields[marker]; ok {
+			return true
+		}
+	}
+	return false
+}
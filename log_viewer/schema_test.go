@@ -0,0 +1,81 @@
+// log_viewer/schema_test.go
+
+package main
+
+import "testing"
+
+func TestSelectSchemas(t *testing.T) {
+	tests := []struct {
+		choice  string
+		want    string // Name of the only schema, "" for none
+		all     bool
+		wantErr bool
+	}{
+		{"auto", "", true, false},
+		{"istio", "", false, false},
+		{"otel", "otel", false, false},
+		{"1.4", "mixer", false, false},
+		{"v1.3.2", "mixer", false, false},
+		{"1.22", "", false, false},
+		{"splunk", "", false, true},
+	}
+	for _, tt := range tests {
+		schemas, err := selectSchemas(tt.choice)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("selectSchemas(%q) error = %v, wantErr %v", tt.choice, err, tt.wantErr)
+			continue
+		}
+		switch {
+		case tt.all:
+			if len(schemas) != len(fieldSchemas) {
+				t.Errorf("selectSchemas(%q) = %d schemas, expected all", tt.choice, len(schemas))
+			}
+		case tt.want == "":
+			if len(schemas) != 0 {
+				t.Errorf("selectSchemas(%q) = %d schemas, expected none", tt.choice, len(schemas))
+			}
+		case len(schemas) != 1 || schemas[0].name != tt.want || schemas[0].markers != nil:
+			t.Errorf("selectSchemas(%q) = %+v, expected only %s applied to every entry", tt.choice, schemas, tt.want)
+		}
+	}
+}
+
+func TestWithSchemas(t *testing.T) {
+	lines := []string{
+		`{"responseCode":503,"responseFlags":"UF","url":"/reviews","requestId":"abc"}`,
+		`{"http.request.method":"GET","url.path":"/ratings","http.response.status_code":"200"}`,
+		`{"level":"info","url":"https://example.com","msg":"fetched"}`,
+		`{"response_code":200,"responseCode":404}`,
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	if code, ok := logs[0].ResponseCode(); !ok || code != 503 || logs[0].Path() != "/reviews" || !logs[0].HasFlag("UF") {
+		t.Errorf("expected the Mixer entry renamed, got %v", logs[0].Fields)
+	}
+	if logs[1].Method() != "GET" || logs[1].Path() != "/ratings" {
+		t.Errorf("expected the OpenTelemetry entry renamed, got %v", logs[1].Fields)
+	}
+	if _, ok := logs[2].Fields["path"]; ok {
+		t.Errorf("expected an application log left alone, got %v", logs[2].Fields)
+	}
+	if code, _ := logs[3].ResponseCode(); code != 200 {
+		t.Errorf("expected the default field kept over its alias, got %v", logs[3].Fields)
+	}
+
+	// Filters find renamed entries by either name
+	if got := filterLogs(logs, "response_code"); len(got) != 3 {
+		t.Errorf("expected 3 entries with a response_code, got %d", len(got))
+	}
+	if got := filterLogs(logs, "requestId"); len(got) != 1 {
+		t.Errorf("expected the original name still searchable, got %d", len(got))
+	}
+
+	// A schema picked by hand applies to every entry
+	mixer, _ := selectSchemas("mixer")
+	if renamed := withSchemas(logs[2], mixer); renamed.Path() != "https://example.com" {
+		t.Errorf("expected the selected schema applied, got %v", renamed.Fields)
+	}
+}
@@ -0,0 +1,236 @@
+// log_viewer/screenshot.go
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Formats the current view can be exported in.
+const (
+	screenshotText = "txt"
+	screenshotHTML = "html"
+)
+
+// escapeSequence matches the escape sequences a rendered view can hold: SGR
+// styling, which the HTML export translates, and others, which are dropped.
+var escapeSequence = regexp.MustCompile("\x1b\\[([0-9;?]*)([A-Za-z])|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)")
+
+// viewExportedMsg reports where the view, or the entries named by what,
+// were exported.
+type viewExportedMsg struct {
+	what string // Empty for the view
+	path string
+	err  error
+}
+
+// exportView writes the view, as it is on screen, to a file in the working
+// directory named after the time, for attaching to tickets.
+func exportView(view, format string, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		content := plainView(view)
+		if format == screenshotHTML {
+			content = htmlView(view)
+		}
+		path := fmt.Sprintf("log_viewer-view-%s.%s", now.Format("20060102-150405"), format)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return viewExportedMsg{err: fmt.Errorf("error exporting view: %v", err)}
+		}
+		return viewExportedMsg{path: path}
+	}
+}
+
+// plainView strips the styling from a view, and the padding it leaves at the
+// end of lines.
+func plainView(view string) string {
+	lines := strings.Split(escapeSequence.ReplaceAllString(view, ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// sgrState is the styling in effect at a point of a view.
+type sgrState struct {
+	fg, bg                                  string // CSS colors, empty for the default
+	bold, faint, italic, underline, reverse bool
+}
+
+// css returns the inline style for the state, empty for plain text.
+func (s sgrState) css() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = screenshotBackground
+		}
+		if bg == "" {
+			bg = screenshotForeground
+		}
+	}
+	var rules []string
+	if fg != "" {
+		rules = append(rules, "color:"+fg)
+	}
+	if bg != "" {
+		rules = append(rules, "background:"+bg)
+	}
+	if s.bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if s.faint {
+		rules = append(rules, "opacity:0.6")
+	}
+	if s.italic {
+		rules = append(rules, "font-style:italic")
+	}
+	if s.underline {
+		rules = append(rules, "text-decoration:underline")
+	}
+	return strings.Join(rules, ";")
+}
+
+// apply updates the state with the parameters of an SGR sequence.
+func (s sgrState) apply(params string) sgrState {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i]) // An empty parameter is a reset
+		switch {
+		case code == 0:
+			s = sgrState{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.faint = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 7:
+			s.reverse = true
+		case code == 22:
+			s.bold, s.faint = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code == 27:
+			s.reverse = false
+		case code >= 30 && code <= 37:
+			s.fg = ansiColor(code - 30)
+		case code >= 90 && code <= 97:
+			s.fg = ansiColor(code - 90 + 8)
+		case code >= 40 && code <= 47:
+			s.bg = ansiColor(code - 40)
+		case code >= 100 && code <= 107:
+			s.bg = ansiColor(code - 100 + 8)
+		case code == 39:
+			s.fg = ""
+		case code == 49:
+			s.bg = ""
+		case code == 38 || code == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if code == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+	return s
+}
+
+// extendedColor reads a 5;n or 2;r;g;b color, returning it with the number
+// of parameters it took.
+func extendedColor(params []string) (string, int) {
+	number := func(i int) int {
+		if i >= len(params) {
+			return 0
+		}
+		n, _ := strconv.Atoi(params[i])
+		return n
+	}
+	switch number(0) {
+	case 5:
+		return ansiColor(number(1)), 2
+	case 2:
+		return fmt.Sprintf("#%02x%02x%02x", number(1), number(2), number(3)), 4
+	}
+	return "", len(params)
+}
+
+// ansi16 are the xterm colors of the 16 basic ANSI colors.
+var ansi16 = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ansiColor returns the xterm color of a 256-color palette index.
+func ansiColor(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansi16[n]
+	case n < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	}
+	gray := 8 + 10*(n-232)
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// Colors of the HTML page, those of a dark terminal.
+const (
+	screenshotBackground = "#1c1c1c"
+	screenshotForeground = "#d0d0d0"
+)
+
+// htmlView renders a view as a standalone HTML page keeping its colors.
+func htmlView(view string) string {
+	var body strings.Builder
+	var state sgrState
+	text := func(s string) {
+		if s == "" {
+			return
+		}
+		if css := state.css(); css != "" {
+			fmt.Fprintf(&body, `<span style="%s">%s</span>`, css, html.EscapeString(s))
+			return
+		}
+		body.WriteString(html.EscapeString(s))
+	}
+
+	last := 0
+	for _, match := range escapeSequence.FindAllStringSubmatchIndex(view, -1) {
+		text(view[last:match[0]])
+		last = match[1]
+		if match[4] >= 0 && view[match[4]:match[5]] == "m" {
+			state = state.apply(view[match[2]:match[3]])
+		}
+	}
+	text(view[last:])
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>log_viewer view</title>
+<style>body { background: %s; color: %s; } pre { font-family: monospace; line-height: 1.2; }</style>
+</head>
+<body>
+<pre>%s</pre>
+</body>
+</html>
+`, screenshotBackground, screenshotForeground, body.String())
+}
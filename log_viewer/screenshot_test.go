@@ -0,0 +1,83 @@
+// log_viewer/screenshot_test.go
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPlainView(t *testing.T) {
+	view := "\x1b[1;38;5;105mHeader\x1b[0m   \n\x1b]8;;http://x\x07link\x1b]8;;\x07 row"
+	expected := "Header\nlink row\n"
+	if got := plainView(view); got != expected {
+		t.Errorf("plainView() = %q, expected %q", got, expected)
+	}
+}
+
+func TestHTMLView(t *testing.T) {
+	view := "\x1b[1;38;5;196mError <503>\x1b[0m ok \x1b[7mselected\x1b[27m \x1b[38;2;1;2;3mrgb\x1b[39m"
+	page := htmlView(view)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<span style="color:#ff0000;font-weight:bold">Error &lt;503&gt;</span> ok `,
+		`<span style="color:#1c1c1c;background:#d0d0d0">selected</span>`,
+		`<span style="color:#010203">rgb</span>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("htmlView() missing %q in:\n%s", want, page)
+		}
+	}
+}
+
+func TestAnsiColor(t *testing.T) {
+	tests := map[int]string{1: "#cd0000", 39: "#00afff", 236: "#303030", 256: ""}
+	for n, expected := range tests {
+		if got := ansiColor(n); got != expected {
+			t.Errorf("ansiColor(%d) = %q, expected %q", n, got, expected)
+		}
+	}
+}
+
+func TestExportViewKey(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	logs := mustParse(t, snapshotLines...)
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 40}
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	if cmd == nil {
+		t.Fatal("expected X to export the view")
+	}
+	msg, ok := cmd().(viewExportedMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("export returned %#v", msg)
+	}
+	if !strings.HasSuffix(msg.path, ".html") {
+		t.Errorf("exported to %q, expected an HTML file", msg.path)
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil || !strings.Contains(string(data), "Parsed Log Details") {
+		t.Errorf("exported file lacks the detail pane: %v", err)
+	}
+
+	updated, _ = updated.(Model).Update(msg)
+	model = updated.(Model)
+	if !strings.Contains(model.View(), "View exported to "+msg.path) {
+		t.Errorf("expected the view to say where it was exported")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if updated.(Model).notice != "" {
+		t.Errorf("expected the next key to clear the notice")
+	}
+}
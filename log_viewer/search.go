@@ -0,0 +1,281 @@
+// log_viewer/search.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// sidecarContainer is the container Istio injects its proxy as.
+const sidecarContainer = "istio-proxy"
+
+// defaultSearchWorkers bounds how many sidecars search reads at once, so a
+// large namespace does not flood the API server.
+const defaultSearchWorkers = 8
+
+// searchTarget is a sidecar whose logs are searched.
+type searchTarget struct {
+	namespace, pod string
+	cluster        string                 // Set when several clusters are searched
+	podFields      map[string]interface{} // Added to the pod's entries, see withTargetPodFields
+}
+
+func (t searchTarget) String() string {
+	if t.cluster != "" {
+		return t.cluster + "/" + t.namespace + "/" + t.pod
+	}
+	return t.namespace + "/" + t.pod
+}
+
+// searchResult is what searching one sidecar found.
+type searchResult struct {
+	target  searchTarget
+	matches []ParsedLog
+	err     error
+}
+
+// podLogFetcher reads the sidecar logs of a target.
+type podLogFetcher func(ctx context.Context, target searchTarget) ([]string, error)
+
+// searchPods searches the logs of every target for query, matching as the
+// viewer's filter does, with at most workers fetching at a time. Results are
+// sent as each target finishes; the channel is closed once all have.
+func searchPods(ctx context.Context, targets []searchTarget, query string, workers int, fetch podLogFetcher, opts parseOptions) <-chan searchResult {
+	jobs := make(chan searchTarget)
+	results := make(chan searchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				results <- searchPod(ctx, target, query, fetch, opts)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, target := range targets {
+			select {
+			case jobs <- target:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// searchPod searches one target. Logs with no parsable entries have no
+// matches rather than failing the search.
+func searchPod(ctx context.Context, target searchTarget, query string, fetch podLogFetcher, opts parseOptions) searchResult {
+	lines, err := fetch(ctx, target)
+	if err != nil {
+		return searchResult{target: target, err: err}
+	}
+	if len(lines) == 0 {
+		return searchResult{target: target}
+	}
+	origin := opts.origin
+	origin.Namespace, origin.Pod, origin.Container = target.namespace, target.pod, sidecarContainer
+	logs, err := parseRawLogsWith(lines, opts.from(target.String()).withOrigin(origin).withPodFields(target.podFields))
+	if err != nil {
+		return searchResult{target: target}
+	}
+	return searchResult{target: target, matches: filterLogs(logs, query)}
+}
+
+// writeMatches prints each match prefixed with the pod that logged it, as
+// grep does with several files.
+func writeMatches(w io.Writer, result searchResult) {
+	for _, match := range result.matches {
+		fmt.Fprintf(w, "%s: %s\n", result.target, match.RawLog)
+	}
+}
+
+// searchTargets lists the sidecars in each namespace, then the pods of each
+// gateway not already among them. A gateway's pods are named after its
+// workload, as its Deployment names them.
+func searchTargets(ctx context.Context, client kubernetes.Interface, namespaces []string, gateways []gatewayRef) ([]searchTarget, error) {
+	var targets []searchTarget
+	for _, namespace := range namespaces {
+		pods, err := k8ssource.ListPodsWithContainer(ctx, client, namespace, sidecarContainer, 0)
+		if err != nil {
+			return nil, explainKubeError(err)
+		}
+		for _, pod := range pods {
+			targets = append(targets, searchTarget{namespace: namespace, pod: pod})
+		}
+	}
+	for _, gateway := range gateways {
+		if slices.Contains(namespaces, gateway.namespace) {
+			continue
+		}
+		pods, err := k8ssource.ListPodsWithContainer(ctx, client, gateway.namespace, sidecarContainer, 0)
+		if err != nil {
+			return nil, explainKubeError(err)
+		}
+		for _, pod := range pods {
+			target := searchTarget{namespace: gateway.namespace, pod: pod}
+			listed := slices.ContainsFunc(targets, func(t searchTarget) bool { return t.String() == target.String() })
+			if strings.HasPrefix(pod, gateway.name+"-") && !listed {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// withGatewayNamespaces adds the namespaces of the gateways to namespaces.
+func withGatewayNamespaces(namespaces []string, gateways []gatewayRef) []string {
+	namespaces = slices.Clone(namespaces)
+	for _, gateway := range gateways {
+		if !slices.Contains(namespaces, gateway.namespace) {
+			namespaces = append(namespaces, gateway.namespace)
+		}
+	}
+	return namespaces
+}
+
+// searchSidecars connects to each cluster of the federation and starts
+// searching the sidecars in namespaces and the profile's gateways for query,
+// exiting the process when they cannot be listed. Entries are tagged with
+// the cluster that logged them, and with several clusters so are the
+// targets, as pods of the same name may run in each.
+// It also returns how many sidecars are searched.
+func searchSidecars(opts cliOptions, cfg Config, query string, namespaces []string) (<-chan searchResult, int) {
+	clusters := opts.federation()
+	var searches []<-chan searchResult
+	searched := 0
+	for _, kube := range clusters {
+		results, n := searchCluster(opts, cfg, kube, len(clusters) > 1, query, namespaces)
+		if n > 0 {
+			searches, searched = append(searches, results), searched+n
+		}
+	}
+	if searched == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no pods with an %s container found\n", sidecarContainer)
+		os.Exit(1)
+	}
+	return mergeSearches(searches), searched
+}
+
+// searchCluster starts searching the sidecars of one cluster, see
+// searchSidecars. named sets the cluster of each target.
+func searchCluster(opts cliOptions, cfg Config, kube kubeClientOptions, named bool, query string, namespaces []string) (<-chan searchResult, int) {
+	clientset, err := CreateKubeClient(kube)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
+		log.Println("Error creating Kubernetes client:", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	if !opts.skipAccessCheck {
+		var optional []optionalRule
+		for _, namespace := range withGatewayNamespaces(namespaces, opts.gateways) {
+			if err := checkAccess(ctx, clientset, requiredRules(namespace, true)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				log.Println("Access check failed:", err)
+				os.Exit(1)
+			}
+			optional = append(optional, optionalRules(namespace, false)...)
+		}
+		reportOptionalAccess(ctx, clientset, optional)
+	}
+
+	targets, err := searchTargets(ctx, clientset, namespaces, opts.gateways)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error listing sidecars:", err)
+		os.Exit(1)
+	}
+	if err := withTargetPodFields(ctx, clientset, targets, cfg.PodFields); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	cluster := kube.clusterName()
+	if named {
+		for i := range targets {
+			targets[i].cluster = cluster
+		}
+	}
+
+	fetch := func(ctx context.Context, target searchTarget) ([]string, error) {
+		return FetchLogsFromK8s(clientset, target.namespace, target.pod, sidecarContainer, opts.sourceOptions()...)
+	}
+	parseOpts := parseOptionsFor(opts, cfg).withOrigin(Source{Cluster: cluster})
+	return searchPods(ctx, targets, query, opts.workers, fetch, parseOpts), len(targets)
+}
+
+// mergeSearches sends the results of every search on one channel, closed
+// once they all are.
+func mergeSearches(searches []<-chan searchResult) <-chan searchResult {
+	if len(searches) == 1 {
+		return searches[0]
+	}
+	merged := make(chan searchResult)
+	var wg sync.WaitGroup
+	for _, results := range searches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for result := range results {
+				merged <- result
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
+
+// runSearch implements `log_viewer search [flags] <query> [namespace...]`,
+// searching the namespace in PLUGIN_NAMESPACE when none is given.
+func runSearch(opts cliOptions, cfg Config) {
+	if len(opts.args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: log_viewer search [flags] <query> [namespace...]")
+		os.Exit(2)
+	}
+	query, namespaces := opts.args[0], opts.args[1:]
+	if len(namespaces) == 0 {
+		namespace := os.Getenv("PLUGIN_NAMESPACE")
+		if namespace == "" {
+			fmt.Fprintln(os.Stderr, "Error: name the namespaces to search, or set PLUGIN_NAMESPACE")
+			os.Exit(2)
+		}
+		namespaces = []string{namespace}
+	}
+
+	results, searched := searchSidecars(opts, cfg, query, namespaces)
+	failed := 0
+	for result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Error searching %s: %v\n", result.target, result.err)
+			log.Println("Error searching", result.target, result.err)
+			continue
+		}
+		writeMatches(os.Stdout, result)
+	}
+	if failed == searched {
+		os.Exit(1)
+	}
+}
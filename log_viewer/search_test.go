@@ -0,0 +1,111 @@
+// log_viewer/search_test.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSearchPods(t *testing.T) {
+	logsByPod := map[string][]string{
+		"default/reviews-v1":    {`{"request_id":"abc-123","path":"/reviews"}`, `{"request_id":"def-456"}`},
+		"default/ratings-v1":    {`{"request_id":"ABC-123","path":"/ratings"}`},
+		"bookinfo/productpage":  {`{"request_id":"zzz"}`},
+		"bookinfo/details-fail": nil,
+	}
+	targets := []searchTarget{
+		{namespace: "default", pod: "reviews-v1"}, {namespace: "default", pod: "ratings-v1"},
+		{namespace: "bookinfo", pod: "productpage"}, {namespace: "bookinfo", pod: "details-fail"},
+	}
+
+	var running, peak atomic.Int32
+	fetch := func(_ context.Context, target searchTarget) ([]string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if target.pod == "details-fail" {
+			return nil, errors.New("forbidden")
+		}
+		return logsByPod[target.String()], nil
+	}
+
+	var matched, failed []string
+	for result := range searchPods(context.Background(), targets, "abc-123", 2, fetch, defaultParseOptions()) {
+		if result.err != nil {
+			failed = append(failed, result.target.String())
+			continue
+		}
+		var out strings.Builder
+		writeMatches(&out, result)
+		if out.Len() > 0 {
+			matched = append(matched, strings.TrimSpace(out.String()))
+		}
+	}
+	sort.Strings(matched)
+
+	expected := []string{
+		`default/ratings-v1: {"request_id":"ABC-123","path":"/ratings"}`,
+		`default/reviews-v1: {"request_id":"abc-123","path":"/reviews"}`,
+	}
+	if strings.Join(matched, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("searchPods() matched\n%s\nexpected\n%s", strings.Join(matched, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(failed) != 1 || failed[0] != "bookinfo/details-fail" {
+		t.Errorf("searchPods() failed for %v, expected bookinfo/details-fail", failed)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("searchPods() fetched %d pods at once, expected at most 2", peak.Load())
+	}
+}
+
+func TestSearchFederation(t *testing.T) {
+	opts := cliOptions{kube: kubeClientOptions{kubeconfig: "/kubeconfig", context: "ignored"}, contexts: []string{"east, west", "central"}}
+	var names []string
+	for _, kube := range opts.federation() {
+		if kube.kubeconfig != "/kubeconfig" {
+			t.Errorf("expected the other client options kept, got %+v", kube)
+		}
+		names = append(names, kube.context)
+	}
+	if strings.Join(names, ",") != "east,west,central" {
+		t.Errorf("federation() = %v, expected each of -contexts", names)
+	}
+	if single := (cliOptions{kube: kubeClientOptions{context: "kind"}}).federation(); len(single) != 1 || single[0].context != "kind" {
+		t.Errorf("expected only -context without -contexts, got %+v", single)
+	}
+
+	// The same pod in two clusters is searched in each, and its entries
+	// tagged with the cluster that logged them
+	var searches []<-chan searchResult
+	for _, cluster := range []string{"east", "west"} {
+		fetch := func(context.Context, searchTarget) ([]string, error) {
+			return []string{`{"request_id":"abc","path":"/` + cluster + `"}`}, nil
+		}
+		targets := []searchTarget{{namespace: "default", pod: "reviews-v1", cluster: cluster}}
+		searches = append(searches, searchPods(context.Background(), targets, "abc", 1, fetch, defaultParseOptions().withOrigin(Source{Cluster: cluster})))
+	}
+	var found []string
+	for result := range mergeSearches(searches) {
+		for _, match := range result.matches {
+			found = append(found, fmt.Sprintf("%s %s %s", result.target, match.Source.Cluster, match.ID.Source))
+		}
+	}
+	sort.Strings(found)
+	expected := "east/default/reviews-v1 east east/default/reviews-v1,west/default/reviews-v1 west west/default/reviews-v1"
+	if strings.Join(found, ",") != expected {
+		t.Errorf("mergeSearches() found %v, expected %s", found, expected)
+	}
+}
@@ -0,0 +1,73 @@
+// log_viewer/selection.go
+
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// selectedID returns the ID of the selected entry, or the zero ID when
+// nothing is selected.
+func (m Model) selectedID() EntryID {
+	if m.selectedLogIndex < 0 || m.selectedLogIndex >= len(m.filteredLogs) {
+		return EntryID{}
+	}
+	return m.filteredLogs[m.selectedLogIndex].ID
+}
+
+// indexOfID returns the index of the entry with id in logs, which are in ID
+// order. When that entry is gone the nearest surviving neighbor is picked,
+// the earlier one on a tie, so the user keeps their place.
+func indexOfID(logs []ParsedLog, id EntryID) int {
+	if len(logs) == 0 || id.IsZero() {
+		return 0
+	}
+	i := sort.Search(len(logs), func(i int) bool { return logs[i].ID.Compare(id) >= 0 })
+	switch {
+	case i == len(logs):
+		return i - 1
+	case logs[i].ID.Compare(id) == 0 || i == 0:
+		return i
+	case closerTo(id, logs[i].ID, logs[i-1].ID):
+		return i
+	}
+	return i - 1
+}
+
+// closerTo reports whether a is strictly closer to id than b: by position
+// when all three come from the same source, by time otherwise.
+func closerTo(id, a, b EntryID) bool {
+	if a.Source == id.Source && b.Source == id.Source {
+		return abs(a.Seq-id.Seq) < abs(b.Seq-id.Seq)
+	}
+	return abs(a.Time.Sub(id.Time)) < abs(b.Time.Sub(id.Time))
+}
+
+func abs[T int | time.Duration](v T) T {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// refilter reapplies the filters to the logs. The selection follows the
+// entry that was selected rather than its position, except when pinned to
+// the newest entry.
+func (m Model) refilter() Model {
+	id := m.selectedID()
+	m.filteredLogs = m.filter(m.logs)
+	m.overhead = overheadOf(m.filteredLogs)
+	return m.selectID(id)
+}
+
+// selectID selects the entry with id, or its nearest neighbor in the
+// filtered logs.
+func (m Model) selectID(id EntryID) Model {
+	if m.pinned && len(m.filteredLogs) > 0 {
+		m.selectedLogIndex = len(m.filteredLogs) - 1
+		return m
+	}
+	m.selectedLogIndex = indexOfID(m.filteredLogs, id)
+	return m
+}
@@ -0,0 +1,115 @@
+// log_viewer/selection_test.go
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIndexOfID(t *testing.T) {
+	logs := []ParsedLog{{ID: EntryID{Seq: 2}}, {ID: EntryID{Seq: 5}}, {ID: EntryID{Seq: 9}}}
+	tests := []struct {
+		name string
+		seq  int
+		want int
+	}{
+		{"still present", 5, 1},
+		{"closer to the earlier neighbor", 6, 1},
+		{"closer to the later neighbor", 8, 2},
+		{"tie prefers the earlier neighbor", 7, 1},
+		{"before the first", 1, 0},
+		{"after the last", 12, 2},
+		{"nothing selected", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := indexOfID(logs, EntryID{Seq: tt.seq}); got != tt.want {
+				t.Errorf("indexOfID(%d) = %d, want %d", tt.seq, got, tt.want)
+			}
+		})
+	}
+
+	if got := indexOfID(nil, EntryID{Seq: 3}); got != 0 {
+		t.Errorf("indexOfID() on no logs = %d, want 0", got)
+	}
+}
+
+func TestIndexOfIDAcrossSources(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2024, 1, 1, 0, 0, sec, 0, time.UTC) }
+	// The selected entry came from a source that is now filtered out
+	logs := []ParsedLog{
+		{ID: EntryID{Time: at(1), Source: "reviews", Seq: 1}},
+		{ID: EntryID{Time: at(9), Source: "reviews", Seq: 2}},
+	}
+	if got := indexOfID(logs, EntryID{Time: at(7), Source: "ratings", Seq: 40}); got != 1 {
+		t.Errorf("expected the entry closest in time, got index %d", got)
+	}
+}
+
+func TestSelectionFollowsEntryAcrossFilters(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"message":"review ok"}`,
+		`{"message":"rating ok"}`,
+		`{"message":"review failed"}`,
+		`{"message":"rating failed"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	model := Model{logs: logs, filteredLogs: logs, selectedLogIndex: 2}
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			if key == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			updated, _ := model.Update(msg)
+			model = updated.(Model)
+		}
+	}
+	search := func(query string) {
+		press("s")
+		for _, r := range query {
+			press(string(r))
+		}
+		press("enter")
+	}
+
+	search("review")
+	if got := model.selectedID().Seq; got != 3 {
+		t.Errorf("expected line 3 to stay selected after filtering, got line %d", got)
+	}
+
+	search("")
+	if got := model.selectedID().Seq; got != 3 {
+		t.Errorf("expected line 3 to stay selected after clearing the filter, got line %d", got)
+	}
+
+	// The selected entry is filtered out, its nearest neighbor is selected
+	search("rating")
+	if got := model.selectedID().Seq; got != 2 {
+		t.Errorf("expected the neighboring line 2 to be selected, got line %d", got)
+	}
+}
+
+func TestRefetchKeepsSelection(t *testing.T) {
+	lines := []string{`{"message":"one"}`, `{"message":"two"}`, `{"message":"three"}`}
+	target := kubeTarget{pod: "reviews-v1"}
+	model := Model{target: &target}.receiveLogs(logsFetchedMsg{target: target, lines: lines})
+	model.selectedLogIndex = 2
+
+	model = model.receiveLogs(logsFetchedMsg{target: target, lines: lines})
+	if model.selectedLogIndex != 2 {
+		t.Errorf("expected a refetch to keep the selection, got index %d", model.selectedLogIndex)
+	}
+
+	other := kubeTarget{pod: "ratings-v1"}
+	model.target = &other
+	model = model.receiveLogs(logsFetchedMsg{target: other, lines: lines})
+	if model.selectedLogIndex != 0 {
+		t.Errorf("expected another pod to start at the top, got index %d", model.selectedLogIndex)
+	}
+}
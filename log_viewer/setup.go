@@ -0,0 +1,293 @@
+// log_viewer/setup.go
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The setup wizard asks for a kubeconfig context, looks at how Istio is
+// installed there, and writes a first config file with a profile for that
+// cluster, see ClusterProfile.
+
+// Labels the wizard finds the control plane and gateways by.
+const (
+	istiodSelector  = "app=istiod"
+	gatewaySelector = "istio=ingressgateway"
+)
+
+// istioInstall is what the wizard found out about a cluster's Istio.
+type istioInstall struct {
+	namespace string       // Namespace of istiod, empty when not found
+	revisions []string     // Revisions of the running control planes
+	accessLog string       // accessLogFile of the mesh config, empty when off
+	gateways  []gatewayRef // Ingress gateway workloads
+}
+
+// detectIstio finds istiod, the mesh config of its first revision and the
+// ingress gateways.
+func detectIstio(ctx context.Context, client kubernetes.Interface) (istioInstall, error) {
+	var install istioInstall
+	istiods, err := k8ssource.ListPods(ctx, client, "", istiodSelector, 0)
+	if err != nil {
+		return install, explainKubeError(err)
+	}
+	for _, pod := range istiods {
+		if install.namespace == "" {
+			install.namespace = pod.Namespace
+		}
+		revision := pod.Labels[revisionLabel]
+		if revision == "" {
+			revision = "default"
+		}
+		if !slices.Contains(install.revisions, revision) {
+			install.revisions = append(install.revisions, revision)
+		}
+	}
+	if install.namespace == "" {
+		return install, nil
+	}
+
+	// Revisioned control planes read istio-<revision>
+	meshConfig := "istio"
+	if install.revisions[0] != "default" {
+		meshConfig += "-" + install.revisions[0]
+	}
+	configMap, err := client.CoreV1().ConfigMaps(install.namespace).Get(ctx, meshConfig, metav1.GetOptions{})
+	if err != nil {
+		return install, explainKubeError(err)
+	}
+	install.accessLog = meshValue(configMap.Data["mesh"], "accessLogFile")
+
+	gateways, err := k8ssource.ListPods(ctx, client, "", gatewaySelector, 0)
+	if err != nil {
+		return install, explainKubeError(err)
+	}
+	for _, pod := range gateways {
+		gateway := gatewayRef{namespace: pod.Namespace, name: pod.Labels["app"]}
+		if gateway.name != "" && !slices.Contains(install.gateways, gateway) {
+			install.gateways = append(install.gateways, gateway)
+		}
+	}
+	return install, nil
+}
+
+// meshValue reads a top-level setting from mesh config YAML, which is flat
+// enough for the settings the wizard needs not to warrant a YAML parser.
+func meshValue(mesh, key string) string {
+	for _, line := range strings.Split(mesh, "\n") {
+		if value, ok := strings.CutPrefix(line, key+":"); ok {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
+
+// setupWizard asks its questions on in and out. connect returns a client for
+// a kubeconfig context.
+type setupWizard struct {
+	in       *bufio.Reader
+	out      io.Writer
+	contexts []string
+	current  string // Current context of the kubeconfig
+	connect  func(kubeContext string) (kubernetes.Interface, error)
+}
+
+// ask prints question and returns the answer, or fallback when the answer
+// is empty.
+func (w setupWizard) ask(question, fallback string) (string, error) {
+	if fallback != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, fallback)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && answer != "") {
+		return "", fmt.Errorf("setup canceled")
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return fallback, nil
+	}
+	return answer, nil
+}
+
+// chooseContext asks for a context by number or name until a known one is
+// given.
+func (w setupWizard) chooseContext() (string, error) {
+	fmt.Fprintln(w.out, "Kubeconfig contexts:")
+	for i, name := range w.contexts {
+		current := ""
+		if name == w.current {
+			current = " (current)"
+		}
+		fmt.Fprintf(w.out, "  %d. %s%s\n", i+1, name, current)
+	}
+	for {
+		answer, err := w.ask("Context", w.current)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(w.contexts) {
+			return w.contexts[n-1], nil
+		}
+		if slices.Contains(w.contexts, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(w.out, "No context %q, pick one of the list\n", answer)
+	}
+}
+
+// run asks the questions and returns the profile's name and the profile.
+func (w setupWizard) run(ctx context.Context) (string, ClusterProfile, error) {
+	var profile ClusterProfile
+	if len(w.contexts) == 0 {
+		fmt.Fprintln(w.out, "No kubeconfig contexts found, the profile will use the in-cluster config")
+	} else {
+		kubeContext, err := w.chooseContext()
+		if err != nil {
+			return "", profile, err
+		}
+		profile.Context = kubeContext
+	}
+
+	install, err := w.inspect(ctx, profile.Context)
+	if err != nil {
+		fmt.Fprintf(w.out, "Could not inspect the cluster: %v\n", err)
+	}
+	for _, gateway := range install.gateways {
+		profile.Gateways = append(profile.Gateways, gateway.String())
+	}
+
+	if profile.Namespace, err = w.ask("Namespace to read logs from", "default"); err != nil {
+		return "", profile, err
+	}
+	fallback := profile.Context
+	if fallback == "" {
+		fallback = "default"
+	}
+	name, err := w.ask("Profile name", fallback)
+	if err != nil {
+		return "", profile, err
+	}
+	return name, profile, nil
+}
+
+// inspect connects with the context and reports what it finds of Istio.
+func (w setupWizard) inspect(ctx context.Context, kubeContext string) (istioInstall, error) {
+	client, err := w.connect(kubeContext)
+	if err != nil {
+		return istioInstall{}, err
+	}
+	install, err := detectIstio(ctx, client)
+	if err != nil {
+		return install, err
+	}
+
+	if install.namespace == "" {
+		fmt.Fprintln(w.out, "Istio: istiod not found, is Istio installed in this cluster?")
+		return install, nil
+	}
+	fmt.Fprintf(w.out, "Istio: istiod in %s, revisions %s\n", install.namespace, strings.Join(install.revisions, ", "))
+	if install.accessLog != "" {
+		fmt.Fprintf(w.out, "Access logging: on, to %s\n", install.accessLog)
+	} else {
+		fmt.Fprintln(w.out, "Access logging: off in the mesh config (a Telemetry resource may still enable it)")
+		fmt.Fprintln(w.out, "  Turn it on with: istioctl install --set meshConfig.accessLogFile=/dev/stdout --set meshConfig.accessLogEncoding=JSON")
+	}
+	for _, gateway := range install.gateways {
+		fmt.Fprintf(w.out, "Gateway: %s\n", gateway)
+	}
+	return install, nil
+}
+
+// setupConfig is the config file the wizard writes, leaving everything else
+// to the defaults.
+type setupConfig struct {
+	DefaultProfile string                    `json:"default_profile"`
+	Profiles       map[string]ClusterProfile `json:"profiles"`
+}
+
+// writeSetupConfig writes the profile to a new config file at path, refusing
+// to replace one that exists.
+func writeSetupConfig(path, name string, profile ClusterProfile) error {
+	data, err := json.MarshalIndent(setupConfig{
+		DefaultProfile: name,
+		Profiles:       map[string]ClusterProfile{name: profile},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, edit it to add profiles", path)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing config: %v", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing config: %v", err)
+	}
+	return file.Close()
+}
+
+// runSetup implements `log_viewer setup [flags]`, the first-run wizard
+// writing the config file named by -config.
+func runSetup(opts cliOptions, cfg Config) {
+	if opts.configPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: no config location, pass -config")
+		os.Exit(2)
+	}
+	if _, err := os.Stat(opts.configPath); err == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists, edit it to add profiles\n", opts.configPath)
+		os.Exit(1)
+	}
+
+	contexts, err := kubeContexts(opts.kube.kubeconfig)
+	if err != nil {
+		log.Println("Error listing kubeconfig contexts:", err)
+	}
+	current := opts.kube
+	current.context = ""
+	wizard := setupWizard{
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		contexts: contexts,
+		current:  current.clusterName(),
+		connect: func(kubeContext string) (kubernetes.Interface, error) {
+			kube := opts.kube
+			kube.context = kubeContext
+			return CreateKubeClient(kube)
+		},
+	}
+
+	name, profile, err := wizard.run(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeSetupConfig(opts.configPath, name, profile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error writing config:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s with the profile %s\n", opts.configPath, name)
+}
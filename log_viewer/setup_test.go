@@ -0,0 +1,90 @@
+// log_viewer/setup_test.go
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetupWizard(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istiod-1-22-abc", Namespace: "istio-system",
+			Labels: map[string]string{"app": "istiod", revisionLabel: "1-22"}}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "istio-1-22", Namespace: "istio-system"},
+			Data: map[string]string{"mesh": "accessLogEncoding: JSON\naccessLogFile: /dev/stdout\n"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway-5d-xyz", Namespace: "istio-system",
+			Labels: map[string]string{"app": "istio-ingressgateway", "istio": "ingressgateway"}}},
+	)
+	var connected string
+	var out bytes.Buffer
+	wizard := setupWizard{
+		// An unknown context is asked for again, then the second is picked by
+		// number; the namespace is typed and the profile name left as offered
+		in:       bufio.NewReader(strings.NewReader("prod-eu\n2\nbookinfo\n\n")),
+		out:      &out,
+		contexts: []string{"kind", "prod-us"},
+		current:  "kind",
+		connect: func(kubeContext string) (kubernetes.Interface, error) {
+			connected = kubeContext
+			return client, nil
+		},
+	}
+
+	name, profile, err := wizard.run(context.Background())
+	if err != nil {
+		t.Fatalf("run() unexpected error: %v", err)
+	}
+	if connected != "prod-us" || name != "prod-us" || profile.Context != "prod-us" || profile.Namespace != "bookinfo" {
+		t.Errorf("unexpected profile %s: %+v", name, profile)
+	}
+	if len(profile.Gateways) != 1 || profile.Gateways[0] != "istio-system/istio-ingressgateway" {
+		t.Errorf("expected the ingress gateway found, got %v", profile.Gateways)
+	}
+	for _, want := range []string{"No context \"prod-eu\"", "istiod in istio-system, revisions 1-22", "Access logging: on, to /dev/stdout"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSetupWizardAccessLogOff(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istiod-abc", Namespace: "istio-system", Labels: map[string]string{"app": "istiod"}}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "istio", Namespace: "istio-system"}, Data: map[string]string{"mesh": "defaultConfig: {}\n"}},
+	)
+	install, err := detectIstio(context.Background(), client)
+	if err != nil {
+		t.Fatalf("detectIstio() unexpected error: %v", err)
+	}
+	if install.revisions[0] != "default" || install.accessLog != "" {
+		t.Errorf("expected the default revision without access logs, got %+v", install)
+	}
+}
+
+func TestWriteSetupConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "istio-parsin", "config.json")
+	if err := writeSetupConfig(path, "prod-us", ClusterProfile{Context: "prod-us", Namespace: "bookinfo"}); err != nil {
+		t.Fatalf("writeSetupConfig() unexpected error: %v", err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+	if profile, err := cfg.selectProfile(cfg.DefaultProfile); err != nil || profile.Namespace != "bookinfo" {
+		t.Errorf("expected the written profile as the default, got %+v, %v", profile, err)
+	}
+
+	if err := writeSetupConfig(path, "staging", ClusterProfile{}); err == nil {
+		t.Errorf("expected an existing config left alone")
+	}
+}
@@ -0,0 +1,355 @@
+// log_viewer/sink.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Forwarded entries are batched so an exec sink does not start a process per
+// line and an HTTP sink does not post one.
+const (
+	sinkBatchSize     = 500
+	sinkFlushInterval = time.Second
+	sinkTimeout       = 10 * time.Second // Bounds one batch to one sink
+	sinkQueue         = 64               // Batches waiting for the forwarder
+)
+
+// OutputSink receives the entries forwarded while viewing.
+type OutputSink interface {
+	// Write delivers one batch of entries.
+	Write(ctx context.Context, batch []ParsedLog) error
+	Close() error
+}
+
+// sinkFactory opens a sink from the part of a -sink value after the scheme.
+type sinkFactory func(target string) (OutputSink, error)
+
+var sinkFactories = make(map[string]sinkFactory)
+
+// registerSink makes a sink available to -sink as scheme:target. HTTP sinks
+// register both http and https, and keep the scheme in their target.
+func registerSink(scheme string, factory sinkFactory) {
+	sinkFactories[scheme] = factory
+}
+
+func init() {
+	registerSink("file", newFileSink)
+	registerSink("exec", newExecSink)
+	registerSink("http", newHTTPSink("http"))
+	registerSink("https", newHTTPSink("https"))
+}
+
+// openSink opens the sink a -sink value names, such as file:/tmp/out.ndjson,
+// exec:jq -c . or https://collector.example/ingest.
+func openSink(spec string) (OutputSink, error) {
+	scheme, target, ok := strings.Cut(spec, ":")
+	factory := sinkFactories[scheme]
+	if !ok || factory == nil {
+		var schemes []string
+		for name := range sinkFactories {
+			schemes = append(schemes, name)
+		}
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("unknown sink %q, expected one of %s followed by a colon", spec, strings.Join(schemes, ", "))
+	}
+	if target == "" {
+		return nil, fmt.Errorf("sink %q names no destination", spec)
+	}
+	return factory(target)
+}
+
+// encodeBatch writes a batch as newline-delimited JSON, one object per entry
+// holding the fields shown in the detail view.
+func encodeBatch(w io.Writer, batch []ParsedLog) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range batch {
+		if err := encoder.Encode(detailFields(entry, detectProfile(entry))); err != nil {
+			return fmt.Errorf("error encoding entry %s: %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// fileSink appends to a file.
+type fileSink struct {
+	file *os.File
+}
+
+func newFileSink(path string) (OutputSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sink file: %v", err)
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (s *fileSink) Write(_ context.Context, batch []ParsedLog) error {
+	var buf bytes.Buffer
+	if err := encodeBatch(&buf, batch); err != nil {
+		return err
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("error writing sink file: %v", err)
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// execSink runs a command per batch with the batch on its stdin. The command
+// is run by the shell, so it may quote arguments and use pipes, as in
+// exec:jq -c 'select(.response_code >= 500)'.
+type execSink struct {
+	command string
+}
+
+func newExecSink(command string) (OutputSink, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("exec sink names no command")
+	}
+	return &execSink{command: command}, nil
+}
+
+func (s *execSink) Write(ctx context.Context, batch []ParsedLog) error {
+	var buf bytes.Buffer
+	if err := encodeBatch(&buf, batch); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.command)
+	}
+	cmd.Stdin = &buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sink command %q failed: %v: %s", s.command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *execSink) Close() error {
+	return nil
+}
+
+// httpSink posts each batch.
+type httpSink struct {
+	url string
+}
+
+func newHTTPSink(scheme string) sinkFactory {
+	return func(target string) (OutputSink, error) {
+		return &httpSink{url: scheme + ":" + target}, nil
+	}
+}
+
+func (s *httpSink) Write(ctx context.Context, batch []ParsedLog) error {
+	var buf bytes.Buffer
+	if err := encodeBatch(&buf, batch); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &buf)
+	if err != nil {
+		return fmt.Errorf("error creating sink request: %v", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to sink: %v", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sink returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// sinkForwarder batches entries and hands them to every sink from its own
+// goroutine, so a slow sink never stalls the TUI. Failed batches are dropped
+// and reported through waitForFailure. Entries are forwarded once: a reload, retry or switch back to
+// a pod forwards only the entries not forwarded before, told apart by ID.
+type sinkForwarder struct {
+	sinks   []OutputSink
+	sent    map[EntryID]bool // Entries already forwarded, only used by run
+	entries chan []ParsedLog
+	failed  chan error    // The latest failure not yet shown, see waitForFailure
+	quit    chan struct{} // Closed by close to flush and stop
+	done    chan struct{} // Closed once the last batch was written
+}
+
+// startSinks opens the sinks named by -sink. It returns nil when there are none.
+func startSinks(specs []string) (*sinkForwarder, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	f := &sinkForwarder{
+		sent:    make(map[EntryID]bool),
+		entries: make(chan []ParsedLog, sinkQueue),
+		failed:  make(chan error, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, spec := range specs {
+		sink, err := openSink(spec)
+		if err != nil {
+			for _, opened := range f.sinks {
+				opened.Close()
+			}
+			return nil, err
+		}
+		f.sinks = append(f.sinks, sink)
+	}
+	go f.run()
+	return f, nil
+}
+
+// forward returns a command queueing entries for the sinks. It is nil safe,
+// so callers need not check whether any sink is configured.
+func (f *sinkForwarder) forward(entries []ParsedLog) tea.Cmd {
+	if f == nil || len(entries) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case f.entries <- entries:
+		case <-f.quit:
+		}
+		return nil
+	}
+}
+
+func (f *sinkForwarder) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(sinkFlushInterval)
+	defer ticker.Stop()
+
+	var pending []ParsedLog
+	flush := func() {
+		for len(pending) > 0 {
+			n := min(len(pending), sinkBatchSize)
+			f.write(pending[:n])
+			pending = pending[n:]
+		}
+		pending = nil
+	}
+	for {
+		select {
+		case entries := <-f.entries:
+			pending = f.unsent(pending, entries)
+			if len(pending) >= sinkBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-f.quit:
+			for {
+				select {
+				case entries := <-f.entries:
+					pending = f.unsent(pending, entries)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// unsent appends the entries not forwarded before to pending. Entries without
+// an ID cannot be told apart and are always forwarded.
+func (f *sinkForwarder) unsent(pending, entries []ParsedLog) []ParsedLog {
+	for _, entry := range entries {
+		if !entry.ID.IsZero() {
+			if f.sent[entry.ID] {
+				continue
+			}
+			f.sent[entry.ID] = true
+		}
+		pending = append(pending, entry)
+	}
+	return pending
+}
+
+func (f *sinkForwarder) write(batch []ParsedLog) {
+	var wg sync.WaitGroup
+	for _, sink := range f.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+			defer cancel()
+			if err := sink.Write(ctx, batch); err != nil {
+				f.fail(fmt.Errorf("error forwarding entries: %v", err))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fail queues err for waitForFailure. While an earlier failure has not been
+// shown, later ones are dropped rather than stall forwarding.
+func (f *sinkForwarder) fail(err error) {
+	select {
+	case f.failed <- err:
+	default:
+	}
+}
+
+// sinkFailedMsg carries a failure to forward entries.
+type sinkFailedMsg struct {
+	err error
+}
+
+// waitForFailure returns a command waiting for the next failure to forward
+// entries, to be called again once it is shown. It is nil safe, like forward.
+func (f *sinkForwarder) waitForFailure() tea.Cmd {
+	if f == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case err := <-f.failed:
+			return sinkFailedMsg{err: err}
+		case <-f.quit:
+			return nil
+		}
+	}
+}
+
+// close flushes what is queued and closes the sinks. Entries still being
+// queued by commands of an exited program are lost.
+func (f *sinkForwarder) close() {
+	if f == nil {
+		return
+	}
+	close(f.quit)
+	<-f.done
+	for _, sink := range f.sinks {
+		if err := sink.Close(); err != nil {
+			log.Println("Error closing sink:", err)
+		}
+	}
+}
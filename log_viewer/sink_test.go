@@ -0,0 +1,126 @@
+// log_viewer/sink_test.go
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenSink(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"file:" + filepath.Join(dir, "out.ndjson"), ""},
+		{"exec:cat", ""},
+		{"https://collector.example/ingest", ""},
+		{"file:", "names no destination"},
+		{"kafka:topic", "unknown sink"},
+		{"out.ndjson", "unknown sink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sink, err := openSink(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("openSink() unexpected error: %v", err)
+			}
+			sink.Close()
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"message":"one"}`,
+		`{"message":"two"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	want := "{\"message\":\"one\"}\n{\"message\":\"two\"}\n"
+
+	var posted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = string(body)
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "out.ndjson")
+	execPath := filepath.Join(dir, "exec out.ndjson")
+	forwarder, err := startSinks([]string{
+		"file:" + filePath,
+		"exec:grep -v 'no such message' | tee '" + execPath + "'",
+		server.URL,
+	})
+	if err != nil {
+		t.Fatalf("startSinks() unexpected error: %v", err)
+	}
+	forwarder.forward(logs[:1])()
+	// A reload forwards every entry again, only the new one is sent
+	forwarder.forward(logs)()
+	forwarder.close()
+
+	for _, path := range []string{filePath, execPath} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("expected %s to hold %q, got %q", filepath.Base(path), want, got)
+		}
+	}
+	if posted != want {
+		t.Errorf("expected the batch to be posted, got %q", posted)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	if err := (&httpSink{url: failing.URL}).Write(context.Background(), logs); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected the sink's error to be reported, got %v", err)
+	}
+	if cmd := (*sinkForwarder)(nil).forward(logs); cmd != nil {
+		t.Error("expected no command without sinks")
+	}
+
+	// A failed batch is shown in the TUI, and the next failure waited for
+	forwarder, err = startSinks([]string{failing.URL})
+	if err != nil {
+		t.Fatalf("startSinks() unexpected error: %v", err)
+	}
+	model := Model{sinks: forwarder}
+	waitForFailure := model.Init()
+	forwarder.forward(logs)()
+	updated, next := model.Update(forwarder.waitForFailure()())
+	forwarder.close()
+	if err := updated.(Model).err; err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected the failed batch to be shown, got %v", err)
+	}
+	if waitForFailure == nil || next == nil {
+		t.Error("expected failures to be waited for from the start and after each")
+	}
+	if cmd := (*sinkForwarder)(nil).waitForFailure(); cmd != nil {
+		t.Error("expected no failures waited for without sinks")
+	}
+}
@@ -0,0 +1,114 @@
+// log_viewer/sizelatency.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The histogram of duration or bytes_received is followed by a table of
+// latency by request size, to tell whether large payloads are slow.
+
+// sizeBucketBounds are the upper bounds of the request size buckets, in
+// bytes; the last bucket has none.
+var sizeBucketBounds = []float64{1 << 10, 10 << 10, 100 << 10, 1 << 20}
+
+// sizeBucketLabels name the buckets, one more than there are bounds.
+var sizeBucketLabels = []string{"< 1 KiB", "1–10 KiB", "10–100 KiB", "100 KiB–1 MiB", "≥ 1 MiB"}
+
+// Large payloads are slow when the largest bucket with at least
+// minSizeBucketRequests has a median slowPayloadFactor times that of the
+// smallest such bucket.
+const (
+	minSizeBucketRequests = 5
+	slowPayloadFactor     = 3
+)
+
+// sizeLatencyFields are the histogram fields the table is shown with.
+var sizeLatencyFields = []string{"duration", "bytes_received"}
+
+// sizeBucket holds the sorted durations of the requests of one size range.
+type sizeBucket struct {
+	label     string
+	durations []float64
+}
+
+// sizeLatency buckets the requests of logs that logged both their size and
+// duration by bytes_received. Empty buckets are left out.
+func sizeLatency(logs []ParsedLog) []sizeBucket {
+	buckets := make([]sizeBucket, len(sizeBucketLabels))
+	for i, label := range sizeBucketLabels {
+		buckets[i].label = label
+	}
+	for _, log := range logs {
+		size, hasSize := numericValue(log.Fields["bytes_received"])
+		duration, hasDuration := numericValue(log.Fields["duration"])
+		if !hasSize || !hasDuration {
+			continue
+		}
+		i := sort.SearchFloat64s(sizeBucketBounds, size)
+		if i < len(sizeBucketBounds) && size == sizeBucketBounds[i] {
+			// Bounds are exclusive
+			i++
+		}
+		buckets[i].durations = append(buckets[i].durations, duration)
+	}
+
+	var filled []sizeBucket
+	for _, bucket := range buckets {
+		if len(bucket.durations) > 0 {
+			sort.Float64s(bucket.durations)
+			filled = append(filled, bucket)
+		}
+	}
+	return filled
+}
+
+// slowPayloads describes how much slower the largest requests are than the
+// smallest, or is "" when they are not, or too few to tell.
+func slowPayloads(buckets []sizeBucket) string {
+	var judged []sizeBucket
+	for _, bucket := range buckets {
+		if len(bucket.durations) >= minSizeBucketRequests {
+			judged = append(judged, bucket)
+		}
+	}
+	if len(judged) < 2 {
+		return ""
+	}
+	small, large := judged[0], judged[len(judged)-1]
+	smallMedian, largeMedian := percentile(small.durations, 50), percentile(large.durations, 50)
+	if smallMedian <= 0 || largeMedian < smallMedian*slowPayloadFactor {
+		return ""
+	}
+	return fmt.Sprintf("Large payloads are slow: p50 %gms for %s against %gms for %s (%.1fx).",
+		largeMedian, large.label, smallMedian, small.label, largeMedian/smallMedian)
+}
+
+// renderSizeLatency draws the buckets as a table, followed by the finding
+// when large payloads are slow.
+func renderSizeLatency(buckets []sizeBucket) string {
+	if len(buckets) == 0 {
+		return ""
+	}
+	labelWidth := len("Request size")
+	for _, bucket := range buckets {
+		labelWidth = max(labelWidth, len([]rune(bucket.label)))
+	}
+
+	var b strings.Builder
+	pad := func(label string) string { return label + strings.Repeat(" ", labelWidth-len([]rune(label))) }
+	fmt.Fprintf(&b, "%s  %8s  %8s  %8s  %8s\n", pad("Request size"), "requests", "p50", "p95", "p99")
+	for _, bucket := range buckets {
+		fmt.Fprintf(&b, "%s  %8d  %8s  %8s  %8s\n", pad(bucket.label), len(bucket.durations),
+			fmt.Sprintf("%gms", percentile(bucket.durations, 50)),
+			fmt.Sprintf("%gms", percentile(bucket.durations, 95)),
+			fmt.Sprintf("%gms", percentile(bucket.durations, 99)))
+	}
+	if finding := slowPayloads(buckets); finding != "" {
+		fmt.Fprintf(&b, "\n%s\n", finding)
+	}
+	return b.String()
+}
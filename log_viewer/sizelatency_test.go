@@ -0,0 +1,49 @@
+// log_viewer/sizelatency_test.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSizeLatency(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"response_code":200,"bytes_received":%d,"duration":%d}`, 200+i, 10+i))
+		lines = append(lines, fmt.Sprintf(`{"response_code":200,"bytes_received":%d,"duration":%d}`, 2<<20, 100+i))
+	}
+	lines = append(lines,
+		`{"response_code":200,"bytes_received":1024,"duration":20}`,
+		`{"response_code":200,"duration":5}`,
+	)
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	buckets := sizeLatency(logs)
+	if len(buckets) != 3 || buckets[0].label != "< 1 KiB" || buckets[1].label != "1–10 KiB" || len(buckets[2].durations) != 10 {
+		t.Fatalf("sizeLatency() = %+v, expected the small, 1 KiB and large buckets", buckets)
+	}
+
+	table := renderSizeLatency(buckets)
+	if !strings.Contains(table, "Large payloads are slow: p50 104ms for ≥ 1 MiB against 14ms for < 1 KiB") {
+		t.Errorf("expected the slow payload finding, got:\n%s", table)
+	}
+
+	// Sizes that do not change the latency are not called out
+	even := []sizeBucket{
+		{label: "< 1 KiB", durations: []float64{10, 10, 11, 12, 12}},
+		{label: "≥ 1 MiB", durations: []float64{11, 12, 12, 13, 14}},
+	}
+	if finding := slowPayloads(even); finding != "" {
+		t.Errorf("expected no finding when sizes do not matter, got %q", finding)
+	}
+
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 40, histogram: &histogramView{fields: []string{"bytes_received"}}}
+	if view := model.View(); !strings.Contains(view, "Request size") {
+		t.Errorf("expected the table in the bytes_received histogram, got:\n%s", view)
+	}
+}
@@ -0,0 +1,170 @@
+// log_viewer/slo.go
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// An SLO is a target success rate for the requests to an upstream cluster, or
+// to every cluster. Its burn rate is how fast the loaded window spent the
+// error budget the target allows: 1 spends exactly the budget, above 1 the
+// SLO is violated.
+
+// atRiskBurn is the burn rate from which an SLO is shown as at risk.
+const atRiskBurn = 0.75
+
+// sloTarget is one -slo or config slos value.
+type sloTarget struct {
+	cluster string  // Matched against upstream_cluster, empty for every request
+	target  float64 // Success rate in percent, e.g. 99.9
+}
+
+// scope names the requests the target applies to.
+func (t sloTarget) scope() string {
+	if t.cluster == "" {
+		return "all requests"
+	}
+	return t.cluster
+}
+
+// parseSLO reads a target written as PERCENT or CLUSTER=PERCENT, where CLUSTER
+// is part of the upstream cluster name, e.g. reviews=99.9.
+func parseSLO(value string) (sloTarget, error) {
+	cluster, target, found := strings.Cut(value, "=")
+	if !found {
+		cluster, target = "", value
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(target), "%"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return sloTarget{}, fmt.Errorf("invalid SLO %q, expected a success rate between 0 and 100 such as 99.9 or reviews=99.9", value)
+	}
+	return sloTarget{cluster: strings.TrimSpace(cluster), target: percent}, nil
+}
+
+// parseSLOs reads every target.
+func parseSLOs(values []string) ([]sloTarget, error) {
+	var targets []sloTarget
+	for _, value := range values {
+		t, err := parseSLO(value)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// sloStatus grades an evaluated SLO.
+type sloStatus string
+
+const (
+	sloNoData    sloStatus = "no data"
+	sloGood      sloStatus = "good"
+	sloAtRisk    sloStatus = "at risk"
+	sloViolating sloStatus = "violating"
+)
+
+// sloResult is an SLO evaluated over the loaded entries.
+type sloResult struct {
+	sloTarget
+	requests int
+	failed   int
+}
+
+// evaluateSLOs counts the requests each target applies to, and those that
+// failed as requestFailed has it. Health checks only count with
+// healthChecks set.
+func evaluateSLOs(targets []sloTarget, logs []ParsedLog, healthChecks bool) []sloResult {
+	results := make([]sloResult, len(targets))
+	for i, t := range targets {
+		results[i].sloTarget = t
+	}
+	for _, entry := range logs {
+		code, ok := entry.ResponseCode()
+		if !ok || !healthChecks && trafficClass(entry) == trafficHealthCheck {
+			continue
+		}
+		failed := requestFailed(code, entry.Flags())
+		for i := range results {
+			if !strings.Contains(entry.UpstreamCluster(), results[i].cluster) {
+				continue
+			}
+			results[i].requests++
+			if failed {
+				results[i].failed++
+			}
+		}
+	}
+	return results
+}
+
+// success returns the observed success rate in percent.
+func (r sloResult) success() float64 {
+	if r.requests == 0 {
+		return 0
+	}
+	return 100 * float64(r.requests-r.failed) / float64(r.requests)
+}
+
+// burnRate returns the observed failure rate over the failure rate the
+// target allows.
+func (r sloResult) burnRate() float64 {
+	if r.requests == 0 {
+		return 0
+	}
+	return (100 - r.success()) / (100 - r.target)
+}
+
+// status grades the burn rate.
+func (r sloResult) status() sloStatus {
+	switch burn := r.burnRate(); {
+	case r.requests == 0:
+		return sloNoData
+	case burn > 1:
+		return sloViolating
+	case burn >= atRiskBurn:
+		return sloAtRisk
+	}
+	return sloGood
+}
+
+// style colors the result by its status.
+func (r sloResult) style() lipgloss.Style {
+	switch r.status() {
+	case sloViolating:
+		return heatErrorStyle
+	case sloAtRisk:
+		return heatWarnStyle
+	case sloGood:
+		return markerStyle
+	}
+	return jsonNullStyle
+}
+
+// render describes the result in one line, e.g.
+// "SLO reviews 99.20% of 99.9% burn 8.0x VIOLATING".
+func (r sloResult) render() string {
+	if r.requests == 0 {
+		return r.style().Render(fmt.Sprintf("SLO %s %g%% NO DATA", r.scope(), r.target))
+	}
+	return r.style().Render(fmt.Sprintf("SLO %s %.2f%% of %g%% burn %.1fx %s",
+		r.scope(), r.success(), r.target, r.burnRate(), strings.ToUpper(string(r.status()))))
+}
+
+// renderSLOs returns the TUI line of every SLO evaluated over the loaded
+// entries, "" without any.
+func (m Model) renderSLOs() string {
+	if len(m.slos) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, result := range evaluateSLOs(m.slos, m.logs, m.healthChecks) {
+		parts = append(parts, result.render())
+	}
+	return lipgloss.NewStyle().PaddingLeft(1).Render(strings.Join(parts, "  "))
+}
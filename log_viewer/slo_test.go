@@ -0,0 +1,76 @@
+// log_viewer/slo_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseSLO(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    sloTarget
+		wantErr bool
+	}{
+		{"99.9", sloTarget{target: 99.9}, false},
+		{"reviews=99.5%", sloTarget{cluster: "reviews", target: 99.5}, false},
+		{"100", sloTarget{}, true},
+		{"reviews=high", sloTarget{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSLO(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSLO(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSLO(%q) = %+v, expected %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateSLOs(t *testing.T) {
+	var lines []string
+	for i := 0; i < 98; i++ {
+		lines = append(lines, `{"response_code":200,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}`)
+	}
+	lines = append(lines,
+		`{"response_code":503,"response_flags":"UF","upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}`,
+		`{"response_code":404,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local"}`,
+		`{"level":"info","msg":"not an access log"}`,
+	)
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	targets, _ := parseSLOs([]string{"reviews=99.9", "99", "98", "ratings=99"})
+	results := evaluateSLOs(targets, logs, false)
+	want := []struct {
+		requests int
+		status   sloStatus
+	}{
+		{100, sloViolating}, // 1% failed against a 0.1% budget
+		{100, sloAtRisk},    // The whole budget spent
+		{100, sloGood},
+		{0, sloNoData},
+	}
+	for i, w := range want {
+		if results[i].requests != w.requests || results[i].status() != w.status {
+			t.Errorf("SLO %+v: %d requests %s, expected %d %s", targets[i], results[i].requests, results[i].status(), w.requests, w.status)
+		}
+	}
+	if burn := results[0].burnRate(); burn < 9.99 || burn > 10.01 {
+		t.Errorf("burnRate() = %v, expected 10", burn)
+	}
+
+	report := summarize(logs, "test")
+	report.slos = results
+	var out bytes.Buffer
+	report.writeMarkdown(&out)
+	if !strings.Contains(out.String(), "| reviews | 99.9% | 100 | 99.00% | 10.0x | **violating** |") {
+		t.Errorf("expected the SLO table in the report, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,96 @@
+// log_viewer/snapshot_test.go
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// ansiPattern matches SGR escape sequences so snapshots only capture layout.
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+var snapshotLines = []string{
+	`{"start_time":"2024-11-26T02:30:58.502Z","method":"GET","path":"/reviews/1","response_code":200,"response_flags":"-","duration":12,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local","upstream_host":"10.42.0.12:9080"}`,
+	`{"start_time":"2024-11-26T02:30:59.100Z","response_code":0,"response_flags":"UF,URX","duration":0,"upstream_cluster":"PassthroughCluster","upstream_host":"10.43.236.215:4200","upstream_transport_failure_reason":"delayed_connect_error:_Connection_refused"}`,
+	`{"start_time":"2024-11-26T02:31:00.000Z","method":"POST","path":"/ratings","response_code":503,"response_flags":"UO","duration":3}`,
+}
+
+// assertGolden compares got with testdata/golden/<name>.golden, rewriting the
+// file instead when the test runs with -update.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	got = ansiPattern.ReplaceAllString(got, "")
+	path := filepath.Join("testdata", "golden", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file %s, run go test -run %s -update: %v", path, t.Name(), err)
+	}
+	if got != string(expected) {
+		t.Errorf("view does not match %s, run go test -run %s -update to accept\n--- got ---\n%s\n--- expected ---\n%s",
+			path, t.Name(), got, expected)
+	}
+}
+
+func TestViewSnapshots(t *testing.T) {
+	defer applyTheme(themes["default"])
+	applyTheme(themes["default"])
+
+	logs := mustParse(t, snapshotLines...)
+
+	tests := []struct {
+		name  string
+		model Model
+	}{
+		{
+			name:  "list_80x24",
+			model: Model{logs: logs, filteredLogs: logs, width: 80, height: 24},
+		},
+		{
+			name:  "selected_error_120x40",
+			model: Model{logs: logs, filteredLogs: logs, selectedLogIndex: 1, width: 120, height: 40},
+		},
+		{
+			name:  "search_overlay_100x30",
+			model: Model{logs: logs, filteredLogs: logs, searchMode: true, searchQuery: "UF", width: 100, height: 30},
+		},
+		{
+			name:  "list_layout_80x24",
+			model: Model{logs: logs, filteredLogs: logs, selectedLogIndex: 2, width: 80, height: 24, layout: layoutList},
+		},
+		{
+			name:  "detail_layout_80x24",
+			model: Model{logs: logs, filteredLogs: logs, selectedLogIndex: 1, width: 80, height: 24, layout: layoutDetail},
+		},
+		{
+			name:  "expanded_list_80x30",
+			model: Model{logs: logs, filteredLogs: logs, selectedLogIndex: 1, width: 80, height: 30, layout: layoutList, expandedList: true},
+		},
+		{
+			name:  "empty",
+			model: Model{width: 80, height: 24},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, tt.model.View())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// log_viewer/source.go
+
+package main
+
+import "strings"
+
+// Source is where an entry was read from. Labels that do not apply to how
+// the logs were read, such as the pod of a file, are empty.
+type Source struct {
+	Cluster   string // Kubeconfig context, or "in-cluster"
+	Namespace string
+	Pod       string
+	Container string
+	File      string // Path of a log file
+}
+
+// sourcePrefix starts the names of the source labels in filters and -compare,
+// e.g. source.pod.
+const sourcePrefix = "source."
+
+// sourceLabels are the label names, in the order they are shown.
+var sourceLabels = []string{"cluster", "namespace", "pod", "container", "file"}
+
+// Label returns the named label, "" for one the source does not have or that
+// does not exist.
+func (s Source) Label(name string) string {
+	switch name {
+	case "cluster":
+		return s.Cluster
+	case "namespace":
+		return s.Namespace
+	case "pod":
+		return s.Pod
+	case "container":
+		return s.Container
+	case "file":
+		return s.File
+	}
+	return ""
+}
+
+// IsZero reports whether nothing is known about the source.
+func (s Source) IsZero() bool {
+	return s == Source{}
+}
+
+// fileSource labels the entries of a log file, none for stdin ("-").
+func fileSource(path string) Source {
+	if path == "-" {
+		return Source{}
+	}
+	return Source{File: path}
+}
+
+// withOrigin returns the options for logs read from origin, recorded on every
+// entry.
+func (o parseOptions) withOrigin(origin Source) parseOptions {
+	o.origin = origin
+	return o
+}
+
+// compareFields are the -compare values that are not log fields.
+func compareFields() []string {
+	fields := []string{compareSubset, compareClientFamily, compareClientVersion}
+	for _, label := range sourceLabels {
+		fields = append(fields, sourcePrefix+label)
+	}
+	return fields
+}
+
+// isSourceLabel reports whether name is a known label.
+func isSourceLabel(name string) bool {
+	for _, label := range sourceLabels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceTerm restricts a filter to entries whose label contains value,
+// ignoring case.
+type sourceTerm struct {
+	label string
+	value string // Lowercased
+}
+
+// parseSourceTerm parses a source.<label>=<value> term, which keeps the
+// entries whose label contains value, so "source.pod=reviews 503" keeps the
+// 503s logged by the reviews pods. A term naming an unknown label is not
+// one, and is matched as text.
+func parseSourceTerm(word string) (sourceTerm, bool) {
+	name, value, ok := strings.Cut(strings.TrimPrefix(word, sourcePrefix), "=")
+	if !strings.HasPrefix(word, sourcePrefix) || !ok || !isSourceLabel(name) {
+		return sourceTerm{}, false
+	}
+	return sourceTerm{label: name, value: strings.ToLower(value)}, true
+}
+
+func (t sourceTerm) String() string {
+	return sourcePrefix + t.label + "=" + t.value
+}
+
+// carriedFilter returns the part of filter that still applies to entries from
+// origin: source terms selecting another pod or namespace are dropped, the
+// rest is kept.
+func carriedFilter(filter string, origin Source) string {
+	var kept []string
+	dropped := false
+	for _, word := range strings.Split(filter, " ") {
+		if term, ok := parseSourceTerm(word); ok && !matchesSource(origin, []sourceTerm{term}) {
+			dropped = true
+			continue
+		}
+		kept = append(kept, word)
+	}
+	if !dropped {
+		return filter
+	}
+	return strings.TrimSpace(strings.Join(kept, " "))
+}
+
+// matchesSource reports whether source satisfies every term.
+func matchesSource(source Source, terms []sourceTerm) bool {
+	for _, term := range terms {
+		if !containsFold(source.Label(term.label), term.value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+// log_viewer/source_test.go
+
+package main
+
+import "testing"
+
+func TestFilterLogsBySource(t *testing.T) {
+	reviews, err := parseRawLogsWith([]string{`{"response_code":503}`, `{"response_code":200}`},
+		defaultParseOptions().withOrigin(Source{Cluster: "prod", Namespace: "default", Pod: "reviews-v1-abc"}))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	ratings, err := parseRawLogsWith([]string{`{"response_code":503}`},
+		defaultParseOptions().withOrigin(Source{Cluster: "prod", Namespace: "default", Pod: "ratings-v1-xyz"}))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	logs := append(reviews, ratings...)
+
+	if got := filterLogs(logs, "source.pod=reviews"); len(got) != 2 {
+		t.Errorf("expected both reviews entries, got %d", len(got))
+	}
+	got := filterLogs(logs, "source.cluster=prod 503")
+	if len(got) != 2 || got[0].Source.Pod != "reviews-v1-abc" || got[1].Source.Pod != "ratings-v1-xyz" {
+		t.Errorf("expected the 503s of both pods, got %+v", got)
+	}
+	if got := filterLogs(logs, "503 source.pod=reviews"); len(got) != 1 {
+		t.Errorf("expected the term to apply after the text, got %d", len(got))
+	}
+	if got := filterLogs(logs, "source.pod=details"); len(got) != 0 {
+		t.Errorf("expected no entries from another pod, got %d", len(got))
+	}
+}
+
+func TestCompareBySource(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{`{"response_code":200,"duration":10}`}, defaultParseOptions().withOrigin(fileSource("a.log")))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	more, err := parseRawLogsWith([]string{`{"response_code":503,"duration":30}`}, defaultParseOptions().withOrigin(fileSource("b.log")))
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	c := compareBy(append(logs, more...), "source.file")
+	if len(c.variants) != 2 || c.variants[0].value != "a.log" || c.variants[1].errors != 1 {
+		t.Errorf("expected a.log and b.log with one error, got %+v", c.variants)
+	}
+}
+
+func TestKubeTargetOrigin(t *testing.T) {
+	target := kubeTarget{
+		namespace: "default",
+		pod:       "reviews-v1-abc",
+		container: sidecarContainer,
+		parseOpts: defaultParseOptions().withOrigin(Source{Cluster: "kind-mesh"}),
+	}
+	model := Model{target: &target}.receiveLogs(logsFetchedMsg{target: target, lines: []string{`{"response_code":200}`}})
+
+	want := Source{Cluster: "kind-mesh", Namespace: "default", Pod: "reviews-v1-abc", Container: sidecarContainer}
+	if len(model.logs) != 1 || model.logs[0].Source != want {
+		t.Fatalf("expected entries labelled %+v, got %+v", want, model.logs)
+	}
+
+	model = model.appendLine(`{"response_code":503}`)
+	if got := model.logs[len(model.logs)-1].Source; got != want {
+		t.Errorf("expected followed entries labelled %+v, got %+v", want, got)
+	}
+}
+
+func TestCarriedFilter(t *testing.T) {
+	origin := Source{Namespace: "default", Pod: "ratings-v1", Container: sidecarContainer}
+	tests := []struct{ filter, want string }{
+		{"503 UF", "503 UF"},
+		{"source.pod=reviews 503", "503"},
+		{"source.namespace=default source.pod=reviews", "source.namespace=default"},
+		{"source.container=istio-proxy", "source.container=istio-proxy"},
+	}
+	for _, tt := range tests {
+		if got := carriedFilter(tt.filter, origin); got != tt.want {
+			t.Errorf("carriedFilter(%q) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
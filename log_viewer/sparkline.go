@@ -0,0 +1,78 @@
+// log_viewer/sparkline.go
+
+package main
+
+import "time"
+
+// sparklineBuckets is how many time buckets the filter's sparkline has.
+const sparklineBuckets = 16
+
+// sparkLevels draw a bucket's count relative to the busiest one; an empty
+// bucket is drawn as a dot so a resolved condition reads as a flat tail.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+const sparkEmpty = '·'
+
+// matchCounts spreads the times of the matched entries over buckets spanning
+// every entry, so the sparkline covers the whole log rather than only the
+// matches. It reports false when the entries carry no usable time span.
+func matchCounts(logs, matched []ParsedLog, buckets int) ([]int, bool) {
+	var first, last time.Time
+	for _, log := range logs {
+		t, ok := eventTime(log)
+		if !ok {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	span := last.Sub(first)
+	if span <= 0 {
+		return nil, false
+	}
+
+	counts := make([]int, buckets)
+	for _, log := range matched {
+		t, ok := eventTime(log)
+		if !ok {
+			continue
+		}
+		i := min(int(t.Sub(first)*time.Duration(buckets)/span), buckets-1)
+		counts[max(i, 0)]++
+	}
+	return counts, true
+}
+
+// sparkline draws counts as a row of block characters.
+func sparkline(counts []int) string {
+	most := 0
+	for _, count := range counts {
+		most = max(most, count)
+	}
+	line := make([]rune, len(counts))
+	for i, count := range counts {
+		if count == 0 {
+			line[i] = sparkEmpty
+			continue
+		}
+		line[i] = sparkLevels[(count*len(sparkLevels)-1)/most]
+	}
+	return string(line)
+}
+
+// filterSparkline returns the sparkline of the filter's matches over time,
+// or "" when no filter is applied or the entries carry no times.
+func (m Model) filterSparkline() string {
+	if m.activeFilter == "" && m.connectionFilter == "" {
+		return ""
+	}
+	counts, ok := matchCounts(m.logs, m.filteredLogs, sparklineBuckets)
+	if !ok {
+		return ""
+	}
+	return sparkline(counts)
+}
@@ -0,0 +1,43 @@
+// log_viewer/sparkline_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 4, 8}); got != "·▁▄█" {
+		t.Errorf("sparkline() = %q, expected %q", got, "·▁▄█")
+	}
+}
+
+func TestFilterSparkline(t *testing.T) {
+	// 503s early on that have since stopped
+	var lines []string
+	for minute := 0; minute < 8; minute++ {
+		code := "200"
+		if minute < 3 {
+			code = "503"
+		}
+		lines = append(lines, `{"start_time":"2024-05-01T12:0`+string(rune('0'+minute))+`:00Z","response_code":`+code+`}`)
+	}
+	logs, err := parseRawLogsWith(lines, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 40}
+	if model.filterSparkline() != "" {
+		t.Errorf("expected no sparkline without a filter")
+	}
+
+	model = model.applyFilter("503")
+	trend := model.filterSparkline()
+	if len([]rune(trend)) != sparklineBuckets || !strings.HasSuffix(trend, "········") || strings.HasPrefix(trend, "·") {
+		t.Errorf("expected matches early and none at the end, got %q", trend)
+	}
+	if !strings.Contains(model.View(), "Matches "+trend) {
+		t.Errorf("expected the sparkline in the header")
+	}
+}
@@ -1 +1,267 @@
+// log_viewer/styles.go
+
 package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme describes the palette used by the TUI. Themes with decorate set rely on
+// bold/underline/reverse rather than color to mark selection and severity.
+type Theme struct {
+	Name       string
+	Highlight  lipgloss.TerminalColor
+	Normal     lipgloss.TerminalColor
+	Header     lipgloss.TerminalColor
+	Error      lipgloss.TerminalColor
+	Warn       lipgloss.TerminalColor
+	Info       lipgloss.TerminalColor
+	JSONKey    lipgloss.TerminalColor
+	JSONString lipgloss.TerminalColor
+	JSONNumber lipgloss.TerminalColor
+	JSONNull   lipgloss.TerminalColor
+	Muted      lipgloss.TerminalColor
+	SelectedBg lipgloss.TerminalColor
+	Decorate   bool
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Name:       "default",
+		Highlight:  lipgloss.Color("39"),  // Blue
+		Normal:     lipgloss.Color("252"), // Light gray
+		Header:     lipgloss.Color("105"), // Light purple
+		Error:      lipgloss.Color("196"), // Red
+		Warn:       lipgloss.Color("214"), // Orange
+		Info:       lipgloss.Color("83"),  // Green
+		JSONKey:    lipgloss.Color("105"), // Purple for JSON keys
+		JSONString: lipgloss.Color("83"),  // Green for strings
+		JSONNumber: lipgloss.Color("214"), // Orange for numbers
+		JSONNull:   lipgloss.Color("245"), // Gray for null values
+		Muted:      lipgloss.Color("242"), // Dim gray for explanations
+		SelectedBg: lipgloss.Color("236"), // Dark gray selection background
+	},
+	// high-contrast sticks to the 16 basic ANSI colors and adds decorations
+	"high-contrast": {
+		Name:       "high-contrast",
+		Highlight:  lipgloss.Color("11"), // Bright yellow
+		Normal:     lipgloss.Color("15"), // Bright white
+		Header:     lipgloss.Color("14"), // Bright cyan
+		Error:      lipgloss.Color("9"),  // Bright red
+		Warn:       lipgloss.Color("11"), // Bright yellow
+		Info:       lipgloss.Color("10"), // Bright green
+		JSONKey:    lipgloss.Color("14"),
+		JSONString: lipgloss.Color("15"),
+		JSONNumber: lipgloss.Color("11"),
+		JSONNull:   lipgloss.Color("7"),
+		Muted:      lipgloss.Color("7"),
+		SelectedBg: lipgloss.NoColor{},
+		Decorate:   true,
+	},
+	"mono": {
+		Name:       "mono",
+		Highlight:  lipgloss.NoColor{},
+		Normal:     lipgloss.NoColor{},
+		Header:     lipgloss.NoColor{},
+		Error:      lipgloss.NoColor{},
+		Warn:       lipgloss.NoColor{},
+		Info:       lipgloss.NoColor{},
+		JSONKey:    lipgloss.NoColor{},
+		JSONString: lipgloss.NoColor{},
+		JSONNumber: lipgloss.NoColor{},
+		JSONNull:   lipgloss.NoColor{},
+		Muted:      lipgloss.NoColor{},
+		SelectedBg: lipgloss.NoColor{},
+		Decorate:   true,
+	},
+}
+
+var (
+	activeTheme Theme
+
+	// Colors
+	highlightColor  lipgloss.TerminalColor
+	normalColor     lipgloss.TerminalColor
+	headerColor     lipgloss.TerminalColor
+	errorColor      lipgloss.TerminalColor
+	warnColor       lipgloss.TerminalColor
+	infoColor       lipgloss.TerminalColor
+	jsonKeyColor    lipgloss.TerminalColor
+	jsonStringColor lipgloss.TerminalColor
+	jsonNumberColor lipgloss.TerminalColor
+	jsonNullColor   lipgloss.TerminalColor
+	mutedColor      lipgloss.TerminalColor
+
+	headerStyle      lipgloss.Style
+	logStyle         lipgloss.Style
+	selectedLogStyle lipgloss.Style
+	searchStyle      lipgloss.Style
+	errorStyle       lipgloss.Style
+	markerStyle      lipgloss.Style
+	heatErrorStyle   lipgloss.Style
+	heatWarnStyle    lipgloss.Style
+	matchStyle       lipgloss.Style
+	activeMatchStyle lipgloss.Style
+	jsonKeyStyle     lipgloss.Style
+	jsonStringStyle  lipgloss.Style
+	jsonNumberStyle  lipgloss.Style
+	jsonNullStyle    lipgloss.Style
+)
+
+func init() {
+	applyTheme(themes["default"])
+}
+
+// themeNames lists the available themes for help and error messages.
+func themeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectTheme picks the theme to use. --no-color and a non-empty NO_COLOR
+// (https://no-color.org) force the monochrome theme.
+func selectTheme(name string, noColor bool) (Theme, error) {
+	if noColor {
+		return themes["mono"], nil
+	}
+	if name == "" {
+		return themes["default"], nil
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %v)", name, themeNames())
+	}
+	return theme, nil
+}
+
+// applyTheme rebuilds the package-level colors and styles from theme.
+func applyTheme(theme Theme) {
+	activeTheme = theme
+
+	highlightColor = theme.Highlight
+	normalColor = theme.Normal
+	headerColor = theme.Header
+	errorColor = theme.Error
+	warnColor = theme.Warn
+	infoColor = theme.Info
+	jsonKeyColor = theme.JSONKey
+	jsonStringColor = theme.JSONString
+	jsonNumberColor = theme.JSONNumber
+	jsonNullColor = theme.JSONNull
+	mutedColor = theme.Muted
+
+	// Header style
+	headerStyle = lipgloss.NewStyle().
+		Foreground(headerColor).
+		Bold(true).
+		Underline(theme.Decorate).
+		PaddingLeft(1).
+		PaddingRight(1).
+		MarginBottom(1)
+
+	// Log styles
+	logStyle = lipgloss.NewStyle().
+		Foreground(normalColor)
+
+	selectedLogStyle = lipgloss.NewStyle().
+		Foreground(highlightColor).
+		Bold(true).
+		Reverse(theme.Decorate).
+		Background(theme.SelectedBg)
+
+	// Search overlay style
+	searchStyle = lipgloss.NewStyle().
+		Foreground(highlightColor).
+		Background(theme.SelectedBg).
+		Reverse(theme.Decorate).
+		Padding(0, 1).
+		MarginTop(1)
+
+	// Error style
+	errorStyle = lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true).
+		Padding(1)
+
+	// Marker style, for deploys and events among the entries
+	markerStyle = lipgloss.NewStyle().
+		Foreground(infoColor).
+		Bold(true)
+
+	// Heatmap cells of failed and rejected requests, see heatCell
+	heatErrorStyle = lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true)
+	heatWarnStyle = lipgloss.NewStyle().
+		Foreground(warnColor)
+
+	// Matches of a search within the selected entry, the current one stronger
+	matchStyle = lipgloss.NewStyle().
+		Foreground(warnColor).
+		Reverse(true)
+	activeMatchStyle = matchStyle.
+		Bold(true).
+		Underline(true)
+
+	// JSON highlighting styles
+	jsonKeyStyle = lipgloss.NewStyle().
+		Foreground(jsonKeyColor).
+		Bold(true)
+
+	jsonStringStyle = lipgloss.NewStyle().
+		Foreground(jsonStringColor)
+
+	jsonNumberStyle = lipgloss.NewStyle().
+		Foreground(jsonNumberColor)
+
+	jsonNullStyle = lipgloss.NewStyle().
+		Foreground(jsonNullColor).
+		Italic(true)
+}
+
+// errorRowStyle marks a list row as an error. Decorated themes also embolden
+// and underline it so the row stands out without color.
+func errorRowStyle(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy().Foreground(errorColor)
+	if activeTheme.Decorate {
+		style = style.Bold(true).Underline(true)
+	}
+	return style
+}
+
+// warnRowStyle marks a list row as a warning. Decorated themes also underline it.
+func warnRowStyle(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy().Foreground(warnColor)
+	if activeTheme.Decorate {
+		style = style.Underline(true)
+	}
+	return style
+}
+
+// flagTagStyle colors a response flag's tag within a list row by the flag's
+// severity, see Flag.severity.
+func flagTagStyle(flag Flag, style lipgloss.Style) lipgloss.Style {
+	switch flag.severity() {
+	case 2:
+		return errorRowStyle(style).Bold(true)
+	case 1:
+		return warnRowStyle(style).Bold(true)
+	}
+	return debugRowStyle(style)
+}
+
+// debugRowStyle dims a list row holding debug output. Decorated themes render it faint.
+func debugRowStyle(style lipgloss.Style) lipgloss.Style {
+	style = style.Copy().Foreground(jsonNullColor)
+	if activeTheme.Decorate {
+		style = style.Faint(true)
+	}
+	return style
+}
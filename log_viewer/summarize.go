@@ -0,0 +1,781 @@
+// log_viewer/summarize.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
+)
+
+// topEndpoints is how many of the slowest endpoints the report lists.
+const topEndpoints = 10
+
+// Anomaly thresholds. They are deliberately conservative, so the anomalies
+// section stays empty for healthy traffic.
+const (
+	highErrorRate      = 0.05            // Overall error rate worth calling out
+	minRequestsForRate = 20              // Fewer requests make any rate noise
+	failingClusterRate = 0.5             // Error rate of a cluster worth calling out
+	minClusterRequests = 5               // Requests a cluster needs to be judged
+	slowOutlierFactor  = 10              // Multiple of the median that makes a request an outlier
+	minOutlierDuration = 1000.0          // Milliseconds; faster requests are never outliers
+	errorBurstFactor   = 3               // Multiple of the per-minute error average that makes a burst
+	minErrorBurst      = 3               // Errors a minute needs to be a burst
+	minLoggingGap      = 5 * time.Minute // Silence worth calling out
+	markerWindow       = 5 * time.Minute // Errors counted either side of a marker
+)
+
+// summary aggregates parsed logs into the figures of an incident report.
+type summary struct {
+	source       string
+	links        []deepLink   // Deep links for the whole time range
+	notes        []notedEntry // Entries the user left notes on
+	markers      []marker     // Deploys and the like, resolved against the logs
+	slos         []sloResult  // -slo targets evaluated over the logs
+	comparison   *comparison  // Requests split by -compare, nil without it
+	entries      int
+	requests     int
+	healthChecks int // Left out of the request stats, see trafficClass
+	errors       int
+	first, last  time.Time
+	timestamps   []time.Time
+
+	errorsByFlag    map[string]int
+	errorsByCode    map[string]int
+	clusters        map[string]*clusterStats
+	protocols       map[string]*clusterStats // Requests by requestProtocol
+	endpoints       map[string][]float64     // Durations in milliseconds by "METHOD /path"
+	durations       []float64
+	errorsPerMinute map[time.Time]int
+	levels          map[string]int // Application log levels
+	pools           []poolDiagnosis
+	denials         denialStats         // Authz and JWT denials, see analyzeDenials
+	reusedIDs       map[string]reusedID // Request IDs distinct requests sent, see reusedRequestIDs
+	skews           []clockSkew         // Nodes whose clocks disagree, see clockSkews
+}
+
+type clusterStats struct {
+	requests int
+	errors   int
+}
+
+// summarize aggregates logs read from source, leaving health checks out of
+// the request stats.
+func summarize(logs []ParsedLog, source string) summary {
+	return summarizeWith(logs, source, false)
+}
+
+// summarizeWith aggregates logs read from source, counting health checks as
+// requests when healthChecks is set.
+func summarizeWith(logs []ParsedLog, source string, healthChecks bool) summary {
+	s := summary{
+		source:          source,
+		entries:         len(logs),
+		errorsByFlag:    make(map[string]int),
+		errorsByCode:    make(map[string]int),
+		clusters:        make(map[string]*clusterStats),
+		protocols:       make(map[string]*clusterStats),
+		endpoints:       make(map[string][]float64),
+		errorsPerMinute: make(map[time.Time]int),
+		levels:          make(map[string]int),
+	}
+
+	for _, entry := range logs {
+		t, hasTime := eventTime(entry)
+		if hasTime {
+			s.timestamps = append(s.timestamps, t)
+			if s.first.IsZero() || t.Before(s.first) {
+				s.first = t
+			}
+			if t.After(s.last) {
+				s.last = t
+			}
+		}
+
+		if level := entry.Level(); level != "" {
+			s.levels[strings.ToLower(level)]++
+		}
+
+		code, isRequest := entry.ResponseCode()
+		if !isRequest {
+			continue
+		}
+		if !healthChecks && trafficClass(entry) == trafficHealthCheck {
+			s.healthChecks++
+			continue
+		}
+		s.requests++
+
+		cluster := getFieldSafely(entry.Fields, "upstream_cluster")
+		stats := s.clusters[cluster]
+		if stats == nil {
+			stats = &clusterStats{}
+			s.clusters[cluster] = stats
+		}
+		stats.requests++
+
+		protocol := requestProtocol(entry)
+		protocolStats := s.protocols[protocol]
+		if protocol != "" && protocolStats == nil {
+			protocolStats = &clusterStats{}
+			s.protocols[protocol] = protocolStats
+		}
+		if protocolStats != nil {
+			protocolStats.requests++
+		}
+
+		if duration, ok := entry.FloatField("duration"); ok {
+			s.durations = append(s.durations, duration)
+			if endpoint := endpointName(entry); endpoint != "" {
+				s.endpoints[endpoint] = append(s.endpoints[endpoint], duration)
+			}
+		}
+
+		flags := entry.Flags()
+		if !requestFailed(code, flags) {
+			continue
+		}
+		s.errors++
+		stats.errors++
+		if protocolStats != nil {
+			protocolStats.errors++
+		}
+		s.errorsByCode[fmt.Sprintf("%d", int(code))]++
+		for _, flag := range flags {
+			s.errorsByFlag[string(flag)]++
+		}
+		if hasTime {
+			s.errorsPerMinute[t.Truncate(time.Minute)]++
+		}
+	}
+
+	sort.Slice(s.timestamps, func(i, j int) bool { return s.timestamps[i].Before(s.timestamps[j]) })
+	sort.Float64s(s.durations)
+	s.pools = diagnosePools(logs)
+	s.denials = analyzeDenials(logs)
+	s.reusedIDs = reusedRequestIDs(logs)
+	s.skews = clockSkews(logs)
+	return s
+}
+
+// requestFailed reports whether an access log entry records a failed request:
+// a server error, no response at all, or any Envoy response flag.
+func requestFailed(code int, flags []Flag) bool {
+	if code == 0 || code >= 500 {
+		return true
+	}
+	return len(flags) > 0
+}
+
+// endpointName groups requests by method and path, ignoring the query string.
+func endpointName(entry ParsedLog) string {
+	path := barePath(entry)
+	if path == "" {
+		return ""
+	}
+	method := entry.Method()
+	return strings.TrimSpace(method + " " + path)
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// countsByValue sorts a count map by count, most first, then by key.
+func countsByValue(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// markdownCell escapes a value for a markdown table. Envoy cluster names are
+// full of pipes.
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+// writeMarkdown prints the report in markdown, ready to paste into an
+// incident channel. Sections with nothing to report are left out.
+func (s summary) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Log summary: %s\n\n", s.source)
+
+	if s.first.IsZero() {
+		fmt.Fprintln(w, "- **Time range:** unknown (no timestamps)")
+	} else {
+		fmt.Fprintf(w, "- **Time range:** %s to %s (%s)\n",
+			activeTimeDisplay.timestamp(s.first), activeTimeDisplay.timestamp(s.last), s.last.Sub(s.first).Round(time.Second))
+	}
+	fmt.Fprintf(w, "- **Entries:** %d (%d requests)\n", s.entries, s.requests)
+	if s.healthChecks > 0 {
+		fmt.Fprintf(w, "- **Health checks:** %d, not counted as requests (see -health-checks)\n", s.healthChecks)
+	}
+	if s.requests > 0 {
+		fmt.Fprintf(w, "- **Errors:** %d (%s of requests)\n", s.errors, percent(s.errors, s.requests))
+	}
+	if len(s.durations) > 0 {
+		fmt.Fprintf(w, "- **Latency:** p50 %gms, p95 %gms, p99 %gms\n",
+			percentile(s.durations, 50), percentile(s.durations, 95), percentile(s.durations, 99))
+	}
+	if len(s.levels) > 0 {
+		var levels []string
+		for _, level := range countsByValue(s.levels) {
+			levels = append(levels, fmt.Sprintf("%s %d", level, s.levels[level]))
+		}
+		fmt.Fprintf(w, "- **Log levels:** %s\n", strings.Join(levels, ", "))
+	}
+
+	if len(s.notes) > 0 {
+		fmt.Fprintln(w, "\n## Notes")
+		fmt.Fprintln(w)
+		for _, noted := range s.notes {
+			when := ""
+			if t, ok := eventTime(noted.log); ok {
+				when = fmt.Sprintf(" (%s)", activeTimeDisplay.timestamp(t))
+			}
+			fmt.Fprintf(w, "- **Line %d%s:** %s\n", noted.log.LineNumber, when, noted.note)
+		}
+	}
+
+	if len(s.slos) > 0 {
+		fmt.Fprintln(w, "\n## SLOs\n\n| Scope | Target | Requests | Success | Burn rate | Status |\n| --- | ---: | ---: | ---: | ---: | --- |")
+		for _, slo := range s.slos {
+			if slo.requests == 0 {
+				fmt.Fprintf(w, "| %s | %g%% | 0 | - | - | %s |\n", markdownCell(slo.scope()), slo.target, slo.status())
+				continue
+			}
+			status := string(slo.status())
+			if slo.status() == sloViolating {
+				status = "**" + status + "**"
+			}
+			fmt.Fprintf(w, "| %s | %g%% | %d | %.2f%% | %.1fx | %s |\n",
+				markdownCell(slo.scope()), slo.target, slo.requests, slo.success(), slo.burnRate(), status)
+		}
+	}
+
+	if len(s.markers) > 0 {
+		window := int(markerWindow.Minutes())
+		fmt.Fprintf(w, "\n## Markers\n\n| Time | Marker | Errors %dm before | Errors %dm after |\n| --- | --- | ---: | ---: |\n",
+			window, window)
+		for _, m := range s.markers {
+			before, after := s.errorsAround(m.Time)
+			fmt.Fprintf(w, "| %s | %s | %d | %d |\n", activeTimeDisplay.timestamp(m.Time), markdownCell(m.Label), before, after)
+		}
+	}
+
+	if len(s.protocols) > 0 {
+		requests := make(map[string]int, len(s.protocols))
+		for protocol, stats := range s.protocols {
+			requests[protocol] = stats.requests
+		}
+		fmt.Fprintln(w, "\n## Requests by protocol\n\n| Protocol | Requests | Share | Errors | Error rate |\n| --- | ---: | ---: | ---: | ---: |")
+		for _, protocol := range countsByValue(requests) {
+			stats := s.protocols[protocol]
+			fmt.Fprintf(w, "| %s | %d | %s | %d | %s |\n", protocol, stats.requests, percent(stats.requests, s.requests),
+				stats.errors, percent(stats.errors, stats.requests))
+		}
+	}
+
+	if len(s.errorsByFlag) > 0 {
+		fmt.Fprintln(w, "\n## Errors by response flag\n\n| Flag | Count | Meaning |\n| --- | ---: | --- |")
+		for _, flag := range countsByValue(s.errorsByFlag) {
+			fmt.Fprintf(w, "| %s | %d | %s |\n", markdownCell(flag), s.errorsByFlag[flag], explainResponseFlags(flag))
+		}
+	}
+
+	if len(s.errorsByCode) > 0 {
+		fmt.Fprintln(w, "\n## Errors by response code\n\n| Code | Count | Meaning |\n| --- | ---: | --- |")
+		for _, code := range countsByValue(s.errorsByCode) {
+			fmt.Fprintf(w, "| %s | %d | %s |\n", code, s.errorsByCode[code], getResponseCodeExplanation(code))
+		}
+	}
+
+	if s.errors > 0 {
+		failing := make(map[string]int)
+		for cluster, stats := range s.clusters {
+			if stats.errors > 0 {
+				failing[cluster] = stats.errors
+			}
+		}
+		fmt.Fprintln(w, "\n## Errors by upstream cluster\n\n| Cluster | Errors | Requests | Error rate |\n| --- | ---: | ---: | ---: |")
+		for _, cluster := range countsByValue(failing) {
+			stats := s.clusters[cluster]
+			fmt.Fprintf(w, "| %s | %d | %d | %s |\n", markdownCell(cluster), stats.errors, stats.requests, percent(stats.errors, stats.requests))
+		}
+	}
+
+	if s.denials.count > 0 {
+		s.denials.writeMarkdown(w)
+	}
+
+	if len(s.reusedIDs) > 0 {
+		writeReusedMarkdown(w, s.reusedIDs)
+	}
+
+	if len(s.skews) > 0 {
+		writeSkewMarkdown(w, s.skews)
+	}
+
+	if endpoints := s.slowestEndpoints(); len(endpoints) > 0 {
+		fmt.Fprintln(w, "\n## Slowest endpoints\n\n| Endpoint | Requests | p50 | p95 | Max |\n| --- | ---: | ---: | ---: | ---: |")
+		for _, endpoint := range endpoints {
+			durations := s.endpoints[endpoint]
+			fmt.Fprintf(w, "| %s | %d | %gms | %gms | %gms |\n", markdownCell(endpoint), len(durations),
+				percentile(durations, 50), percentile(durations, 95), durations[len(durations)-1])
+		}
+	}
+
+	if s.comparison != nil {
+		s.comparison.writeMarkdown(w)
+	}
+
+	if len(s.links) > 0 {
+		fmt.Fprintln(w, "\n## Links")
+		fmt.Fprintln(w)
+		for _, link := range s.links {
+			fmt.Fprintf(w, "- [%s](%s)\n", link.Name, link.URL)
+		}
+	}
+
+	if anomalies := s.anomalies(); len(anomalies) > 0 {
+		fmt.Fprintln(w, "\n## Anomalies")
+		fmt.Fprintln(w)
+		for _, anomaly := range anomalies {
+			fmt.Fprintf(w, "- %s\n", anomaly)
+		}
+	}
+}
+
+// slowestEndpoints returns up to topEndpoints endpoints by p95 latency. It
+// sorts each endpoint's durations in place.
+func (s summary) slowestEndpoints() []string {
+	names := make([]string, 0, len(s.endpoints))
+	for name, durations := range s.endpoints {
+		sort.Float64s(durations)
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := percentile(s.endpoints[names[i]], 95), percentile(s.endpoints[names[j]], 95)
+		if pi != pj {
+			return pi > pj
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > topEndpoints {
+		names = names[:topEndpoints]
+	}
+	return names
+}
+
+// finding is one notable thing in the logs, with a search query that shows
+// the entries behind it in the TUI. filter is empty when no query can.
+type finding struct {
+	message string
+	filter  string
+}
+
+func (f finding) String() string {
+	if f.filter == "" {
+		return f.message
+	}
+	return fmt.Sprintf("%s Filter: `%s`", f.message, f.filter)
+}
+
+// anomalies lists what stands out: a high error rate, failing clusters,
+// latency outliers, bursts of errors, the start of denials, reused request
+// IDs, clock skew and gaps in the logs.
+func (s summary) anomalies() []finding {
+	var found []finding
+
+	if s.requests >= minRequestsForRate && float64(s.errors)/float64(s.requests) >= highErrorRate {
+		var filter string
+		if flags := countsByValue(s.errorsByFlag); len(flags) > 0 {
+			filter = flags[0]
+		}
+		found = append(found, finding{
+			message: fmt.Sprintf("Error rate is %s (%d of %d requests).", percent(s.errors, s.requests), s.errors, s.requests),
+			filter:  filter,
+		})
+	}
+
+	var clusters []string
+	for cluster, stats := range s.clusters {
+		if stats.requests >= minClusterRequests && float64(stats.errors)/float64(stats.requests) >= failingClusterRate {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		stats := s.clusters[cluster]
+		found = append(found, finding{
+			message: fmt.Sprintf("`%s` failed %d of %d requests.", cluster, stats.errors, stats.requests),
+			filter:  cluster,
+		})
+	}
+
+	if median := percentile(s.durations, 50); median > 0 {
+		threshold := math.Max(median*slowOutlierFactor, minOutlierDuration)
+		outliers := len(s.durations) - sort.SearchFloat64s(s.durations, math.Nextafter(threshold, math.Inf(1)))
+		if outliers > 0 {
+			found = append(found, finding{message: fmt.Sprintf("%d requests took over %gms, more than %dx the median of %gms.",
+				outliers, threshold, slowOutlierFactor, median)})
+		}
+	}
+
+	if burst, count, ok := s.errorBurst(); ok {
+		found = append(found, finding{
+			message: fmt.Sprintf("Errors peaked at %s with %d in one minute.", activeTimeDisplay.minute(burst), count),
+			filter:  burst.UTC().Format("2006-01-02T15:04"),
+		})
+	}
+
+	for _, pool := range s.pools {
+		found = append(found, finding{message: pool.String(), filter: pool.cluster})
+	}
+
+	if start, ok := s.denials.onset(); ok {
+		found = append(found, finding{
+			message: fmt.Sprintf("Authz and JWT denials started at %s, %d since.", activeTimeDisplay.timestamp(start), s.denials.count),
+			filter:  "denied",
+		})
+	}
+
+	if len(s.reusedIDs) > 0 {
+		found = append(found, reusedFinding(s.reusedIDs))
+	}
+
+	for _, skew := range s.skews {
+		found = append(found, finding{message: "Clock skew: " + skew.String() + "."})
+	}
+
+	for i := 1; i < len(s.timestamps); i++ {
+		gap := s.timestamps[i].Sub(s.timestamps[i-1])
+		if gap >= minLoggingGap {
+			found = append(found, finding{message: fmt.Sprintf("No logs for %s between %s and %s.", gap.Round(time.Second),
+				activeTimeDisplay.timestamp(s.timestamps[i-1]), activeTimeDisplay.timestamp(s.timestamps[i]))})
+		}
+	}
+
+	return found
+}
+
+// errorsAround counts the errors in the markerWindow before the minute of t
+// and in the window starting with it, so a deploy can be told apart from the
+// errors it caused.
+func (s summary) errorsAround(t time.Time) (before, after int) {
+	start := t.Truncate(time.Minute)
+	for minute, errors := range s.errorsPerMinute {
+		switch {
+		case !minute.Before(start.Add(-markerWindow)) && minute.Before(start):
+			before += errors
+		case !minute.Before(start) && minute.Before(start.Add(markerWindow)):
+			after += errors
+		}
+	}
+	return before, after
+}
+
+// errorBurst returns the minute with the most errors when it stands well
+// above the average over the whole time range.
+func (s summary) errorBurst() (time.Time, int, bool) {
+	var peak time.Time
+	var count int
+	for minute, errors := range s.errorsPerMinute {
+		if errors > count || (errors == count && minute.Before(peak)) {
+			peak, count = minute, errors
+		}
+	}
+
+	minutes := math.Max(s.last.Sub(s.first).Minutes(), 1)
+	average := float64(s.errors) / minutes
+	if count < minErrorBurst || float64(count) < average*errorBurstFactor {
+		return time.Time{}, 0, false
+	}
+	return peak, count, true
+}
+
+// logSource describes where logs were read from. The namespace, pod and
+// container are only set for Kubernetes sources.
+type logSource struct {
+	name                      string
+	namespace, pod, container string
+	origin                    Source                // Labels recorded on the parsed entries
+	parts                     []logSource           // Several files read together, each with its lines, merged by parseBatch
+	lines                     []string              // Lines of a part
+	checkpoints               checkpoints           // Read positions to save once the logs are handled, with -resume
+	metadata                  k8ssource.PodMetadata // Labels and annotations of the pod, see podFields
+}
+
+func (s logSource) String() string {
+	return s.name
+}
+
+// batchInput reads every log up front for the subcommands that are not
+// interactive: the files named on the command line ("-" for stdin), piped
+// stdin, or the pod named by the PLUGIN_* variables. With -resume, only what
+// was not read by a previous run is returned, and the source carries the
+// checkpoints to save with commitCheckpoints. The pod's labels are looked up
+// when the config names pod fields.
+func batchInput(opts cliOptions, cfg Config) ([]string, logSource, error) {
+	var saved checkpoints
+	if opts.resume {
+		var err error
+		if saved, err = loadCheckpoints(opts.statePath); err != nil {
+			return nil, logSource{}, err
+		}
+	}
+
+	if len(opts.args) > 0 {
+		var lines []string
+		source := logSource{name: strings.Join(opts.args, ", ")}
+		if len(opts.args) == 1 {
+			source.origin = fileSource(opts.args[0])
+		}
+		for _, path := range opts.args {
+			fileLines, err := readLogFile(path)
+			if err != nil {
+				return nil, logSource{}, err
+			}
+			if opts.resume && path != "-" {
+				key := fileCheckpointKey(path)
+				var cp checkpoint
+				fileLines, cp = fileLinesAfter(fileLines, saved[key])
+				source.addCheckpoint(key, cp)
+			}
+			lines = append(lines, fileLines...)
+			source.parts = append(source.parts, logSource{name: path, origin: fileSource(path), lines: fileLines})
+		}
+		if len(source.parts) == 1 {
+			source.parts = nil
+		}
+		return lines, source, nil
+	}
+
+	lines, stdinDetected, err := detectInput()
+	if err != nil {
+		return nil, logSource{}, err
+	}
+	if stdinDetected {
+		if opts.resume {
+			return nil, logSource{}, fmt.Errorf("piped logs cannot be resumed, pass log files or set the PLUGIN_* variables")
+		}
+		return lines, logSource{name: "stdin"}, nil
+	}
+
+	source := logSource{
+		namespace: os.Getenv("PLUGIN_NAMESPACE"),
+		pod:       os.Getenv("PLUGIN_POD"),
+		container: os.Getenv("PLUGIN_CONTAINER"),
+	}
+	if source.pod == "" || source.namespace == "" || source.container == "" {
+		return nil, logSource{}, fmt.Errorf("no input source: pass log files, pipe logs on stdin, or set PLUGIN_POD, PLUGIN_NAMESPACE and PLUGIN_CONTAINER")
+	}
+	source.name = fmt.Sprintf("%s/%s", source.namespace, source.pod)
+	source.origin = Source{
+		Cluster:   opts.kube.clusterName(),
+		Namespace: source.namespace,
+		Pod:       source.pod,
+		Container: source.container,
+	}
+
+	clientset, err := CreateKubeClient(opts.kube)
+	if err != nil {
+		return nil, logSource{}, fmt.Errorf("error creating Kubernetes client: %v", err)
+	}
+
+	sourceOpts := opts.sourceOptions()
+	var key string
+	if opts.resume {
+		config, err := opts.kube.restConfig()
+		if err != nil {
+			return nil, logSource{}, err
+		}
+		key = kubeCheckpointKey(config.Host, source.namespace, source.pod, source.container)
+		// Timestamps mark where this run stopped reading
+		sourceOpts = append(sourceOpts, k8ssource.WithTimestamps(true))
+		if cp := saved[key]; !cp.Time.IsZero() {
+			sourceOpts = append(sourceOpts, k8ssource.WithSinceTime(cp.Time))
+		}
+	}
+
+	lines, err = FetchLogsFromK8s(clientset, source.namespace, source.pod, source.container, sourceOpts...)
+	if err != nil {
+		return nil, logSource{}, err
+	}
+	// Without access to the pod its entries are read without its labels
+	if source.metadata, err = k8ssource.GetPodMetadata(context.TODO(), clientset, source.namespace, source.pod); err != nil {
+		log.Println("Error looking up pod labels:", explainKubeError(err))
+	}
+	if opts.resume {
+		var cp checkpoint
+		lines, cp = linesAfter(lines, saved[key])
+		source.addCheckpoint(key, cp)
+	}
+	return lines, source, nil
+}
+
+// addCheckpoint records how far the source was read.
+func (s *logSource) addCheckpoint(key string, cp checkpoint) {
+	if s.checkpoints == nil {
+		s.checkpoints = make(checkpoints)
+	}
+	s.checkpoints[key] = cp
+}
+
+// commitCheckpoints saves how far source was read, once its logs have been
+// handled, so the next run with -resume starts after them. A run that fails
+// before this reads the same logs again.
+func commitCheckpoints(opts cliOptions, source logSource) error {
+	if !opts.resume {
+		return nil
+	}
+	return saveCheckpoints(opts.statePath, source.checkpoints)
+}
+
+// parseBatch parses what batchInput read, merging several files by time.
+// Nothing new since the last run is not an error with -resume.
+func parseBatch(lines []string, source logSource, opts cliOptions, cfg Config) ([]ParsedLog, error) {
+	if len(lines) == 0 && opts.resume {
+		return nil, nil
+	}
+	parseOpts := parseOptionsFor(opts, cfg)
+	if len(source.parts) > 0 {
+		return parseMerged(source.parts, parseOpts, opts.merge)
+	}
+	parseOpts = parseOpts.withPodFields(podFields(source.metadata, cfg.PodFields))
+	return parseRawLogsWith(lines, parseOpts.from(source.name).withOrigin(source.origin))
+}
+
+// parseMerged parses each part on its own, so entries keep their source in
+// IDs and labels, and merges them. A part with nothing parsable is left out;
+// only all of them failing is an error.
+func parseMerged(parts []logSource, parseOpts parseOptions, mergeOpts mergeOptions) ([]ParsedLog, error) {
+	var sources [][]ParsedLog
+	lastErr := fmt.Errorf("no valid logs found")
+	for _, part := range parts {
+		if len(part.lines) == 0 {
+			continue
+		}
+		logs, err := parseRawLogsWith(part.lines, parseOpts.from(part.name).withOrigin(part.origin))
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", part.name, err)
+			continue
+		}
+		sources = append(sources, logs)
+	}
+	if len(sources) == 0 {
+		return nil, lastErr
+	}
+	return mergeLogs(sources, mergeOpts), nil
+}
+
+// readLogFile reads a log file, or stdin for "-".
+func readLogFile(path string) ([]string, error) {
+	if path == "-" {
+		return readLines(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", path, err)
+	}
+	defer file.Close()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return lines, nil
+}
+
+// runSummarize implements `log_viewer summarize [flags] [file...]`.
+func runSummarize(opts cliOptions, cfg Config) {
+	lines, source, err := batchInput(opts, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error reading logs to summarize:", err)
+		os.Exit(exitFailure)
+	}
+
+	logs, err := parseBatch(lines, source, opts, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing logs: %v\n", err)
+		log.Println("Error parsing logs:", err)
+		os.Exit(exitFailure)
+	}
+	if len(logs) == 0 && len(lines) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: none of the %d lines could be parsed\n", len(lines))
+		log.Println("No parsable log lines to summarize")
+		os.Exit(exitFailure)
+	}
+
+	notes, err := loadNotes(opts.notesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error loading notes:", err)
+		os.Exit(exitFailure)
+	}
+
+	report := summarizeWith(logs, source.String(), opts.healthChecks)
+	report.notes = notedEntries(logs, notes)
+	report.markers = resolveMarkers(opts.markers, logs)
+	report.slos = evaluateSLOs(opts.slos, logs, opts.healthChecks)
+	if opts.compareBy != "" {
+		c := compareBy(logs, opts.compareBy)
+		report.comparison = &c
+	}
+	report.links = renderLinks(cfg.Links, linkContext{}.withSource(source).withRange(report.first, report.last))
+	if !opts.quiet {
+		report.writeMarkdown(os.Stdout)
+	}
+
+	if opts.notifyURL != "" {
+		if err := notify(context.Background(), opts.notifyURL, opts.notifyFormat, report.notification()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending notification: %v\n", err)
+			log.Println("Error sending notification:", err)
+			os.Exit(exitFailure)
+		}
+	}
+
+	if err := commitCheckpoints(opts, source); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error saving checkpoints:", err)
+		os.Exit(exitFailure)
+	}
+
+	if violations := report.exceeded(opts.limits); len(violations) > 0 {
+		if !opts.quiet {
+			for _, violation := range violations {
+				fmt.Fprintf(os.Stderr, "Threshold exceeded: %s\n", violation)
+			}
+		}
+		os.Exit(exitThreshold)
+	}
+}
@@ -0,0 +1,91 @@
+// log_viewer/summarize_test.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	var rawLogs []string
+	// 20 healthy requests a second apart, then 5 failures to reviews in one minute
+	for i := 0; i < 20; i++ {
+		rawLogs = append(rawLogs, fmt.Sprintf(
+			`{"start_time":"2024-11-26T02:30:%02d.000Z","method":"GET","path":"/productpage?u=%d","response_code":200,"response_flags":"-","duration":%d,"upstream_cluster":"outbound|9080||productpage.default.svc.cluster.local"}`,
+			i, i, 10+i))
+	}
+	for i := 0; i < 5; i++ {
+		rawLogs = append(rawLogs, fmt.Sprintf(
+			`{"start_time":"2024-11-26T02:31:%02d.000Z","method":"GET","path":"/reviews/1","response_code":503,"response_flags":"UF,URX","duration":5000,"upstream_cluster":"outbound|9080||reviews.default.svc.cluster.local"}`,
+			i))
+	}
+	rawLogs = append(rawLogs, `{"timestamp":"2024-11-26T02:45:00Z","level":"error","message":"upstream reset"}`)
+
+	logs, err := parseRawLogs(rawLogs)
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	s := summarize(logs, "test")
+	if s.entries != 26 || s.requests != 25 || s.errors != 5 {
+		t.Errorf("summarize() counted %d entries, %d requests, %d errors; expected 26, 25, 5", s.entries, s.requests, s.errors)
+	}
+	if s.errorsByFlag["UF"] != 5 || s.errorsByFlag["URX"] != 5 || s.errorsByCode["503"] != 5 {
+		t.Errorf("summarize() errors by flag = %v, by code = %v", s.errorsByFlag, s.errorsByCode)
+	}
+
+	s.links = []deepLink{{Name: "Loki", URL: "https://grafana/explore"}}
+	var report strings.Builder
+	s.writeMarkdown(&report)
+	got := report.String()
+
+	expected := []string{
+		"- [Loki](https://grafana/explore)",
+		"# Log summary: test",
+		"- **Time range:** 2024-11-26T02:30:00Z to 2024-11-26T02:45:00Z (15m0s)",
+		"- **Errors:** 5 (20.0% of requests)",
+		"| UF | 5 | upstream connection failure |",
+		"| 503 | 5 | Service Unavailable |",
+		`| outbound\|9080\|\|reviews.default.svc.cluster.local | 5 | 5 | 100.0% |`,
+		"| GET /reviews/1 | 5 | 5000ms | 5000ms | 5000ms |",
+		"| GET /productpage | 20 |",
+		"- Error rate is 20.0% (5 of 25 requests).",
+		"- `outbound|9080||reviews.default.svc.cluster.local` failed 5 of 5 requests.",
+		"- 5 requests took over 1000ms",
+		"- Errors peaked at 02:31 with 5 in one minute.",
+		"- No logs for 13m56s between",
+	}
+	for _, want := range expected {
+		if !strings.Contains(got, want) {
+			t.Errorf("report is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "GET /reviews/1") > strings.Index(got, "GET /productpage") {
+		t.Errorf("expected the slowest endpoint first:\n%s", got)
+	}
+}
+
+func TestSummarizeHealthy(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"method":"GET","path":"/","response_code":200,"response_flags":"-","duration":12}`,
+		`{"method":"GET","path":"/","response_code":404,"response_flags":"-","duration":3}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	var report strings.Builder
+	summarize(logs, "stdin").writeMarkdown(&report)
+	got := report.String()
+
+	for _, unexpected := range []string{"## Errors", "## Anomalies"} {
+		if strings.Contains(got, unexpected) {
+			t.Errorf("expected no %q section for healthy traffic:\n%s", unexpected, got)
+		}
+	}
+	if !strings.Contains(got, "unknown (no timestamps)") {
+		t.Errorf("expected an unknown time range:\n%s", got)
+	}
+}
@@ -0,0 +1,124 @@
+// log_viewer/text_parser.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTextLog parses Istio's tab-separated text format, as written by istiod,
+// pilot-agent and ztunnel:
+//
+//	<timestamp>\t<level>\t[<scope>\t]<message>[\t<key=value ...>]
+//
+// Trailing key=value pairs (ztunnel access logs) become individual fields.
+func ParseTextLog(line string, lineNumber int) (ParsedLog, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		return ParsedLog{}, fmt.Errorf("error parsing log line %d: not a tab-separated text log", lineNumber)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return ParsedLog{}, fmt.Errorf("error parsing log line %d: invalid timestamp %q", lineNumber, parts[0])
+	}
+
+	fields := map[string]interface{}{
+		"timestamp": parts[0],
+		"level":     parts[1],
+	}
+
+	rest := parts[2:]
+	if len(rest) == 1 {
+		fields["message"] = rest[0]
+	} else {
+		fields["scope"] = rest[0]
+		fields["message"] = rest[1]
+		for key, value := range parseLogfmt(strings.Join(rest[2:], " ")) {
+			fields[key] = value
+		}
+	}
+
+	// Envoy debug lines tag connection-scoped messages with [C123]
+	if message, ok := fields["message"].(string); ok {
+		if id, ok := extractConnectionID(message); ok {
+			fields["connection_id"] = id
+		}
+	}
+
+	return ParsedLog{
+		RawLog:     line,
+		Fields:     fields,
+		LineNumber: lineNumber,
+	}, nil
+}
+
+// parseLogfmt parses space-separated key=value pairs. Values may be double
+// quoted with backslash escapes; numeric values are returned as json.Number to
+// match the JSON parser.
+func parseLogfmt(input string) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	i := 0
+	for i < len(input) {
+		// Skip separators
+		for i < len(input) && (input[i] == ' ' || input[i] == '\t') {
+			i++
+		}
+		if i >= len(input) {
+			break
+		}
+
+		keyStart := i
+		for i < len(input) && input[i] != '=' && input[i] != ' ' && input[i] != '\t' {
+			i++
+		}
+		key := input[keyStart:i]
+		if i >= len(input) || input[i] != '=' {
+			// Bare word without a value
+			if key != "" {
+				fields[key] = true
+			}
+			continue
+		}
+		i++ // Skip '='
+
+		var value string
+		quoted := i < len(input) && input[i] == '"'
+		if quoted {
+			var builder strings.Builder
+			i++
+			for i < len(input) && input[i] != '"' {
+				if input[i] == '\\' && i+1 < len(input) {
+					i++
+				}
+				builder.WriteByte(input[i])
+				i++
+			}
+			i++ // Skip closing quote
+			value = builder.String()
+		} else {
+			valueStart := i
+			for i < len(input) && input[i] != ' ' && input[i] != '\t' {
+				i++
+			}
+			value = input[valueStart:i]
+		}
+
+		if key == "" {
+			continue
+		}
+		if !quoted {
+			// ParseFloat also takes Inf, NaN and hex, which are not JSON numbers
+			if _, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+				fields[key] = json.Number(value)
+				continue
+			}
+		}
+		fields[key] = value
+	}
+
+	return fields
+}
@@ -0,0 +1,107 @@
+// log_viewer/timefmt.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Time formats for -time-format. Anything else is a Go layout such as
+// "Jan 2 15:04:05.000".
+const (
+	timeFormatShort   = "short"   // Clock times in lists and findings, RFC 3339 elsewhere
+	timeFormatRFC3339 = "rfc3339" // RFC 3339 everywhere
+)
+
+// timeDisplay is how timestamps are shown: in the list, the detail pane,
+// summarize's findings and exports alike.
+type timeDisplay struct {
+	location *time.Location
+	format   string
+}
+
+// activeTimeDisplay is set by loadSettings, UTC and short by default.
+var activeTimeDisplay = timeDisplay{location: time.UTC, format: timeFormatShort}
+
+// selectTimeDisplay checks a timezone (UTC, Local or an IANA name such as
+// Europe/Berlin) and a format. Empty values are the defaults.
+func selectTimeDisplay(zone, format string) (timeDisplay, error) {
+	display := timeDisplay{location: time.UTC, format: timeFormatShort}
+	if zone != "" {
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return display, fmt.Errorf("unknown timezone %q: %v", zone, err)
+		}
+		display.location = location
+	}
+	if format != "" {
+		// A layout without a single element would print itself
+		if format != timeFormatShort && format != timeFormatRFC3339 && time.Unix(0, 0).Format(format) == format {
+			return display, fmt.Errorf("invalid time format %q, expected %s, %s or a Go layout such as 15:04:05.000",
+				format, timeFormatShort, timeFormatRFC3339)
+		}
+		display.format = format
+	}
+	return display, nil
+}
+
+// clock formats a time where space is short, such as a list row.
+func (d timeDisplay) clock(t time.Time) string {
+	switch d.format {
+	case timeFormatShort:
+		return t.In(d.location).Format("15:04:05")
+	case timeFormatRFC3339:
+		return t.In(d.location).Format(time.RFC3339)
+	}
+	return t.In(d.location).Format(d.format)
+}
+
+// minute formats the start of a minute, such as an error burst.
+func (d timeDisplay) minute(t time.Time) string {
+	if d.format == timeFormatShort {
+		return t.In(d.location).Format("15:04")
+	}
+	return d.clock(t)
+}
+
+// timestamp formats a time standing on its own, such as a report's range.
+func (d timeDisplay) timestamp(t time.Time) string {
+	switch d.format {
+	case timeFormatShort, timeFormatRFC3339:
+		return t.In(d.location).Format(time.RFC3339)
+	}
+	return t.In(d.location).Format(d.format)
+}
+
+// timeFields are the fields holding a timestamp, see eventTime.
+var timeFields = map[string]bool{
+	"start_time": true, "timestamp": true, "time": true, "ts": true, "kubelet_timestamp": true,
+}
+
+// displayTimeField returns a time field's value as activeTimeDisplay shows
+// it, and any other value as it is. Unless a layout is set, the value stays
+// RFC 3339 with as many fractional digits as it had, for the detail pane and
+// exports.
+func displayTimeField(field string, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || !timeFields[field] {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return value
+	}
+
+	d := activeTimeDisplay
+	if d.format != timeFormatShort && d.format != timeFormatRFC3339 {
+		return t.In(d.location).Format(d.format)
+	}
+	layout := "2006-01-02T15:04:05"
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		digits := len(s[dot+1:]) - len(strings.TrimLeft(s[dot+1:], "0123456789"))
+		layout += "." + strings.Repeat("0", digits)
+	}
+	return t.In(d.location).Format(layout + "Z07:00")
+}
@@ -0,0 +1,93 @@
+// log_viewer/timefmt_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectTimeDisplay(t *testing.T) {
+	tests := []struct {
+		zone, format string
+		wantErr      bool
+	}{
+		{zone: "", format: ""},
+		{zone: "Local", format: timeFormatRFC3339},
+		{zone: "Europe/Berlin", format: "Jan 2 15:04:05.000"},
+		{zone: "Mars/Olympus", wantErr: true},
+		{format: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := selectTimeDisplay(tt.zone, tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("selectTimeDisplay(%q, %q) error = %v, wantErr %v", tt.zone, tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTimeDisplayFormats(t *testing.T) {
+	at := time.Date(2024, 11, 26, 2, 30, 58, 502_000_000, time.UTC)
+	berlin, err := selectTimeDisplay("Europe/Berlin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := berlin.clock(at); got != "03:30:58" {
+		t.Errorf("clock() = %q, expected 03:30:58", got)
+	}
+	if got := berlin.minute(at); got != "03:30" {
+		t.Errorf("minute() = %q, expected 03:30", got)
+	}
+	if got := berlin.timestamp(at); got != "2024-11-26T03:30:58+01:00" {
+		t.Errorf("timestamp() = %q, expected 2024-11-26T03:30:58+01:00", got)
+	}
+
+	full, _ := selectTimeDisplay("", timeFormatRFC3339)
+	if got := full.clock(at); got != "2024-11-26T02:30:58Z" {
+		t.Errorf("rfc3339 clock() = %q, expected 2024-11-26T02:30:58Z", got)
+	}
+	custom, _ := selectTimeDisplay("UTC", "15:04:05.000")
+	if got := custom.timestamp(at); got != "02:30:58.502" {
+		t.Errorf("custom timestamp() = %q, expected 02:30:58.502", got)
+	}
+}
+
+func TestDisplayTimeField(t *testing.T) {
+	defer func(previous timeDisplay) { activeTimeDisplay = previous }(activeTimeDisplay)
+
+	if got := displayTimeField("start_time", "2024-11-26T02:30:59.100Z"); got != "2024-11-26T02:30:59.100Z" {
+		t.Errorf("default display changed the value to %v", got)
+	}
+	if got := displayTimeField("path", "2024-11-26T02:30:59.100Z"); got != "2024-11-26T02:30:59.100Z" {
+		t.Errorf("display changed a field that is not a time: %v", got)
+	}
+
+	activeTimeDisplay, _ = selectTimeDisplay("Asia/Tokyo", "")
+	if got := displayTimeField("start_time", "2024-11-26T02:30:59.100Z"); got != "2024-11-26T11:30:59.100+09:00" {
+		t.Errorf("displayTimeField() = %v, expected 2024-11-26T11:30:59.100+09:00", got)
+	}
+	if got := displayTimeField("start_time", "not a time"); got != "not a time" {
+		t.Errorf("displayTimeField() = %v, expected the value unchanged", got)
+	}
+}
+
+func TestTimeDisplayAppliesEverywhere(t *testing.T) {
+	defer func(previous timeDisplay) { activeTimeDisplay = previous }(activeTimeDisplay)
+	activeTimeDisplay, _ = selectTimeDisplay("Asia/Tokyo", "")
+
+	logs := mustParse(t, snapshotLines...)
+	if preview := formatLogPreview(logs[0], 80); !strings.HasPrefix(preview, "11:30:58") {
+		t.Errorf("formatLogPreview() = %q, expected the time in Tokyo", preview)
+	}
+	if pane := renderDetailPane(logs[0], detailExtras{}, 120, 40, false); !strings.Contains(pane, "2024-11-26T11:30:58.502+09:00") {
+		t.Errorf("renderDetailPane() does not show start_time in Tokyo")
+	}
+	_, rows := exportTable(logs, nil, nil)
+	if got := rows[0]["start_time"]; got != "2024-11-26T11:30:58.502+09:00" {
+		t.Errorf("exportTable() start_time = %v, expected it in Tokyo", got)
+	}
+	if logs[0].Fields["start_time"] != "2024-11-26T02:30:58.502Z" {
+		t.Errorf("exportTable() changed the entry itself")
+	}
+}
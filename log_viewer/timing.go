@@ -0,0 +1,84 @@
+// log_viewer/timing.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// breakdownBarWidth is the width of the bar splitting a request's duration.
+const breakdownBarWidth = 30
+
+// durationBreakdown splits the time a proxy spent on a request into the time
+// the upstream took to respond (upstream_service_time) and the rest: the
+// proxy's own processing, connection setup, retries and streaming the
+// response body.
+type durationBreakdown struct {
+	total    float64 // Milliseconds
+	upstream float64
+}
+
+func (b durationBreakdown) overhead() float64 {
+	return b.total - b.upstream
+}
+
+// breakdownOf returns the breakdown of an access log entry that logs both
+// durations.
+func breakdownOf(log ParsedLog) (durationBreakdown, bool) {
+	total, ok := log.FloatField("duration")
+	upstream, hasUpstream := log.Fields["upstream_service_time"]
+	if !ok || !hasUpstream || total <= 0 {
+		return durationBreakdown{}, false
+	}
+	b := durationBreakdown{total: total, upstream: parseDurationField(upstream)}
+	if b.upstream <= 0 || b.upstream > b.total {
+		// "-" when the upstream never answered, or clocks disagreeing
+		return durationBreakdown{}, false
+	}
+	return b, true
+}
+
+// renderBreakdownBar draws the upstream share of the duration as solid
+// cells and the proxy overhead as shaded ones.
+func renderBreakdownBar(b durationBreakdown) string {
+	upstreamCells := int(b.upstream / b.total * breakdownBarWidth)
+	bar := strings.Repeat("█", upstreamCells) + strings.Repeat("░", breakdownBarWidth-upstreamCells)
+	return fmt.Sprintf("[%s] upstream %gms, proxy %gms (%.0f%%)", bar, b.upstream, b.overhead(), b.overhead()/b.total*100)
+}
+
+// overheadStats sums the breakdowns of a set of entries. It is kept up to
+// date as entries are filtered or streamed in, rather than recomputed on
+// every render.
+type overheadStats struct {
+	requests int     // Entries with a breakdown
+	total    float64 // Sum of their durations
+	overhead float64 // Sum of their proxy overhead
+	max      float64 // Largest proxy overhead
+}
+
+func (s overheadStats) add(log ParsedLog) overheadStats {
+	b, ok := breakdownOf(log)
+	if !ok {
+		return s
+	}
+	s.requests++
+	s.total += b.total
+	s.overhead += b.overhead()
+	s.max = max(s.max, b.overhead())
+	return s
+}
+
+// overheadOf sums the breakdowns of logs.
+func overheadOf(logs []ParsedLog) overheadStats {
+	var s overheadStats
+	for _, log := range logs {
+		s = s.add(log)
+	}
+	return s
+}
+
+func (s overheadStats) String() string {
+	return fmt.Sprintf("proxy overhead avg %.1fms, max %gms, %.1f%% of the time of %d requests",
+		s.overhead/float64(s.requests), s.max, s.overhead/s.total*100, s.requests)
+}
@@ -0,0 +1,62 @@
+// log_viewer/timing_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBreakdownOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		ok       bool
+		overhead float64
+	}{
+		{"string upstream time", map[string]interface{}{"duration": 50.0, "upstream_service_time": "40"}, true, 10},
+		{"numeric upstream time", map[string]interface{}{"duration": 50.0, "upstream_service_time": 45.0}, true, 5},
+		{"no answer", map[string]interface{}{"duration": 50.0, "upstream_service_time": "-"}, false, 0},
+		{"upstream longer than total", map[string]interface{}{"duration": 5.0, "upstream_service_time": "9"}, false, 0},
+		{"no upstream time", map[string]interface{}{"duration": 50.0}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ok := breakdownOf(ParsedLog{Fields: tt.fields})
+			if ok != tt.ok || (ok && b.overhead() != tt.overhead) {
+				t.Errorf("breakdownOf() = %+v, %t; want overhead %g, %t", b, ok, tt.overhead, tt.ok)
+			}
+		})
+	}
+
+	bar := renderBreakdownBar(durationBreakdown{total: 50, upstream: 40})
+	if !strings.HasPrefix(bar, "["+strings.Repeat("█", 24)+strings.Repeat("░", 6)+"]") || !strings.Contains(bar, "proxy 10ms (20%)") {
+		t.Errorf("unexpected bar %q", bar)
+	}
+}
+
+func TestOverheadFollowsFilter(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"response_code":200,"path":"/reviews","duration":50,"upstream_service_time":"40"}`,
+		`{"response_code":200,"path":"/ratings","duration":20,"upstream_service_time":"18"}`,
+		`{"response_code":200,"path":"/reviews","duration":100,"upstream_service_time":"70"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	model := Model{logs: logs, activeFilter: "reviews"}.refilter()
+	if model.overhead.requests != 2 || model.overhead.overhead != 40 || model.overhead.max != 30 {
+		t.Errorf("expected the overhead of the two reviews requests, got %+v", model.overhead)
+	}
+
+	// A followed line matching the filter is added
+	model = model.appendLine(`{"response_code":200,"path":"/reviews","duration":10,"upstream_service_time":"9"}`)
+	if model.overhead.requests != 3 || model.overhead.overhead != 41 {
+		t.Errorf("expected the streamed request to be added, got %+v", model.overhead)
+	}
+
+	view := renderDetailView(logs[0], detailExtras{overhead: model.overhead}, 120, 40)
+	if !strings.Contains(view, "upstream 40ms, proxy 10ms (20%)") || !strings.Contains(view, "avg 13.7ms, max 30ms") {
+		t.Errorf("expected the timing group with the filtered overhead, got:\n%s", view)
+	}
+}
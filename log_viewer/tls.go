@@ -0,0 +1,75 @@
+// log_viewer/tls.go
+
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// tlsFields are the TLS details an access log format may include, using the
+// names of Envoy's command operators (%DOWNSTREAM_TLS_VERSION% and so on).
+// Istio's default format logs none of them but requested_server_name.
+var tlsFields = []string{
+	"downstream_tls_version", "downstream_tls_cipher", "requested_server_name", "alpn",
+	"downstream_peer_uri_san", "downstream_local_uri_san",
+	"upstream_tls_version", "upstream_tls_cipher",
+}
+
+// legacyTLSVersions are deprecated by RFC 8996.
+var legacyTLSVersions = []string{"TLSv1", "TLSv1.0", "TLSv1.1"}
+
+// withTLSGroup adds a TLS group, holding the TLS fields present, to access
+// log groups. requested_server_name moves there from the downstream group.
+// Entries without TLS details keep the groups as they are.
+func withTLSGroup(groups []fieldGroup, fields map[string]interface{}) []fieldGroup {
+	tls := fieldGroup{name: "TLS Info"}
+	present := false
+	for _, field := range tlsFields {
+		if _, ok := fields[field]; ok {
+			tls.fields = append(tls.fields, field)
+			present = present || field != "requested_server_name"
+		}
+	}
+	if !present {
+		return groups
+	}
+
+	withTLS := make([]fieldGroup, 0, len(groups)+1)
+	for _, group := range groups {
+		if slices.Contains(group.fields, "requested_server_name") {
+			group.fields = slices.DeleteFunc(slices.Clone(group.fields), func(field string) bool {
+				return field == "requested_server_name"
+			})
+		}
+		withTLS = append(withTLS, group)
+	}
+	return append(withTLS, tls)
+}
+
+// tlsWarning names what is wrong with the downstream connection's TLS, or
+// returns "" when it is fine or not logged. Envoy logs "-" for the version of
+// a plaintext connection.
+func tlsWarning(log ParsedLog) string {
+	version, ok := log.Fields["downstream_tls_version"].(string)
+	switch {
+	case !ok:
+		return ""
+	case version == "-" || version == "":
+		return "plaintext"
+	case slices.Contains(legacyTLSVersions, version):
+		return version
+	}
+	return ""
+}
+
+// explainTLSVersion describes a downstream or upstream TLS version.
+func explainTLSVersion(version string) string {
+	switch {
+	case strings.HasPrefix(version, "TLSv1.3"), strings.HasPrefix(version, "TLSv1.2"):
+		return ""
+	case slices.Contains(legacyTLSVersions, version):
+		return "deprecated, upgrade the client to TLS 1.2 or later"
+	}
+	return ""
+}
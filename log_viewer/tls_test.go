@@ -0,0 +1,68 @@
+// log_viewer/tls_test.go
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithTLSGroup(t *testing.T) {
+	fields := map[string]interface{}{
+		"response_code":          200.0,
+		"downstream_tls_version": "TLSv1.3",
+		"downstream_tls_cipher":  "TLS_AES_128_GCM_SHA256",
+		"requested_server_name":  "reviews.default.svc.cluster.local",
+	}
+	groups := detailGroupsFor(profileSidecar, fields)
+
+	last := groups[len(groups)-1]
+	expected := fieldGroup{"TLS Info", []string{"downstream_tls_version", "downstream_tls_cipher", "requested_server_name"}}
+	if !reflect.DeepEqual(last, expected) {
+		t.Errorf("expected a TLS group last, got %+v", last)
+	}
+	for _, group := range groups[:len(groups)-1] {
+		for _, field := range group.fields {
+			if field == "requested_server_name" {
+				t.Errorf("expected requested_server_name to move out of %s", group.name)
+			}
+		}
+	}
+	if !reflect.DeepEqual(sidecarGroups[3].fields, []string{
+		"downstream_local_address", "downstream_remote_address", "requested_server_name", "route_name", "connection_id",
+	}) {
+		t.Errorf("expected the shared sidecar groups to be left unchanged, got %v", sidecarGroups[3].fields)
+	}
+
+	// Istio's default format only has the SNI, which stays where it was
+	plain := detailGroupsFor(profileSidecar, map[string]interface{}{"requested_server_name": "reviews"})
+	if !reflect.DeepEqual(plain, sidecarGroups) {
+		t.Errorf("expected the default groups without TLS details, got %+v", plain)
+	}
+}
+
+func TestTLSWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  map[string]interface{}
+		warning string
+	}{
+		{"not logged", map[string]interface{}{"response_code": 200.0}, ""},
+		{"plaintext", map[string]interface{}{"downstream_tls_version": "-"}, "plaintext"},
+		{"TLS 1.1", map[string]interface{}{"downstream_tls_version": "TLSv1.1"}, "TLSv1.1"},
+		{"TLS 1.2", map[string]interface{}{"downstream_tls_version": "TLSv1.2"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := ParsedLog{Fields: tt.fields}
+			if got := tlsWarning(log); got != tt.warning {
+				t.Errorf("tlsWarning() = %q, want %q", got, tt.warning)
+			}
+			preview := formatLogPreview(log, 80)
+			if hasBadge := strings.Contains(preview, "["+tt.warning+"]"); tt.warning != "" && !hasBadge {
+				t.Errorf("expected the list preview to flag %s, got %q", tt.warning, preview)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// log_viewer/traffic.go
+
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// Traffic leaving the cluster stands out from the mesh's own: requests to
+// another cluster through an east-west gateway, and requests to external
+// services, declared in a ServiceEntry or let through as passthrough.
+const (
+	trafficEastWest = "east-west"
+	trafficExternal = "external"
+)
+
+// trafficHealthCheck is the traffic_class of probes and health checks, see
+// trafficClass. Unlike the kinds above it is about who sent the request.
+const trafficHealthCheck = "health-check"
+
+// trafficTermPrefix starts the filter term keeping one kind of traffic,
+// e.g. traffic=external, or with a ! dropping it, e.g. traffic=!health-check.
+const trafficTermPrefix = "traffic="
+
+// healthCheckPaths are the paths probes and load balancers commonly check.
+var healthCheckPaths = []string{"/healthz", "/health", "/livez", "/readyz", "/ready", "/ping"}
+
+// proxyHealthPort serves the sidecar's own readiness, /healthz/ready.
+const proxyHealthPort = "15021"
+
+// eastWestPort is where east-west gateways accept mTLS traffic from the
+// mesh's other clusters.
+const eastWestPort = "15443"
+
+// trafficKind tells east-west and external requests apart by their upstream
+// cluster, authority and addresses. Other entries are "".
+func trafficKind(log ParsedLog) string {
+	cluster := log.UpstreamCluster()
+	authority := getFieldSafely(log.Fields, "authority")
+	if host, _, err := net.SplitHostPort(authority); err == nil {
+		authority = host
+	}
+	switch {
+	// An east-west gateway routes by SNI to clusters named like
+	// outbound_.9080_._.reviews.default.svc.cluster.local
+	case strings.HasPrefix(cluster, "outbound_."):
+		return trafficEastWest
+	case addressPort(getFieldSafely(log.Fields, "upstream_host")) == eastWestPort,
+		addressPort(getFieldSafely(log.Fields, "downstream_local_address")) == eastWestPort:
+		return trafficEastWest
+	// Older multi-cluster meshes name remote services host.namespace.global
+	case strings.HasSuffix(authority, ".global"), strings.HasSuffix(cluster, ".global"):
+		return trafficEastWest
+	case cluster == "PassthroughCluster", strings.Contains(cluster, "istio-egressgateway"):
+		return trafficExternal
+	}
+
+	// outbound|443||api.stripe.com, from a ServiceEntry
+	parts := strings.Split(cluster, "|")
+	if len(parts) == 4 && parts[0] == "outbound" && parts[3] != "" && !clusterLocal(parts[3]) {
+		return trafficExternal
+	}
+	return ""
+}
+
+// clusterLocal reports whether host names a Kubernetes service.
+func clusterLocal(host string) bool {
+	return strings.Contains(host, ".svc.") || strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".cluster.local")
+}
+
+// addressPort returns the port of an address like 10.1.2.3:15443, or "".
+func addressPort(value string) string {
+	if _, port, err := net.SplitHostPort(value); err == nil {
+		return port
+	}
+	return ""
+}
+
+// trafficClass returns the derived traffic_class of a request: health-check
+// for kubelet probes, Envoy's active health checks, the proxy's readiness
+// endpoint and requests to the usual health paths, otherwise "".
+func trafficClass(log ParsedLog) string {
+	if _, ok := log.ResponseCode(); !ok {
+		return ""
+	}
+	userAgent := getFieldSafely(log.Fields, "user_agent")
+	if strings.HasPrefix(userAgent, "kube-probe/") || strings.HasPrefix(userAgent, "Envoy/HC") {
+		return trafficHealthCheck
+	}
+	if addressPort(getFieldSafely(log.Fields, "downstream_local_address")) == proxyHealthPort {
+		return trafficHealthCheck
+	}
+
+	path := log.Path()
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	// Probes the sidecar injector rewrote look like /app-health/reviews/readyz
+	if strings.HasPrefix(path, "/app-health/") {
+		return trafficHealthCheck
+	}
+	for _, health := range healthCheckPaths {
+		if path == health || strings.HasPrefix(path, health+"/") {
+			return trafficHealthCheck
+		}
+	}
+	return ""
+}
+
+// trafficBadge is the short form shown in the list.
+func trafficBadge(kind string) string {
+	switch kind {
+	case trafficEastWest:
+		return "e-w"
+	case trafficExternal:
+		return "ext"
+	}
+	return ""
+}
+
+// trafficTerm keeps, or with drop set leaves out, one kind of traffic.
+type trafficTerm struct {
+	kind string // Empty for no term
+	drop bool
+}
+
+// parseTrafficTerm parses a traffic=<kind> or traffic=!<kind> term. A kind
+// that is not known is not one, and is matched as text.
+func parseTrafficTerm(word string) (trafficTerm, bool) {
+	kind, ok := strings.CutPrefix(strings.ToLower(word), trafficTermPrefix)
+	if !ok {
+		return trafficTerm{}, false
+	}
+	drop := strings.HasPrefix(kind, "!")
+	kind = strings.TrimPrefix(kind, "!")
+	if kind != trafficEastWest && kind != trafficExternal && kind != trafficHealthCheck {
+		return trafficTerm{}, false
+	}
+	return trafficTerm{kind: kind, drop: drop}, true
+}
+
+// matches reports whether the term keeps the entry.
+func (t trafficTerm) matches(log ParsedLog) bool {
+	if t.kind == "" {
+		return true
+	}
+	is := trafficKind(log) == t.kind
+	if t.kind == trafficHealthCheck {
+		is = trafficClass(log) == trafficHealthCheck
+	}
+	return is != t.drop
+}
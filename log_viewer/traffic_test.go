@@ -0,0 +1,98 @@
+// log_viewer/traffic_test.go
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var trafficTestLines = []string{
+	`{"response_code":200,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local","upstream_host":"10.0.0.5:9080"}`,
+	`{"response_code":200,"upstream_cluster":"outbound|9080||reviews.bookinfo.svc.cluster.local","upstream_host":"172.18.0.10:15443"}`,
+	`{"response_code":200,"upstream_cluster":"outbound_.9080_._.reviews.bookinfo.svc.cluster.local"}`,
+	`{"response_code":200,"upstream_cluster":"outbound|443||api.stripe.com","authority":"api.stripe.com"}`,
+	`{"response_code":502,"upstream_cluster":"PassthroughCluster","upstream_host":"52.1.2.3:443"}`,
+	`{"response_code":200,"upstream_cluster":"outbound|80||istio-egressgateway.istio-system.svc.cluster.local"}`,
+	`{"response_code":200,"upstream_cluster":"inbound|9080||","authority":"ratings.bookinfo.global:9080"}`,
+}
+
+func TestTrafficKind(t *testing.T) {
+	expected := []string{"", trafficEastWest, trafficEastWest, trafficExternal, trafficExternal, trafficExternal, trafficEastWest}
+	for i, log := range mustParse(t, trafficTestLines...) {
+		if got := trafficKind(log); got != expected[i] {
+			t.Errorf("trafficKind(%s) = %q, expected %q", log.RawLog, got, expected[i])
+		}
+	}
+}
+
+func TestTrafficFilter(t *testing.T) {
+	logs := mustParse(t, trafficTestLines...)
+	if got := filterLogs(logs, "traffic=external"); len(got) != 3 {
+		t.Errorf("expected 3 external entries, got %d", len(got))
+	}
+	if got := filterLogs(logs, "traffic=external 502"); len(got) != 1 || !strings.Contains(got[0].RawLog, "PassthroughCluster") {
+		t.Errorf("expected the rest of the query to narrow the preset, got %v", got)
+	}
+	if got := filterLogs(logs, "502 traffic=external"); len(got) != 1 {
+		t.Errorf("expected the preset to apply after the text, got %d", len(got))
+	}
+	if got := filterLogs(logs, "traffic=east-west"); len(got) != 3 {
+		t.Errorf("expected 3 east-west entries, got %d", len(got))
+	}
+	// An unknown kind is searched for as text
+	if got := filterLogs(logs, "traffic=mars"); len(got) != 0 {
+		t.Errorf("expected no matches for an unknown kind, got %d", len(got))
+	}
+
+	model := Model{logs: logs, filteredLogs: logs, width: 120, height: 30}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlK})
+	model = typePalette(updated.(Model), "external and egress")
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if model.activeFilter != "traffic=external" || len(model.filteredLogs) != 3 {
+		t.Errorf("expected the palette preset to keep external traffic, got filter %q and %d entries", model.activeFilter, len(model.filteredLogs))
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"response_code":200,"path":"/reviews/1","user_agent":"Mozilla/5.0"}`,
+		`{"response_code":503,"path":"/app-health/reviews/readyz","user_agent":"kube-probe/1.29"}`,
+		`{"response_code":503,"path":"/healthz/ready","downstream_local_address":"10.0.0.5:15021"}`,
+		`{"response_code":200,"path":"/health?full=1"}`,
+		`{"response_code":200,"path":"/","user_agent":"Envoy/HC"}`,
+		`{"response_code":200,"path":"/healthy-recipes"}`,
+		`{"level":"info","msg":"GET /healthz"}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+	expected := []string{"", trafficHealthCheck, trafficHealthCheck, trafficHealthCheck, trafficHealthCheck, "", ""}
+	for i, log := range logs {
+		if got := trafficClass(log); got != expected[i] {
+			t.Errorf("trafficClass(%s) = %q, expected %q", log.RawLog, got, expected[i])
+		}
+	}
+	if class := detailFields(logs[1], detectProfile(logs[1]))["traffic_class"]; class != trafficHealthCheck {
+		t.Errorf("expected the derived traffic_class in the details, got %v", class)
+	}
+
+	if got := filterLogs(logs, "traffic=!health-check"); len(got) != 3 {
+		t.Errorf("expected the health checks hidden, got %d entries", len(got))
+	}
+
+	// Failing probes do not count toward the error rate or SLOs by default
+	s := summarize(logs, "test")
+	if s.requests != 2 || s.errors != 0 || s.healthChecks != 4 {
+		t.Errorf("summarize() = %d requests, %d errors, %d health checks; expected 2, 0, 4", s.requests, s.errors, s.healthChecks)
+	}
+	if s := summarizeWith(logs, "test", true); s.requests != 6 || s.errors != 2 {
+		t.Errorf("summarizeWith() counting health checks = %d requests, %d errors; expected 6, 2", s.requests, s.errors)
+	}
+	if results := evaluateSLOs([]sloTarget{{target: 99}}, logs, false); results[0].requests != 2 || results[0].failed != 0 {
+		t.Errorf("expected the SLO to leave health checks out, got %+v", results[0])
+	}
+}
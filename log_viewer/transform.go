@@ -0,0 +1,135 @@
+// log_viewer/transform.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transformFields returns an entry as `log_viewer transform` writes it: the
+// fields shown in the detail view, plus what the viewer works out from them
+// so downstream tools such as jq need not. Derived fields are only added
+// when the entry has what they are derived from:
+//
+//   - profile and severity, always
+//   - traffic_class, for probes and health checks (see trafficClass)
+//   - query, the parsed query parameters of a path that has them, which
+//     leaves path without its query string (see splitPathQuery)
+//   - user_agent.family and user_agent.version from user_agent
+//   - cluster.direction, cluster.port, cluster.subset and cluster.service
+//     from a sidecar's upstream_cluster (waypoints get waypoint.* instead)
+//   - response_flags_explained from response_flags
+//   - duration_ms, upstream_service_time_ms and proxy_overhead_ms in
+//     milliseconds, whether logged as numbers, numeric strings or Go
+//     durations such as ztunnel's "1ms"
+func transformFields(entry ParsedLog) map[string]interface{} {
+	profile := detectProfile(entry)
+	source := detailFields(entry, profile)
+	fields := make(map[string]interface{}, len(source)+8)
+	for key, value := range source {
+		fields[key] = value
+	}
+
+	fields["profile"] = string(profile)
+	fields["severity"] = severityOf(entry)
+
+	// Sidecar clusters look like outbound|9080|v2|reviews.default.svc.cluster.local
+	if cluster, ok := entry.Fields["upstream_cluster"].(string); ok && profile == profileSidecar {
+		if parts := strings.Split(cluster, "|"); len(parts) == 4 {
+			for i, name := range []string{"cluster.direction", "cluster.port", "cluster.subset", "cluster.service"} {
+				if parts[i] != "" {
+					fields[name] = parts[i]
+				}
+			}
+		}
+	}
+
+	if ua, ok := parseUserAgent(getFieldSafely(entry.Fields, "user_agent")); ok {
+		fields[compareClientFamily] = ua.family
+		fields[compareClientVersion] = ua.String()
+	}
+
+	if flags, ok := entry.Fields["response_flags"].(string); ok {
+		if explanation := explainResponseFlags(flags); explanation != "" {
+			fields["response_flags_explained"] = explanation
+		}
+	}
+
+	for _, name := range []string{"duration", "upstream_service_time"} {
+		if ms, ok := durationMillis(entry.Fields[name]); ok {
+			fields[name+"_ms"] = ms
+		}
+	}
+	if b, ok := breakdownOf(entry); ok {
+		fields["proxy_overhead_ms"] = b.overhead()
+	}
+	return fields
+}
+
+// durationMillis reads a logged duration in milliseconds. Numbers and
+// numeric strings are taken as milliseconds, as Envoy logs them; other
+// strings are parsed as Go durations. "-" and anything unparsable are not
+// durations.
+func durationMillis(value interface{}) (float64, bool) {
+	if ms, ok := floatValue(value); ok {
+		return ms, true
+	}
+	switch v := value.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		if ms, err := strconv.ParseFloat(v, 64); err == nil {
+			return ms, true
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return float64(d) / float64(time.Millisecond), true
+		}
+	}
+	return 0, false
+}
+
+// transform reads raw lines from r and writes one enriched JSON object per
+// entry to w as soon as it is parsed, so it can sit in the middle of a
+// pipeline on a followed source. Lines that cannot be parsed are reported to
+// errOut and skipped. Records are shaped by fields when it is not nil.
+func transform(r io.Reader, w, errOut io.Writer, opts parseOptions, fields projection) error {
+	encoder := json.NewEncoder(w)
+	scanner := newLineScanner(r)
+	var previous time.Time
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry, err := parseLine(line, lineNumber)
+		if err != nil {
+			fmt.Fprintf(errOut, "Skipping log line %d: %v\n", lineNumber, err)
+			continue
+		}
+		entry.ID = entryID(entry, opts.source, previous)
+		previous = entry.ID.Time
+		entry = redactLog(withSchemas(entry, opts.schemas), opts.redact)
+
+		if err := encoder.Encode(fields.apply(transformFields(entry))); err != nil {
+			return fmt.Errorf("error writing entry %s: %v", entry.ID, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// runTransform implements `log_viewer transform [flags] < logs`.
+func runTransform(opts cliOptions, cfg Config) {
+	if err := transform(os.Stdin, os.Stdout, os.Stderr, parseOptionsFor(opts, cfg).from("stdin"), opts.projection); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		log.Println("Error transforming logs:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+// log_viewer/transform_test.go
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTransformFields(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"upstream_cluster":"outbound|9080|v2|reviews.default.svc.cluster.local","response_code":503,"response_flags":"UF,URX","duration":40,"upstream_service_time":"30"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+
+	fields := transformFields(logs[0])
+	expected := map[string]interface{}{
+		"profile":                  "sidecar",
+		"severity":                 "error",
+		"cluster.direction":        "outbound",
+		"cluster.port":             "9080",
+		"cluster.subset":           "v2",
+		"cluster.service":          "reviews.default.svc.cluster.local",
+		"response_flags_explained": "upstream connection failure, upstream retry limit exceeded",
+		"duration_ms":              40.0,
+		"upstream_service_time_ms": 30.0,
+		"proxy_overhead_ms":        10.0,
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("transformFields()[%q] = %v, expected %v", key, fields[key], value)
+		}
+	}
+	if _, ok := logs[0].Fields["severity"]; ok {
+		t.Error("transformFields() modified the entry's own fields")
+	}
+}
+
+func TestDurationMillis(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected float64
+		ok       bool
+	}{
+		{12.0, 12, true},
+		{"30", 30, true},
+		{"1ms", 1, true},
+		{"1.5s", 1500, true},
+		{"-", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		ms, ok := durationMillis(tt.value)
+		if ms != tt.expected || ok != tt.ok {
+			t.Errorf("durationMillis(%v) = %v, %v, expected %v, %v", tt.value, ms, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	input := "{\"level\":\"warn\",\"msg\":\"slow\"}\nnot a log\n\n" +
+		"2024-11-25T19:47:07.374828Z\tinfo\tready\n"
+	var out, errOut strings.Builder
+	if err := transform(strings.NewReader(input), &out, &errOut, defaultParseOptions(), nil); err != nil {
+		t.Fatalf("transform() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("transform() wrote %d lines, expected 2:\n%s", len(lines), out.String())
+	}
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("transform() wrote invalid JSON %q: %v", lines[0], err)
+	}
+	if first["severity"] != "warn" || first["msg"] != "slow" {
+		t.Errorf("transform() first entry = %v", first)
+	}
+	if !strings.Contains(errOut.String(), "Skipping log line 2") {
+		t.Errorf("transform() reported %q, expected the unparsable line 2", errOut.String())
+	}
+}
@@ -1,73 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-)
-
-var (
-	// Colors
-	highlightColor  = lipgloss.Color("39")  // Blue
-	normalColor     = lipgloss.Color("252") // Light gray
-	headerColor     = lipgloss.Color("105") // Light purple
-	errorColor      = lipgloss.Color("196") // Red
-	warnColor       = lipgloss.Color("214") // Orange
-	infoColor       = lipgloss.Color("83")  // Green
-	jsonKeyColor    = lipgloss.Color("105") // Purple for JSON keys
-	jsonStringColor = lipgloss.Color("83")  // Green for strings
-	jsonNumberColor = lipgloss.Color("214") // Orange for numbers
-	jsonNullColor   = lipgloss.Color("245") // Gray for null values
-
-	// Header style
-	headerStyle = lipgloss.NewStyle().
-			Foreground(headerColor).
-			Bold(true).
-			PaddingLeft(1).
-			PaddingRight(1).
-			MarginBottom(1)
-
-	// Log styles
-	logStyle = lipgloss.NewStyle().
-			Foreground(normalColor)
-
-	selectedLogStyle = lipgloss.NewStyle().
-				Foreground(highlightColor).
-				Bold(true).
-				Background(lipgloss.Color("236"))
-
-	// Search overlay style
-	searchStyle = lipgloss.NewStyle().
-			Foreground(highlightColor).
-			Background(lipgloss.Color("236")).
-			Padding(0, 1).
-			MarginTop(1)
-
-	// Error style
-	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true).
-			Padding(1)
-
-	// JSON highlighting styles
-	jsonKeyStyle = lipgloss.NewStyle().
-			Foreground(jsonKeyColor).
-			Bold(true)
-
-	jsonStringStyle = lipgloss.NewStyle().
-			Foreground(jsonStringColor)
-
-	jsonNumberStyle = lipgloss.NewStyle().
-			Foreground(jsonNumberColor)
-
-	jsonNullStyle = lipgloss.NewStyle().
-			Foreground(jsonNullColor).
-			Italic(true)
+	"github.com/jamestexas/istio-parsin-redeux/pkg/k8ssource"
 )
 
 type Model struct {
@@ -77,12 +23,99 @@ type Model struct {
 	searchMode       bool
 	jumpMode         bool
 	searchQuery      string
+	activeFilter     string        // Last applied search, kept so streamed lines can be filtered
+	connectionFilter string        // Envoy connection ID (e.g. "C123") the view is narrowed to
+	overhead         overheadStats // Proxy overhead across filteredLogs
 	width            int
 	height           int
+
+	// Follow mode
+	stream     <-chan string // Lines from a followed source, nil when not following
+	following  bool          // True while the followed source is still open
+	pinned     bool          // Keep the selection on the newest entry
+	streamErr  func() error  // Reports why the followed source ended, may be nil
+	err        error         // Shown as a toast below the header until dismissed
+	notice     string        // Shown below the header until the next key press
+	multiline  *lineJoiner   // Joins followed lines into entries, nil to take each line on its own
+	pending    []string      // Lines of the followed entry still being joined, see receiveLine
+	pendingSeq int           // Counts lines joined, so an idle wait knows if more arrived
+	idleWait   bool          // A wait for the stream to go idle is in flight
+
+	// Kubernetes sources are loaded inside the TUI so failures can be retried
+	target     *kubeTarget            // Pod being viewed, nil for stdin and exec
+	loading    bool                   // A fetch of target is in flight
+	loadGen    int                    // Counts target switches, see stale
+	logsFrom   string                 // Source of the logs, as recorded in their IDs
+	origin     Source                 // Labels of the same, recorded on followed entries
+	podFields  map[string]interface{} // Fields of the same pod, added to followed entries
+	stopStream context.CancelFunc     // Stops the followed target's stream
+	picker     *podPicker             // Open pod picker, nil when closed
+	palette    *commandPalette        // Open command palette, nil when closed
+
+	flow      *requestFlowView // Open request flow diagram, nil when closed
+	diagnosis *diagnosisView   // Open diagnosis panel, nil when closed
+	histogram *histogramView   // Open histogram popup, nil when closed
+	hidden    *hiddenView      // Open review of hidden entries, nil when closed
+
+	events      []k8ssource.Event // About the target's workload, newest last
+	eventsPanel *eventsPanel      // Open events panel, nil when closed
+	entrySearch *entrySearch      // Open search within the selected entry, nil when closed
+	decode      *decodeView       // Open popup of decoded fields, nil when closed
+
+	marked     map[string]bool // Entries marked for bulk operations, keyed by EntryID.String()
+	rangeStart *EntryID        // Where a range of marks started, nil outside one
+	excluded   map[string]bool // Entries hidden from the view, keyed like marked
+
+	// Past searches, recalled with up and down in the search overlay
+	history      []string // Oldest first
+	historyPath  string   // Where history is saved, empty to not save it
+	historyIndex int      // How far back the recalled query is, 0 when not recalling
+	historyDraft string   // Query typed before recalling
+
+	// Notes on entries, keyed by EntryID.String()
+	notes     map[string]string
+	notesPath string      // Where notes are saved, empty to keep them for the session
+	note      *noteEditor // Open note prompt, nil when closed
+
+	// Layouts remembered per terminal size bucket, see terminalBucket
+	layouts     map[string]savedLayout
+	layoutsPath string // Where layouts are saved, empty to not save them
+	sizeBucket  string // The terminal's bucket, empty before its size is known
+
+	undoStack []viewState // Views before each filter or jump, newest last
+	redoStack []viewState // Views undone, newest last
+
+	keys      KeyMap         // Key bindings, defaults are used when nil
+	layout    layoutPreset   // Panes to show, split when empty; remembered per terminal size
+	links     []LinkTemplate // Deep links shown for the selected entry
+	jwtFields []string       // Fields whose bearer tokens' claims are shown, see tokenClaims
+	redact    []RedactRule   // Masking applied to followed lines as they arrive
+	schemas   []fieldSchema  // Field renaming applied to followed lines, see withSchemas
+
+	peers      map[string]string // Names of IP addresses, see peerName
+	reverseDNS bool              // Look addresses up in reverse DNS
+
+	markers []marker    // Deploys and events shown among the entries, see resolveMarkers
+	slos    []sloTarget // Success rate targets shown under the header, see renderSLOs
+
+	healthChecks bool // Count health checks toward the SLOs
+
+	sinks  *sinkForwarder // Where entries matching the filter are forwarded, nil for nowhere
+	alerts *alerter       // Rules checked as entries stream in, nil for none
+
+	expandedList bool // List rows spread key fields over several lines
+	expandJSON   bool // JSON documents held by string fields are shown expanded, see embeddedJSON
 }
 
+// filterLogs keeps the entries containing query, ignoring case.
+// source.<label>=<value>, traffic=<kind>, status=<code>, query.<name>=<value>
+// and pod.<name>=<value> terms anywhere in it match where entries were read
+// from, the kind of traffic, the response code, the path's query parameters
+// and the labels of the pod instead, see parseFilterTerms.
 func filterLogs(logs []ParsedLog, query string) []ParsedLog {
-	if query == "" {
+	terms := parseFilterTerms(query)
+	query = terms.text
+	if query == "" && !terms.any() {
 		return logs
 	}
 
@@ -90,14 +123,23 @@ func filterLogs(logs []ParsedLog, query string) []ParsedLog {
 	lowerQuery := strings.ToLower(query)
 
 	for _, log := range logs {
-		if strings.Contains(strings.ToLower(log.RawLog), lowerQuery) {
+		if !terms.matches(log) {
+			continue
+		}
+		if log.searchText != "" {
+			if strings.Contains(log.searchText, lowerQuery) {
+				filtered = append(filtered, log)
+			}
+			continue
+		}
+
+		if containsFold(log.RawLog, lowerQuery) {
 			filtered = append(filtered, log)
 			continue
 		}
 
 		for key, value := range log.Fields {
-			if strings.Contains(strings.ToLower(fmt.Sprint(key)), lowerQuery) ||
-				strings.Contains(strings.ToLower(fmt.Sprint(value)), lowerQuery) {
+			if containsFold(key, lowerQuery) || containsFold(fieldString(value), lowerQuery) {
 				filtered = append(filtered, log)
 				break
 			}
@@ -107,54 +149,250 @@ func filterLogs(logs []ParsedLog, query string) []ParsedLog {
 	return filtered
 }
 
+// indexed returns the log with its search text built from the current RawLog
+// and Fields. Fields are separated by NUL so a query cannot match across them.
+func (log ParsedLog) indexed() ParsedLog {
+	text := make([]byte, 0, 2*len(log.RawLog))
+	text = append(text, log.RawLog...)
+	for key, value := range log.Fields {
+		text = append(text, 0)
+		text = append(text, key...)
+		text = append(text, 0)
+		text = append(text, fieldString(value)...)
+	}
+
+	ascii := true
+	for i, c := range text {
+		if c >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+		text[i] = toLowerASCII(c)
+	}
+	if !ascii {
+		log.searchText = strings.ToLower(string(text))
+		return log
+	}
+	log.searchText = string(text)
+	return log
+}
+
+// fieldString formats a field value like fmt.Sprint, without the reflection
+// cost for the string and number values that make up most logs.
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "<nil>"
+	}
+	return fmt.Sprint(value)
+}
+
+// containsFold reports whether s contains lowerQuery, ignoring case. It avoids
+// lowering (and allocating a copy of) s for ASCII queries, which covers
+// practically every search.
+func containsFold(s, lowerQuery string) bool {
+	for i := 0; i < len(lowerQuery); i++ {
+		if lowerQuery[i] >= utf8.RuneSelf {
+			return strings.Contains(strings.ToLower(s), lowerQuery)
+		}
+	}
+	if lowerQuery == "" {
+		return true
+	}
+
+	first := lowerQuery[0]
+	for i := 0; i+len(lowerQuery) <= len(s); i++ {
+		if toLowerASCII(s[i]) != first {
+			continue
+		}
+		match := true
+		for j := 1; j < len(lowerQuery); j++ {
+			if toLowerASCII(s[i+j]) != lowerQuery[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// filter applies the active search and connection filters to logs, and
+// leaves out the hidden entries.
+func (m Model) filter(logs []ParsedLog) []ParsedLog {
+	return withoutExcluded(filterByConnection(filterLogs(logs, m.activeFilter), m.connectionFilter), m.excluded)
+}
+
+// action resolves a key press to the action bound to it.
+func (m Model) action(key string) keyAction {
+	if m.keys == nil {
+		return defaultKeyMap().actionFor(key)
+	}
+	return m.keys.actionFor(key)
+}
+
+// findAction returns the action bound to key while a search within the entry
+// is open, see findActionFor.
+func (m Model) findAction(key string) keyAction {
+	if m.keys == nil {
+		return defaultKeyMap().findActionFor(key)
+	}
+	return m.keys.findActionFor(key)
+}
+
+// failedMsg carries the error of work done in the background, such as saving
+// the search history, to show like any other.
+type failedMsg struct {
+	err error
+}
+
 func (m Model) Init() tea.Cmd {
-	return nil
+	if m.stream != nil {
+		return tea.Batch(waitForLine(m.stream), m.sinks.waitForFailure())
+	}
+	if m.target != nil && m.loading {
+		return tea.Batch(m.target.load(m.loadGen), m.sinks.waitForFailure())
+	}
+	return tea.Batch(m.resolveAddresses(), m.sinks.forward(m.filteredLogs), m.sinks.waitForFailure())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		m.notice = ""
+		if m.picker != nil {
+			return m.updatePodPicker(msg)
+		}
+		if m.palette != nil {
+			return m.updatePalette(msg)
+		}
+		if m.note != nil {
+			return m.updateNoteEditor(msg)
+		}
+		if m.entrySearch != nil && !m.searchMode && !m.jumpMode {
+			updated, cmd, handled := m.updateEntrySearch(msg)
+			if handled {
+				return updated, cmd
+			}
+			m = updated
+		}
+		if m.flow != nil {
+			switch m.action(msg.String()) {
+			case actionQuit:
+				return m, tea.Quit
+			case actionCancel, actionFlow:
+				m.flow = nil
+			}
+			return m, nil
+		}
+		if m.diagnosis != nil {
+			switch m.action(msg.String()) {
+			case actionQuit:
+				return m, tea.Quit
+			case actionCancel, actionDiagnose:
+				m.diagnosis = nil
+			}
+			return m, nil
+		}
+		if m.hidden != nil {
+			return m.updateHidden(msg)
+		}
+		if m.decode != nil {
+			return m.updateDecode(msg)
+		}
+		if m.histogram != nil {
+			switch m.action(msg.String()) {
+			case actionQuit:
+				return m, tea.Quit
+			case actionUp, actionDown:
+				m.histogram = m.histogram.cycle(m.action(msg.String()) == actionDown)
+			case actionCancel, actionHistogram:
+				m.histogram = nil
+			}
+			return m, nil
+		}
+		switch m.action(msg.String()) {
+		case actionQuit:
 			return m, tea.Quit
-		case "up", "k":
+		case actionUp, actionDown:
+			if m.searchMode || m.jumpMode {
+				if msg.Type == tea.KeyRunes {
+					m.searchQuery += msg.String()
+				} else if m.searchMode {
+					m = m.recallHistory(m.action(msg.String()) == actionUp)
+				}
+				break
+			}
+			if m.action(msg.String()) == actionDown {
+				if m.selectedLogIndex < len(m.filteredLogs)-1 {
+					m.selectedLogIndex++
+				}
+				break
+			}
 			if m.selectedLogIndex > 0 {
 				m.selectedLogIndex--
 			}
-		case "down", "j":
-			if m.selectedLogIndex < len(m.filteredLogs)-1 {
-				m.selectedLogIndex++
+			// Scrolling up stops auto-scroll until the user asks for it again
+			m.pinned = false
+		case actionCancel:
+			if !m.searchMode && !m.jumpMode && m.err != nil {
+				m.err = nil
+				break
+			}
+			if !m.searchMode && !m.jumpMode && m.rangeStart != nil {
+				m.rangeStart = nil
+				break
+			}
+			if !m.searchMode && !m.jumpMode && m.connectionFilter != "" {
+				m = m.remember()
+				m.connectionFilter = ""
+				m = m.refilter()
 			}
-		case "/":
-			m.jumpMode = true
-			m.searchMode = false
-			m.searchQuery = ""
-		case "s":
-			m.searchMode = true
-			m.jumpMode = false
-			m.searchQuery = ""
-		case "esc":
 			m.searchMode = false
 			m.jumpMode = false
 			m.searchQuery = ""
-		case "enter":
-			if m.jumpMode {
-				if lineNum, err := strconv.Atoi(m.searchQuery); err == nil {
-					// Convert from 1-based (user input) to 0-based (internal index)
-					targetIdx := lineNum - 1
-					if targetIdx >= 0 && targetIdx < len(m.logs) {
-						m.selectedLogIndex = targetIdx
-					}
+			m.historyIndex = 0
+		case actionBottom, actionJump, actionSearch, actionConnection, actionLayout, actionExpand, actionFlow, actionDiagnose, actionHistogram, actionNote,
+			actionExportText, actionExportHTML, actionUndo, actionRedo, actionRetry, actionPickPod, actionPalette, actionEvents, actionMark, actionMarkRange, actionCopy,
+			actionHide, actionHidden, actionFind, actionExpandJSON, actionDecode:
+			if m.searchMode || m.jumpMode {
+				if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+					m.searchQuery += msg.String()
 				}
+				break
+			}
+			return m.perform(m.action(msg.String()))
+		case actionConfirm:
+			if m.jumpMode {
+				m = m.jump(m.searchQuery)
 				m.jumpMode = false
 				m.searchQuery = ""
 			} else if m.searchMode {
-				m.filteredLogs = filterLogs(m.logs, m.searchQuery)
-				if len(m.filteredLogs) > 0 {
-					m.selectedLogIndex = 0
-				}
+				m = m.remember()
+				m.activeFilter = m.searchQuery
+				m = m.refilter()
+				m.history = addToHistory(m.history, m.searchQuery)
+				m.historyIndex = 0
 				m.searchMode = false
 				m.searchQuery = ""
+				return m, saveHistory(m.historyPath, m.history)
 			}
 		default:
 			if m.searchMode || m.jumpMode {
@@ -166,193 +404,603 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
+		m = m.resize(msg.Width, msg.Height)
+	case logLineMsg:
+		if msg.stream != nil && msg.stream != m.stream {
+			// Left over from a stream replaced by a retry or pod switch
+			return m, nil
+		}
+		matched := len(m.filteredLogs)
+		var idle tea.Cmd
+		m, idle = m.receiveLine(msg.line)
+		return m, tea.Batch(waitForLine(m.stream), idle, m.sinks.forward(m.filteredLogs[matched:]), bellFor(m.filteredLogs[matched:]),
+			m.alerts.check(m.logs, m.logsFrom, m.healthChecks))
+	case pendingIdleMsg:
+		matched := len(m.filteredLogs)
+		var idle tea.Cmd
+		m, idle = m.receiveIdle(msg)
+		return m, tea.Batch(idle, m.sinks.forward(m.filteredLogs[matched:]), bellFor(m.filteredLogs[matched:]),
+			m.alerts.check(m.logs, m.logsFrom, m.healthChecks))
+	case streamClosedMsg:
+		if msg.stream != nil && msg.stream != m.stream {
+			return m, nil
+		}
+		matched := len(m.filteredLogs)
+		m = m.flushPending()
+		m.idleWait = false
+		m.following = false
+		m.pinned = false
+		if m.streamErr != nil {
+			m.err = m.streamErr()
+		}
+		return m, tea.Batch(m.sinks.forward(m.filteredLogs[matched:]), bellFor(m.filteredLogs[matched:]))
+	case logsFetchedMsg:
+		m = m.receiveLogs(msg)
+		if m.err != nil {
+			return m, nil
+		}
+		return m, tea.Batch(m.resolveAddresses(), m.sinks.forward(m.filteredLogs))
+	case failedMsg:
+		m.err = msg.err
+	case alertPostedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("error posting alert %s: %v", msg.rule, msg.err)
+			break
+		}
+		m.notice = "Alert " + msg.rule + " posted"
+	case sinkFailedMsg:
+		m.err = msg.err
+		return m, m.sinks.waitForFailure()
+	case viewExportedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			break
+		}
+		what := msg.what
+		if what == "" {
+			what = "View"
+		}
+		m.notice = what + " exported to " + msg.path
+	case peersResolvedMsg:
+		m = m.receivePeers(msg)
+	case streamStartedMsg:
+		return m.receiveStream(msg)
+	case podsListedMsg:
+		m = m.receivePods(msg)
+	case namespacesListedMsg:
+		m = m.receiveNamespaces(msg)
+	case eventsListedMsg:
+		m = m.receiveEvents(msg)
 	}
 	return m, nil
 }
 
-func renderRawLog(log ParsedLog, width, height int) string {
-	rawStyle := lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(normalColor).
-		Padding(0, 1).
-		Width(width - 2).
-		Height(height).
-		BorderTop(false).
-		BorderBottom(true)
+// perform runs an action outside of search and jump mode, from its key or
+// the command palette.
+func (m Model) perform(action keyAction) (Model, tea.Cmd) {
+	switch action {
+	case actionQuit:
+		return m, tea.Quit
+	case actionBottom:
+		if len(m.filteredLogs) > 0 {
+			m.selectedLogIndex = len(m.filteredLogs) - 1
+		}
+		m.pinned = m.following
+	case actionJump:
+		m.jumpMode = true
+		m.searchMode = false
+		m.searchQuery = ""
+	case actionSearch:
+		m.searchMode = true
+		m.jumpMode = false
+		m.searchQuery = ""
+		m.historyIndex = 0
+	case actionConnection:
+		if len(m.filteredLogs) == 0 {
+			break
+		}
+		if id, ok := connectionID(m.filteredLogs[m.selectedLogIndex]); ok {
+			m = m.remember()
+			m.connectionFilter = id
+			m = m.refilter()
+		}
+	case actionLayout:
+		m.layout = m.layout.next()
+		return m.rememberLayout()
+	case actionExpand:
+		m.expandedList = !m.expandedList
+		return m.rememberLayout()
+	case actionFlow:
+		m = m.openFlow()
+	case actionDiagnose:
+		m = m.openDiagnosis()
+	case actionHistogram:
+		m = m.openHistogram()
+	case actionNote:
+		m = m.openNoteEditor()
+	case actionExportText:
+		return m, exportView(m.View(), screenshotText, time.Now())
+	case actionExportHTML:
+		return m, exportView(m.View(), screenshotHTML, time.Now())
+	case actionUndo:
+		m = m.undo()
+	case actionRedo:
+		m = m.redo()
+	case actionRetry:
+		return m.retry()
+	case actionPickPod:
+		return m.openPodPicker()
+	case actionPalette:
+		return m.openPalette(), nil
+	case actionEvents:
+		return m.toggleEvents()
+	case actionMark:
+		m = m.toggleMark()
+	case actionMarkRange:
+		m = m.markRange()
+	case actionCopy:
+		return m.copyMarked()
+	case actionHide:
+		m = m.hideMarked()
+	case actionFind:
+		m = m.openEntrySearch()
+	case actionExpandJSON:
+		m.expandJSON = !m.expandJSON
+	case actionDecode:
+		m = m.openDecode()
+	case actionHidden:
+		m = m.openHidden()
+	}
+	return m, nil
+}
 
-	var builder strings.Builder
-	builder.WriteString(headerStyle.Render("Raw Log") + "\n")
+func renderRawLog(log ParsedLog, width, height int, expandJSON bool) string {
+	content := headerStyle.Render("Raw Log") + "\n" + jsonStringStyle.Render(rawLogText(log, expandJSON))
+	return fitPane(content, width, height, false)
+}
 
-	// Parse the JSON first to ensure it's valid
+// rawLogText pretty-prints the raw log when it is JSON, expanding the JSON
+// documents its strings hold when expandJSON is set.
+func rawLogText(log ParsedLog, expandJSON bool) string {
 	var parsedJSON interface{}
-	if err := json.Unmarshal([]byte(log.RawLog), &parsedJSON); err == nil {
-		// Re-marshal with indentation
-		prettyJSON, err := json.MarshalIndent(parsedJSON, "", "  ")
-		if err == nil {
-			builder.WriteString(jsonStringStyle.Render(string(prettyJSON)))
-		} else {
-			builder.WriteString(jsonStringStyle.Render(log.RawLog))
-		}
-	} else {
-		builder.WriteString(jsonStringStyle.Render(log.RawLog))
+	if err := decodeJSON([]byte(log.RawLog), &parsedJSON); err != nil {
+		return log.RawLog
 	}
-
-	return rawStyle.Render(builder.String())
+	if expandJSON {
+		parsedJSON = expandEmbeddedJSON(parsedJSON)
+	}
+	prettyJSON, err := json.MarshalIndent(parsedJSON, "", "  ")
+	if err != nil {
+		return log.RawLog
+	}
+	return string(prettyJSON)
 }
 
 func (m Model) View() string {
+	if m.width < minWidth || m.height < minHeight {
+		return renderTooSmall(m.width, m.height)
+	}
+
+	if m.picker != nil {
+		return clampView(m.renderPodPicker(), m.width, m.height)
+	}
+	if m.palette != nil {
+		return clampView(m.renderPalette(), m.width, m.height)
+	}
+	if m.flow != nil {
+		return clampView(m.renderFlowView(), m.width, m.height)
+	}
+	if m.diagnosis != nil {
+		return clampView(m.renderDiagnosisView(), m.width, m.height)
+	}
+	if m.histogram != nil {
+		return clampView(m.renderHistogramView(), m.width, m.height)
+	}
+	if m.hidden != nil {
+		return clampView(m.renderHidden(), m.width, m.height)
+	}
+	if m.decode != nil {
+		return clampView(m.renderDecode(), m.width, m.height)
+	}
+
 	if len(m.filteredLogs) == 0 {
-		return errorStyle.Render("No valid logs found. Press 'q' to quit.")
+		return clampView(m.renderEmpty(), m.width, m.height)
+	}
+
+	followStatus := ""
+	if trend := m.filterSparkline(); trend != "" {
+		followStatus += " | Matches " + trend
+	}
+	if m.connectionFilter != "" {
+		followStatus += fmt.Sprintf(" | Connection %s (esc to clear)", m.connectionFilter)
+	}
+	if m.layout != "" && m.layout != layoutSplit {
+		followStatus += fmt.Sprintf(" | %s VIEW", strings.ToUpper(string(m.layout)))
+	}
+	if m.expandedList {
+		followStatus += " | EXPANDED"
+	}
+	if len(m.marked) > 0 {
+		followStatus += fmt.Sprintf(" | %d MARKED (ctrl+k for actions)", len(m.marked))
+	}
+	if m.rangeStart != nil {
+		followStatus += " | RANGE (V to mark)"
+	}
+	if len(m.excluded) > 0 {
+		followStatus += fmt.Sprintf(" | %d HIDDEN (H to review)", len(m.excluded))
+	}
+	if m.loading {
+		followStatus += " | LOADING"
+	}
+	if m.following {
+		if m.pinned {
+			followStatus += " | FOLLOWING"
+		} else {
+			followStatus += " | PAUSED (press 'G' to resume)"
+		}
 	}
 
 	header := headerStyle.Render(fmt.Sprintf(
-		"Log %d of %d | Press 's' to search, '/' to jump, 'q' to quit",
+		"Log %d of %d%s | Press 's' to search, '/' to jump, 'q' to quit",
 		m.selectedLogIndex+1,
 		len(m.filteredLogs),
+		followStatus,
 	))
-
-	// Calculate heights - top section should be smaller since it's just a list
-	mainHeight := m.height - 4 // Reserve space for header
-	if mainHeight < 0 {
-		mainHeight = 0
+	if slos := m.renderSLOs(); slos != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, slos, header)
+	}
+	header = lipgloss.NewStyle().MaxWidth(m.width).Render(header)
+	if m.err != nil {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, m.renderToast())
+	} else if m.notice != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, searchStyle.Render(m.notice))
 	}
 
-	// Allocate heights: 20% list (it's compact), 10% raw log (single line), 70% details
-	listHeight := (mainHeight * 20) / 100
-	rawLogHeight := 3 // Fixed height for single line + borders
-	detailHeight := mainHeight - listHeight - rawLogHeight
-
-	// Ensure minimum heights
-	if listHeight < 5 {
-		listHeight = 5
+	var overlay string
+	if m.searchMode || m.jumpMode {
+		mode := "Search"
+		if m.jumpMode {
+			mode = "Jump to (line, source:line, #entry, time or %)"
+		}
+		overlay = searchStyle.Render(fmt.Sprintf("%s: %s", mode, m.searchQuery))
 	}
-	if detailHeight < 10 {
-		detailHeight = 10
+	if m.note != nil {
+		overlay = searchStyle.Render(fmt.Sprintf("Note (enter to save, empty to remove): %s", m.note.text))
 	}
 
-	logList := renderLogList(m.filteredLogs, m.selectedLogIndex, m.width, listHeight)
-	rawLog := renderRawLog(m.filteredLogs[m.selectedLogIndex], m.width, m.height)
-	detailView := renderDetailView(m.filteredLogs[m.selectedLogIndex], m.width, m.height)
+	// Lay the panes out in what is left under the header (and above the overlay)
+	selected := m.filteredLogs[m.selectedLogIndex]
+	if m.entrySearch != nil {
+		overlay = m.renderEntrySearch(selected)
+	}
+	available := m.height - lipgloss.Height(header)
+	if overlay != "" {
+		available -= lipgloss.Height(overlay)
+	}
 
-	mainContent := lipgloss.JoinVertical(
-		lipgloss.Left,
-		logList,
-		rawLog,
-		detailView,
-	)
+	extras := detailExtras{
+		note:       m.notes[selected.ID.String()],
+		links:      renderLinks(m.links, entryLinkContext(selected, m.source())),
+		peers:      m.peers,
+		overhead:   m.overhead,
+		expandJSON: m.expandJSON,
+		jwtFields:  m.jwtFields,
+	}
+	// The events panel takes the right of the screen, or all of it when narrow
+	width := m.width
+	if m.eventsPanel != nil {
+		width -= eventsWidth(m.width)
+	}
 
-	if m.searchMode || m.jumpMode {
-		mode := "Search"
-		if m.jumpMode {
-			mode = "Jump to line"
+	annotations := listAnnotations{notes: m.notes, marked: m.marked, markers: resolveMarkers(m.markers, m.logs)}
+	var mainContent string
+	switch {
+	case m.layout != layoutSplit && m.layout != "" && m.entrySearch != nil && !m.entrySearch.editing:
+		// The raw log is not shown in these layouts, so its matches take the screen
+		if available < minDetailHeight+1 {
+			return renderTooSmall(m.width, m.height)
+		}
+		mainContent = m.renderRawLogMatches(selected, width, available, true)
+	case m.layout == layoutList:
+		if available < minListHeight {
+			return renderTooSmall(m.width, m.height)
+		}
+		mainContent = renderLogList(m.filteredLogs, annotations, m.selectedLogIndex, width, available, m.expandedList)
+	case m.layout == layoutDetail:
+		if available < minDetailHeight+1 {
+			return renderTooSmall(m.width, m.height)
+		}
+		mainContent = renderDetailPane(selected, extras, width, available, true)
+	default:
+		heights, ok := splitPanes(available, strings.Count(rawLogText(selected, m.expandJSON), "\n")+1)
+		if !ok {
+			return renderTooSmall(m.width, m.height)
+		}
+		mainContent = lipgloss.JoinVertical(
+			lipgloss.Left,
+			renderLogList(m.filteredLogs, annotations, m.selectedLogIndex, width, heights.list, m.expandedList),
+			m.renderRawPane(selected, width, heights.raw),
+			renderDetailView(selected, extras, width, heights.detail),
+		)
+	}
+	if m.eventsPanel != nil {
+		if width == m.width {
+			mainContent = m.renderEventsPanel(selected, m.width, lipgloss.Height(mainContent))
+		} else {
+			mainContent = lipgloss.JoinHorizontal(lipgloss.Top, mainContent,
+				m.renderEventsPanel(selected, m.width-width, lipgloss.Height(mainContent)))
 		}
-		overlay := searchStyle.Render(fmt.Sprintf("%s: %s", mode, m.searchQuery))
-		return lipgloss.JoinVertical(lipgloss.Left, header, mainContent, overlay)
 	}
 
+	if overlay != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, header, mainContent, overlay)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left, header, mainContent)
 }
 
-func renderLogList(logs []ParsedLog, selectedIdx, width, height int) string {
+// renderEmpty explains why there is nothing to show yet.
+func (m Model) renderEmpty() string {
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, m.renderToast(), headerStyle.Render("Press 'q' to quit."))
+	}
+	if m.loading {
+		return headerStyle.Render(fmt.Sprintf("Loading logs from pod %s... Press 'q' to quit.", m.target))
+	}
+	if m.following {
+		return headerStyle.Render("Waiting for logs... Press 'q' to quit.")
+	}
+	return errorStyle.Render("No valid logs found. Press 'q' to quit.")
+}
+
+// renderToast shows the current error with the actions available for it.
+func (m Model) renderToast() string {
+	toast := errorStyle.Width(m.width).Render(fmt.Sprintf("Error: %v", m.err))
+	actions := "Press esc to dismiss"
+	if m.target != nil {
+		actions = "Press 'r' to retry, 'p' to pick another pod, esc to dismiss"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, toast, searchStyle.Render(actions))
+}
+
+// listAnnotations is what the list shows alongside the entries.
+type listAnnotations struct {
+	notes   map[string]string // Entries with a note get ✎
+	marked  map[string]bool   // Entries marked for bulk operations get •
+	markers []marker          // Lines of their own between the entries, see markersBefore
+}
+
+// renderLogList renders the entries around the selection. Expanded rows take
+// up to rowLinesExpanded lines each, with key fields on lines of their own.
+func renderLogList(logs []ParsedLog, annotations listAnnotations, selectedIdx, width, height int, expanded bool) string {
+	markers := annotations.markers
 	if len(logs) == 0 {
 		return ""
 	}
 
 	var builder strings.Builder
-	listStyle := lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(normalColor).
-		Width(width - 2).
-		Height(height).
-		BorderBottom(true)
-
-	builder.WriteString(headerStyle.Render("Log List (use ↑↓ to navigate)") + "\n")
+	title := headerStyle.Render("Log List (use ↑↓ to navigate)")
+	builder.WriteString(title + "\n")
 
 	// Calculate available lines for logs
-	availableLines := height - 4 // Account for border, title, and padding
+	availableLines := height - 2 - lipgloss.Height(title) // Account for borders and title
 	if availableLines < 0 {
 		availableLines = 0
 	}
 
-	// Calculate visible range
-	startIdx := selectedIdx - (availableLines / 2)
+	// Calculate visible range, in entries
+	linesPerEntry := 1
+	if expanded {
+		linesPerEntry = rowLinesExpanded
+	}
+	availableEntries := availableLines / linesPerEntry
+	startIdx := selectedIdx - (availableEntries / 2)
 	if startIdx < 0 {
 		startIdx = 0
 	}
-	endIdx := startIdx + availableLines
+	endIdx := startIdx + availableEntries
 	if endIdx > len(logs) {
 		endIdx = len(logs)
-		startIdx = endIdx - availableLines
+		startIdx = endIdx - availableEntries
 		if startIdx < 0 {
 			startIdx = 0
 		}
 	}
 
+	// Markers in view take the place of entries, away from the selection
+	shown := func(start, end int) int {
+		lines := (end - start) * linesPerEntry
+		for i := start; i < end; i++ {
+			lines += len(markersBefore(markers, logs, i))
+		}
+		if end == len(logs) {
+			lines += len(markersBefore(markers, logs, end))
+		}
+		return lines
+	}
+	for endIdx-startIdx > 1 && shown(startIdx, endIdx) > availableLines {
+		if endIdx-1 > selectedIdx {
+			endIdx--
+		} else {
+			startIdx++
+		}
+	}
+
 	// Render logs
 	for i := startIdx; i < endIdx && i < len(logs); i++ {
 		log := logs[i]
+		for _, m := range markersBefore(markers, logs, i) {
+			builder.WriteString(renderMarker(m, width) + "\n")
+		}
 
 		// Format line number and cursor
 		cursor := "  "
 		if i == selectedIdx {
 			cursor = "▶ "
 		}
+		if annotations.marked[log.ID.String()] {
+			cursor = strings.TrimSuffix(cursor, " ") + "•"
+		}
 		lineNum := fmt.Sprintf("%s%3d:", cursor, log.LineNumber)
+		if annotations.notes[log.ID.String()] != "" {
+			lineNum += "✎"
+		}
 
-		// Format preview with max width
-		preview := formatLogPreview(log, width-len(lineNum)-6)
-		line := fmt.Sprintf("%s %s", lineNum, preview)
+		// Format preview to fit the pane without wrapping
+		previewWidth := width - lipgloss.Width(lineNum) - 5
+		lines := []string{fmt.Sprintf("%s %s", lineNum, formatLogPreview(log, previewWidth))}
+		if expanded {
+			indent := strings.Repeat(" ", lipgloss.Width(lineNum)+1)
+			lines = lines[:0]
+			for j, row := range formatExpandedRows(log, previewWidth) {
+				if j == 0 {
+					lines = append(lines, fmt.Sprintf("%s %s", lineNum, row))
+				} else {
+					lines = append(lines, indent+row)
+				}
+			}
+		}
 
 		style := logStyle
 		if i == selectedIdx {
 			style = selectedLogStyle
 		}
+		style = severityRowStyle(log, style)
 
-		if flags, ok := log.Fields["response_flags"].(string); ok {
-			switch {
-			case strings.Contains(flags, "UF"), strings.Contains(flags, "URX"):
-				style = style.Copy().Foreground(errorColor)
-			case strings.Contains(flags, "UH"), strings.Contains(flags, "UO"):
-				style = style.Copy().Foreground(warnColor)
-			}
+		for _, line := range lines {
+			builder.WriteString(renderRow(line, log, style) + "\n")
+		}
+	}
+	if endIdx == len(logs) {
+		for _, m := range markersBefore(markers, logs, endIdx) {
+			builder.WriteString(renderMarker(m, width) + "\n")
+		}
+	}
+
+	return fitPane(builder.String(), width, height, true)
+}
+
+// Severities assigned by severityOf.
+const (
+	severityError = "error"
+	severityWarn  = "warn"
+	severityInfo  = "info"
+	severityDebug = "debug"
+)
+
+// severityOf grades an entry by response flags for access logs, failures
+// above capacity shedding, or by the level field for structured application
+// logs.
+func severityOf(log ParsedLog) string {
+	flags := log.Flags()
+	for _, flag := range flags {
+		if flag.IsError() && !flag.IsOverload() {
+			return severityError
 		}
+	}
+	for _, flag := range flags {
+		if flag.IsOverload() {
+			return severityWarn
+		}
+	}
+	if tlsWarning(log) != "" {
+		return severityWarn
+	}
 
-		builder.WriteString(style.Render(line) + "\n")
+	if level := log.Level(); level != "" {
+		switch strings.ToLower(level) {
+		case "error", "err", "fatal", "critical", "panic":
+			return severityError
+		case "warn", "warning":
+			return severityWarn
+		case "debug", "trace":
+			return severityDebug
+		}
 	}
 
-	return listStyle.Render(builder.String())
+	return severityInfo
+}
+
+// severityRowStyle colors a list row by the entry's severity, unless a
+// configured highlight matches it.
+func severityRowStyle(log ParsedLog, style lipgloss.Style) lipgloss.Style {
+	if rule, ok := highlightFor(log); ok {
+		return rule.apply(style)
+	}
+	switch severityOf(log) {
+	case severityError:
+		return errorRowStyle(style)
+	case severityWarn:
+		return warnRowStyle(style)
+	case severityDebug:
+		return debugRowStyle(style)
+	}
+	return style
+}
+
+// renderRow styles a list row, coloring the tag of its most severe response
+// flag by that flag's severity. A tag cut off by truncation is left plain.
+func renderRow(line string, log ParsedLog, style lipgloss.Style) string {
+	flags, _ := log.Fields["response_flags"].(string)
+	_, tag := taggedResponseFlags(flags)
+	before, after, found := strings.Cut(line, tag)
+	if tag == "" || !found {
+		return style.Render(line)
+	}
+	flag, _ := mostSevereFlag(parseResponseFlags(flags))
+	return style.Render(before) + flagTagStyle(flag, style).Render(tag) + style.Render(after)
 }
 
 func formatLogPreview(log ParsedLog, maxWidth int) string {
 	var parts []string
 
 	// Always try to get and format timestamp first
-	if startTime, ok := log.Fields["start_time"].(string); ok && startTime != "" {
-		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
-			parts = append(parts, t.Format("15:04:05"))
-		}
+	if t, ok := eventTime(log); ok {
+		parts = append(parts, activeTimeDisplay.clock(t))
+	}
+
+	// Ambient ztunnel connections are summarized as source → destination
+	if detectProfile(log) == profileZtunnel {
+		parts = append(parts, fmt.Sprintf("%s → %s",
+			getFieldSafely(log.Fields, "src.workload"),
+			getFieldSafely(log.Fields, "dst.service")))
 	}
 
 	// Add response code
-	if code, ok := log.Fields["response_code"].(float64); ok {
-		parts = append(parts, fmt.Sprintf("[%d]", int(code)))
+	if code, ok := log.ResponseCode(); ok {
+		parts = append(parts, fmt.Sprintf("[%d]", code))
 	}
 
-	// Add response flags
+	// Add response flags, the most severe tagged with what it means
 	if flags, ok := log.Fields["response_flags"].(string); ok && flags != "" {
-		parts = append(parts, flags)
+		tagged, _ := taggedResponseFlags(flags)
+		parts = append(parts, tagged)
+	}
+
+	// Plaintext and deprecated TLS stand out in a mesh that should encrypt everything
+	if warning := tlsWarning(log); warning != "" {
+		parts = append(parts, fmt.Sprintf("[%s]", warning))
+	}
+
+	if badge := protocolBadge(requestProtocol(log)); badge != "" {
+		parts = append(parts, badge)
+	}
+	if badge := trafficBadge(trafficKind(log)); badge != "" {
+		parts = append(parts, badge)
 	}
 
 	// Add method and path if available
-	if method, ok := log.Fields["method"].(string); ok && method != "" && method != "null" {
+	if method := log.Method(); method != "" {
 		parts = append(parts, method)
 	}
-	if path, ok := log.Fields["path"].(string); ok && path != "" && path != "null" {
+	if path := log.Path(); path != "" {
 		parts = append(parts, path)
 	}
 
+	// Application and control plane logs carry a free-text message
+	if message := log.Message(); message != "" {
+		parts = append(parts, message)
+	}
+
 	// Format the preview
 	preview := strings.Join(parts, " ")
 	if len(preview) == 0 {
@@ -363,6 +1011,68 @@ func formatLogPreview(log ParsedLog, maxWidth int) string {
 	return truncate(preview, maxWidth)
 }
 
+// rowLinesExpanded is the most lines an expanded list row uses.
+const rowLinesExpanded = 3
+
+// formatExpandedRows lays the preview out over up to rowLinesExpanded lines:
+// the status, what was requested, and where it went. Labels are aligned so
+// the values line up down the list.
+func formatExpandedRows(log ParsedLog, maxWidth int) []string {
+	var status []string
+	if t, ok := eventTime(log); ok {
+		status = append(status, activeTimeDisplay.clock(t))
+	}
+	if code, ok := log.ResponseCode(); ok {
+		status = append(status, fmt.Sprintf("[%d]", code))
+	}
+	if flags, ok := log.Fields["response_flags"].(string); ok && flags != "" {
+		tagged, _ := taggedResponseFlags(flags)
+		status = append(status, tagged)
+	}
+	if level := log.Level(); level != "" {
+		status = append(status, strings.ToUpper(level))
+	}
+	if duration, ok := log.Duration(); ok {
+		status = append(status, fmt.Sprintf("%gms", float64(duration)/float64(time.Millisecond)))
+	}
+
+	rows := []string{strings.Join(status, " ")}
+	addRow := func(label, value string) {
+		if value != "" && value != "-" {
+			rows = append(rows, truncate(fmt.Sprintf("%-9s %s", label, value), maxWidth))
+		}
+	}
+
+	method, path, message := log.Method(), log.Path(), log.Message()
+	switch {
+	case path != "":
+		addRow("request", strings.TrimSpace(method+" "+path))
+	case message != "":
+		addRow("message", message)
+	}
+
+	if detectProfile(log) == profileZtunnel {
+		addRow("conn", fmt.Sprintf("%s → %s",
+			getFieldSafely(log.Fields, "src.workload"),
+			getFieldSafely(log.Fields, "dst.service")))
+	} else {
+		var upstream []string
+		for _, field := range []string{"upstream_cluster", "upstream_host"} {
+			if value := getFieldSafely(log.Fields, field); value != "-" {
+				upstream = append(upstream, value)
+			}
+		}
+		addRow("upstream", strings.Join(upstream, " "))
+	}
+
+	if rows[0] == "" {
+		// Nothing recognizable, fall back to the raw log
+		rows[0] = log.RawLog
+	}
+	rows[0] = truncate(rows[0], maxWidth)
+	return rows
+}
+
 func getFieldSafely(fields map[string]interface{}, key string) string {
 	if value, exists := fields[key]; exists {
 		if value == nil {
@@ -377,47 +1087,95 @@ func getFieldSafely(fields map[string]interface{}, key string) string {
 	return "-"
 }
 
-func renderDetailView(log ParsedLog, width, height int) string {
-	if width <= 0 {
-		fmt.Print("Width is 0, bailing out of renderDetailView early")
-		return ""
-	}
-	if height <= 0 {
-		fmt.Print("Height is 0, bailing out of renderDetailView early")
-		return ""
+func renderDetailView(log ParsedLog, extras detailExtras, width, height int) string {
+	return renderDetailPane(log, extras, width, height, false)
+}
+
+// detailExtras is what the detail pane shows besides the entry's own fields.
+type detailExtras struct {
+	note  string            // The user's note on the entry
+	links []deepLink        // Deep links for the entry
+	peers map[string]string // Names of IP addresses, see peerName
+
+	overhead overheadStats // Proxy overhead across the filtered entries
+
+	expandJSON bool     // Show the JSON documents string fields hold indented
+	jwtFields  []string // Fields whose bearer tokens' claims are shown
+}
+
+// renderDetailPane renders the details, with a top border when the pane is
+// not stacked under another one.
+func renderDetailPane(log ParsedLog, extras detailExtras, width, height int, topBorder bool) string {
+	profile := detectProfile(log)
+	fields := detailFields(log, profile)
+	groups := detailGroupsFor(profile, fields)
+	if claims, ok := tokenClaims(log, extras.jwtFields); ok {
+		fields, groups = claims.withSecurityGroup(log, fields, groups)
 	}
-	detailStyle := lipgloss.NewStyle().
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(normalColor).
-		Padding(0, 1).
-		Width(width - 2).
-		Height(height). // Does not include border
-		BorderTop(false)
 
 	var builder strings.Builder
-	builder.WriteString(headerStyle.Render("Parsed Log Details") + "\n\n")
-
-	// Group and format fields with improved error handling
-	groups := []struct {
-		name   string
-		fields []string
-	}{
-		{"Request Info", []string{
-			"start_time", "method", "protocol", "authority", "path",
-			"request_id", "user_agent", "client_ip", "x_forwarded_for",
-		}},
-		{"Response Info", []string{
-			"response_code", "response_code_details", "response_flags",
-			"duration", "bytes_sent", "bytes_received",
-		}},
-		{"Upstream Info", []string{
-			"upstream_cluster", "upstream_host", "upstream_local_address",
-			"upstream_service_time", "upstream_transport_failure_reason",
-		}},
-		{"Downstream Info", []string{
-			"downstream_local_address", "downstream_remote_address",
-			"requested_server_name", "route_name",
-		}},
+	title := "Parsed Log Details"
+	if profile != profileSidecar {
+		title = fmt.Sprintf("Parsed Log Details (%s)", profile)
+	}
+	builder.WriteString(headerStyle.Render(title) + "\n\n")
+
+	// The note, timing, diagnosis, source and links come first, the groups below are often taller than the pane
+	if extras.note != "" {
+		builder.WriteString(lipgloss.NewStyle().
+			Bold(true).
+			Foreground(headerColor).
+			Render("Note") + "\n")
+		builder.WriteString(jsonStringStyle.Render(extras.note) + "\n\n")
+	}
+	if breakdown, ok := breakdownOf(log); ok {
+		builder.WriteString(lipgloss.NewStyle().
+			Bold(true).
+			Foreground(headerColor).
+			Render("Timing") + "\n")
+		builder.WriteString(renderBreakdownBar(breakdown) + "\n")
+		if extras.overhead.requests > 1 {
+			builder.WriteString(jsonNullStyle.Render("Filtered: "+extras.overhead.String()) + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	if diagnoses := diagnoseEntry(log); len(diagnoses) > 0 {
+		builder.WriteString(lipgloss.NewStyle().
+			Bold(true).
+			Foreground(headerColor).
+			Render("Diagnosis") + "\n")
+		for _, d := range diagnoses {
+			builder.WriteString(warnRowStyle(jsonKeyStyle).Render(d.name) + "\n")
+			builder.WriteString(jsonStringStyle.Render(d.explain) + "\n")
+			for _, command := range d.commands {
+				builder.WriteString(jsonNullStyle.Render("$ ") + jsonStringStyle.Render(command) + "\n")
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	if !log.Source.IsZero() {
+		builder.WriteString(lipgloss.NewStyle().
+			Bold(true).
+			Foreground(headerColor).
+			Render("Source") + "\n")
+		for _, label := range sourceLabels {
+			if value := log.Source.Label(label); value != "" {
+				builder.WriteString(fmt.Sprintf("%s: %s\n", jsonKeyStyle.Render(fmt.Sprintf("%-30s", label)), jsonStringStyle.Render(value)))
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	if len(extras.links) > 0 {
+		builder.WriteString(lipgloss.NewStyle().
+			Bold(true).
+			Foreground(headerColor).
+			Render("Links") + "\n")
+		for _, link := range extras.links {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", jsonKeyStyle.Render(fmt.Sprintf("%-30s", link.Name)), jsonStringStyle.Render(link.URL)))
+		}
+		builder.WriteString("\n")
 	}
 
 	for _, group := range groups {
@@ -428,12 +1186,25 @@ func renderDetailView(log ParsedLog, width, height int) string {
 
 		hasData := false
 		for _, field := range group.fields {
-			value := getFieldSafely(log.Fields, field)
+			value := getFieldSafely(fields, field)
+			if params, ok := fields[field].(map[string]interface{}); ok && field == "query" {
+				value = formatQuery(params)
+			}
 			if value != "-" {
 				hasData = true
 			}
 			fieldStr := jsonKeyStyle.Render(fmt.Sprintf("%-30s", field))
 			valueStr := formatFieldValue(field, value)
+			if pretty, ok := prettyEmbeddedJSON(value); ok {
+				if extras.expandJSON {
+					valueStr = "\n" + jsonStringStyle.Render(pretty)
+				} else {
+					valueStr += " " + lipgloss.NewStyle().Foreground(mutedColor).Italic(true).Render("(JSON, press 'J' to expand)")
+				}
+			}
+			if peer := peerName(value, extras.peers); peer != "" && slices.Contains(addressFields, field) {
+				valueStr = fmt.Sprintf("%s → %s", jsonStringStyle.Render(value), jsonKeyStyle.Render(peer))
+			}
 			builder.WriteString(fmt.Sprintf("%s: %s\n", fieldStr, valueStr))
 		}
 
@@ -443,14 +1214,23 @@ func renderDetailView(log ParsedLog, width, height int) string {
 		builder.WriteString("\n")
 	}
 
-	return detailStyle.Render(builder.String())
+	return fitPane(builder.String(), width, height, topBorder)
 }
 
 func formatFieldValue(field, value string) string {
+	if value == "-" && field == "downstream_tls_version" {
+		return jsonNullStyle.Render("-") + " " + lipgloss.NewStyle().
+			Foreground(warnColor).
+			Italic(true).
+			Render("(plaintext, not encrypted)")
+	}
 	if value == "-" {
 		return jsonNullStyle.Render("-")
 	}
 
+	if timeFields[field] {
+		value = fmt.Sprint(displayTimeField(field, value))
+	}
 	valueStr := value
 	var explanation string
 
@@ -458,14 +1238,28 @@ func formatFieldValue(field, value string) string {
 	case "response_flags":
 		explanation = explainResponseFlags(value)
 	case "response_code":
-		explanation = getResponseCodeExplanation(value)
+		// Stringified codes may read " 503" or "503.0"
+		if code, ok := intValue(coerceNumber(value)); ok {
+			explanation = getResponseCodeExplanation(strconv.FormatInt(code, 10))
+		}
 	case "upstream_transport_failure_reason":
 		explanation = getFailureExplanation(value)
+	case "protocol":
+		explanation = explainProtocol(value)
+	case "user_agent":
+		explanation = explainUserAgent(value)
+	case "downstream_tls_version", "upstream_tls_version":
+		explanation = explainTLSVersion(value)
+	case "jwt.exp":
+		if strings.Contains(value, "(expired") {
+			return heatErrorStyle.Render(value)
+		}
 	case "duration":
 		if value == "0" {
 			explanation = "request did not complete"
 		}
-	case "downstream_local_address", "downstream_remote_address", "upstream_host":
+	case "downstream_local_address", "downstream_remote_address", "upstream_host",
+		"src.addr", "dst.addr", "dst.hbone_addr":
 		explanation = formatAddress(value)
 	}
 
@@ -473,7 +1267,7 @@ func formatFieldValue(field, value string) string {
 		valueStr = fmt.Sprintf("%s %s",
 			jsonStringStyle.Render(value),
 			lipgloss.NewStyle().
-				Foreground(lipgloss.Color("242")).
+				Foreground(mutedColor).
 				Italic(true).
 				Render(fmt.Sprintf("(%s)", explanation)))
 	} else {
@@ -517,49 +1311,12 @@ func getFailureExplanation(reason string) string {
 }
 
 func formatAddress(value string) string {
-	parts := strings.Split(value, ":")
-	if len(parts) == 2 {
-		return fmt.Sprintf("IP: %s, Port: %s", parts[0], parts[1])
-	}
-	return ""
-}
-
-func explainResponseFlags(flags string) string {
-	explanations := []string{}
-
-	flagMap := map[string]string{
-		"UH":   "upstream unhealthy",
-		"UF":   "upstream connection failure",
-		"UO":   "upstream overflow",
-		"NR":   "no route configured",
-		"URX":  "upstream request timeout",
-		"DC":   "downstream connection termination",
-		"LH":   "local service healthy",
-		"UR":   "upstream retry",
-		"UC":   "upstream connection termination",
-		"DT":   "downstream request timeout",
-		"LR":   "local service rejected",
-		"RL":   "rate limited",
-		"UAEX": "unauthorized external service",
-		"RLSE": "rate limited service error",
-		"IH":   "invalid HTTP response",
-		"SI":   "stream idle timeout",
-		"DPE":  "downstream protocol error",
-		"UPE":  "upstream protocol error",
-		"NC":   "no cluster found",
-	}
-
-	parts := strings.Split(flags, ",")
-	for _, flag := range parts {
-		if explanation, exists := flagMap[strings.TrimSpace(flag)]; exists {
-			explanations = append(explanations, explanation)
-		}
-	}
-
-	if len(explanations) > 0 {
-		return strings.Join(explanations, ", ")
+	// SplitHostPort also handles bracketed IPv6 addresses like [::1]:8080
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" || port == "" {
+		return ""
 	}
-	return ""
+	return fmt.Sprintf("IP: %s, Port: %s", host, port)
 }
 
 func truncate(input string, maxLen int) string {
@@ -3,10 +3,12 @@
 package main
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func TestUpdate(t *testing.T) {
@@ -39,8 +41,9 @@ func TestUpdate(t *testing.T) {
 		t.Errorf("expected searchMode to be true, got %v", newModel.searchMode)
 	}
 
-	// Test entering jump mode
-	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	// Test entering jump mode, once the search is closed since / is typed into it
+	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
 	newModel = updatedModel.(Model)
 	if !newModel.jumpMode {
 		t.Errorf("expected jumpMode to be true, got %v", newModel.jumpMode)
@@ -113,10 +116,41 @@ func TestFilterLogs(t *testing.T) {
 	}
 }
 
+func TestFilterLogsIndexed(t *testing.T) {
+	indexed, err := parseRawLogs([]string{
+		`{"level":"info","message":"Server started","upstream_host":"10.42.0.12:9080"}`,
+		"2024-11-25T19:47:07.374828Z\terror\tConnection FAILED\tattempt=3",
+		`{"level":"warn","message":"Überlastung"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	unindexed := make([]ParsedLog, len(indexed))
+	for i, log := range indexed {
+		log.searchText = ""
+		unindexed[i] = log
+	}
+
+	// Indexed entries must match exactly what the unindexed path matches
+	for _, query := range []string{"error", "connection failed", "ATTEMPT", "3", "überlastung", "upstream_host", "missing"} {
+		got := filterLogs(indexed, query)
+		want := filterLogs(unindexed, query)
+		if len(got) != len(want) {
+			t.Errorf("filterLogs(%q) matched %d indexed entries, %d unindexed", query, len(got), len(want))
+		}
+	}
+
+	// A query must not match across the boundary between two fields
+	log := ParsedLog{Fields: map[string]interface{}{"level": "info"}}.indexed()
+	if len(filterLogs([]ParsedLog{log}, "levelinfo")) != 0 {
+		t.Errorf("expected no match across a key and its value")
+	}
+}
+
 func TestJumpToLine(t *testing.T) {
 	logs := []ParsedLog{
-		{RawLog: `{"level":"info","message":"Server started"}`},
-		{RawLog: `{"level":"error","message":"Connection failed"}`},
+		{RawLog: `{"level":"info","message":"Server started"}`, LineNumber: 1},
+		{RawLog: `{"level":"error","message":"Connection failed"}`, LineNumber: 2},
 	}
 	model := &Model{
 		logs:         logs,
@@ -233,3 +267,244 @@ func TestViewSections(t *testing.T) {
 		t.Error("Sections are not in the correct order")
 	}
 }
+
+func TestFollowPinning(t *testing.T) {
+	lines := make(chan string)
+	model := Model{stream: lines, following: true, pinned: true}
+
+	// New lines keep the selection on the newest entry while pinned
+	updatedModel, _ := model.Update(logLineMsg{line: `{"message":"first"}`})
+	updatedModel, _ = updatedModel.Update(logLineMsg{line: `{"message":"second"}`})
+	newModel := updatedModel.(Model)
+	if newModel.selectedLogIndex != 1 {
+		t.Errorf("expected selectedLogIndex to be 1, got %d", newModel.selectedLogIndex)
+	}
+
+	// Scrolling up unpins and new lines no longer move the selection
+	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updatedModel, _ = updatedModel.Update(logLineMsg{line: `{"message":"third"}`})
+	newModel = updatedModel.(Model)
+	if newModel.pinned {
+		t.Errorf("expected pinned to be false after scrolling up")
+	}
+	if newModel.selectedLogIndex != 0 {
+		t.Errorf("expected selectedLogIndex to stay at 0, got %d", newModel.selectedLogIndex)
+	}
+
+	// G jumps to the newest entry and resumes auto-scroll
+	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	updatedModel, _ = updatedModel.Update(logLineMsg{line: `{"message":"fourth"}`})
+	newModel = updatedModel.(Model)
+	if !newModel.pinned {
+		t.Errorf("expected pinned to be true after pressing G")
+	}
+	if newModel.selectedLogIndex != 3 {
+		t.Errorf("expected selectedLogIndex to be 3, got %d", newModel.selectedLogIndex)
+	}
+
+	// In the search overlay G, s and / are typed like any other key
+	newModel.selectedLogIndex = 0
+	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	for _, key := range []string{"G", "E", "T", " ", "/", "s"} {
+		updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+	newModel = updatedModel.(Model)
+	if newModel.searchQuery != "GET /s" || !newModel.searchMode {
+		t.Errorf("expected the query to be typed, got %q", newModel.searchQuery)
+	}
+	if newModel.selectedLogIndex != 0 {
+		t.Errorf("expected the selection to stay at 0, got %d", newModel.selectedLogIndex)
+	}
+}
+
+func TestFollowStreamError(t *testing.T) {
+	streamErr := explainKubeError(errors.New("getting credentials: exec: executable aws not found"))
+	model := Model{following: true, pinned: true, streamErr: func() error { return streamErr }, width: 120, height: 40}
+
+	// An error ending the stream stays visible, with or without entries
+	updatedModel, _ := model.Update(streamClosedMsg{})
+	if view := updatedModel.View(); !strings.Contains(view, "not on your PATH") {
+		t.Errorf("expected the stream error hint in the empty view, got %q", view)
+	}
+
+	model = model.appendLine(`{"message":"first"}`)
+	updatedModel, _ = model.Update(streamClosedMsg{})
+	if view := updatedModel.View(); !strings.Contains(view, "executable aws not found") {
+		t.Errorf("expected the stream error below the header")
+	}
+}
+
+func TestFollowJoinsMultiline(t *testing.T) {
+	joiner, err := newLineJoiner(defaultMultilineConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string)
+	var model tea.Model = Model{stream: lines, following: true, pinned: true, multiline: joiner}
+	for _, line := range []string{
+		`{"message":"request failed"}`,
+		`java.lang.IllegalStateException: boom`,
+		`    at com.example.Cart.checkout(Cart.java:42)`,
+		`{"message":"next"}`,
+	} {
+		model, _ = model.Update(logLineMsg{line: line, stream: lines})
+	}
+	followed := model.(Model)
+	if len(followed.logs) != 1 || !strings.Contains(fieldString(followed.logs[0].Fields["stack_trace"]), "Cart.java:42") {
+		t.Fatalf("expected the stack trace joined into the first entry, got %v", followed.logs)
+	}
+
+	// The last entry waits until no line arrived during an idle wait
+	model, cmd := model.Update(pendingIdleMsg{stream: lines, seq: followed.pendingSeq - 1})
+	if followed = model.(Model); len(followed.logs) != 1 || cmd == nil {
+		t.Errorf("expected another wait after a line arrived, got %d entries", len(followed.logs))
+	}
+	model, _ = model.Update(pendingIdleMsg{stream: lines, seq: followed.pendingSeq})
+	if followed = model.(Model); len(followed.logs) != 2 || followed.logs[1].Fields["message"] != "next" {
+		t.Errorf("expected the last entry added once idle, got %v", followed.logs)
+	}
+
+	// Closing the stream adds the entry being joined
+	model, _ = model.Update(logLineMsg{line: `{"message":"last"}`, stream: lines})
+	model, _ = model.Update(logLineMsg{line: `  continued`, stream: lines})
+	model, _ = model.Update(streamClosedMsg{stream: lines})
+	if followed = model.(Model); len(followed.logs) != 3 || followed.logs[2].Fields["stack_trace"] != "  continued" {
+		t.Errorf("expected the closing stream to add the last entry, got %v", followed.logs)
+	}
+}
+
+func TestSelectTheme(t *testing.T) {
+	defer applyTheme(themes["default"])
+
+	theme, err := selectTheme("high-contrast", true)
+	if err != nil {
+		t.Fatalf("selectTheme() unexpected error: %v", err)
+	}
+	if theme.Name != "mono" {
+		t.Errorf("expected --no-color to force the mono theme, got %s", theme.Name)
+	}
+
+	applyTheme(theme)
+	if !errorRowStyle(logStyle).GetUnderline() {
+		t.Errorf("expected error rows to be underlined in the mono theme")
+	}
+	if !selectedLogStyle.GetReverse() {
+		t.Errorf("expected the selected row to use reverse video in the mono theme")
+	}
+
+	if _, err := selectTheme("neon", false); err == nil {
+		t.Errorf("expected an error for an unknown theme")
+	}
+}
+
+func TestConnectionCorrelation(t *testing.T) {
+	var logs []ParsedLog
+	lines := []string{
+		"2024-11-25T19:47:07.374828Z\tdebug\tenvoy connection external/envoy/source/common/network/connection_impl.cc:150\t[C12] new connection\tthread=21",
+		"2024-11-25T19:47:07.374900Z\tdebug\tenvoy connection external/envoy/source/common/network/connection_impl.cc:150\t[C13] new connection\tthread=22",
+		`{"connection_id":12,"response_code":503,"response_flags":"UF"}`,
+		"2024-11-25T19:47:07.375000Z\tdebug\tenvoy connection external/envoy/source/common/network/connection_impl.cc:150\t[C12] closing socket: 0\tthread=21",
+	}
+	for i, line := range lines {
+		parsedLog, err := parseLine(line, i+1)
+		if err != nil {
+			t.Fatalf("parseLine() unexpected error: %v", err)
+		}
+		logs = append(logs, parsedLog)
+	}
+
+	model := Model{logs: logs, filteredLogs: logs}
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	newModel := updatedModel.(Model)
+	if newModel.connectionFilter != "C12" {
+		t.Fatalf("expected connectionFilter to be C12, got %q", newModel.connectionFilter)
+	}
+	if len(newModel.filteredLogs) != 3 {
+		t.Fatalf("expected 3 logs for connection C12, got %d", len(newModel.filteredLogs))
+	}
+	if newModel.filteredLogs[1].LineNumber != 3 {
+		t.Errorf("expected the access log entry to stay in line order, got line %d", newModel.filteredLogs[1].LineNumber)
+	}
+
+	updatedModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	newModel = updatedModel.(Model)
+	if newModel.connectionFilter != "" || len(newModel.filteredLogs) != 4 {
+		t.Errorf("expected esc to clear the connection filter, got %q with %d logs", newModel.connectionFilter, len(newModel.filteredLogs))
+	}
+}
+
+func TestSeverityRowStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		expected lipgloss.TerminalColor
+	}{
+		{"Error level", map[string]interface{}{"level": "error"}, errorColor},
+		{"Upper-case warning level", map[string]interface{}{"level": "WARN"}, warnColor},
+		{"Debug level", map[string]interface{}{"level": "debug"}, jsonNullColor},
+		{"Response flags win over level", map[string]interface{}{"level": "info", "response_flags": "UF"}, errorColor},
+		{"Info level keeps the row style", map[string]interface{}{"level": "info"}, normalColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := severityRowStyle(ParsedLog{Fields: tt.fields}, logStyle)
+			if style.GetForeground() != tt.expected {
+				t.Errorf("severityRowStyle() foreground = %v, expected %v", style.GetForeground(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatAddress(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"10.43.236.215:4200", "IP: 10.43.236.215, Port: 4200"},
+		{"[fd00::12]:9080", "IP: fd00::12, Port: 9080"},
+		{"10.43.236.215", ""},
+		{":", ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatAddress(tt.value); got != tt.expected {
+			t.Errorf("formatAddress(%q) = %q, expected %q", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatExpandedRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "Application log",
+			fields:   map[string]interface{}{"level": "error", "message": "Connection failed"},
+			expected: []string{"ERROR", "message   Connection failed"},
+		},
+		{
+			name:     "Ztunnel connection",
+			fields:   map[string]interface{}{"src.workload": "sleep", "dst.service": "productpage"},
+			expected: []string{"", "conn      sleep → productpage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := formatExpandedRows(ParsedLog{RawLog: "", Fields: tt.fields}, 80)
+			if strings.Join(rows, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("formatExpandedRows() = %q, expected %q", rows, tt.expected)
+			}
+		})
+	}
+
+	// e toggles expanded rows
+	model := Model{logs: []ParsedLog{{RawLog: "log1"}}, filteredLogs: []ParsedLog{{RawLog: "log1"}}}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if !updated.(Model).expandedList {
+		t.Errorf("expected e to expand the list")
+	}
+}
@@ -0,0 +1,67 @@
+// log_viewer/undo.go
+
+package main
+
+// maxUndo bounds how many view changes can be undone.
+const maxUndo = 50
+
+// viewState is what undo and redo restore: the filters narrowing the list,
+// the hidden entries and the selected entry.
+type viewState struct {
+	activeFilter     string
+	connectionFilter string
+	excluded         map[string]bool // Never modified in place, see hideMarked
+	selectedID       EntryID
+}
+
+func (m Model) viewState() viewState {
+	return viewState{
+		activeFilter:     m.activeFilter,
+		connectionFilter: m.connectionFilter,
+		excluded:         m.excluded,
+		selectedID:       m.selectedID(),
+	}
+}
+
+// remember records the current view before a filter or jump changes it.
+// Anything undone before can no longer be redone.
+func (m Model) remember() Model {
+	m.undoStack = append(m.undoStack, m.viewState())
+	if len(m.undoStack) > maxUndo {
+		m.undoStack = m.undoStack[len(m.undoStack)-maxUndo:]
+	}
+	m.redoStack = nil
+	return m
+}
+
+// undo restores the view before the last filter or jump.
+func (m Model) undo() Model {
+	if len(m.undoStack) == 0 {
+		return m
+	}
+	previous := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, m.viewState())
+	return m.restore(previous)
+}
+
+// redo reapplies the last undone change.
+func (m Model) redo() Model {
+	if len(m.redoStack) == 0 {
+		return m
+	}
+	next := m.redoStack[len(m.redoStack)-1]
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack, m.viewState())
+	return m.restore(next)
+}
+
+func (m Model) restore(state viewState) Model {
+	m.activeFilter = state.activeFilter
+	m.connectionFilter = state.connectionFilter
+	m.excluded = state.excluded
+	m.filteredLogs = m.filter(m.logs)
+	m.overhead = overheadOf(m.filteredLogs)
+	m.selectedLogIndex = indexOfID(m.filteredLogs, state.selectedID)
+	return m
+}
@@ -0,0 +1,78 @@
+// log_viewer/undo_test.go
+
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUndoRedo(t *testing.T) {
+	logs, err := parseRawLogs([]string{
+		`{"message":"review ok"}`,
+		`{"message":"review failed"}`,
+		`{"message":"rating ok"}`,
+	})
+	if err != nil {
+		t.Fatalf("parseRawLogs() unexpected error: %v", err)
+	}
+	model := Model{logs: logs, filteredLogs: logs}
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "ctrl+r":
+				msg = tea.KeyMsg{Type: tea.KeyCtrlR}
+			}
+			updated, _ := model.Update(msg)
+			model = updated.(Model)
+		}
+	}
+	search := func(query string) {
+		press("s")
+		for _, r := range query {
+			press(string(r))
+		}
+		press("enter")
+	}
+
+	// Narrow the view (queries avoid s, which starts a new search), then fat-finger a filter that matches nothing
+	search("review")
+	press("j")
+	search("reviewz")
+	if len(model.filteredLogs) != 0 {
+		t.Fatalf("expected the typo to match nothing, got %d entries", len(model.filteredLogs))
+	}
+
+	press("u")
+	if model.activeFilter != "review" || len(model.filteredLogs) != 2 || model.selectedLogIndex != 1 {
+		t.Errorf("expected undo to restore the review filter and selection, got filter=%q entries=%d selected=%d",
+			model.activeFilter, len(model.filteredLogs), model.selectedLogIndex)
+	}
+
+	press("u")
+	if model.activeFilter != "" || len(model.filteredLogs) != 3 {
+		t.Errorf("expected a second undo to clear the filter, got %q", model.activeFilter)
+	}
+
+	press("ctrl+r")
+	if model.activeFilter != "review" {
+		t.Errorf("expected redo to reapply the review filter, got %q", model.activeFilter)
+	}
+
+	// A new change drops what could be redone
+	search("rating")
+	press("ctrl+r")
+	if model.activeFilter != "rating" {
+		t.Errorf("expected nothing to redo after a new search, got %q", model.activeFilter)
+	}
+
+	// u types in the search overlay
+	press("s", "u")
+	if model.searchQuery != "u" {
+		t.Errorf("expected u to be typed while searching, got %q", model.searchQuery)
+	}
+}
@@ -0,0 +1,103 @@
+// log_viewer/useragent.go
+
+package main
+
+import "strings"
+
+// Derived user_agent fields, also -compare values: the client's family, such
+// as Chrome or okhttp, and the family with its version, to spot a
+// misbehaving SDK release among the clients of an ingress gateway.
+const (
+	compareClientFamily  = "user_agent.family"
+	compareClientVersion = "user_agent.version"
+)
+
+// userAgent is what a User-Agent header says about the client.
+type userAgent struct {
+	family  string
+	version string // Major version for browsers, as released for libraries
+}
+
+func (ua userAgent) String() string {
+	return strings.TrimSpace(ua.family + " " + ua.version)
+}
+
+// browserTokens pick out browsers, which all claim to be Mozilla and most to
+// be Safari, by the product token only they send. Order matters: Edge and
+// Opera also send Chrome's.
+var browserTokens = []struct {
+	token, family string
+}{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"CriOS/", "Chrome"},
+	{"FxiOS/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Firefox/", "Firefox"},
+	{"Version/", "Safari"},
+}
+
+// parseUserAgent reads the client family and version from a User-Agent:
+// browsers by their product token, bots by the name in "compatible; ...",
+// and libraries and tools such as okhttp/4.9.3 or grpc-go/1.59.0 by their
+// first product. It is false for "-" and empty values.
+func parseUserAgent(value string) (userAgent, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "-" {
+		return userAgent{}, false
+	}
+
+	if strings.HasPrefix(value, "Mozilla/") {
+		for _, browser := range browserTokens {
+			if i := strings.Index(value, browser.token); i >= 0 {
+				version := productVersion(value[i+len(browser.token):])
+				major, _, _ := strings.Cut(version, ".")
+				return userAgent{family: browser.family, version: major}, true
+			}
+		}
+		if _, rest, ok := strings.Cut(value, "compatible; "); ok {
+			product, _, _ := strings.Cut(rest, ";")
+			return productAgent(strings.TrimSuffix(product, ")")), true
+		}
+	}
+	return productAgent(value), true
+}
+
+// productAgent reads the first name/version product of value.
+func productAgent(value string) userAgent {
+	product, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+	family, version, _ := strings.Cut(product, "/")
+	return userAgent{family: family, version: productVersion(version)}
+}
+
+// productVersion returns the version at the start of value, up to a space,
+// semicolon or closing parenthesis.
+func productVersion(value string) string {
+	if i := strings.IndexAny(value, " ;)"); i >= 0 {
+		return value[:i]
+	}
+	return value
+}
+
+// explainUserAgent names the client when the header does not say it plainly,
+// as browsers' do not.
+func explainUserAgent(value string) string {
+	ua, ok := parseUserAgent(value)
+	if !ok || ua.String() == strings.Replace(value, "/", " ", 1) {
+		return ""
+	}
+	return ua.String()
+}
+
+// userAgentValue returns the derived field of an entry, see
+// compareClientFamily, or "" when it has no user agent.
+func userAgentValue(log ParsedLog, field string) string {
+	ua, ok := parseUserAgent(getFieldSafely(log.Fields, "user_agent"))
+	switch {
+	case !ok:
+		return ""
+	case field == compareClientFamily:
+		return ua.family
+	}
+	return ua.String()
+}
@@ -0,0 +1,66 @@
+// log_viewer/useragent_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36", "Chrome 120", true},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.77", "Edge 120", true},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15", "Safari 17", true},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox 121", true},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Googlebot 2.1", true},
+		{"okhttp/4.9.3", "okhttp 4.9.3", true},
+		{"grpc-go/1.59.0", "grpc-go 1.59.0", true},
+		{"python-requests/2.31.0", "python-requests 2.31.0", true},
+		{"MyApp/3.2 (iPhone; iOS 17.1) Alamofire/5.8", "MyApp 3.2", true},
+		{"-", "", false},
+	}
+	for _, tt := range tests {
+		ua, ok := parseUserAgent(tt.value)
+		if ok != tt.ok || ua.String() != tt.want {
+			t.Errorf("parseUserAgent(%q) = %q, %v, expected %q, %v", tt.value, ua, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got := explainUserAgent("okhttp/4.9.3"); got != "" {
+		t.Errorf("expected no explanation for a plain product, got %q", got)
+	}
+	if got := explainUserAgent(tests[0].value); got != "Chrome 120" {
+		t.Errorf("expected the browser named, got %q", got)
+	}
+}
+
+func TestCompareByUserAgent(t *testing.T) {
+	logs, err := parseRawLogsWith([]string{
+		`{"response_code":200,"user_agent":"okhttp/4.9.3","duration":10}`,
+		`{"response_code":503,"user_agent":"okhttp/4.12.0","duration":30}`,
+		`{"response_code":503,"user_agent":"okhttp/4.12.0","duration":30}`,
+		`{"response_code":200,"user_agent":"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0","duration":12}`,
+		`{"response_code":200,"duration":5}`,
+	}, defaultParseOptions())
+	if err != nil {
+		t.Fatalf("parseRawLogsWith() unexpected error: %v", err)
+	}
+
+	c := compareBy(logs, compareClientVersion)
+	var out bytes.Buffer
+	c.writeMarkdown(&out)
+	for _, want := range []string{"| okhttp 4.12.0 | 2 | 2 | 100.0%", "| okhttp 4.9.3 | 1 | 0 |", "| Firefox 121 | 1 |"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the comparison, got:\n%s", want, out.String())
+		}
+	}
+	if families := compareBy(logs, compareClientFamily); len(families.variants) != 2 || families.variants[1].requests != 3 {
+		t.Errorf("expected okhttp's releases grouped by family, got %+v", families.variants)
+	}
+}
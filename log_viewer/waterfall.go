@@ -0,0 +1,106 @@
+// log_viewer/waterfall.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// waterfallWidth is the width of the bars, which span the request's first
+// hop.
+const waterfallWidth = 40
+
+// waterfallHop is one row of the waterfall: a call of the request's flow
+// with the time it spent outside the calls it made.
+type waterfallHop struct {
+	call  *flowCall
+	depth int
+	own   float64 // Milliseconds of the duration not spent in nested calls
+}
+
+// waterfallHops flattens the flow, each call followed by the calls it made.
+func waterfallHops(calls []*flowCall, depth int) []waterfallHop {
+	var hops []waterfallHop
+	for _, call := range calls {
+		own := call.duration
+		for _, nested := range call.calls {
+			own -= nested.duration
+		}
+		hops = append(hops, waterfallHop{call: call, depth: depth, own: max(own, 0)})
+		hops = append(hops, waterfallHops(call.calls, depth+1)...)
+	}
+	return hops
+}
+
+// waterfallBar places a hop on the time axis of the first hop: spaces until
+// it started, then a cell per slice of its duration.
+func waterfallBar(call *flowCall, start time.Time, total float64) string {
+	if !call.timed || start.IsZero() || total <= 0 {
+		return strings.Repeat("?", waterfallWidth)
+	}
+	offset := float64(call.start.Sub(start)) / float64(time.Millisecond)
+	from := min(max(int(offset/total*waterfallWidth), 0), waterfallWidth-1)
+	cells := max(int(call.duration/total*waterfallWidth), 1)
+	cells = min(cells, waterfallWidth-from)
+	return strings.Repeat(" ", from) + strings.Repeat("█", cells) + strings.Repeat(" ", waterfallWidth-from-cells)
+}
+
+// renderWaterfall writes the hops of the request with their timing, the
+// response flags explained, and the hop most of the time went to.
+func renderWaterfall(w io.Writer, requestID string, calls []*flowCall) {
+	hops := waterfallHops(calls, 0)
+	if len(hops) == 0 {
+		fmt.Fprintf(w, "No hops of request %s could be rebuilt.\n", requestID)
+		return
+	}
+	first := hops[0].call
+	total := first.duration
+	fmt.Fprintf(w, "%s\n\n", headerStyle.Render(fmt.Sprintf("Request %s: %gms end to end over %d hops", requestID, total, len(hops))))
+
+	labels := make([]string, len(hops))
+	labelWidth := 0
+	for i, hop := range hops {
+		labels[i] = strings.Repeat("  ", hop.depth) + hop.call.from + " → " + hop.call.to
+		labelWidth = max(labelWidth, len([]rune(labels[i])))
+	}
+
+	slowest := hops[0]
+	for i, hop := range hops {
+		call := hop.call
+		offset := "     "
+		if call.timed && first.timed {
+			offset = fmt.Sprintf("+%gms", float64(call.start.Sub(first.start))/float64(time.Millisecond))
+		}
+		bar := waterfallBar(call, first.start, total)
+		if call.failed {
+			bar = heatErrorStyle.Render(bar)
+		}
+		fmt.Fprintf(w, "%-*s  %8s  |%s|  %7gms  own %gms  %s %s\n", labelWidth, labels[i], offset, bar,
+			call.duration, hop.own, call.request, call.response)
+		if explanation := explainResponseFlags(call.flags); explanation != "" {
+			fmt.Fprintf(w, "%-*s  %s\n", labelWidth, "", heatWarnStyle.Render(call.flags+": "+explanation))
+		}
+		if hop.own > slowest.own {
+			slowest = hop
+		}
+	}
+
+	if total > 0 {
+		fmt.Fprintf(w, "\nMost of the time went to %s: %gms of its own (%.0f%% of %gms), outside the calls it made.\n",
+			slowest.call.to, slowest.own, slowest.own/total*100, total)
+	}
+}
+
+// runWaterfall implements `log_viewer waterfall [flags] <x-request-id> [file...]`,
+// drawing where the time of one request went from the gateway to the
+// application. The entries are read as find-request reads them. Hops timed
+// by clocks that disagree, see clockSkews, are warned about under it.
+func runWaterfall(opts cliOptions, cfg Config) {
+	requestID, entries := loadRequest(opts, cfg, "log_viewer waterfall [flags] <x-request-id> [file...]")
+	renderWaterfall(os.Stdout, requestID, requestFlow(entries, requestID))
+	writeClockSkews(os.Stdout, clockSkews(entries))
+}
@@ -0,0 +1,51 @@
+// log_viewer/waterfall_test.go
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWaterfall(t *testing.T) {
+	hops := waterfallHops(requestFlow(mustParse(t, flowTestLines...), "abc"), 0)
+	expected := []struct {
+		to    string
+		depth int
+		own   float64
+	}{
+		{"ingress", 0, 2},      // The gateway's 40ms less the 38ms upstream
+		{"productpage", 1, 13}, // 38ms less the 25ms call to reviews
+		{"reviews", 2, 25},
+	}
+	if len(hops) != len(expected) {
+		t.Fatalf("waterfallHops() = %d hops, expected %d", len(hops), len(expected))
+	}
+	for i, want := range expected {
+		if hops[i].call.to != want.to || hops[i].depth != want.depth || hops[i].own != want.own {
+			t.Errorf("hop %d = %s at depth %d, own %gms, expected %+v", i, hops[i].call.to, hops[i].depth, hops[i].own, want)
+		}
+	}
+
+	var out bytes.Buffer
+	renderWaterfall(&out, "abc", requestFlow(mustParse(t, flowTestLines...), "abc"))
+	for _, want := range []string{"40ms end to end over 3 hops", "+5ms", "UF: ", "Most of the time went to reviews"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the waterfall, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWaterfallBar(t *testing.T) {
+	hops := waterfallHops(requestFlow(mustParse(t, flowTestLines...), "abc"), 0)
+	first := hops[0].call
+	if bar := waterfallBar(first, first.start, first.duration); bar != strings.Repeat("█", waterfallWidth) {
+		t.Errorf("expected the first hop to span the axis, got %q", bar)
+	}
+	// reviews starts 5ms into 40ms and lasts 25ms
+	bar := waterfallBar(hops[2].call, first.start, first.duration)
+	if !strings.HasPrefix(bar, strings.Repeat(" ", 5)+"█") || strings.Count(bar, "█") != 25 {
+		t.Errorf("expected reviews offset by 5 cells and 25 wide, got %q", bar)
+	}
+}
@@ -0,0 +1,121 @@
+// pkg/k8ssource/events.go
+
+package k8ssource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Event is a Kubernetes Event about a pod or the workload running it, such as
+// a rollout scaling a replica set or a container being OOM killed.
+type Event struct {
+	Time    time.Time // When it last happened
+	Kind    string    // Kind of the object it is about, such as Pod or Deployment
+	Name    string
+	Type    string // Normal or Warning
+	Reason  string // Such as ScalingReplicaSet, OOMKilling or Unhealthy
+	Message string
+	Count   int32 // How often it happened, at least 1
+}
+
+// ListEvents returns the events about the object of the given kind and name
+// in namespace, oldest first, paging like ListPodNames.
+func ListEvents(ctx context.Context, client kubernetes.Interface, namespace, kind, name string, pageSize int64) ([]Event, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	selector := fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}.AsSelector().String()
+	opts := metav1.ListOptions{FieldSelector: selector, Limit: pageSize}
+	var events []Event
+	for {
+		list, err := client.CoreV1().Events(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing events of %s %s in namespace %s: %w", kind, name, namespace, err)
+		}
+		for _, event := range list.Items {
+			// Not every server, nor the fake clientset, applies field selectors
+			if event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+				events = append(events, eventOf(event))
+			}
+		}
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// ListWorkloadEvents returns the events about pod and the objects controlling
+// it, such as its replica set and deployment, oldest first. Owners that cannot
+// be read, for lack of permission say, end the walk up without failing it.
+func ListWorkloadEvents(ctx context.Context, client kubernetes.Interface, namespace, pod string, pageSize int64) ([]Event, error) {
+	found, err := client.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting pod %s in namespace %s: %w", pod, namespace, err)
+	}
+
+	objects := []metav1.OwnerReference{{Kind: "Pod", Name: pod}}
+	if owner := metav1.GetControllerOf(found); owner != nil {
+		objects = append(objects, *owner)
+		if owner.Kind == "ReplicaSet" {
+			replicaSet, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if err == nil {
+				if deployment := metav1.GetControllerOf(replicaSet); deployment != nil {
+					objects = append(objects, *deployment)
+				}
+			}
+		}
+	}
+
+	var events []Event
+	for _, object := range objects {
+		found, err := ListEvents(ctx, client, namespace, object.Kind, object.Name, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// eventOf converts an API event, taking the most precise of the times it may
+// carry.
+func eventOf(event v1.Event) Event {
+	converted := Event{
+		Kind:    event.InvolvedObject.Kind,
+		Name:    event.InvolvedObject.Name,
+		Type:    event.Type,
+		Reason:  event.Reason,
+		Message: event.Message,
+		Count:   max(event.Count, 1),
+	}
+	switch {
+	case !event.LastTimestamp.IsZero():
+		converted.Time = event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		converted.Time = event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		converted.Time = event.FirstTimestamp.Time
+	default:
+		converted.Time = event.CreationTimestamp.Time
+	}
+	return converted
+}
+
+// sortEvents orders events oldest first, keeping the order of those at the
+// same time.
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+}
@@ -0,0 +1,78 @@
+// pkg/k8ssource/peers.go
+
+package k8ssource
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Peer is the pod or service an IP address belongs to.
+type Peer struct {
+	Service   bool // A service's cluster IP rather than a pod IP
+	Name      string
+	Namespace string
+}
+
+// String returns e.g. "reviews-v2-abc (default)" or "service reviews (default)".
+func (p Peer) String() string {
+	if p.Service {
+		return fmt.Sprintf("service %s (%s)", p.Name, p.Namespace)
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.Namespace)
+}
+
+// ListPeers maps the IPs of the pods and services in namespace, or in every
+// namespace when it is empty, to what they belong to. Pods on the host
+// network share their node's IP and are left out.
+func ListPeers(ctx context.Context, client kubernetes.Interface, namespace string, pageSize int64) (map[string]Peer, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	peers := make(map[string]Peer)
+
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := client.CoreV1().Pods(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pods in namespace %s: %w", namespace, err)
+		}
+		for _, pod := range list.Items {
+			if pod.Spec.HostNetwork {
+				continue
+			}
+			for _, ip := range pod.Status.PodIPs {
+				peers[ip.IP] = Peer{Name: pod.Name, Namespace: pod.Namespace}
+			}
+			if pod.Status.PodIP != "" {
+				peers[pod.Status.PodIP] = Peer{Name: pod.Name, Namespace: pod.Namespace}
+			}
+		}
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+
+	opts = metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := client.CoreV1().Services(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing services in namespace %s: %w", namespace, err)
+		}
+		for _, service := range list.Items {
+			for _, ip := range append([]string{service.Spec.ClusterIP}, service.Spec.ClusterIPs...) {
+				if ip != "" && ip != "None" {
+					peers[ip] = Peer{Service: true, Name: service.Name, Namespace: service.Namespace}
+				}
+			}
+		}
+		if list.Continue == "" {
+			return peers, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
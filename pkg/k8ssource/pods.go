@@ -0,0 +1,184 @@
+// pkg/k8ssource/pods.go
+
+package k8ssource
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultPageSize is how many pods are requested per list call. Paging keeps
+// each response small in namespaces with thousands of pods.
+const DefaultPageSize int64 = 500
+
+// ListPodNames returns the names of the pods in namespace matching selector,
+// fetching them pageSize at a time. An empty selector matches every pod; a
+// pageSize of zero or less uses DefaultPageSize.
+func ListPodNames(ctx context.Context, client kubernetes.Interface, namespace, selector string, pageSize int64) ([]string, error) {
+	pods, err := ListPods(ctx, client, namespace, selector, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return podNames(pods, func(v1.Pod) bool { return true }), nil
+}
+
+// ListPodsWithContainer returns the names of the pods in namespace that run
+// a container with the given name, such as the istio-proxy sidecar.
+func ListPodsWithContainer(ctx context.Context, client kubernetes.Interface, namespace, container string, pageSize int64) ([]string, error) {
+	pods, err := ListPods(ctx, client, namespace, "", pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return podNames(pods, func(pod v1.Pod) bool {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == container {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// ListPods returns the pods in namespace matching selector, paging like
+// ListPodNames.
+func ListPods(ctx context.Context, client kubernetes.Interface, namespace, selector string, pageSize int64) ([]v1.Pod, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var pods []v1.Pod
+	opts := metav1.ListOptions{LabelSelector: selector, Limit: pageSize}
+	for {
+		list, err := client.CoreV1().Pods(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing pods in namespace %s with selector %q: %w", namespace, selector, err)
+		}
+		pods = append(pods, list.Items...)
+		if list.Continue == "" {
+			return pods, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+// zoneLabels hold a node's topology zone, the beta label on older clusters.
+var zoneLabels = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+
+// PodMetadata is what a pod's labels and annotations say about it, such as
+// its app, version or owning team, and where it runs.
+type PodMetadata struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	Node        string // Node the pod is scheduled on
+	Zone        string // Topology zone of the node, empty when the node cannot be read
+}
+
+func metadataOf(pod v1.Pod, zones map[string]string) PodMetadata {
+	return PodMetadata{
+		Labels:      pod.Labels,
+		Annotations: pod.Annotations,
+		Node:        pod.Spec.NodeName,
+		Zone:        zones[pod.Spec.NodeName],
+	}
+}
+
+// nodeZone returns the topology zone of a node.
+func nodeZone(node v1.Node) string {
+	for _, label := range zoneLabels {
+		if zone := node.Labels[label]; zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// GetPodMetadata returns the labels and annotations of one pod, and its
+// node's zone, see nodeZones.
+func GetPodMetadata(ctx context.Context, client kubernetes.Interface, namespace, name string) (PodMetadata, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return PodMetadata{}, fmt.Errorf("error getting pod %s/%s: %w", namespace, name, err)
+	}
+	return metadataOf(*pod, nodeZones(ctx, client, []v1.Pod{*pod})), nil
+}
+
+// ListPodMetadata returns the labels and annotations of the pods in
+// namespace by pod name, paging like ListPodNames, with their nodes' zones
+// as GetPodMetadata has them.
+func ListPodMetadata(ctx context.Context, client kubernetes.Interface, namespace string, pageSize int64) (map[string]PodMetadata, error) {
+	pods, err := ListPods(ctx, client, namespace, "", pageSize)
+	if err != nil {
+		return nil, err
+	}
+	zones := nodeZones(ctx, client, pods)
+	metadata := make(map[string]PodMetadata, len(pods))
+	for _, pod := range pods {
+		metadata[pod.Name] = metadataOf(pod, zones)
+	}
+	return metadata, nil
+}
+
+// nodeZones returns the topology zone of the nodes pods run on by node name,
+// getting each node once rather than listing the cluster's. Nodes are
+// cluster-scoped, so users limited to a namespace often cannot read them;
+// the zones are left empty then rather than failing.
+func nodeZones(ctx context.Context, client kubernetes.Interface, pods []v1.Pod) map[string]string {
+	zones := make(map[string]string)
+	tried := make(map[string]bool)
+	for _, pod := range pods {
+		name := pod.Spec.NodeName
+		if name == "" || tried[name] {
+			continue
+		}
+		tried[name] = true
+		node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsForbidden(err) {
+			// Nor will the other nodes be readable
+			break
+		}
+		if err == nil {
+			zones[name] = nodeZone(*node)
+		}
+	}
+	return zones
+}
+
+// podNames returns the names of the pods keep accepts.
+func podNames(pods []v1.Pod, keep func(v1.Pod) bool) []string {
+	var names []string
+	for _, pod := range pods {
+		if keep(pod) {
+			names = append(names, pod.Name)
+		}
+	}
+	return names
+}
+
+// ListNamespaceNames returns the names of the cluster's namespaces, paging
+// like ListPodNames.
+func ListNamespaceNames(ctx context.Context, client kubernetes.Interface, pageSize int64) ([]string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var names []string
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := client.CoreV1().Namespaces().List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing namespaces: %w", err)
+		}
+		for _, namespace := range list.Items {
+			names = append(names, namespace.Name)
+		}
+		if list.Continue == "" {
+			return names, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
@@ -0,0 +1,270 @@
+// pkg/k8ssource/source.go
+
+// Package k8ssource fetches and follows container logs from the Kubernetes API.
+// Errors from the API are wrapped, so callers can inspect them with the
+// k8s.io/apimachinery/pkg/api/errors helpers.
+package k8ssource
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MaxLineSize bounds a single log line. Envoy access logs with large headers or
+// stack traces easily exceed bufio.Scanner's 64KB default.
+const MaxLineSize = 16 * 1024 * 1024
+
+// NewLineScanner returns a scanner that reassembles complete lines regardless
+// of how the underlying reader chunks its data, up to MaxLineSize.
+func NewLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineSize)
+	return scanner
+}
+
+// Line is a single log line and the container it came from.
+type Line struct {
+	Namespace string
+	Pod       string
+	Container string
+	Text      string
+}
+
+// Source streams logs for a pod, or for every pod matching a label selector.
+type Source struct {
+	client     kubernetes.Interface
+	namespace  string
+	pod        string
+	container  string
+	selector   string
+	follow     bool
+	timestamps bool
+	since      time.Duration
+	sinceTime  time.Time
+	tailLines  *int64
+	limitBytes *int64
+	pageSize   int64
+
+	mu  sync.Mutex
+	err error
+}
+
+// Option configures a Source.
+type Option func(*Source)
+
+// WithClient sets the Kubernetes client used to fetch logs. Required.
+func WithClient(client kubernetes.Interface) Option {
+	return func(s *Source) { s.client = client }
+}
+
+// WithNamespace sets the namespace of the target pods. Required.
+func WithNamespace(namespace string) Option {
+	return func(s *Source) { s.namespace = namespace }
+}
+
+// WithPod targets a single pod by name.
+func WithPod(pod string) Option {
+	return func(s *Source) { s.pod = pod }
+}
+
+// WithContainer selects the container to read logs from.
+func WithContainer(container string) Option {
+	return func(s *Source) { s.container = container }
+}
+
+// WithSelector targets every pod matching a label selector (e.g. "app=reviews").
+func WithSelector(selector string) Option {
+	return func(s *Source) { s.selector = selector }
+}
+
+// WithFollow keeps the streams open and delivers new lines as they are written.
+func WithFollow(follow bool) Option {
+	return func(s *Source) { s.follow = follow }
+}
+
+// WithTimestamps asks the kubelet to prefix every line with an RFC3339Nano timestamp.
+func WithTimestamps(timestamps bool) Option {
+	return func(s *Source) { s.timestamps = timestamps }
+}
+
+// WithSince only returns lines newer than the given duration.
+func WithSince(since time.Duration) Option {
+	return func(s *Source) { s.since = since }
+}
+
+// WithSinceTime only returns lines written at or after t. The API has second
+// precision, so lines from earlier in the same second are returned too. It
+// takes precedence over WithSince.
+func WithSinceTime(t time.Time) Option {
+	return func(s *Source) { s.sinceTime = t }
+}
+
+// WithTailLines only returns the last n lines of each container's log.
+func WithTailLines(n int64) Option {
+	return func(s *Source) { s.tailLines = &n }
+}
+
+// WithLimitBytes stops reading each container's log after roughly n bytes.
+// The kubelet may cut the last line short.
+func WithLimitBytes(n int64) Option {
+	return func(s *Source) { s.limitBytes = &n }
+}
+
+// WithPageSize sets how many pods are listed per API call when resolving a
+// selector. Defaults to DefaultPageSize.
+func WithPageSize(n int64) Option {
+	return func(s *Source) { s.pageSize = n }
+}
+
+// NewSource creates a Source from the given options.
+func NewSource(opts ...Option) (*Source, error) {
+	s := &Source{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	switch {
+	case s.client == nil:
+		return nil, errors.New("a Kubernetes client is required")
+	case s.namespace == "":
+		return nil, errors.New("a namespace is required")
+	case s.pod == "" && s.selector == "":
+		return nil, errors.New("either a pod or a label selector is required")
+	case s.pod != "" && s.selector != "":
+		return nil, errors.New("a pod and a label selector are mutually exclusive")
+	}
+	return s, nil
+}
+
+// Stream opens a log stream for every target pod and returns their lines on a
+// single channel, which is closed once all streams end or ctx is canceled.
+// Errors opening a stream are returned directly; errors while reading are
+// reported by Err once the channel is closed.
+func (s *Source) Stream(ctx context.Context) (<-chan Line, error) {
+	pods, err := s.targetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []io.ReadCloser
+	for _, pod := range pods {
+		stream, err := s.client.CoreV1().Pods(s.namespace).GetLogs(pod, s.logOptions()).Stream(ctx)
+		if err != nil {
+			for _, opened := range streams {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("error streaming logs from pod %s/%s: %w", s.namespace, pod, err)
+		}
+		streams = append(streams, stream)
+	}
+
+	lines := make(chan Line)
+	var wg sync.WaitGroup
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(pod string, stream io.ReadCloser) {
+			defer wg.Done()
+			defer stream.Close()
+			if err := s.forward(ctx, pod, stream, lines); err != nil {
+				s.setErr(fmt.Errorf("error reading logs from pod %s/%s: %w", s.namespace, pod, err))
+			}
+		}(pods[i], stream)
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	return lines, nil
+}
+
+// Err returns the first error encountered while reading, if any.
+func (s *Source) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Source) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *Source) logOptions() *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Container:  s.container,
+		Follow:     s.follow,
+		Timestamps: s.timestamps,
+		TailLines:  s.tailLines,
+		LimitBytes: s.limitBytes,
+	}
+	if !s.sinceTime.IsZero() {
+		sinceTime := metav1.NewTime(s.sinceTime)
+		opts.SinceTime = &sinceTime
+	} else if s.since > 0 {
+		seconds := int64(s.since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	return opts
+}
+
+// targetPods resolves the pod names to read from.
+func (s *Source) targetPods(ctx context.Context) ([]string, error) {
+	if s.pod != "" {
+		return []string{s.pod}, nil
+	}
+
+	pods, err := ListPodNames(ctx, s.client, s.namespace, s.selector, s.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods in namespace %s match selector %q", s.namespace, s.selector)
+	}
+	return pods, nil
+}
+
+// forward sends complete lines from stream until it ends or ctx is canceled.
+func (s *Source) forward(ctx context.Context, pod string, stream io.Reader, lines chan<- Line) error {
+	scanner := NewLineScanner(stream)
+	for scanner.Scan() {
+		line := Line{Namespace: s.namespace, Pod: pod, Container: s.container, Text: scanner.Text()}
+		select {
+		case lines <- line:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Collect reads every line from a non-following source.
+func (s *Source) Collect(ctx context.Context) ([]Line, error) {
+	if s.follow {
+		return nil, errors.New("cannot collect a following source")
+	}
+
+	stream, err := s.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []Line
+	for line := range stream {
+		lines = append(lines, line)
+	}
+	return lines, s.Err()
+}
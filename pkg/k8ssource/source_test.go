@@ -0,0 +1,340 @@
+// pkg/k8ssource/source_test.go
+
+package k8ssource
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testPod(name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+}
+
+func TestNewSourceValidation(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"Pod target", []Option{WithClient(client), WithNamespace("default"), WithPod("reviews")}, false},
+		{"Selector target", []Option{WithClient(client), WithNamespace("default"), WithSelector("app=reviews")}, false},
+		{"Missing client", []Option{WithNamespace("default"), WithPod("reviews")}, true},
+		{"Missing namespace", []Option{WithClient(client), WithPod("reviews")}, true},
+		{"Missing target", []Option{WithClient(client), WithNamespace("default")}, true},
+		{"Pod and selector", []Option{WithClient(client), WithNamespace("default"), WithPod("reviews"), WithSelector("app=reviews")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSource(tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogOptions(t *testing.T) {
+	source, err := NewSource(
+		WithClient(fake.NewSimpleClientset()),
+		WithNamespace("default"),
+		WithPod("reviews"),
+		WithContainer("istio-proxy"),
+		WithFollow(true),
+		WithSince(5*time.Minute),
+		WithTailLines(100),
+		WithLimitBytes(1024),
+	)
+	if err != nil {
+		t.Fatalf("NewSource() unexpected error: %v", err)
+	}
+
+	opts := source.logOptions()
+	if opts.Container != "istio-proxy" || !opts.Follow {
+		t.Errorf("expected container istio-proxy with follow, got %+v", opts)
+	}
+	if opts.SinceSeconds == nil || *opts.SinceSeconds != 300 {
+		t.Errorf("expected SinceSeconds=300, got %v", opts.SinceSeconds)
+	}
+	if opts.TailLines == nil || *opts.TailLines != 100 {
+		t.Errorf("expected TailLines=100, got %v", opts.TailLines)
+	}
+	if opts.LimitBytes == nil || *opts.LimitBytes != 1024 {
+		t.Errorf("expected LimitBytes=1024, got %v", opts.LimitBytes)
+	}
+}
+
+func TestLogOptionsSinceTime(t *testing.T) {
+	since := time.Date(2024, 5, 4, 9, 59, 5, 0, time.UTC)
+	source, err := NewSource(
+		WithClient(fake.NewSimpleClientset()),
+		WithNamespace("default"),
+		WithPod("reviews"),
+		WithSince(5*time.Minute),
+		WithSinceTime(since),
+	)
+	if err != nil {
+		t.Fatalf("NewSource() unexpected error: %v", err)
+	}
+
+	opts := source.logOptions()
+	if opts.SinceTime == nil || !opts.SinceTime.Time.Equal(since) {
+		t.Errorf("expected SinceTime=%v, got %v", since, opts.SinceTime)
+	}
+	if opts.SinceSeconds != nil {
+		t.Errorf("expected SinceTime to replace SinceSeconds, got %v", *opts.SinceSeconds)
+	}
+}
+
+func TestCollectBySelector(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		testPod("reviews-v1", map[string]string{"app": "reviews"}),
+		testPod("reviews-v2", map[string]string{"app": "reviews"}),
+		testPod("ratings-v1", map[string]string{"app": "ratings"}),
+	)
+
+	source, err := NewSource(
+		WithClient(client),
+		WithNamespace("default"),
+		WithSelector("app=reviews"),
+		WithContainer("istio-proxy"),
+	)
+	if err != nil {
+		t.Fatalf("NewSource() unexpected error: %v", err)
+	}
+
+	lines, err := source.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Collect() unexpected error: %v", err)
+	}
+
+	// The fake clientset answers every log request with "fake logs"
+	var pods []string
+	for _, line := range lines {
+		if line.Text != "fake logs" || line.Container != "istio-proxy" {
+			t.Errorf("unexpected line %+v", line)
+		}
+		pods = append(pods, line.Pod)
+	}
+	sort.Strings(pods)
+	if len(pods) != 2 || pods[0] != "reviews-v1" || pods[1] != "reviews-v2" {
+		t.Errorf("expected one line from each reviews pod, got %v", pods)
+	}
+}
+
+func TestStreamNoMatchingPods(t *testing.T) {
+	source, err := NewSource(
+		WithClient(fake.NewSimpleClientset()),
+		WithNamespace("default"),
+		WithSelector("app=missing"),
+	)
+	if err != nil {
+		t.Fatalf("NewSource() unexpected error: %v", err)
+	}
+	if _, err := source.Stream(context.Background()); err == nil {
+		t.Errorf("expected an error when no pods match the selector")
+	}
+}
+
+func TestListPodsWithContainer(t *testing.T) {
+	withSidecar := testPod("reviews", nil)
+	withSidecar.Spec.Containers = []v1.Container{{Name: "reviews"}, {Name: "istio-proxy"}}
+	without := testPod("job", nil)
+	without.Spec.Containers = []v1.Container{{Name: "job"}}
+
+	names, err := ListPodsWithContainer(context.Background(), fake.NewSimpleClientset(withSidecar, without), "default", "istio-proxy", 0)
+	if err != nil {
+		t.Fatalf("ListPodsWithContainer() unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "reviews" {
+		t.Errorf("ListPodsWithContainer() = %v, expected [reviews]", names)
+	}
+}
+
+func TestPodMetadata(t *testing.T) {
+	reviews := testPod("reviews-v2-abc", map[string]string{"app": "reviews", "version": "v2"})
+	reviews.Annotations = map[string]string{"team": "storefront"}
+	reviews.Spec.NodeName = "node-a"
+	ratings := testPod("ratings-v1-def", nil)
+	ratings.Spec.NodeName = "node-b"
+	nodeA := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}}}
+	nodeB := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1b"}}}
+	client := fake.NewSimpleClientset(reviews, ratings, nodeA, nodeB)
+
+	metadata, err := GetPodMetadata(context.Background(), client, "default", "reviews-v2-abc")
+	if err != nil {
+		t.Fatalf("GetPodMetadata() unexpected error: %v", err)
+	}
+	if metadata.Labels["version"] != "v2" || metadata.Annotations["team"] != "storefront" ||
+		metadata.Node != "node-a" || metadata.Zone != "us-east-1a" {
+		t.Errorf("GetPodMetadata() = %+v", metadata)
+	}
+	if _, err := GetPodMetadata(context.Background(), client, "default", "missing"); err == nil {
+		t.Error("expected an error for a missing pod")
+	}
+
+	client.ClearActions()
+	byPod, err := ListPodMetadata(context.Background(), client, "default", 0)
+	if err != nil {
+		t.Fatalf("ListPodMetadata() unexpected error: %v", err)
+	}
+	if len(byPod) != 2 || byPod["reviews-v2-abc"].Labels["app"] != "reviews" || byPod["ratings-v1-def"].Zone != "us-east-1b" {
+		t.Errorf("ListPodMetadata() = %v", byPod)
+	}
+	// Only the nodes the pods run on are read, each once
+	var nodes []string
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource == "nodes" {
+			nodes = append(nodes, action.GetVerb())
+		}
+	}
+	if !reflect.DeepEqual(nodes, []string{"get", "get"}) {
+		t.Errorf("expected a get per node, got %v", nodes)
+	}
+
+	// Without access to nodes, one is tried
+	denied := fake.NewSimpleClientset(reviews, ratings)
+	denied.PrependReactor("get", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(v1.Resource("nodes"), "node-a", fmt.Errorf("denied"))
+	})
+	if byPod, err := ListPodMetadata(context.Background(), denied, "default", 0); err != nil || byPod["reviews-v2-abc"].Zone != "" {
+		t.Errorf("ListPodMetadata() = %v, %v, expected the pods without zones", byPod, err)
+	}
+	if gets := len(denied.Actions()); gets != 2 {
+		t.Errorf("expected the pods listed and one node tried, got %d calls", gets)
+	}
+
+	// Pods whose node cannot be read keep their labels
+	orphan := testPod("orphan", map[string]string{"app": "orphan"})
+	orphan.Spec.NodeName = "node-gone"
+	metadata, err = GetPodMetadata(context.Background(), fake.NewSimpleClientset(orphan), "default", "orphan")
+	if err != nil || metadata.Node != "node-gone" || metadata.Zone != "" || metadata.Labels["app"] != "orphan" {
+		t.Errorf("GetPodMetadata() = %+v, %v, expected the pod without a zone", metadata, err)
+	}
+}
+
+func TestListPodNamesPaging(t *testing.T) {
+	var names []string
+	for i := 0; i < 5; i++ {
+		names = append(names, fmt.Sprintf("reviews-%d", i))
+	}
+
+	// The fake clientset ignores Limit and Continue, so serve pages by hand
+	client := fake.NewSimpleClientset()
+	var calls int
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		start, _ := strconv.Atoi(opts.Continue)
+		end := start + int(opts.Limit)
+		list := &v1.PodList{}
+		if end < len(names) {
+			list.Continue = strconv.Itoa(end)
+		} else {
+			end = len(names)
+		}
+		for _, name := range names[start:end] {
+			list.Items = append(list.Items, *testPod(name, nil))
+		}
+		return true, list, nil
+	})
+
+	got, err := ListPodNames(context.Background(), client, "default", "", 2)
+	if err != nil {
+		t.Fatalf("ListPodNames() unexpected error: %v", err)
+	}
+	if len(got) != len(names) {
+		t.Errorf("expected %d pods, got %v", len(names), got)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 pages of 2 pods, got %d calls", calls)
+	}
+}
+
+func TestListPeers(t *testing.T) {
+	reviews := testPod("reviews-v2-abc", nil)
+	reviews.Status.PodIP = "10.1.2.3"
+	reviews.Status.PodIPs = []v1.PodIP{{IP: "10.1.2.3"}, {IP: "fd00::3"}}
+	node := testPod("node-exporter", nil)
+	node.Spec.HostNetwork = true
+	node.Status.PodIP = "192.168.0.10"
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "default"},
+		Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.20", ClusterIPs: []string{"10.96.0.20"}},
+	}
+	headless := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-headless", Namespace: "default"},
+		Spec:       v1.ServiceSpec{ClusterIP: "None"},
+	}
+	client := fake.NewSimpleClientset(reviews, node, service, headless)
+
+	peers, err := ListPeers(context.Background(), client, "", 0)
+	if err != nil {
+		t.Fatalf("ListPeers() unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"10.1.2.3":   "reviews-v2-abc (default)",
+		"fd00::3":    "reviews-v2-abc (default)",
+		"10.96.0.20": "service reviews (default)",
+	}
+	if len(peers) != len(expected) {
+		t.Errorf("ListPeers() = %v, expected %d peers", peers, len(expected))
+	}
+	for ip, want := range expected {
+		if got := peers[ip].String(); got != want {
+			t.Errorf("peer of %s = %q, expected %q", ip, got, want)
+		}
+	}
+}
+
+func testEvent(name, kind, object, reason string, at time.Time) *v1.Event {
+	return &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+		InvolvedObject: v1.ObjectReference{Kind: kind, Name: object, Namespace: "default"},
+		Reason:         reason,
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+func TestListWorkloadEvents(t *testing.T) {
+	controller := true
+	pod := testPod("reviews-v2-abc", nil)
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "reviews-v2-5d", Controller: &controller}}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "reviews-v2-5d", Namespace: "default",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "reviews-v2", Controller: &controller}},
+	}}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(pod, replicaSet,
+		testEvent("a", "Pod", "reviews-v2-abc", "Started", start.Add(time.Minute)),
+		testEvent("b", "Deployment", "reviews-v2", "ScalingReplicaSet", start),
+		testEvent("c", "Pod", "ratings-v1-xyz", "Started", start),
+	)
+
+	events, err := ListWorkloadEvents(context.Background(), client, "default", "reviews-v2-abc", 0)
+	if err != nil {
+		t.Fatalf("ListWorkloadEvents() unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].Reason != "ScalingReplicaSet" || events[1].Name != "reviews-v2-abc" {
+		t.Errorf("expected the deployment's rollout then the pod starting, got %+v", events)
+	}
+	if events[0].Count != 1 {
+		t.Errorf("expected a single occurrence counted once, got %d", events[0].Count)
+	}
+}